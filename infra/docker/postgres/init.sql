@@ -26,12 +26,150 @@ CREATE TABLE IF NOT EXISTS ingestion_events (
     event_id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     user_id UUID NOT NULL REFERENCES users(user_id),
     source_type VARCHAR(50) NOT NULL CHECK (source_type IN ('VC', 'OIDC', 'MANUAL')),
-    raw_payload JSONB NOT NULL,
+    raw_payload JSONB,              -- NULL when sealed in encrypted_payload
     checksum VARCHAR(64) NOT NULL,  -- SHA-256 hash for integrity
+    enrichment JSONB,               -- Data added from external directories
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    occurred_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(), -- Logical time; earlier than created_at for backfills
+    verification_status VARCHAR(20) NOT NULL DEFAULT 'unverified',
+    verified_at TIMESTAMP WITH TIME ZONE,
+    delivery_status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    extracted_dates JSONB,          -- Payload dates normalized to UTC, with original offsets
+    expires_at TIMESTAMP WITH TIME ZONE,
+    tags TEXT[],
+    metadata JSONB,
+    data_model VARCHAR(8),
+    normalized_payload JSONB,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    encrypted_payload BYTEA,        -- AES-256-GCM sealed raw and normalized payloads
+    key_version INTEGER,            -- Key version encrypted_payload is sealed with
+    parent_event_id UUID,           -- Event this one was derived from
+    idempotency_key VARCHAR(255),   -- Client-supplied Idempotency-Key header
+    idempotency_scope VARCHAR(128), -- Namespace the key is unique in (user, user/source type or *)
+    tenant_id VARCHAR(128) NOT NULL DEFAULT 'default', -- Organization reads are scoped to
+    credential_jwt TEXT,            -- VC-JWT the credential was submitted as; NULL when sealed
     
     -- Indexing for common queries
-    CONSTRAINT valid_payload CHECK (raw_payload IS NOT NULL)
+    CONSTRAINT valid_payload CHECK (raw_payload IS NOT NULL OR encrypted_payload IS NOT NULL)
+);
+
+-- Index of event batches moved to cold storage
+CREATE TABLE IF NOT EXISTS event_archives (
+    archive_id UUID PRIMARY KEY,
+    location TEXT NOT NULL,             -- Archive store location of the JSONL file
+    checksum VARCHAR(64) NOT NULL,      -- SHA-256 of the archive file
+    event_count INTEGER NOT NULL,
+    event_ids UUID[] NOT NULL,
+    oldest_created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    newest_created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    restored_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Last event delivered to each real-time stream subscriber
+CREATE TABLE IF NOT EXISTS stream_watermarks (
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    subscriber_id VARCHAR(128) NOT NULL,   -- Client-chosen, stable across reconnects
+    last_created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_event_id UUID NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, subscriber_id)
+);
+
+-- Per-user webhook endpoints, one per event type (opt-in)
+CREATE TABLE IF NOT EXISTS user_webhooks (
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    event_type VARCHAR(64) NOT NULL,
+    url TEXT NOT NULL,
+    secret VARCHAR(128) NOT NULL,          -- HMAC-SHA256 signing secret
+    ordered BOOLEAN NOT NULL DEFAULT FALSE, -- Deliver one at a time, in event order
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, event_type)
+);
+
+-- Named ingestion presets, per tenant
+CREATE TABLE IF NOT EXISTS ingestion_presets (
+    tenant_id VARCHAR(128) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    source_type VARCHAR(50) NOT NULL CHECK (source_type IN ('VC', 'OIDC', 'MANUAL')),
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    metadata JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, name)
+);
+
+-- Verified events held back because field extraction failed
+CREATE TABLE IF NOT EXISTS quarantined_events (
+    quarantine_id UUID PRIMARY KEY,
+    event_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    source_type VARCHAR(50) NOT NULL,
+    event JSONB NOT NULL,
+    error TEXT NOT NULL,
+    field VARCHAR(255),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    reprocessed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Credential subject identifiers each user may ingest credentials about
+CREATE TABLE IF NOT EXISTS user_subject_ids (
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    subject_id VARCHAR(512) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, subject_id)
+);
+
+-- Bucket objects imported from S3 object-created notifications
+CREATE TABLE IF NOT EXISTS s3_ingested_objects (
+    bucket VARCHAR(255) NOT NULL,
+    object_key TEXT NOT NULL,
+    etag VARCHAR(128) NOT NULL,            -- Distinguishes overwrites of the same key
+    status VARCHAR(20) NOT NULL,           -- processing, processed or failed
+    ingested INTEGER NOT NULL DEFAULT 0,
+    rejected INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    claimed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (bucket, object_key, etag)
+);
+
+-- Files uploaded with an event's credential and the result of scanning
+-- them. Content is NULL when an infected upload was rejected.
+CREATE TABLE IF NOT EXISTS event_attachments (
+    attachment_id UUID PRIMARY KEY,
+    event_id UUID NOT NULL REFERENCES ingestion_events(event_id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(user_id),
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 VARCHAR(64) NOT NULL,
+    content BYTEA,
+    key_version INTEGER,                   -- Set when content is encrypted
+    scan_status VARCHAR(20) NOT NULL,      -- clean, infected or unscanned
+    scan_signature VARCHAR(255),
+    scan_error TEXT,
+    scanned_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Parsed claims of OIDC events, queryable by email or subject
+CREATE TABLE IF NOT EXISTS oidc_claims (
+    event_id UUID PRIMARY KEY REFERENCES ingestion_events(event_id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    audience TEXT,
+    email TEXT,
+    email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    name TEXT,
+    given_name TEXT,
+    family_name TEXT,
+    picture TEXT,
+    issued_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
 -- ============================================================================
@@ -50,6 +188,10 @@ CREATE INDEX IF NOT EXISTS idx_ingestion_events_source_type
 CREATE INDEX IF NOT EXISTS idx_ingestion_events_created_at 
     ON ingestion_events(created_at DESC);
 
+-- Index for cursor reads of a user's events in (created_at, event_id) order
+CREATE INDEX IF NOT EXISTS idx_ingestion_events_user_cursor
+    ON ingestion_events(user_id, created_at, event_id);
+
 -- Index for JSON path queries on raw_payload
 CREATE INDEX IF NOT EXISTS idx_ingestion_events_payload 
     ON ingestion_events USING GIN (raw_payload);
@@ -68,6 +210,107 @@ VALUES (
     'testuser@example.com'
 ) ON CONFLICT (username) DO NOTHING;
 
+-- ============================================================================
+-- MIGRATIONS (columns added to existing tables)
+-- ============================================================================
+
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS enrichment JSONB;
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS verification_status VARCHAR(20) NOT NULL DEFAULT 'unverified';
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS delivery_status VARCHAR(20) NOT NULL DEFAULT 'pending';
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS extracted_dates JSONB;
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS tags TEXT[];
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS metadata JSONB;
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS data_model VARCHAR(8);
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS normalized_payload JSONB;
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS encrypted_payload BYTEA;
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS key_version INTEGER;
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS parent_event_id UUID;
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(255);
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS idempotency_scope VARCHAR(128);
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS occurred_at TIMESTAMP WITH TIME ZONE;
+UPDATE ingestion_events SET occurred_at = created_at WHERE occurred_at IS NULL;
+ALTER TABLE ingestion_events ALTER COLUMN occurred_at SET DEFAULT NOW();
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(128) NOT NULL DEFAULT 'default';
+ALTER TABLE ingestion_events ADD COLUMN IF NOT EXISTS credential_jwt TEXT;
+ALTER TABLE user_webhooks ADD COLUMN IF NOT EXISTS ordered BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE ingestion_events ALTER COLUMN raw_payload DROP NOT NULL;
+ALTER TABLE ingestion_events DROP CONSTRAINT IF EXISTS valid_payload;
+ALTER TABLE ingestion_events ADD CONSTRAINT valid_payload
+    CHECK (raw_payload IS NOT NULL OR encrypted_payload IS NOT NULL);
+
+-- Index for credential expiry queries
+CREATE INDEX IF NOT EXISTS idx_ingestion_events_expires_at
+    ON ingestion_events(expires_at)
+    WHERE expires_at IS NOT NULL;
+
+-- Indexes for finding OIDC events by email or by subject
+CREATE INDEX IF NOT EXISTS idx_oidc_claims_email
+    ON oidc_claims(lower(email))
+    WHERE email IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_oidc_claims_subject
+    ON oidc_claims(issuer, subject);
+
+-- Index for listing an event's attachments
+CREATE INDEX IF NOT EXISTS idx_event_attachments_event_id
+    ON event_attachments(event_id);
+
+-- Index for the pending quarantine review queue
+CREATE INDEX IF NOT EXISTS idx_quarantined_events_pending
+    ON quarantined_events(created_at)
+    WHERE reprocessed_at IS NULL;
+
+-- Index for purging soft-deleted events
+CREATE INDEX IF NOT EXISTS idx_ingestion_events_deleted_at
+    ON ingestion_events(deleted_at)
+    WHERE deleted_at IS NOT NULL;
+
+-- Index for finding events to re-encrypt during key rotation
+CREATE INDEX IF NOT EXISTS idx_ingestion_events_key_version
+    ON ingestion_events(key_version);
+
+-- Index for finding the events derived from an event
+CREATE INDEX IF NOT EXISTS idx_ingestion_events_parent_event_id
+    ON ingestion_events(parent_event_id)
+    WHERE parent_event_id IS NOT NULL;
+
+-- Idempotency keys are unique within their scope
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ingestion_events_idempotency
+    ON ingestion_events(idempotency_scope, idempotency_key)
+    WHERE idempotency_key IS NOT NULL;
+
+-- Index for the deferred verification backlog
+CREATE INDEX IF NOT EXISTS idx_ingestion_events_deferred
+    ON ingestion_events(created_at, event_id)
+    WHERE verification_status = 'deferred';
+
+-- Index for listing a user's events by when they occurred
+CREATE INDEX IF NOT EXISTS idx_ingestion_events_user_occurred_at
+    ON ingestion_events(user_id, occurred_at DESC);
+
+-- Index for finding every ingestion of a credential by its checksum
+CREATE INDEX IF NOT EXISTS idx_ingestion_events_checksum
+    ON ingestion_events(checksum);
+
+-- Index for the admin event search across users, in (created_at, event_id)
+-- order, and its occurred_at range filter
+CREATE INDEX IF NOT EXISTS idx_ingestion_events_search
+    ON ingestion_events(created_at DESC, event_id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_ingestion_events_occurred_at
+    ON ingestion_events(occurred_at DESC, event_id DESC);
+
+-- Index for listing a tenant's user's events in (created_at, event_id) order
+CREATE INDEX IF NOT EXISTS idx_ingestion_events_tenant_user_created
+    ON ingestion_events(tenant_id, user_id, created_at DESC, event_id DESC);
+
+-- Index for the outbox relay's undelivered events
+CREATE INDEX IF NOT EXISTS idx_ingestion_events_undelivered
+    ON ingestion_events(created_at, event_id)
+    WHERE delivery_status IN ('pending', 'failed');
+
 -- ============================================================================
 -- FUNCTIONS
 -- ============================================================================
@@ -3,79 +3,960 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/admission"
+	"github.com/uigs/ingestion/internal/archive"
+	"github.com/uigs/ingestion/internal/capture"
+	"github.com/uigs/ingestion/internal/challenge"
 	"github.com/uigs/ingestion/internal/config"
+	"github.com/uigs/ingestion/internal/credstatus"
+	"github.com/uigs/ingestion/internal/deferred"
+	"github.com/uigs/ingestion/internal/did"
+	"github.com/uigs/ingestion/internal/enrich"
+	"github.com/uigs/ingestion/internal/forward"
 	"github.com/uigs/ingestion/internal/handlers"
+	"github.com/uigs/ingestion/internal/issuerrate"
+	"github.com/uigs/ingestion/internal/keyring"
 	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/oidc"
+	"github.com/uigs/ingestion/internal/outbox"
+	"github.com/uigs/ingestion/internal/proof"
+	"github.com/uigs/ingestion/internal/purge"
 	"github.com/uigs/ingestion/internal/queue"
+	"github.com/uigs/ingestion/internal/receipt"
+	"github.com/uigs/ingestion/internal/redact"
+	"github.com/uigs/ingestion/internal/replay"
 	"github.com/uigs/ingestion/internal/repository"
+	"github.com/uigs/ingestion/internal/rotation"
+	"github.com/uigs/ingestion/internal/s3ingest"
+	"github.com/uigs/ingestion/internal/scan"
+	"github.com/uigs/ingestion/internal/slo"
+	"github.com/uigs/ingestion/internal/timecheck"
+	"github.com/uigs/ingestion/internal/tracing"
+	"github.com/uigs/ingestion/internal/validation"
+	"github.com/uigs/ingestion/internal/vcmodel"
+	"github.com/uigs/ingestion/internal/webhook"
 )
 
 func main() {
-	// Initialize logger
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		slog.New(slog.NewJSONHandler(os.Stdout, nil)).Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	logOpts := &slog.HandlerOptions{Level: cfg.LogLevel}
+	var logHandler slog.Handler = slog.NewJSONHandler(os.Stdout, logOpts)
+	if cfg.LogFormat == config.LogFormatText {
+		logHandler = slog.NewTextHandler(os.Stdout, logOpts)
+	}
+	logger := slog.New(middleware.ContextLogHandler(logHandler))
 	slog.SetDefault(logger)
 
 	logger.Info("Starting UIGS Ingestion Service")
-
-	// Load configuration
-	cfg := config.Load()
+	for _, warning := range cfg.Warnings {
+		logger.Warn("Invalid configuration: " + warning)
+	}
+	if err := cfg.CheckJWTSecret(); err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
 	logger.Info("Configuration loaded",
 		"port", cfg.Port,
+		"region_role", cfg.RegionRole,
+		"log_level", cfg.LogLevel.String(),
 	)
 
-	// Initialize database repository
 	ctx := context.Background()
-	repo, err := repository.NewPostgresRepository(ctx, cfg.PostgresURL)
+
+	// Export traces to an OpenTelemetry collector; spans still queued are
+	// flushed on exit
+	if cfg.TracingEnabled {
+		shutdownTracing, err := tracing.Init(tracing.Config{
+			Endpoint:      cfg.OTLPEndpoint,
+			ServiceName:   cfg.OTelServiceName,
+			ExportTimeout: cfg.TracingExportTimeout,
+		}, logger)
+		if err != nil {
+			logger.Error("Failed to initialize tracing", "error", err)
+			os.Exit(1)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.TracingExportTimeout)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				logger.Error("Failed to flush traces", "error", err)
+			}
+		}()
+		logger.Info("Tracing enabled", "endpoint", cfg.OTLPEndpoint, "service_name", cfg.OTelServiceName)
+	}
+
+	// Initialize database repository
+	poolOptions := repository.PoolOptions{
+		MaxConns:           int32(cfg.DBMaxConns),
+		MinConns:           int32(cfg.DBMinConns),
+		MaxConnLifetime:    cfg.DBMaxConnLifetime,
+		MaxConnIdleTime:    cfg.DBMaxConnIdleTime,
+		HealthCheckPeriod:  cfg.DBHealthCheckPeriod,
+		IdleCheckThreshold: cfg.DBIdleCheckThreshold,
+		IdleCheckTimeout:   cfg.DBIdleCheckTimeout,
+		QueryTimeout:       cfg.DBQueryTimeout,
+		RetryMaxAttempts:   cfg.DBRetryMaxAttempts,
+		RetryBaseDelay:     cfg.DBRetryBaseDelay,
+		RetryMaxDelay:      cfg.DBRetryMaxDelay,
+	}
+	repo, err := repository.NewPostgresRepository(ctx, cfg.PostgresURL, poolOptions)
 	if err != nil {
 		logger.Error("Failed to initialize database", "error", err)
 		os.Exit(1)
 	}
 	defer repo.Close()
-	logger.Info("Database connection established")
+	poolConfig := repo.PoolConfig()
+	logger.Info("Database connection established",
+		"max_conns", poolConfig.MaxConns,
+		"min_conns", poolConfig.MinConns,
+		"max_conn_lifetime", poolConfig.MaxConnLifetime.String(),
+		"max_conn_idle_time", poolConfig.MaxConnIdleTime.String(),
+		"health_check_period", poolConfig.HealthCheckPeriod.String(),
+		"query_exec_mode", poolConfig.ConnConfig.DefaultQueryExecMode.String(),
+	)
+
+	// Encrypt event payloads at rest; older key versions stay readable
+	// until a rotation has re-encrypted every event
+	var keys *keyring.Keyring
+	if cfg.EncryptionKeys != "" {
+		keys, err = keyring.Parse(cfg.EncryptionKeys, cfg.EncryptionKeyVersion)
+		if err != nil {
+			logger.Error("Failed to load encryption keys", "error", err)
+			os.Exit(1)
+		}
+		repo.SetKeyring(keys)
+		logger.Info("Payload encryption enabled", "key_version", keys.Current(), "versions", keys.Versions())
+	}
 
 	// Initialize message queue publisher
-	publisher, err := queue.NewRabbitMQPublisher(cfg.RabbitMQURL, logger)
+	var publisher queue.Backend
+	switch cfg.QueueBackend {
+	case "kafka":
+		publisher, err = queue.NewKafkaPublisher(cfg.KafkaBrokers, cfg.KafkaTopic, cfg.PublishConfirmTimeout, cfg.PublishMaxAttempts, logger)
+	case "rabbitmq":
+		publisher, err = queue.NewRabbitMQPublisher(cfg.RabbitMQURL, cfg.PublishConfirmTimeout, cfg.PublishMaxAttempts, logger)
+	default:
+		err = fmt.Errorf("unknown QUEUE_BACKEND %q", cfg.QueueBackend)
+	}
 	if err != nil {
 		logger.Error("Failed to initialize message queue", "error", err)
 		os.Exit(1)
 	}
-	defer publisher.Close()
-	logger.Info("Message queue connection established")
+	// Runs after the workers that publish have stopped; publishes still
+	// waiting for a confirm get a bounded time to finish
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := publisher.Drain(ctx); err != nil {
+			logger.Warn("Closing message queue with publishes in flight", "error", err)
+		}
+		publisher.Close()
+	}()
+	logger.Info("Message queue connection established", "backend", cfg.QueueBackend)
+
+	// Every time-based validation shares one clock-skew tolerance
+	clock := timecheck.New(cfg.ClockSkew)
+	// Payloads are logged and captured with sensitive fields masked
+	redactor := redact.New(cfg.RedactKeys)
+	ingestOpts := []handlers.IngestOption{handlers.WithClock(clock), handlers.WithRedactor(redactor)}
+	if cfg.RejectFutureIssuance {
+		ingestOpts = append(ingestOpts, handlers.WithFutureIssuanceRejection())
+	}
+
+	// Replica regions forward writes to the primary region
+	switch cfg.RegionRole {
+	case config.RegionRolePrimary:
+	case config.RegionRoleReplica:
+		fwd, err := forward.New(cfg.PrimaryIngestURL, cfg.ForwardTimeout)
+		if err != nil {
+			logger.Error("Failed to initialize write forwarding", "error", err)
+			os.Exit(1)
+		}
+		ingestOpts = append(ingestOpts, handlers.WithForwarder(fwd))
+		logger.Info("Running as replica, forwarding writes", "primary", fwd.Target())
+	default:
+		logger.Error("Invalid region role", "region_role", cfg.RegionRole)
+		os.Exit(1)
+	}
+
+	// Load payload schemas
+	schemaStatus := validation.LoadStatus{
+		Enabled: cfg.SchemaValidationEnabled,
+		Mode:    cfg.SchemaLoadMode,
+		Dir:     cfg.SchemaDir,
+	}
+	if cfg.SchemaLoadMode != validation.LoadModeStrict && cfg.SchemaLoadMode != validation.LoadModeLenient {
+		logger.Error("Invalid schema load mode", "schema_load_mode", cfg.SchemaLoadMode)
+		os.Exit(1)
+	}
+	var drift *validation.DriftDetector
+	if cfg.SchemaValidationEnabled {
+		// Without a schema directory the schemas built into the binary apply
+		load := validation.Builtin
+		if cfg.SchemaDir != "" {
+			load = func() (*validation.Schemas, error) { return validation.LoadDir(cfg.SchemaDir) }
+		}
+		schemas, err := load()
+		switch {
+		case err == nil:
+			schemaStatus.Loaded = true
+			ingestOpts = append(ingestOpts, handlers.WithSchemas(schemas))
+			logger.Info("Payload schemas loaded", "dir", cfg.SchemaDir)
+
+			// Spot fields producers added before the schemas know about them
+			if cfg.SchemaDriftSampleRate > 0 {
+				drift = validation.NewDriftDetector(schemas, validation.DriftConfig{
+					SampleRate: cfg.SchemaDriftSampleRate,
+					MaxFields:  cfg.SchemaDriftMaxFields,
+				}, logger)
+				ingestOpts = append(ingestOpts, handlers.WithSchemaDrift(drift))
+				expvar.Publish("schema_drift", expvar.Func(func() any { return drift.Report() }))
+				logger.Info("Schema drift detection enabled", "sample_rate", cfg.SchemaDriftSampleRate)
+			}
+		case cfg.SchemaLoadMode == validation.LoadModeLenient:
+			schemaStatus.Error = err.Error()
+			logger.Error("SCHEMA VALIDATION DISABLED: failed to load payload schemas, continuing in lenient mode",
+				"error", err,
+				"dir", cfg.SchemaDir,
+			)
+		default:
+			logger.Error("Failed to load payload schemas", "error", err, "dir", cfg.SchemaDir)
+			os.Exit(1)
+		}
+	}
+
+	// Only configured source types are published to the graph engine
+	publishable := make([]models.SourceType, 0, len(cfg.PublishSourceTypes))
+	for _, t := range cfg.PublishSourceTypes {
+		publishable = append(publishable, models.SourceType(strings.ToUpper(t)))
+	}
+	ingestOpts = append(ingestOpts, handlers.WithPublishableSourceTypes(publishable))
+	logger.Info("Publishing enabled for source types", "source_types", publishable)
+
+	// Fail publishes fast while the broker is degraded; their events are
+	// left to the outbox relay
+	var eventPublisher queue.Publisher = publisher
+	if cfg.PublishBreakerThreshold > 0 {
+		breaker := queue.NewBreakerPublisher(publisher, queue.BreakerConfig{
+			FailureThreshold: cfg.PublishBreakerThreshold,
+			Cooldown:         cfg.PublishBreakerCooldown,
+		}, logger)
+		eventPublisher = breaker
+		expvar.Publish("publish_breaker", expvar.Func(func() any { return breaker.Stats() }))
+		logger.Info("Publish circuit breaker enabled",
+			"threshold", cfg.PublishBreakerThreshold,
+			"cooldown", cfg.PublishBreakerCooldown.String(),
+		)
+	}
+
+	// Buffer publishes per source type so a slow consumer of one type
+	// cannot hold up the others
+	if cfg.PublishBufferSize > 0 {
+		typed, err := queue.NewTypedPublisher(eventPublisher, publishable, queue.TypedConfig{
+			BufferSize:     cfg.PublishBufferSize,
+			Mode:           cfg.PublishBackpressure,
+			EnqueueTimeout: cfg.PublishEnqueueTimeout,
+			OnResult: func(msg *models.QueueMessage, err error) {
+				if err := repo.UpdateDeliveryStatus(context.Background(), msg.EventID, queue.DeliveryStatus(err)); err != nil {
+					logger.Error("Failed to record delivery status", "error", err, "event_id", msg.EventID)
+				}
+			},
+		}, logger)
+		if err != nil {
+			logger.Error("Failed to initialize publish buffering", "error", err)
+			os.Exit(1)
+		}
+		defer typed.Close()
+		eventPublisher = typed
+		ingestOpts = append(ingestOpts, handlers.WithAsyncDelivery())
+		expvar.Publish("publish_lanes", expvar.Func(func() any { return typed.Stats() }))
+		logger.Info("Per-source-type publish buffering enabled",
+			"buffer_size", cfg.PublishBufferSize,
+			"backpressure", cfg.PublishBackpressure,
+		)
+	}
+
+	// Track ingestion latency against the SLO target
+	sloTracker := slo.NewTracker(cfg.SLOTarget, cfg.SLOWindow)
+	ingestOpts = append(ingestOpts, handlers.WithSLOTracker(sloTracker))
+	expvar.Publish("ingest_slo", expvar.Func(func() any { return sloTracker.Snapshot() }))
+
+	// Archive aged events to cold storage
+	var archiveWorker *archive.Worker
+	if cfg.ArchiveEnabled {
+		store, err := archive.NewFileStore(cfg.ArchiveDir)
+		if err != nil {
+			logger.Error("Failed to initialize archive store", "error", err)
+			os.Exit(1)
+		}
+		archiveWorker = archive.NewWorker(repo, store, archive.WorkerConfig{
+			After:     cfg.ArchiveAfter,
+			Interval:  cfg.ArchiveInterval,
+			BatchSize: cfg.ArchiveBatchSize,
+		}, logger)
+		archiveWorker.Start(ctx)
+		defer archiveWorker.Stop()
+		logger.Info("Archive worker started", "after", cfg.ArchiveAfter.String(), "dir", cfg.ArchiveDir)
+	}
+
+	// Hard-delete soft-deleted events once they can no longer be restored
+	purgeWorker := purge.NewWorker(repo, purge.Config{
+		Grace:     cfg.DeleteGracePeriod,
+		Interval:  cfg.PurgeInterval,
+		BatchSize: cfg.PurgeBatchSize,
+	}, logger)
+	purgeWorker.Start(ctx)
+	defer purgeWorker.Stop()
+
+	// Check credential revocation status, selected by credentialStatus.type
+	if cfg.StatusCheckEnabled {
+		registry := credstatus.NewRegistry(cfg.StatusCheckFailOpen)
+		registry.Register(credstatus.EndpointType, credstatus.NewEndpointChecker(cfg.StatusCheckTimeout, cfg.StatusCacheTTL))
+		registry.Register(credstatus.StatusList2021Type, credstatus.NewStatusListChecker(cfg.StatusCheckTimeout, cfg.StatusCacheTTL))
+		ingestOpts = append(ingestOpts, handlers.WithStatusChecker(registry))
+		logger.Info("Credential status checks enabled", "fail_open", cfg.StatusCheckFailOpen)
+	}
+
+	// Enrich events with issuer details from the external directory
+	if cfg.EnrichmentEnabled {
+		if cfg.EnrichmentDirectoryURL == "" {
+			logger.Error("Enrichment is enabled but ENRICHMENT_DIRECTORY_URL is not set")
+			os.Exit(1)
+		}
+		enrichTypes := make([]models.SourceType, 0, len(cfg.EnrichmentSourceTypes))
+		for _, t := range cfg.EnrichmentSourceTypes {
+			enrichTypes = append(enrichTypes, models.SourceType(strings.ToUpper(t)))
+		}
+		directory := enrich.NewDirectoryEnricher(cfg.EnrichmentDirectoryURL, cfg.EnrichmentCacheTTL)
+		ingestOpts = append(ingestOpts, handlers.WithEnrichment(enrich.NewStep(directory, cfg.EnrichmentTimeout, enrichTypes)))
+		logger.Info("Event enrichment enabled", "source_types", enrichTypes)
+	}
+
+	// Deliver webhooks to user-configured endpoints
+	webhooks := webhook.NewDispatcher(webhook.Config{
+		Timeout:      cfg.WebhookTimeout,
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		QueueSize:    cfg.WebhookQueueSize,
+		Workers:      cfg.WebhookWorkers,
+		AllowPrivate: cfg.WebhookAllowPrivate,
+	}, logger)
+	webhooks.Start(ctx)
+	defer webhooks.Stop()
+	ingestOpts = append(ingestOpts, handlers.WithVerificationWebhooks(repo, webhooks))
+	if cfg.CallbacksEnabled {
+		ingestOpts = append(ingestOpts, handlers.WithCallbacks(webhooks, cfg.CallbackSecret))
+		logger.Info("Ingestion callbacks enabled")
+	}
+
+	// Sign a receipt for every accepted event
+	var receiptSigner *receipt.Signer
+	if cfg.ReceiptSigningKey != "" {
+		receiptSigner, err = receipt.Parse(cfg.ReceiptSigningKey, cfg.ReceiptKeyID)
+		if err != nil {
+			logger.Error("Invalid receipt signing key", "error", err)
+			os.Exit(1)
+		}
+		ingestOpts = append(ingestOpts, handlers.WithReceipts(receiptSigner))
+		logger.Info("Ingestion receipts enabled", "key_id", receiptSigner.KeyID())
+	}
+	ingestOpts = append(ingestOpts, handlers.WithPresets(repo))
+
+	// Flag issuers whose ingestion rate spikes above their baseline
+	if cfg.IssuerRateEnabled {
+		issuerRates := issuerrate.NewTracker(issuerrate.Config{
+			Window:      cfg.IssuerRateWindow,
+			Baseline:    cfg.IssuerRateBaseline,
+			SpikeFactor: cfg.IssuerRateSpikeFactor,
+			MinCount:    cfg.IssuerRateMinCount,
+			MaxIssuers:  cfg.IssuerRateMaxIssuers,
+			OnAnomaly: func(a issuerrate.Anomaly) {
+				logger.Warn("Issuer ingestion rate spike",
+					"issuer", a.Issuer,
+					"current", a.Current,
+					"baseline", a.Baseline,
+				)
+				if cfg.IssuerAlertWebhookURL == "" {
+					return
+				}
+				err := webhooks.Enqueue(webhook.Delivery{
+					URL:     cfg.IssuerAlertWebhookURL,
+					Secret:  cfg.IssuerAlertWebhookSecret,
+					Event:   issuerrate.AlertEvent,
+					Payload: a,
+				})
+				if err != nil {
+					logger.Error("Failed to send issuer rate alert", "error", err, "issuer", a.Issuer)
+				}
+			},
+		})
+		ingestOpts = append(ingestOpts, handlers.WithIssuerRates(issuerRates))
+		expvar.Publish("issuer_rates", expvar.Func(func() any { return issuerRates.Stats() }))
+		logger.Info("Issuer rate tracking enabled",
+			"window", cfg.IssuerRateWindow.String(),
+			"spike_factor", cfg.IssuerRateSpikeFactor,
+		)
+	}
+
+	// Publish credentials in one VC Data Model version
+	switch cfg.CanonicalDataModel {
+	case "":
+	case vcmodel.V1, vcmodel.V2:
+		ingestOpts = append(ingestOpts, handlers.WithCanonicalDataModel(cfg.CanonicalDataModel))
+		logger.Info("VC data model normalization enabled", "version", cfg.CanonicalDataModel)
+	default:
+		logger.Error("Invalid canonical VC data model version", "version", cfg.CanonicalDataModel)
+		os.Exit(1)
+	}
+
+	// Decide what happens to events whose fields cannot be extracted
+	switch cfg.ExtractionFailureMode {
+	case models.ExtractionFailureIgnore, models.ExtractionFailureQuarantine, models.ExtractionFailureReject:
+		ingestOpts = append(ingestOpts, handlers.WithExtractionFailureMode(cfg.ExtractionFailureMode, repo))
+		logger.Info("Extraction failure handling configured", "mode", cfg.ExtractionFailureMode)
+	default:
+		logger.Error("Invalid extraction failure mode", "mode", cfg.ExtractionFailureMode)
+		os.Exit(1)
+	}
+
+	// Bound concurrent credential verification separately from admission
+	if cfg.MaxConcurrentVerifications > 0 {
+		verifyLimit := admission.NewLimiter(cfg.MaxConcurrentVerifications, cfg.VerificationQueueTimeout)
+		ingestOpts = append(ingestOpts, handlers.WithVerificationLimit(verifyLimit))
+		expvar.Publish("verification_limit", expvar.Func(func() any { return verifyLimit.Stats() }))
+		logger.Info("Verification concurrency limited", "max_concurrent", cfg.MaxConcurrentVerifications)
+	}
+
+	// Keep accepting credentials when verification is overloaded, checking
+	// them in the background instead
+	if cfg.VerificationDeferralEnabled {
+		ingestOpts = append(ingestOpts, handlers.WithDeferredVerification(cfg.VerificationDeferAfter))
+		logger.Info("Deferred verification enabled", "defer_after", cfg.VerificationDeferAfter.String())
+	}
+
+	// Bound payload sizes, with room for issuers known to send large payloads
+	ingestOpts = append(ingestOpts, handlers.WithPayloadLimits(cfg.MaxPayloadBytes, cfg.IssuerPayloadLimits))
+
+	// Bind credential subjects to the ingesting user for selected tenants
+	if len(cfg.SubjectBindingTenants) > 0 {
+		ingestOpts = append(ingestOpts, handlers.WithSubjectBinding(repo, cfg.SubjectBindingTenants))
+		logger.Info("Credential subject binding enabled", "tenants", cfg.SubjectBindingTenants)
+	}
+
+	// Accept VCs only from trusted issuers
+	if len(cfg.IssuerAllowlist) > 0 {
+		ingestOpts = append(ingestOpts, handlers.WithIssuerAllowlist(cfg.IssuerAllowlist))
+		logger.Info("Issuer allowlist enabled", "issuers", cfg.IssuerAllowlist)
+	}
+
+	// DID documents are shared by request signing and proof verification
+	resolver := did.NewMultiResolver()
+	resolver.Register("key", did.KeyResolver{})
+	resolver.Register("web", did.NewWebResolver(cfg.DIDResolveTimeout, cfg.DIDDocumentCacheTTL))
+
+	// Every credential must be signed by the key it names
+	if cfg.ProofVerificationEnabled {
+		ingestOpts = append(ingestOpts, handlers.WithProofVerification(proof.NewVerifier(resolver)))
+		logger.Info("Credential proof verification enabled")
+	}
+
+	// OIDC tokens must be signed by their issuer for one of our clients
+	if cfg.OIDCValidationEnabled {
+		var audiences []string
+		for _, id := range []string{cfg.GoogleClientID, cfg.GitHubClientID} {
+			if id != "" {
+				audiences = append(audiences, id)
+			}
+		}
+		validator, err := oidc.NewValidator(oidc.Config{
+			Issuers:   cfg.OIDCIssuers,
+			Audiences: audiences,
+			Constraints: map[string]oidc.Constraint{
+				oidc.GitHubActionsIssuer: {
+					Audience:     cfg.OIDCGitHubAudience,
+					Repositories: cfg.OIDCGitHubRepositories,
+				},
+			},
+			FetchTimeout: cfg.OIDCFetchTimeout,
+			KeyCacheTTL:  cfg.OIDCKeyCacheTTL,
+		})
+		if err != nil {
+			logger.Error("Failed to initialize OIDC validation", "error", err)
+			os.Exit(1)
+		}
+		ingestOpts = append(ingestOpts, handlers.WithIDTokenValidation(validator))
+		logger.Info("OIDC ID token validation enabled", "issuers", cfg.OIDCIssuers)
+	}
+
+	// Sensitive credential types need proofs from several issuers
+	if cfg.MultisigPolicyFile != "" {
+		policies, err := proof.LoadPolicies(cfg.MultisigPolicyFile)
+		if err != nil {
+			logger.Error("Failed to load signature policies", "error", err)
+			os.Exit(1)
+		}
+		ingestOpts = append(ingestOpts, handlers.WithSignaturePolicies(proof.NewVerifier(resolver), policies))
+		logger.Info("Multi-issuer signature policies loaded", "credential_types", len(policies))
+	}
+
+	// Derived credentials must descend from intact, valid ancestors
+	if cfg.ProvenanceVerificationEnabled {
+		if cfg.ProvenanceMaxDepth < 1 {
+			logger.Error("Invalid provenance max depth", "provenance_max_depth", cfg.ProvenanceMaxDepth)
+			os.Exit(1)
+		}
+		ingestOpts = append(ingestOpts, handlers.WithProvenanceVerification(repo, cfg.ProvenanceMaxDepth))
+		logger.Info("Provenance chain verification enabled", "max_depth", cfg.ProvenanceMaxDepth)
+	}
+
+	// Scope idempotency keys; some clients reuse them across source types
+	switch cfg.IdempotencyKeyScope {
+	case models.IdempotencyScopeUser, models.IdempotencyScopeUserSourceType, models.IdempotencyScopeGlobal:
+	default:
+		logger.Error("Invalid idempotency key scope", "idempotency_key_scope", cfg.IdempotencyKeyScope)
+		os.Exit(1)
+	}
+	ingestOpts = append(ingestOpts, handlers.WithIdempotency(repo, cfg.IdempotencyKeyScope, cfg.IdempotencyKeyTTL))
+
+	// Scan uploaded attachments before storing them
+	if cfg.AttachmentsEnabled {
+		switch cfg.AttachmentInfectedAction {
+		case models.AttachmentInfectedReject, models.AttachmentInfectedQuarantine:
+		default:
+			logger.Error("Invalid infected attachment action", "attachment_infected_action", cfg.AttachmentInfectedAction)
+			os.Exit(1)
+		}
+		var scanner scan.Scanner
+		if cfg.AttachmentScanAddr != "" {
+			scanner = scan.NewClamAV(cfg.AttachmentScanAddr)
+		} else {
+			logger.Warn("ATTACHMENT SCANNING DISABLED: attachments are stored unscanned")
+		}
+		guard := scan.NewGuard(scanner, cfg.AttachmentScanTimeout, cfg.AttachmentScanFailOpen)
+		ingestOpts = append(ingestOpts, handlers.WithAttachments(repo, guard, int64(cfg.AttachmentMaxBytes), cfg.AttachmentInfectedAction))
+		logger.Info("Event attachments enabled",
+			"scanner", cfg.AttachmentScanAddr,
+			"fail_open", cfg.AttachmentScanFailOpen,
+			"infected_action", cfg.AttachmentInfectedAction,
+		)
+	}
+
+	// Presentation challenges are held in memory, and remembered for a
+	// while after use so replays get a distinct error
+	challenges := challenge.NewMemoryStore(cfg.ChallengeTTL, cfg.ChallengeRetention)
 
 	// Create handlers
-	ingestHandler := handlers.NewIngestHandler(repo, publisher, logger)
+	ingestOpts = append(ingestOpts, handlers.WithConfirmPublisher(publisher))
+	ingestHandler := handlers.NewIngestHandler(repo, eventPublisher, challenges, logger, ingestOpts...)
+
+	// Each database with deferred events gets its own verification worker
+	deferredWorkers := make(map[string]*deferred.Worker)
+	startDeferredWorker := func(region string, repo repository.DeferredRepository, h *handlers.IngestHandler) {
+		if !cfg.VerificationDeferralEnabled {
+			return
+		}
+		w := deferred.NewWorker(repo, h, deferred.Config{
+			Interval:  cfg.DeferredVerificationInterval,
+			BatchSize: cfg.DeferredVerificationBatchSize,
+		}, logger)
+		w.Start(ctx)
+		deferredWorkers[region] = w
+	}
+
+	// Each database also gets a relay for events left unpublished, such as
+	// those stored while the broker was down
+	relays := make(map[string]*outbox.Relay)
+	startRelay := func(region string, repo repository.OutboxRepository) {
+		if !cfg.OutboxRelayEnabled {
+			return
+		}
+		r := outbox.NewRelay(repo, publisher, outbox.Config{
+			Interval:      cfg.OutboxRelayInterval,
+			After:         cfg.OutboxRelayAfter,
+			MinBatchSize:  cfg.OutboxRelayMinBatchSize,
+			MaxBatchSize:  cfg.OutboxRelayMaxBatchSize,
+			LatencyTarget: cfg.OutboxRelayLatencyTarget,
+		}, logger)
+		r.Start(ctx)
+		relays[region] = r
+	}
+
+	localRegion := cfg.Region
+	if localRegion == "" {
+		localRegion = "local"
+	}
+	startDeferredWorker(localRegion, repo, ingestHandler)
+	startRelay(localRegion, repo)
+
+	// Keep each tenant's events in its contractual data residency region
+	route := func(serve func(*handlers.IngestHandler, *gin.Context)) gin.HandlerFunc {
+		return func(c *gin.Context) { serve(ingestHandler, c) }
+	}
+	var residency *handlers.ResidencyHandler
+	if len(cfg.TenantRegions) > 0 {
+		if cfg.Region == "" {
+			logger.Error("REGION is required when TENANT_REGIONS is set")
+			os.Exit(1)
+		}
+		residency = handlers.NewResidencyHandler(cfg.Region, ingestHandler, cfg.TenantRegions, logger)
+		pools := map[string]*repository.PostgresRepository{cfg.Region: repo}
+		for region, url := range cfg.RegionDatabaseURLs {
+			if region == cfg.Region {
+				continue
+			}
+			regionRepo, err := repository.NewPostgresRepository(ctx, url, poolOptions)
+			if err != nil {
+				logger.Error("Failed to initialize regional database", "region", region, "error", err)
+				os.Exit(1)
+			}
+			defer regionRepo.Close()
+			if keys != nil {
+				regionRepo.SetKeyring(keys)
+			}
+			regionOpts := append(slices.Clip(ingestOpts), handlers.WithRegionalStore(regionRepo))
+			regionHandler := handlers.NewIngestHandler(regionRepo, eventPublisher, challenges, logger, regionOpts...)
+			residency.AddRegion(region, regionHandler)
+			startDeferredWorker(region, regionRepo, regionHandler)
+			startRelay(region, regionRepo)
+			pools[region] = regionRepo
+		}
+		for region, url := range cfg.RegionIngestURLs {
+			if _, ok := pools[region]; ok {
+				continue
+			}
+			fwd, err := forward.New(url, cfg.ForwardTimeout)
+			if err != nil {
+				logger.Error("Failed to initialize regional forwarding", "region", region, "error", err)
+				os.Exit(1)
+			}
+			residency.AddForwarder(region, fwd)
+		}
+		route = residency.Route
+		expvar.Publish("region_pools", expvar.Func(func() any {
+			stats := make(map[string]repository.PoolStats, len(pools))
+			for region, pool := range pools {
+				stats[region] = pool.PoolStats()
+			}
+			return stats
+		}))
+		logger.Info("Data residency routing enabled", "region", cfg.Region, "tenants", len(cfg.TenantRegions), "regions", len(pools))
+	}
+	if len(deferredWorkers) > 0 {
+		defer func() {
+			for _, w := range deferredWorkers {
+				w.Stop()
+			}
+		}()
+		expvar.Publish("deferred_verification", expvar.Func(func() any {
+			stats := make(map[string]deferred.Stats, len(deferredWorkers))
+			for region, w := range deferredWorkers {
+				stats[region] = w.Stats()
+			}
+			return stats
+		}))
+	}
+	if len(relays) > 0 {
+		defer func() {
+			for _, r := range relays {
+				r.Stop()
+			}
+		}()
+		expvar.Publish("outbox_relay", expvar.Func(func() any {
+			stats := make(map[string]outbox.Stats, len(relays))
+			for region, r := range relays {
+				stats[region] = r.Stats()
+			}
+			return stats
+		}))
+		logger.Info("Outbox relay enabled",
+			"min_batch_size", cfg.OutboxRelayMinBatchSize,
+			"max_batch_size", cfg.OutboxRelayMaxBatchSize,
+			"latency_target", cfg.OutboxRelayLatencyTarget.String(),
+		)
+	}
+	challengeHandler := handlers.NewChallengeHandler(challenges, logger)
+
+	// Import credential files dropped into a bucket
+	if cfg.S3IngestEnabled {
+		if cfg.S3IngestUserID == "" {
+			logger.Error("S3_INGEST_USER_ID is required for S3 ingestion")
+			os.Exit(1)
+		}
+		client, err := s3ingest.NewClient(cfg.S3IngestEndpoint, cfg.S3IngestRegion, s3ingest.Credentials{
+			AccessKeyID:     cfg.S3IngestAccessKeyID,
+			SecretAccessKey: cfg.S3IngestSecretAccessKey,
+		}, cfg.S3IngestTimeout)
+		if err != nil {
+			logger.Error("Failed to initialize S3 client", "error", err)
+			os.Exit(1)
+		}
+		s3Worker, err := s3ingest.NewWorker(cfg.RabbitMQURL, client, repo, ingestHandler, s3ingest.Config{
+			Queue:          cfg.S3IngestQueue,
+			UserID:         cfg.S3IngestUserID,
+			TenantID:       cfg.S3IngestTenantID,
+			MaxObjectBytes: int64(cfg.S3IngestMaxObjectBytes),
+		}, logger)
+		if err != nil {
+			logger.Error("Failed to initialize S3 ingestion", "error", err)
+			os.Exit(1)
+		}
+		if err := s3Worker.Start(ctx); err != nil {
+			logger.Error("Failed to start S3 ingestion", "error", err)
+			os.Exit(1)
+		}
+		defer s3Worker.Stop()
+		logger.Info("S3 ingestion enabled", "queue", cfg.S3IngestQueue, "endpoint", cfg.S3IngestEndpoint)
+	}
+	sloHandler := handlers.NewSLOHandler(sloTracker)
+	webhookHandler := handlers.NewWebhookHandler(repo, webhooks, logger)
+	presetHandler := handlers.NewPresetHandler(repo, logger)
+	deletionHandler := handlers.NewDeletionHandler(repo, cfg.DeleteGracePeriod, logger)
+	exportHandler := handlers.NewExportHandler(repo, cfg.ExportQueryTimeout, logger)
+	statsHandler := handlers.NewStatsHandler(repo, logger)
+	searchHandler := handlers.NewSearchHandler(repo, logger)
+	subjectHandler := handlers.NewSubjectHandler(repo, logger)
+	userHandler := handlers.NewUserHandler(repo, logger)
+
+	// Republish stored events straight to the broker with confirms, bypassing
+	// the per-source-type buffers so ordering is preserved
+	replayer, err := replay.New(repo, publisher, replay.Config{
+		Mode:        cfg.ReplayMode,
+		Concurrency: cfg.ReplayConcurrency,
+		BatchSize:   cfg.ReplayBatchSize,
+	}, logger)
+	if err != nil {
+		logger.Error("Failed to initialize event replay", "error", err)
+		os.Exit(1)
+	}
+	replayHandler := handlers.NewReplayHandler(replayer, repo, logger)
+	streamHandler := handlers.NewStreamHandler(repo, handlers.StreamConfig{
+		PollInterval: cfg.StreamPollInterval,
+		SettleDelay:  cfg.StreamSettleDelay,
+		BatchSize:    cfg.StreamBatchSize,
+	}, logger)
+	healthHandler := handlers.NewHealthHandler(repo, publisher)
+	readinessHandler := handlers.NewReadinessHandler(repo, publisher, schemaStatus)
 
 	// Set up Gin router
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
 
 	// Apply middleware
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Recovery(logger))
 	router.Use(middleware.Logger(logger))
-	router.Use(middleware.CORS())
+	if cfg.MaxHeaderCount > 0 || cfg.MaxHeaderBytes > 0 {
+		router.Use(middleware.MaxHeaders(cfg.MaxHeaderCount, cfg.MaxHeaderBytes))
+	}
+	router.Use(middleware.Tracing())
+	router.Use(middleware.CORS(cfg.CORSAllowedOrigins))
+
+	// Gzipped bodies are inflated before any body limit applies, and never
+	// past the largest one; large responses are gzipped for clients that
+	// accept it
+	maxBodyBytes := max(
+		handlers.RequestBodyLimit(cfg.MaxPayloadBytes, cfg.IssuerPayloadLimits),
+		int64(cfg.MaxBatchBodyBytes),
+	)
+	router.Use(middleware.Decompress(maxBodyBytes))
+	if cfg.CompressMinBytes > 0 {
+		router.Use(middleware.Compress(cfg.CompressMinBytes))
+	}
 
 	// Health check endpoints
-	router.GET("/health", handlers.HandleHealth)
-	router.GET("/ready", handlers.HandleReadiness)
+	router.GET("/health", healthHandler.HandleHealth)
+	router.GET("/ready", readinessHandler.HandleReadiness)
+
+	// Metrics endpoint (expvar JSON)
+	router.GET("/metrics", gin.WrapH(expvar.Handler()))
 
 	// API v1 routes
 	v1 := router.Group("/api/v1")
+
+	// Callers authenticate with a bearer token, or as admin with the admin key
+	v1.Use(middleware.AdminKey(cfg.AdminAPIKey), middleware.AuthJWT(cfg.JWTSecret, clock))
+
+	// Debug capture must wrap every v1 route it may record
+	var captureStore *capture.Store
+	if cfg.CaptureEnabled {
+		captureStore = capture.NewStore(cfg.CaptureTTL, cfg.CaptureMaxEntries)
+		v1.Use(middleware.Capture(captureStore, cfg.CaptureMaxBodyBytes, maxBodyBytes, redactor))
+		logger.Warn("Debug request capture enabled", "ttl", cfg.CaptureTTL.String())
+	}
+
+	// DID-identified producers may also sign their request bodies
+	if cfg.DIDAuthEnabled {
+		v1.Use(middleware.DIDAuth(resolver, clock, cfg.DIDAuthMaxAge))
+		logger.Info("DID request signing enabled", "methods", []string{"key", "web"})
+	}
+
+	// Share the concurrency budget fairly between tenants
+	if cfg.AdmissionEnabled {
+		scheduler := admission.NewScheduler(admission.Config{
+			MaxConcurrent:     cfg.AdmissionMaxConcurrent,
+			MaxQueuePerTenant: cfg.AdmissionMaxQueuePerTenant,
+			Weights:           cfg.AdmissionTenantWeights,
+		})
+		v1.Use(middleware.FairAdmission(scheduler, cfg.AdmissionQueueTimeout))
+		expvar.Publish("admission", expvar.Func(func() any { return scheduler.Stats() }))
+		logger.Info("Fair admission enabled", "max_concurrent", cfg.AdmissionMaxConcurrent)
+	}
+
+	// Bound the time spent on each request once it is admitted; streams and
+	// exports run as long as they need
+	if cfg.RequestTimeout > 0 {
+		v1.Use(middleware.RequestTimeout(cfg.RequestTimeout,
+			"/api/v1/events/stream", "/api/v1/export", "/api/v1/admin/audit/export"))
+		logger.Info("Request timeout enabled", "timeout", cfg.RequestTimeout.String())
+	}
+
+	// Catch non-UTF-8 payloads before they are decoded
+	switch cfg.InvalidUTF8Mode {
+	case middleware.InvalidUTF8Reject, middleware.InvalidUTF8Sanitize:
+	default:
+		logger.Error("Invalid UTF-8 handling mode", "mode", cfg.InvalidUTF8Mode)
+		os.Exit(1)
+	}
+	utf8Body := middleware.UTF8Body(cfg.InvalidUTF8Mode, logger)
+
+	// Endpoints that decode a JSON body refuse other media types
+	jsonBody := middleware.RequireJSON()
+
+	var ingestChain []gin.HandlerFunc
+
+	// Oversized bodies are refused before they are read: a single request
+	// may carry the largest allowed payload, a batch up to its own limit
+	ingestBodyLimit := handlers.RequestBodyLimit(cfg.MaxPayloadBytes, cfg.IssuerPayloadLimits)
+
+	// Pad the latency of ingestion requests that reach the idempotency
+	// lookup, so it does not leak whether their key was already used
+	var ingestOne []gin.HandlerFunc
+	if cfg.IngestMinResponseTime > 0 || cfg.IngestResponseJitter > 0 {
+		ingestOne = append(ingestOne, middleware.MinResponseTime(cfg.IngestMinResponseTime, cfg.IngestResponseJitter))
+		logger.Info("Ingestion response timing normalized", "min", cfg.IngestMinResponseTime.String(), "jitter", cfg.IngestResponseJitter.String())
+	}
+	ingestOne = append(ingestOne, jsonBody, middleware.MaxBodySize(ingestBodyLimit), utf8Body, route((*handlers.IngestHandler).HandleIngest))
+
+	// Keep one client from flooding ingestion at the expense of others
+	if cfg.IngestRateLimit > 0 {
+		if cfg.IngestRateBurst < 1 {
+			logger.Error("Ingestion rate burst must be at least 1", "burst", cfg.IngestRateBurst)
+			os.Exit(1)
+		}
+		ingestChain = append(ingestChain, middleware.UserRateLimit(cfg.IngestRateLimit, cfg.IngestRateBurst))
+		logger.Info("Per-user ingestion rate limit enabled", "rate", cfg.IngestRateLimit, "burst", cfg.IngestRateBurst)
+	}
+
 	{
+		// Routes acting as the caller need a user: the admin key alone
+		// identifies none
+		user := v1.Group("", middleware.RequireUser())
+
 		// Ingestion endpoints
-		v1.POST("/ingest", ingestHandler.HandleIngest)
-		v1.GET("/events", ingestHandler.HandleGetUserEvents)
-		v1.GET("/events/:id", ingestHandler.HandleGetEvent)
+		ingest := user.Group("", ingestChain...)
+		ingest.POST("/ingest", ingestOne...)
+		ingest.POST("/ingest/batch", jsonBody, middleware.MaxBodySize(int64(cfg.MaxBatchBodyBytes)), utf8Body, route((*handlers.IngestHandler).HandleIngestBatch))
+		user.GET("/events", route((*handlers.IngestHandler).HandleGetUserEvents))
+		v1.GET("/events/:id", route((*handlers.IngestHandler).HandleGetEvent))
+		v1.GET("/events/:id/payload", route((*handlers.IngestHandler).HandleGetEventPayload))
+		user.PATCH("/events/:id", jsonBody, route((*handlers.IngestHandler).HandleUpdateVerification))
+		user.POST("/events/status", jsonBody, route((*handlers.IngestHandler).HandleGetEventStatuses))
+		user.POST("/events/:id/attachments", route((*handlers.IngestHandler).HandleUploadAttachment))
+		user.GET("/events/:id/attachments", route((*handlers.IngestHandler).HandleListAttachments))
+		user.GET("/events/:id/attachments/:attachment_id", route((*handlers.IngestHandler).HandleDownloadAttachment))
+		user.GET("/events/stream", streamHandler.HandleStream)
+		user.GET("/events/stats", statsHandler.HandleGetEventStats)
+		user.DELETE("/events", deletionHandler.HandleDeleteEventsBefore)
+		v1.DELETE("/events/:id", deletionHandler.HandleDeleteEvent)
+		v1.POST("/events/:id/restore", deletionHandler.HandleRestoreEvent)
+		v1.POST("/events/:id/replay", middleware.RequireAdmin(), replayHandler.HandleReplayEvent)
+		user.GET("/export", exportHandler.HandleExport)
+
+		// Presentation challenges
+		user.POST("/challenges", jsonBody, challengeHandler.HandleCreateChallenge)
+
+		// Webhook endpoints
+		user.GET("/webhooks", webhookHandler.HandleListWebhooks)
+		user.PUT("/webhooks/:event_type", jsonBody, webhookHandler.HandlePutWebhook)
+		user.DELETE("/webhooks/:event_type", webhookHandler.HandleDeleteWebhook)
+
+		// Ingestion presets
+		v1.GET("/presets", presetHandler.HandleListPresets)
+		v1.GET("/presets/:name", presetHandler.HandleGetPreset)
+		v1.PUT("/presets/:name", jsonBody, presetHandler.HandlePutPreset)
+		v1.DELETE("/presets/:name", presetHandler.HandleDeletePreset)
+
+		// Ingestion receipt key
+		if receiptSigner != nil {
+			v1.GET("/receipt-key", handlers.NewReceiptHandler(receiptSigner).HandleGetKey)
+		}
+	}
+
+	// Admin routes
+	admin := v1.Group("/admin", middleware.RequireAdmin())
+	admin.GET("/slo", sloHandler.HandleGetSLO)
+	admin.GET("/events", searchHandler.HandleSearchEvents)
+	admin.GET("/events/by-checksum/:checksum", searchHandler.HandleSearchByChecksum)
+	admin.POST("/events/:id/reverify", ingestHandler.HandleReverifyEvent)
+	admin.POST("/events/republish", jsonBody, replayHandler.HandleRepublishEvents)
+	if rabbit, ok := publisher.(*queue.RabbitMQPublisher); ok {
+		dlqHandler := handlers.NewDLQHandler(queue.NewDLQManager(rabbit.OpenDLQChannel, logger), logger)
+		admin.GET("/dlq", dlqHandler.HandlePeek)
+		admin.POST("/dlq/requeue", dlqHandler.HandleRequeue)
+	}
+	admin.GET("/quarantine", ingestHandler.HandleListQuarantine)
+	admin.POST("/quarantine/:id/reprocess", ingestHandler.HandleReprocessQuarantine)
+	admin.GET("/webhooks/lag", webhookHandler.HandleWebhookLag)
+	if drift != nil {
+		admin.GET("/schema-drift", handlers.NewDriftHandler(drift).HandleGetSchemaDrift)
+	}
+	admin.GET("/users", userHandler.HandleListUsers)
+	admin.GET("/users/:user_id/subjects", subjectHandler.HandleListSubjects)
+	admin.PUT("/users/:user_id/subjects", jsonBody, subjectHandler.HandlePutSubjects)
+	if archiveWorker != nil {
+		archiveHandler := handlers.NewArchiveHandler(archiveWorker, logger)
+		admin.POST("/archives/:id/restore", archiveHandler.HandleRestoreArchive)
+	}
+	if captureStore != nil {
+		captureHandler := handlers.NewCaptureHandler(captureStore)
+		admin.GET("/captures", captureHandler.HandleListCaptures)
+		admin.POST("/captures", jsonBody, captureHandler.HandleArmCapture)
+	}
+	if keys != nil {
+		rotator := rotation.New(repo, rotation.Config{
+			TargetVersion: keys.Current(),
+			BatchSize:     cfg.KeyRotationBatchSize,
+			Pause:         cfg.KeyRotationPause,
+		}, logger)
+		defer rotator.Stop()
+		rotationHandler := handlers.NewRotationHandler(rotator, repo, keys, logger)
+		admin.POST("/encryption/rotate", rotationHandler.HandleStartRotation)
+		admin.GET("/encryption/rotation", rotationHandler.HandleGetRotation)
+	}
+	if cfg.AuditExportKey != "" {
+		auditHandler := handlers.NewAuditHandler(repo, cfg.AuditExportKey, logger)
+		admin.GET("/audit/export",
+			middleware.RateLimit(cfg.AuditExportRateLimit, cfg.AuditExportRateWindow),
+			auditHandler.HandleExport,
+		)
 	}
 
 	// Create HTTP server
@@ -104,13 +985,31 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
-	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Graceful shutdown with timeout: stop accepting requests and wait for
+	// those in progress
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
 		logger.Error("Server forced to shutdown", "error", err)
 	}
 
+	// Let publishes deferred by the stored durability level finish. The
+	// workers and the publisher are stopped by the deferred calls after.
+	waitBackground := ingestHandler.Wait
+	if residency != nil {
+		waitBackground = residency.Wait
+	}
+	background := make(chan struct{})
+	go func() {
+		waitBackground()
+		close(background)
+	}()
+	select {
+	case <-background:
+	case <-ctx.Done():
+		logger.Warn("Shutting down with background publishes still running")
+	}
+
 	logger.Info("Server exited")
 }
@@ -0,0 +1,61 @@
+// Package main is a reference consumer of the graph engine queue. It logs
+// each message it receives, for integration testing without the graph
+// engine.
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/uigs/ingestion/internal/config"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/queue"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		slog.New(slog.NewJSONHandler(os.Stdout, nil)).Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	logOpts := &slog.HandlerOptions{Level: cfg.LogLevel}
+	var logHandler slog.Handler = slog.NewJSONHandler(os.Stdout, logOpts)
+	if cfg.LogFormat == config.LogFormatText {
+		logHandler = slog.NewTextHandler(os.Stdout, logOpts)
+	}
+	logger := slog.New(logHandler)
+	slog.SetDefault(logger)
+	for _, warning := range cfg.Warnings {
+		logger.Warn("Invalid configuration: " + warning)
+	}
+
+	consumer, err := queue.NewRabbitMQConsumer(cfg.RabbitMQURL, cfg.ConsumerPrefetch, cfg.ConsumerMaxDeliveries, logger)
+	if err != nil {
+		logger.Error("Failed to initialize message queue", "error", err)
+		os.Exit(1)
+	}
+	defer consumer.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger.Info("Consuming messages", "queue", queue.QueueName)
+	err = consumer.Consume(ctx, func(msg *models.QueueMessage) error {
+		logger.Info("Message received",
+			"event_id", msg.EventID,
+			"user_id", msg.UserID,
+			"source_type", msg.SourceType,
+			"occurred_at", msg.OccurredAt,
+		)
+		return nil
+	})
+	if err != nil {
+		logger.Error("Consumer stopped", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Worker stopped")
+}
@@ -0,0 +1,262 @@
+// Package admission bounds request concurrency and shares it fairly between
+// tenants, so a single noisy tenant cannot starve the others.
+package admission
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned when the tenant already has the maximum
+	// number of requests waiting.
+	ErrQueueFull = errors.New("admission queue full")
+	// ErrTimeout is returned when a request waited too long for a slot.
+	ErrTimeout = errors.New("admission wait timed out")
+)
+
+// Config controls the scheduler.
+type Config struct {
+	// MaxConcurrent is the total number of requests admitted at once.
+	MaxConcurrent int
+	// MaxQueuePerTenant bounds how many requests a tenant may have waiting.
+	MaxQueuePerTenant int
+	// Weights gives tenants a larger share of freed slots. Tenants without
+	// an entry have weight 1.
+	Weights map[string]int
+}
+
+type waiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+type tenantState struct {
+	waiting []*waiter
+	credit  int
+
+	admitted  int64
+	queued    int64
+	timeouts  int64
+	rejected  int64
+	waitTotal time.Duration
+	waitMax   time.Duration
+}
+
+// Scheduler admits requests up to a concurrency limit. When the limit is
+// reached, waiting requests are queued per tenant and freed slots are handed
+// out by weighted round robin across tenants with waiters.
+type Scheduler struct {
+	cfg Config
+
+	mu      sync.Mutex
+	inUse   int
+	tenants map[string]*tenantState
+	ring    []string // tenants with waiters, in round-robin order
+	next    int
+}
+
+// NewScheduler creates a fair scheduler.
+func NewScheduler(cfg Config) *Scheduler {
+	return &Scheduler{
+		cfg:     cfg,
+		tenants: make(map[string]*tenantState),
+	}
+}
+
+// Acquire waits for an admission slot for tenant. On success the returned
+// release function must be called exactly once when the request finishes.
+func (s *Scheduler) Acquire(ctx context.Context, tenant string) (func(), error) {
+	start := time.Now()
+
+	s.mu.Lock()
+	ts := s.tenantLocked(tenant)
+	if s.inUse < s.cfg.MaxConcurrent && len(s.ring) == 0 {
+		s.inUse++
+		ts.admitted++
+		s.mu.Unlock()
+		return s.release, nil
+	}
+	if len(ts.waiting) >= s.cfg.MaxQueuePerTenant {
+		ts.rejected++
+		s.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+
+	w := &waiter{ready: make(chan struct{})}
+	if len(ts.waiting) == 0 {
+		s.ring = append(s.ring, tenant)
+	}
+	ts.waiting = append(ts.waiting, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		s.recordWait(tenant, time.Since(start), false)
+		return s.release, nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	if w.granted {
+		// The slot was granted while the context expired; keep it.
+		s.mu.Unlock()
+		s.recordWait(tenant, time.Since(start), false)
+		return s.release, nil
+	}
+	s.removeWaiterLocked(tenant, w)
+	s.mu.Unlock()
+
+	s.recordWait(tenant, time.Since(start), true)
+	return nil, ErrTimeout
+}
+
+// release frees a slot and grants it to the next waiter, if any.
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.inUse--
+	for s.inUse < s.cfg.MaxConcurrent && len(s.ring) > 0 {
+		s.grantNextLocked()
+	}
+}
+
+// grantNextLocked hands a slot to the head waiter of the current tenant in
+// the ring. A tenant keeps the turn until it has used up its weight in
+// grants or has no more waiters. Callers must hold s.mu.
+func (s *Scheduler) grantNextLocked() {
+	if s.next >= len(s.ring) {
+		s.next = 0
+	}
+	tenant := s.ring[s.next]
+	ts := s.tenants[tenant]
+
+	if ts.credit <= 0 {
+		ts.credit = s.weight(tenant)
+	}
+
+	w := ts.waiting[0]
+	ts.waiting = ts.waiting[1:]
+	ts.credit--
+	w.granted = true
+	close(w.ready)
+	s.inUse++
+
+	if len(ts.waiting) == 0 {
+		ts.credit = 0
+		s.ring = append(s.ring[:s.next], s.ring[s.next+1:]...)
+		return
+	}
+	if ts.credit == 0 {
+		s.next++
+	}
+}
+
+// removeWaiterLocked drops a waiter that gave up. Callers must hold s.mu.
+func (s *Scheduler) removeWaiterLocked(tenant string, w *waiter) {
+	ts := s.tenants[tenant]
+	for i, candidate := range ts.waiting {
+		if candidate == w {
+			ts.waiting = append(ts.waiting[:i], ts.waiting[i+1:]...)
+			break
+		}
+	}
+	if len(ts.waiting) > 0 {
+		return
+	}
+
+	ts.credit = 0
+	for i, name := range s.ring {
+		if name == tenant {
+			s.ring = append(s.ring[:i], s.ring[i+1:]...)
+			if s.next > i {
+				s.next--
+			}
+			break
+		}
+	}
+}
+
+func (s *Scheduler) recordWait(tenant string, wait time.Duration, timedOut bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts := s.tenants[tenant]
+	ts.queued++
+	ts.waitTotal += wait
+	if wait > ts.waitMax {
+		ts.waitMax = wait
+	}
+	if timedOut {
+		ts.timeouts++
+	} else {
+		ts.admitted++
+	}
+}
+
+func (s *Scheduler) tenantLocked(tenant string) *tenantState {
+	ts, ok := s.tenants[tenant]
+	if !ok {
+		ts = &tenantState{}
+		s.tenants[tenant] = ts
+	}
+	return ts
+}
+
+func (s *Scheduler) weight(tenant string) int {
+	if w, ok := s.cfg.Weights[tenant]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// TenantStats reports admission and wait-time metrics for one tenant.
+type TenantStats struct {
+	Weight        int     `json:"weight"`
+	Admitted      int64   `json:"admitted"`
+	Queued        int64   `json:"queued"`
+	Rejected      int64   `json:"rejected"`
+	Timeouts      int64   `json:"timeouts"`
+	Waiting       int     `json:"waiting"`
+	AvgWaitMillis float64 `json:"avg_wait_ms"`
+	MaxWaitMillis float64 `json:"max_wait_ms"`
+}
+
+// Stats is a point-in-time snapshot of the scheduler.
+type Stats struct {
+	InUse         int                    `json:"in_use"`
+	MaxConcurrent int                    `json:"max_concurrent"`
+	Tenants       map[string]TenantStats `json:"tenants"`
+}
+
+// Stats returns per-tenant admission and wait-time metrics.
+func (s *Scheduler) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := Stats{
+		InUse:         s.inUse,
+		MaxConcurrent: s.cfg.MaxConcurrent,
+		Tenants:       make(map[string]TenantStats, len(s.tenants)),
+	}
+	for name, ts := range s.tenants {
+		avg := 0.0
+		if ts.queued > 0 {
+			avg = float64(ts.waitTotal) / float64(ts.queued) / float64(time.Millisecond)
+		}
+		stats.Tenants[name] = TenantStats{
+			Weight:        s.weight(name),
+			Admitted:      ts.admitted,
+			Queued:        ts.queued,
+			Rejected:      ts.rejected,
+			Timeouts:      ts.timeouts,
+			Waiting:       len(ts.waiting),
+			AvgWaitMillis: avg,
+			MaxWaitMillis: float64(ts.waitMax) / float64(time.Millisecond),
+		}
+	}
+	return stats
+}
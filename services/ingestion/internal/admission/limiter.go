@@ -0,0 +1,85 @@
+package admission
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Limiter is a counting semaphore for work that is expensive regardless of
+// tenant, such as credential verification. Callers beyond the limit wait up
+// to a timeout for a slot.
+type Limiter struct {
+	slots   chan struct{}
+	timeout time.Duration
+
+	waiting  atomic.Int64
+	admitted atomic.Int64
+	timeouts atomic.Int64
+}
+
+// NewLimiter creates a limiter allowing max concurrent holders. Callers wait
+// at most timeout for a slot.
+func NewLimiter(max int, timeout time.Duration) *Limiter {
+	return &Limiter{
+		slots:   make(chan struct{}, max),
+		timeout: timeout,
+	}
+}
+
+// Acquire waits for a slot. On success the returned release function must be
+// called exactly once. ErrTimeout is returned when no slot freed up in time.
+func (l *Limiter) Acquire(ctx context.Context) (func(), error) {
+	return l.AcquireWithin(ctx, l.timeout)
+}
+
+// AcquireWithin is Acquire with its own wait instead of the limiter's
+// timeout.
+func (l *Limiter) AcquireWithin(ctx context.Context, wait time.Duration) (func(), error) {
+	select {
+	case l.slots <- struct{}{}:
+		l.admitted.Add(1)
+		return l.release, nil
+	default:
+	}
+
+	l.waiting.Add(1)
+	defer l.waiting.Add(-1)
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case l.slots <- struct{}{}:
+		l.admitted.Add(1)
+		return l.release, nil
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	l.timeouts.Add(1)
+	return nil, ErrTimeout
+}
+
+func (l *Limiter) release() {
+	<-l.slots
+}
+
+// LimiterStats is a point-in-time snapshot of a limiter.
+type LimiterStats struct {
+	InUse    int   `json:"in_use"`
+	Max      int   `json:"max"`
+	Waiting  int64 `json:"waiting"`
+	Admitted int64 `json:"admitted"`
+	Timeouts int64 `json:"timeouts"`
+}
+
+// Stats returns the limiter's current usage and counters.
+func (l *Limiter) Stats() LimiterStats {
+	return LimiterStats{
+		InUse:    len(l.slots),
+		Max:      cap(l.slots),
+		Waiting:  l.waiting.Load(),
+		Admitted: l.admitted.Load(),
+		Timeouts: l.timeouts.Load(),
+	}
+}
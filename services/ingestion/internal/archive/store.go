@@ -0,0 +1,64 @@
+// Package archive moves aged events from Postgres into cold storage.
+package archive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store is a pluggable cold-storage destination for archive files.
+type Store interface {
+	// Put writes data under name and returns a location that Get accepts.
+	Put(ctx context.Context, name string, data []byte) (string, error)
+	// Get reads back the data stored at location.
+	Get(ctx context.Context, location string) ([]byte, error)
+}
+
+// FileStore stores archives as files in a local or mounted directory.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a file store rooted at dir, creating it if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Put writes the archive atomically via a temporary file.
+func (s *FileStore) Put(ctx context.Context, name string, data []byte) (string, error) {
+	path := filepath.Join(s.dir, filepath.Base(name))
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return "", fmt.Errorf("failed to write archive: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to finalize archive: %w", err)
+	}
+
+	return "file://" + path, nil
+}
+
+// Get reads an archive previously written by Put.
+func (s *FileStore) Get(ctx context.Context, location string) ([]byte, error) {
+	path, ok := strings.CutPrefix(location, "file://")
+	if !ok {
+		return nil, fmt.Errorf("unsupported archive location %q", location)
+	}
+	if filepath.Dir(path) != filepath.Clean(s.dir) {
+		return nil, fmt.Errorf("archive location %q is outside the archive directory", location)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	return data, nil
+}
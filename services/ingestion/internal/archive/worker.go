@@ -0,0 +1,227 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// ErrAlreadyRestored is returned when restoring an archive twice.
+var ErrAlreadyRestored = errors.New("archive already restored")
+
+// ErrArchiveNotFound is returned when restoring an archive that does not exist.
+var ErrArchiveNotFound = errors.New("archive not found")
+
+// WorkerConfig controls which events are archived and how often.
+type WorkerConfig struct {
+	// After is the minimum age of an event before it is archived.
+	After time.Duration
+	// Interval is the time between archival runs.
+	Interval time.Duration
+	// BatchSize is the maximum number of events per archive file.
+	BatchSize int
+}
+
+// Worker periodically exports aged events to a Store as JSONL files, verifies
+// the upload, and only then removes the events from Postgres.
+type Worker struct {
+	repo   repository.ArchiveRepository
+	store  Store
+	cfg    WorkerConfig
+	logger *slog.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewWorker creates an archive worker.
+func NewWorker(repo repository.ArchiveRepository, store Store, cfg WorkerConfig, logger *slog.Logger) *Worker {
+	return &Worker{
+		repo:   repo,
+		store:  store,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Start runs the worker in the background until ctx is cancelled or Stop is called.
+func (w *Worker) Start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			if n, err := w.RunOnce(ctx); err != nil {
+				w.logger.Error("Archival run failed", "error", err, "archived", n)
+			} else if n > 0 {
+				w.logger.Info("Archival run completed", "archived", n)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop halts the worker and waits for an in-progress run to finish.
+func (w *Worker) Stop() {
+	w.once.Do(func() {
+		if w.cancel != nil {
+			w.cancel()
+			<-w.done
+		}
+	})
+}
+
+// RunOnce archives every event older than the configured age, one batch
+// per archive file, and returns the number of events archived.
+func (w *Worker) RunOnce(ctx context.Context) (int, error) {
+	cutoff := time.Now().UTC().Add(-w.cfg.After)
+	total := 0
+
+	for ctx.Err() == nil {
+		events, err := w.repo.ListEventsBefore(ctx, cutoff, w.cfg.BatchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(events) == 0 {
+			return total, nil
+		}
+
+		if err := w.archiveBatch(ctx, events); err != nil {
+			return total, err
+		}
+		total += len(events)
+
+		if len(events) < w.cfg.BatchSize {
+			return total, nil
+		}
+	}
+
+	return total, ctx.Err()
+}
+
+// archiveBatch uploads one batch, verifies it, and deletes it from the database.
+func (w *Worker) archiveBatch(ctx context.Context, events []models.IngestionEvent) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	ids := make([]string, len(events))
+	for i := range events {
+		if err := enc.Encode(&events[i]); err != nil {
+			return fmt.Errorf("failed to encode event %s: %w", events[i].EventID, err)
+		}
+		ids[i] = events[i].EventID
+	}
+	data := buf.Bytes()
+	checksum := sha256Hex(data)
+
+	record := &models.ArchiveRecord{
+		ArchiveID:       uuid.New().String(),
+		Checksum:        checksum,
+		EventCount:      len(events),
+		EventIDs:        ids,
+		OldestCreatedAt: events[0].CreatedAt,
+		NewestCreatedAt: events[len(events)-1].CreatedAt,
+		CreatedAt:       time.Now().UTC(),
+	}
+
+	name := fmt.Sprintf("events-%s-%s.jsonl",
+		record.OldestCreatedAt.Format("20060102T150405Z"),
+		record.ArchiveID,
+	)
+	location, err := w.store.Put(ctx, name, data)
+	if err != nil {
+		return fmt.Errorf("failed to upload archive: %w", err)
+	}
+	record.Location = location
+
+	// Never delete from the hot table unless the archive reads back intact
+	stored, err := w.store.Get(ctx, location)
+	if err != nil {
+		return fmt.Errorf("failed to verify archive: %w", err)
+	}
+	if sha256Hex(stored) != checksum {
+		return fmt.Errorf("archive %s failed checksum verification", location)
+	}
+
+	if err := w.repo.ArchiveEvents(ctx, record); err != nil {
+		return err
+	}
+
+	w.logger.Info("Events archived",
+		"archive_id", record.ArchiveID,
+		"location", location,
+		"count", record.EventCount,
+	)
+	return nil
+}
+
+// Restore loads an archive back into the hot table and returns the number
+// of events it contained.
+func (w *Worker) Restore(ctx context.Context, archiveID string) (int, error) {
+	record, err := w.repo.GetArchive(ctx, archiveID)
+	if err != nil {
+		return 0, err
+	}
+	if record == nil {
+		return 0, ErrArchiveNotFound
+	}
+	if record.RestoredAt != nil {
+		return 0, ErrAlreadyRestored
+	}
+
+	data, err := w.store.Get(ctx, record.Location)
+	if err != nil {
+		return 0, err
+	}
+	if sha256Hex(data) != record.Checksum {
+		return 0, fmt.Errorf("archive %s failed checksum verification", record.Location)
+	}
+
+	var events []models.IngestionEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		var event models.IngestionEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return 0, fmt.Errorf("failed to decode archived event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read archive: %w", err)
+	}
+
+	if err := w.repo.RestoreEvents(ctx, archiveID, events); err != nil {
+		return 0, err
+	}
+
+	w.logger.Info("Archive restored", "archive_id", archiveID, "count", len(events))
+	return len(events), nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,144 @@
+// Package capture records raw request/response exchanges for debugging partner integrations.
+package capture
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Capture is a single recorded request/response exchange.
+type Capture struct {
+	ID             string      `json:"id"`
+	Key            string      `json:"key"`
+	Method         string      `json:"method"`
+	Path           string      `json:"path"`
+	Query          string      `json:"query,omitempty"`
+	RemoteAddress  string      `json:"remote_address"`
+	RequestHeader  http.Header `json:"request_header"`
+	RequestBody    string      `json:"request_body"`
+	Status         int         `json:"status"`
+	ResponseHeader http.Header `json:"response_header"`
+	ResponseBody   string      `json:"response_body"`
+	Truncated      bool        `json:"truncated"`
+	LatencyMillis  int64       `json:"latency_ms"`
+	CapturedAt     time.Time   `json:"captured_at"`
+	ExpiresAt      time.Time   `json:"expires_at"`
+}
+
+// Arm is an outstanding request to capture the next exchanges for a key.
+type Arm struct {
+	Key       string    `json:"key"`
+	Remaining int       `json:"remaining"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store holds armed capture keys and the captures they produced.
+// Both expire after the configured TTL.
+type Store struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	arms       map[string]*Arm
+	captures   []Capture
+}
+
+// NewStore creates a capture store.
+func NewStore(ttl time.Duration, maxEntries int) *Store {
+	return &Store{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		arms:       make(map[string]*Arm),
+	}
+}
+
+// Arm schedules the next count requests matching key for capture.
+// Re-arming a key replaces its remaining count.
+func (s *Store) Arm(key string, count int) Arm {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	arm := &Arm{
+		Key:       key,
+		Remaining: count,
+		ExpiresAt: time.Now().UTC().Add(s.ttl),
+	}
+	s.arms[key] = arm
+	return *arm
+}
+
+// Claim consumes one capture slot for the first armed key among keys.
+// It returns the matched key, or false when none is armed.
+func (s *Store) Claim(keys ...string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		arm, ok := s.arms[key]
+		if !ok {
+			continue
+		}
+		if now.After(arm.ExpiresAt) {
+			delete(s.arms, key)
+			continue
+		}
+		arm.Remaining--
+		if arm.Remaining <= 0 {
+			delete(s.arms, key)
+		}
+		return key, true
+	}
+	return "", false
+}
+
+// Add records a capture, evicting the oldest when the store is full.
+func (s *Store) Add(c Capture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c.ID = uuid.New().String()
+	c.ExpiresAt = c.CapturedAt.Add(s.ttl)
+
+	s.pruneLocked(time.Now())
+	if len(s.captures) >= s.maxEntries {
+		s.captures = s.captures[1:]
+	}
+	s.captures = append(s.captures, c)
+}
+
+// List returns the unexpired captures, oldest first, and the armed keys.
+func (s *Store) List() ([]Capture, []Arm) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.pruneLocked(now)
+
+	captures := make([]Capture, len(s.captures))
+	copy(captures, s.captures)
+
+	arms := make([]Arm, 0, len(s.arms))
+	for key, arm := range s.arms {
+		if now.After(arm.ExpiresAt) {
+			delete(s.arms, key)
+			continue
+		}
+		arms = append(arms, *arm)
+	}
+	return captures, arms
+}
+
+// pruneLocked drops expired captures. Callers must hold s.mu.
+func (s *Store) pruneLocked(now time.Time) {
+	i := 0
+	for i < len(s.captures) && now.After(s.captures[i].ExpiresAt) {
+		i++
+	}
+	s.captures = s.captures[i:]
+}
@@ -0,0 +1,142 @@
+// Package challenge issues and redeems presentation challenges (nonces)
+// that bind a Verifiable Presentation to a single interactive session.
+package challenge
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrUnknownChallenge is returned when a challenge was never issued for
+	// the user and domain, or was forgotten after the retention period.
+	ErrUnknownChallenge = errors.New("challenge not found")
+	// ErrChallengeExpired is returned when a challenge was issued longer ago
+	// than the freshness window.
+	ErrChallengeExpired = errors.New("challenge expired")
+	// ErrChallengeConsumed is returned when a challenge was already used by
+	// an accepted presentation.
+	ErrChallengeConsumed = errors.New("challenge already consumed")
+)
+
+// Challenge is a nonce issued to a user for presentation to a domain.
+type Challenge struct {
+	Value     string    `json:"challenge"`
+	UserID    string    `json:"-"`
+	Domain    string    `json:"domain"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	consumedAt *time.Time
+}
+
+// Store issues challenges and lets each be consumed exactly once.
+type Store interface {
+	Issue(ctx context.Context, userID, domain string) (*Challenge, error)
+	// Check reports whether a challenge may still be used, without
+	// consuming it.
+	Check(ctx context.Context, userID, domain, value string) error
+	// Consume marks a challenge as used by an accepted presentation.
+	Consume(ctx context.Context, userID, domain, value string) error
+}
+
+// MemoryStore is an in-process Store. Challenges are accepted within the
+// freshness window after they are issued; expired and consumed challenges
+// are remembered for the retention period so they can be told apart from
+// unknown ones, then cleaned up.
+type MemoryStore struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	retention  time.Duration
+	challenges map[string]*Challenge
+}
+
+// NewMemoryStore creates an in-memory challenge store with the given
+// freshness window and retention period. A retention shorter than the
+// window is raised to it.
+func NewMemoryStore(ttl, retention time.Duration) *MemoryStore {
+	return &MemoryStore{
+		ttl:        ttl,
+		retention:  max(ttl, retention),
+		challenges: make(map[string]*Challenge),
+	}
+}
+
+// Issue mints a new random challenge for the user and domain.
+func (s *MemoryStore) Issue(ctx context.Context, userID, domain string) (*Challenge, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+
+	now := time.Now().UTC()
+	ch := &Challenge{
+		Value:     base64.RawURLEncoding.EncodeToString(nonce),
+		UserID:    userID,
+		Domain:    domain,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(now)
+	s.challenges[ch.Value] = ch
+
+	return ch, nil
+}
+
+// Check reports whether a challenge is outstanding for the user and domain:
+// issued to them, within the freshness window and not yet consumed.
+func (s *MemoryStore) Check(ctx context.Context, userID, domain, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.usableLocked(userID, domain, value, time.Now())
+	return err
+}
+
+// Consume marks an outstanding challenge as used, so the same presentation
+// cannot be replayed.
+func (s *MemoryStore) Consume(ctx context.Context, userID, domain, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	ch, err := s.usableLocked(userID, domain, value, now)
+	if err != nil {
+		return err
+	}
+	consumedAt := now.UTC()
+	ch.consumedAt = &consumedAt
+	return nil
+}
+
+// usableLocked returns the challenge if it can be used. Callers must hold s.mu.
+func (s *MemoryStore) usableLocked(userID, domain, value string, now time.Time) (*Challenge, error) {
+	ch, ok := s.challenges[value]
+	switch {
+	case !ok || ch.UserID != userID || ch.Domain != domain:
+		return nil, ErrUnknownChallenge
+	case ch.consumedAt != nil:
+		return nil, ErrChallengeConsumed
+	case now.After(ch.ExpiresAt):
+		return nil, ErrChallengeExpired
+	}
+	return ch, nil
+}
+
+// pruneLocked forgets challenges issued longer ago than the retention
+// period. Callers must hold s.mu.
+func (s *MemoryStore) pruneLocked(now time.Time) {
+	for value, ch := range s.challenges {
+		if now.After(ch.IssuedAt.Add(s.retention)) {
+			delete(s.challenges, value)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package config
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    slog.Level
+		wantErr bool
+	}{
+		{value: "debug", want: slog.LevelDebug},
+		{value: "info", want: slog.LevelInfo},
+		{value: "warn", want: slog.LevelWarn},
+		{value: "warning", want: slog.LevelWarn},
+		{value: "error", want: slog.LevelError},
+		{value: " DEBUG ", want: slog.LevelDebug},
+		{value: "verbose", want: slog.LevelInfo, wantErr: true},
+		{value: "", want: slog.LevelInfo, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseLogLevel(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLogLevel(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseLogLevel(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadLogSettings(t *testing.T) {
+	tests := []struct {
+		name         string
+		level        string
+		format       string
+		wantLevel    slog.Level
+		wantFormat   string
+		wantWarnings int
+	}{
+		{name: "defaults", wantLevel: slog.LevelInfo, wantFormat: LogFormatJSON},
+		{name: "debug text", level: "debug", format: "TEXT", wantLevel: slog.LevelDebug, wantFormat: LogFormatText},
+		{name: "invalid level", level: "loud", format: "json", wantLevel: slog.LevelInfo, wantFormat: LogFormatJSON, wantWarnings: 1},
+		{name: "invalid format", level: "error", format: "xml", wantLevel: slog.LevelError, wantFormat: LogFormatJSON, wantWarnings: 1},
+		{name: "both invalid", level: "loud", format: "xml", wantLevel: slog.LevelInfo, wantFormat: LogFormatJSON, wantWarnings: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.level != "" {
+				t.Setenv("LOG_LEVEL", tt.level)
+			}
+			if tt.format != "" {
+				t.Setenv("LOG_FORMAT", tt.format)
+			}
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.LogLevel != tt.wantLevel || cfg.LogFormat != tt.wantFormat {
+				t.Errorf("log settings = %v, %q; want %v, %q", cfg.LogLevel, cfg.LogFormat, tt.wantLevel, tt.wantFormat)
+			}
+			if len(cfg.Warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %q, want %d", cfg.Warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestLoadPoolSettings(t *testing.T) {
+	t.Setenv("DB_MAX_CONNS", "25")
+	t.Setenv("DB_MIN_CONNS", "5")
+	t.Setenv("DB_MAX_CONN_LIFETIME", "15m")
+	t.Setenv("DB_MAX_CONN_IDLE_TIME", "2m")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DBMaxConns != 25 || cfg.DBMinConns != 5 {
+		t.Errorf("conns = %d..%d, want 5..25", cfg.DBMinConns, cfg.DBMaxConns)
+	}
+	if cfg.DBMaxConnLifetime != 15*time.Minute || cfg.DBMaxConnIdleTime != 2*time.Minute {
+		t.Errorf("lifetime = %v, idle time = %v; want 15m, 2m", cfg.DBMaxConnLifetime, cfg.DBMaxConnIdleTime)
+	}
+}
+
+func TestLoadRequestTimeout(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "default", want: 10 * time.Second},
+		{name: "configured", value: "3s", want: 3 * time.Second},
+		{name: "disabled", value: "0s", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.value != "" {
+				t.Setenv("REQUEST_TIMEOUT", tt.value)
+			}
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.RequestTimeout != tt.want {
+				t.Errorf("RequestTimeout = %v, want %v", cfg.RequestTimeout, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadOutboxRelayEnabled(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{name: "default", want: true},
+		{name: "disabled", value: "false", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.value != "" {
+				t.Setenv("OUTBOX_RELAY_ENABLED", tt.value)
+			}
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.OutboxRelayEnabled != tt.want {
+				t.Errorf("OutboxRelayEnabled = %v, want %v", cfg.OutboxRelayEnabled, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadOIDCIssuers(t *testing.T) {
+	tests := []struct {
+		name             string
+		env              map[string]string
+		wantIssuers      []string
+		wantAudience     string
+		wantRepositories []string
+	}{
+		{name: "none trusted by default"},
+		{
+			name:        "configured",
+			env:         map[string]string{"OIDC_ISSUERS": "https://accounts.google.com"},
+			wantIssuers: []string{"https://accounts.google.com"},
+		},
+		{
+			name: "GitHub Actions with its constraint",
+			env: map[string]string{
+				"OIDC_ISSUERS":             "https://token.actions.githubusercontent.com",
+				"OIDC_GITHUB_AUDIENCE":     "uigs",
+				"OIDC_GITHUB_REPOSITORIES": "acme/app, acme/infra",
+			},
+			wantIssuers:      []string{"https://token.actions.githubusercontent.com"},
+			wantAudience:     "uigs",
+			wantRepositories: []string{"acme/app", "acme/infra"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(cfg.OIDCIssuers, ",") != strings.Join(tt.wantIssuers, ",") {
+				t.Errorf("OIDCIssuers = %v, want %v", cfg.OIDCIssuers, tt.wantIssuers)
+			}
+			if cfg.OIDCGitHubAudience != tt.wantAudience {
+				t.Errorf("OIDCGitHubAudience = %q, want %q", cfg.OIDCGitHubAudience, tt.wantAudience)
+			}
+			if strings.Join(cfg.OIDCGitHubRepositories, ",") != strings.Join(tt.wantRepositories, ",") {
+				t.Errorf("OIDCGitHubRepositories = %v, want %v", cfg.OIDCGitHubRepositories, tt.wantRepositories)
+			}
+		})
+	}
+}
+
+func TestLoadHeaderLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		wantCount int
+		wantBytes int
+	}{
+		{name: "defaults", wantCount: 100, wantBytes: 32 << 10},
+		{name: "configured", env: map[string]string{"MAX_HEADER_COUNT": "50", "MAX_HEADER_BYTES": "8192"}, wantCount: 50, wantBytes: 8192},
+		{name: "unlimited", env: map[string]string{"MAX_HEADER_COUNT": "0", "MAX_HEADER_BYTES": "0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.MaxHeaderCount != tt.wantCount || cfg.MaxHeaderBytes != tt.wantBytes {
+				t.Errorf("header limits = %d fields, %d bytes; want %d, %d", cfg.MaxHeaderCount, cfg.MaxHeaderBytes, tt.wantCount, tt.wantBytes)
+			}
+		})
+	}
+}
+
+func TestCheckJWTSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "generated secret", secret: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"},
+		{name: "exactly 32 bytes", secret: strings.Repeat("k", 32)},
+		{name: "unset", wantErr: true},
+		{name: "published placeholder", secret: "default_jwt_secret_change_me", wantErr: true},
+		{name: "too short", secret: strings.Repeat("k", 31), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_SECRET", tt.secret)
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := cfg.CheckJWTSecret(); (err != nil) != tt.wantErr {
+				t.Errorf("CheckJWTSecret() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// fileRefPrefix marks a setting whose value is the path of a file holding
+// the secret, as produced by secret managers that mount secrets as files
+// (Kubernetes secrets, the Vault agent injector).
+const fileRefPrefix = "file://"
+
+// secretLoader resolves secret settings so they need not be placed in the
+// environment. It keeps the first resolution error for Load to return.
+type secretLoader struct {
+	err error
+}
+
+// get resolves a secret setting, trying in order:
+//   - the file named by <key>_FILE,
+//   - the <key> variable, read from the named file if it is a file:// reference,
+//   - defaultValue.
+//
+// Trailing newlines are trimmed from file contents.
+func (l *secretLoader) get(key, defaultValue string) string {
+	if path, ok := os.LookupEnv(key + "_FILE"); ok && path != "" {
+		return l.readFile(key+"_FILE", path)
+	}
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return defaultValue
+	}
+	if path, ok := strings.CutPrefix(value, fileRefPrefix); ok {
+		return l.readFile(key, path)
+	}
+	return value
+}
+
+func (l *secretLoader) readFile(key, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if l.err == nil {
+			l.err = fmt.Errorf("failed to read secret %s: %w", key, err)
+		}
+		return ""
+	}
+	return strings.TrimRight(string(data), "\r\n")
+}
@@ -0,0 +1,84 @@
+// Package credstatus checks the revocation and suspension status of
+// Verifiable Credentials against the issuer's published status.
+package credstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// Credential status values.
+const (
+	StatusActive    = "active"
+	StatusRevoked   = "revoked"
+	StatusSuspended = "suspended"
+)
+
+var (
+	// ErrUnsupportedType is returned for a credentialStatus.type with no checker.
+	ErrUnsupportedType = errors.New("unsupported credential status type")
+	// ErrUnavailable is returned when the issuer's status source cannot be reached.
+	ErrUnavailable = errors.New("credential status unavailable")
+)
+
+// Result is the outcome of a status check.
+type Result struct {
+	Status    string    `json:"status"`
+	Type      string    `json:"type"`
+	CheckedAt time.Time `json:"checked_at"`
+	// Assumed is true when the status source was unavailable and the
+	// credential was treated as active under a fail-open policy.
+	Assumed bool `json:"assumed,omitempty"`
+}
+
+// Checker resolves the status of a single credentialStatus entry.
+type Checker interface {
+	Check(ctx context.Context, entry *models.CredentialStatus) (string, error)
+}
+
+// Registry selects a Checker by credentialStatus.type.
+type Registry struct {
+	checkers map[string]Checker
+	failOpen bool
+}
+
+// NewRegistry creates a registry. With failOpen, credentials whose status
+// source is unavailable are treated as active instead of being rejected.
+func NewRegistry(failOpen bool) *Registry {
+	return &Registry{
+		checkers: make(map[string]Checker),
+		failOpen: failOpen,
+	}
+}
+
+// Register associates a checker with a credentialStatus.type.
+func (r *Registry) Register(statusType string, c Checker) {
+	r.checkers[statusType] = c
+}
+
+// Check resolves the status of entry using the checker for its type.
+func (r *Registry) Check(ctx context.Context, entry *models.CredentialStatus) (Result, error) {
+	checker, ok := r.checkers[entry.Type]
+	if !ok {
+		return Result{}, fmt.Errorf("%w: %s", ErrUnsupportedType, entry.Type)
+	}
+
+	result := Result{Type: entry.Type, CheckedAt: time.Now().UTC()}
+
+	status, err := checker.Check(ctx, entry)
+	if errors.Is(err, ErrUnavailable) && r.failOpen {
+		result.Status = StatusActive
+		result.Assumed = true
+		return result, nil
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	result.Status = status
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package credstatus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// EndpointType is the credentialStatus.type for issuers that expose a
+// per-credential status endpoint, queried OCSP-style at credentialStatus.id.
+const EndpointType = "CredentialStatusEndpoint"
+
+// EndpointChecker queries an issuer's per-credential status endpoint. The
+// endpoint is expected to return a JSON object with a "status" field of
+// "active", "revoked", or "suspended".
+type EndpointChecker struct {
+	fetcher *fetcher
+}
+
+// NewEndpointChecker creates an endpoint checker. Responses without a
+// Cache-Control header are cached for defaultTTL.
+func NewEndpointChecker(timeout, defaultTTL time.Duration) *EndpointChecker {
+	return &EndpointChecker{fetcher: newFetcher(timeout, defaultTTL)}
+}
+
+// Check queries the status endpoint named by entry.ID.
+func (c *EndpointChecker) Check(ctx context.Context, entry *models.CredentialStatus) (string, error) {
+	if entry.ID == "" {
+		return "", fmt.Errorf("credentialStatus.id is required for %s", EndpointType)
+	}
+
+	body, err := c.fetcher.get(ctx, entry.ID)
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("malformed status response: %w", err)
+	}
+
+	switch resp.Status {
+	case StatusActive, StatusRevoked, StatusSuspended:
+		return resp.Status, nil
+	default:
+		return "", fmt.Errorf("unknown credential status %q", resp.Status)
+	}
+}
@@ -0,0 +1,108 @@
+package credstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uigs/ingestion/internal/netguard"
+	"github.com/uigs/ingestion/internal/ttlcache"
+)
+
+// maxStatusDocumentBytes bounds the size of a fetched status document.
+const maxStatusDocumentBytes = 1 << 20
+
+// maxCachedStatusDocuments bounds the number of status documents cached.
+// The URLs come from the credentials being checked, so they are chosen by
+// whoever submits them.
+const maxCachedStatusDocuments = 1024
+
+// fetcher retrieves status documents over HTTPS and caches them for as long
+// as the issuer's Cache-Control header allows. The URLs come from submitted
+// credentials, so connections to internal addresses are refused.
+type fetcher struct {
+	client     *http.Client
+	defaultTTL time.Duration
+	cache      *ttlcache.Cache[[]byte]
+}
+
+func newFetcher(timeout, defaultTTL time.Duration) *fetcher {
+	return &fetcher{
+		client:     netguard.NewClient(timeout, false),
+		defaultTTL: defaultTTL,
+		cache:      ttlcache.New[[]byte](maxCachedStatusDocuments),
+	}
+}
+
+// get returns the document at rawURL, which must be an https URL of a
+// public host. Network failures and 5xx responses are reported as
+// ErrUnavailable so the registry can apply its fail policy.
+func (f *fetcher) get(ctx context.Context, rawURL string) ([]byte, error) {
+	if body, ok := f.cache.Get(rawURL); ok {
+		return body, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" || u.User != nil {
+		return nil, fmt.Errorf("status URL %q must be an https URL with a host and no credentials", rawURL)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid status URL %q: %w", rawURL, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.client.Do(req)
+	if errors.Is(err, netguard.ErrForbiddenDestination) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return nil, fmt.Errorf("%w: status endpoint returned %d", ErrUnavailable, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status endpoint returned %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxStatusDocumentBytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	f.cache.Set(rawURL, body, cacheTTL(resp.Header.Get("Cache-Control"), f.defaultTTL))
+
+	return body, nil
+}
+
+// cacheTTL derives how long a response may be cached from its Cache-Control
+// header, falling back to defaultTTL when the header does not say.
+func cacheTTL(header string, defaultTTL time.Duration) time.Duration {
+	if header == "" {
+		return defaultTTL
+	}
+	for _, directive := range strings.Split(header, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		switch {
+		case directive == "no-store" || directive == "no-cache":
+			return 0
+		case strings.HasPrefix(directive, "max-age="):
+			seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age="))
+			if err != nil || seconds < 0 {
+				return 0
+			}
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return defaultTTL
+}
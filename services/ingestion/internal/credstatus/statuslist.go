@@ -0,0 +1,97 @@
+package credstatus
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// StatusList2021Type is the credentialStatus.type of a StatusList2021 entry.
+const StatusList2021Type = "StatusList2021Entry"
+
+// maxStatusListBytes bounds the decompressed size of a status list bitstring.
+const maxStatusListBytes = 16 << 20
+
+// StatusListChecker checks a credential's bit in a StatusList2021 credential.
+type StatusListChecker struct {
+	fetcher *fetcher
+}
+
+// NewStatusListChecker creates a StatusList2021 checker. Status list
+// credentials without a Cache-Control header are cached for defaultTTL.
+func NewStatusListChecker(timeout, defaultTTL time.Duration) *StatusListChecker {
+	return &StatusListChecker{fetcher: newFetcher(timeout, defaultTTL)}
+}
+
+// Check fetches the status list credential and reads the entry's bit.
+func (c *StatusListChecker) Check(ctx context.Context, entry *models.CredentialStatus) (string, error) {
+	index, err := strconv.Atoi(entry.StatusListIndex)
+	if err != nil || index < 0 {
+		return "", fmt.Errorf("invalid statusListIndex %q", entry.StatusListIndex)
+	}
+	if entry.StatusListCredential == "" {
+		return "", fmt.Errorf("statusListCredential is required for %s", StatusList2021Type)
+	}
+
+	body, err := c.fetcher.get(ctx, entry.StatusListCredential)
+	if err != nil {
+		return "", err
+	}
+
+	var list struct {
+		CredentialSubject struct {
+			EncodedList string `json:"encodedList"`
+		} `json:"credentialSubject"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return "", fmt.Errorf("malformed status list credential: %w", err)
+	}
+
+	bits, err := decodeStatusList(list.CredentialSubject.EncodedList)
+	if err != nil {
+		return "", err
+	}
+	if index/8 >= len(bits) {
+		return "", fmt.Errorf("statusListIndex %d is out of range", index)
+	}
+
+	// Bit 0 is the most significant bit of the first byte
+	if bits[index/8]&(0x80>>(index%8)) == 0 {
+		return StatusActive, nil
+	}
+	if entry.StatusPurpose == "suspension" {
+		return StatusSuspended, nil
+	}
+	return StatusRevoked, nil
+}
+
+// decodeStatusList decodes a base64url, GZIP-compressed bitstring. A leading
+// multibase "u" prefix, as used by newer status list formats, is accepted.
+func decodeStatusList(encoded string) ([]byte, error) {
+	encoded = strings.TrimPrefix(encoded, "u")
+	compressed, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+	if err != nil {
+		return nil, fmt.Errorf("malformed encodedList: %w", err)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("malformed encodedList: %w", err)
+	}
+	defer zr.Close()
+
+	bits, err := io.ReadAll(io.LimitReader(zr, maxStatusListBytes))
+	if err != nil {
+		return nil, fmt.Errorf("malformed encodedList: %w", err)
+	}
+	return bits, nil
+}
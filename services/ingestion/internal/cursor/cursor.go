@@ -0,0 +1,51 @@
+// Package cursor encodes positions in the event log ordered by
+// (created_at, event_id).
+package cursor
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalid is returned for a malformed cursor string.
+var ErrInvalid = errors.New("invalid cursor")
+
+// Cursor is the position of an event in (created_at, event_id) order.
+// Events strictly after the cursor are those with a later created_at, or
+// the same created_at and a greater event_id.
+type Cursor struct {
+	CreatedAt time.Time
+	EventID   string
+}
+
+// New returns the cursor of an event.
+func New(createdAt time.Time, eventID string) Cursor {
+	return Cursor{CreatedAt: createdAt.UTC(), EventID: eventID}
+}
+
+// String encodes the cursor as "<unix microseconds>_<event id>". PostgreSQL
+// stores timestamps with microsecond precision, so nothing is lost.
+func (c Cursor) String() string {
+	return strconv.FormatInt(c.CreatedAt.UnixMicro(), 10) + "_" + c.EventID
+}
+
+// Parse decodes a cursor produced by String.
+func Parse(s string) (Cursor, error) {
+	micros, eventID, ok := strings.Cut(s, "_")
+	if !ok {
+		return Cursor{}, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+	us, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+	if _, err := uuid.Parse(eventID); err != nil {
+		return Cursor{}, fmt.Errorf("%w: %q", ErrInvalid, s)
+	}
+	return Cursor{CreatedAt: time.UnixMicro(us).UTC(), EventID: eventID}, nil
+}
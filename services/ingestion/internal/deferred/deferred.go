@@ -0,0 +1,163 @@
+// Package deferred verifies, in the background, credentials that were
+// accepted with their checks deferred because verification was overloaded
+// or a dependency such as an issuer's status list was unavailable.
+package deferred
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/uigs/ingestion/internal/handlers"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// Verifier runs the deferred checks on one event and records the outcome.
+// Errors wrapping handlers.ErrVerificationUnavailable leave it deferred.
+type Verifier interface {
+	VerifyDeferred(ctx context.Context, event *models.IngestionEvent) error
+}
+
+// Config controls the deferred verification worker.
+type Config struct {
+	// Interval is the time between passes over the backlog.
+	Interval time.Duration
+	// BatchSize is the number of events read per query.
+	BatchSize int
+}
+
+// Stats summarizes the worker's progress.
+type Stats struct {
+	Verified  int64      `json:"verified"`
+	Retried   int64      `json:"retried"`
+	Failed    int64      `json:"failed"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+}
+
+// Worker periodically verifies deferred events.
+type Worker struct {
+	repo     repository.DeferredRepository
+	verifier Verifier
+	cfg      Config
+	logger   *slog.Logger
+
+	mu    sync.Mutex
+	stats Stats
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewWorker creates a deferred verification worker.
+func NewWorker(repo repository.DeferredRepository, verifier Verifier, cfg Config, logger *slog.Logger) *Worker {
+	return &Worker{
+		repo:     repo,
+		verifier: verifier,
+		cfg:      cfg,
+		logger:   logger,
+	}
+}
+
+// Start runs the worker in the background until ctx is cancelled or Stop is called.
+func (w *Worker) Start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			if n, err := w.RunOnce(ctx); err != nil {
+				w.logger.Error("Deferred verification run failed", "error", err, "processed", n)
+			} else if n > 0 {
+				w.logger.Info("Deferred verification run completed", "processed", n)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop halts the worker and waits for an in-progress run to finish.
+func (w *Worker) Stop() {
+	w.once.Do(func() {
+		if w.cancel != nil {
+			w.cancel()
+			<-w.done
+		}
+	})
+}
+
+// RunOnce makes one pass over the deferred events, oldest first, and
+// returns the number whose verification completed. Events whose checks are
+// still unavailable are skipped until the next pass.
+func (w *Worker) RunOnce(ctx context.Context) (int, error) {
+	var afterCreated time.Time
+	var afterID string
+	processed := 0
+
+	defer func() {
+		now := time.Now().UTC()
+		w.mu.Lock()
+		w.stats.LastRunAt = &now
+		w.mu.Unlock()
+	}()
+
+	for ctx.Err() == nil {
+		events, err := w.repo.ListDeferredEvents(ctx, afterCreated, afterID, w.cfg.BatchSize)
+		if err != nil {
+			return processed, err
+		}
+
+		for i := range events {
+			event := &events[i]
+			afterCreated, afterID = event.CreatedAt, event.EventID
+
+			err := w.verifier.VerifyDeferred(ctx, event)
+			switch {
+			case errors.Is(err, handlers.ErrVerificationUnavailable):
+				w.count(&w.stats.Retried)
+				w.logger.Debug("Deferred verification still unavailable", "error", err, "event_id", event.EventID)
+			case err != nil:
+				w.count(&w.stats.Failed)
+				w.logger.Error("Failed to verify deferred event", "error", err, "event_id", event.EventID)
+			default:
+				w.count(&w.stats.Verified)
+				processed++
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		if len(events) < w.cfg.BatchSize {
+			return processed, nil
+		}
+	}
+
+	return processed, ctx.Err()
+}
+
+// Stats returns the worker's counters.
+func (w *Worker) Stats() Stats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stats
+}
+
+func (w *Worker) count(counter *int64) {
+	w.mu.Lock()
+	*counter++
+	w.mu.Unlock()
+}
@@ -0,0 +1,124 @@
+// Package did resolves Decentralized Identifiers to their public keys.
+// Only the did:key and did:web methods with Ed25519 keys are supported.
+package did
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnsupportedMethod is returned for a DID method with no resolver.
+	ErrUnsupportedMethod = errors.New("unsupported DID method")
+	// ErrKeyNotFound is returned when the DID document has no matching key.
+	ErrKeyNotFound = errors.New("verification method not found")
+)
+
+// VerificationMethod is a public key entry in a DID document.
+type VerificationMethod struct {
+	ID                 string `json:"id"`
+	Type               string `json:"type"`
+	Controller         string `json:"controller"`
+	PublicKeyMultibase string `json:"publicKeyMultibase,omitempty"`
+	PublicKeyJwk       *JWK   `json:"publicKeyJwk,omitempty"`
+}
+
+// JWK is the subset of a JSON Web Key needed for Ed25519 keys.
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+}
+
+// Document is a resolved DID document.
+type Document struct {
+	ID                 string               `json:"id"`
+	VerificationMethod []VerificationMethod `json:"verificationMethod"`
+	Authentication     []any                `json:"authentication,omitempty"`
+}
+
+// Resolver resolves a DID to its document.
+type Resolver interface {
+	Resolve(ctx context.Context, did string) (*Document, error)
+}
+
+// MultiResolver dispatches to a resolver by DID method.
+type MultiResolver struct {
+	methods map[string]Resolver
+}
+
+// NewMultiResolver creates a resolver with no methods registered.
+func NewMultiResolver() *MultiResolver {
+	return &MultiResolver{methods: make(map[string]Resolver)}
+}
+
+// Register associates a resolver with a DID method, e.g. "key" or "web".
+func (m *MultiResolver) Register(method string, r Resolver) {
+	m.methods[method] = r
+}
+
+// Resolve resolves did using the resolver registered for its method.
+func (m *MultiResolver) Resolve(ctx context.Context, did string) (*Document, error) {
+	method, err := Method(did)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := m.methods[method]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedMethod, method)
+	}
+	return r.Resolve(ctx, did)
+}
+
+// Method returns the method name of did.
+func Method(did string) (string, error) {
+	parts := strings.SplitN(did, ":", 3)
+	if len(parts) != 3 || parts[0] != "did" || parts[1] == "" || parts[2] == "" {
+		return "", fmt.Errorf("malformed DID %q", did)
+	}
+	return parts[1], nil
+}
+
+// SplitKeyID splits a DID URL such as did:web:example.com#key-1 into the
+// DID and its fragment.
+func SplitKeyID(keyID string) (string, string) {
+	did, fragment, _ := strings.Cut(keyID, "#")
+	return did, fragment
+}
+
+// PublicKey returns the Ed25519 key of the verification method identified by
+// keyID. A bare fragment ("#key-1") is resolved relative to the document.
+func (d *Document) PublicKey(keyID string) (ed25519.PublicKey, error) {
+	for _, vm := range d.VerificationMethod {
+		if vm.ID != keyID && d.ID+vm.ID != keyID && vm.ID != d.ID+keyID {
+			continue
+		}
+		return vm.ed25519Key()
+	}
+	return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, keyID)
+}
+
+func (vm VerificationMethod) ed25519Key() (ed25519.PublicKey, error) {
+	switch {
+	case vm.PublicKeyMultibase != "":
+		return decodeMultibaseKey(vm.PublicKeyMultibase)
+	case vm.PublicKeyJwk != nil:
+		if vm.PublicKeyJwk.Kty != "OKP" || vm.PublicKeyJwk.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported JWK key type %s/%s", vm.PublicKeyJwk.Kty, vm.PublicKeyJwk.Crv)
+		}
+		raw, err := base64.RawURLEncoding.DecodeString(vm.PublicKeyJwk.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWK x: %w", err)
+		}
+		if len(raw) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key length %d", len(raw))
+		}
+		return ed25519.PublicKey(raw), nil
+	default:
+		return nil, fmt.Errorf("verification method %s has no supported key", vm.ID)
+	}
+}
@@ -0,0 +1,85 @@
+package did
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ed25519Multicodec is the multicodec prefix for an Ed25519 public key.
+var ed25519Multicodec = []byte{0xed, 0x01}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// KeyResolver resolves did:key identifiers. The key is encoded in the DID
+// itself, so no network access is needed.
+type KeyResolver struct{}
+
+// Resolve builds the DID document for a did:key identifier.
+func (KeyResolver) Resolve(_ context.Context, did string) (*Document, error) {
+	encoded, ok := strings.CutPrefix(did, "did:key:")
+	if !ok {
+		return nil, fmt.Errorf("not a did:key identifier: %q", did)
+	}
+	if _, err := decodeMultibaseKey(encoded); err != nil {
+		return nil, err
+	}
+
+	id := did + "#" + encoded
+	return &Document{
+		ID: did,
+		VerificationMethod: []VerificationMethod{{
+			ID:                 id,
+			Type:               "Ed25519VerificationKey2020",
+			Controller:         did,
+			PublicKeyMultibase: encoded,
+		}},
+		Authentication: []any{id},
+	}, nil
+}
+
+// DecodeMultibase decodes a base58btc multibase value ("z..."), the
+// encoding of Ed25519 keys and Data Integrity proof values.
+func DecodeMultibase(value string) ([]byte, error) {
+	encoded, ok := strings.CutPrefix(value, "z")
+	if !ok {
+		return nil, fmt.Errorf("unsupported multibase encoding in %q", value)
+	}
+	return decodeBase58(encoded)
+}
+
+// decodeMultibaseKey decodes a base58btc multibase, multicodec-prefixed
+// Ed25519 public key.
+func decodeMultibaseKey(value string) (ed25519.PublicKey, error) {
+	raw, err := DecodeMultibase(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != len(ed25519Multicodec)+ed25519.PublicKeySize ||
+		raw[0] != ed25519Multicodec[0] || raw[1] != ed25519Multicodec[1] {
+		return nil, fmt.Errorf("multibase key %q is not an Ed25519 public key", value)
+	}
+	return ed25519.PublicKey(raw[len(ed25519Multicodec):]), nil
+}
+
+func decodeBase58(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, r := range s {
+		i := strings.IndexRune(base58Alphabet, r)
+		if i < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", r)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+
+	// Each leading '1' encodes a leading zero byte
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
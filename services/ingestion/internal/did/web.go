@@ -0,0 +1,115 @@
+package did
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/uigs/ingestion/internal/netguard"
+	"github.com/uigs/ingestion/internal/ttlcache"
+)
+
+// maxDocumentBytes bounds the size of a fetched DID document.
+const maxDocumentBytes = 1 << 20
+
+// maxCachedDocuments bounds the number of DID documents cached. The DIDs
+// come from callers, so each one may name a new host.
+const maxCachedDocuments = 1024
+
+// WebResolver resolves did:web identifiers by fetching did.json over HTTPS.
+// Resolved documents are cached for the configured TTL. The host comes
+// from the caller's DID, so connections to internal addresses are refused.
+type WebResolver struct {
+	client *http.Client
+	ttl    time.Duration
+	cache  *ttlcache.Cache[*Document]
+}
+
+// NewWebResolver creates a did:web resolver.
+func NewWebResolver(timeout, ttl time.Duration) *WebResolver {
+	return &WebResolver{
+		client: netguard.NewClient(timeout, false),
+		ttl:    ttl,
+		cache:  ttlcache.New[*Document](maxCachedDocuments),
+	}
+}
+
+// Resolve fetches and caches the DID document for a did:web identifier.
+func (r *WebResolver) Resolve(ctx context.Context, did string) (*Document, error) {
+	if doc, ok := r.cache.Get(did); ok {
+		return doc, nil
+	}
+
+	docURL, err := webDocumentURL(did)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, docURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DID document URL %q: %w", docURL, err)
+	}
+	req.Header.Set("Accept", "application/did+json, application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch DID document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DID document fetch returned %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DID document: %w", err)
+	}
+	if len(body) > maxDocumentBytes {
+		return nil, fmt.Errorf("DID document is over the %d byte limit", maxDocumentBytes)
+	}
+	var doc Document
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("invalid DID document: %w", err)
+	}
+	if doc.ID != did {
+		return nil, fmt.Errorf("DID document id %q does not match %q", doc.ID, did)
+	}
+
+	r.cache.Set(did, &doc, r.ttl)
+	return &doc, nil
+}
+
+// webDocumentURL maps a did:web identifier to its document URL:
+// did:web:example.com becomes https://example.com/.well-known/did.json and
+// did:web:example.com:users:alice becomes https://example.com/users/alice/did.json.
+func webDocumentURL(did string) (string, error) {
+	id, ok := strings.CutPrefix(did, "did:web:")
+	if !ok || id == "" {
+		return "", fmt.Errorf("not a did:web identifier: %q", did)
+	}
+
+	segments := strings.Split(id, ":")
+	host, err := url.PathUnescape(segments[0])
+	if err != nil || host == "" || strings.ContainsAny(host, "/?#@") {
+		return "", fmt.Errorf("invalid did:web host in %q", did)
+	}
+
+	path := "/.well-known"
+	if len(segments) > 1 {
+		for i, s := range segments[1:] {
+			if segments[i+1], err = url.PathUnescape(s); err != nil || segments[i+1] == "" {
+				return "", fmt.Errorf("invalid did:web path in %q", did)
+			}
+		}
+		path = "/" + strings.Join(segments[1:], "/")
+	}
+
+	u := url.URL{Scheme: "https", Host: host, Path: path + "/did.json"}
+	return u.String(), nil
+}
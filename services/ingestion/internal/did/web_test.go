@@ -0,0 +1,119 @@
+package did
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/uigs/ingestion/internal/netguard"
+)
+
+func TestWebDocumentURL(t *testing.T) {
+	tests := []struct {
+		did     string
+		want    string
+		wantErr bool
+	}{
+		{did: "did:web:example.com", want: "https://example.com/.well-known/did.json"},
+		{did: "did:web:example.com:users:alice", want: "https://example.com/users/alice/did.json"},
+		{did: "did:web:example.com%3A8443", want: "https://example.com:8443/.well-known/did.json"},
+		{did: "did:key:z6Mk", wantErr: true},
+		{did: "did:web:", wantErr: true},
+		{did: "did:web:user@example.com", wantErr: true},
+		{did: "did:web:example.com::alice", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.did, func(t *testing.T) {
+			got, err := webDocumentURL(tt.did)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("webDocumentURL(%q) error = %v, want error %v", tt.did, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("webDocumentURL(%q) = %q, want %q", tt.did, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebResolverRejectsInternalHosts(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+	port := srv.URL[strings.LastIndex(srv.URL, ":")+1:]
+
+	tests := []string{
+		"did:web:127.0.0.1%3A" + port,
+		"did:web:localhost%3A" + port,
+		"did:web:10.0.0.5%3A8080",
+		"did:web:192.168.1.20",
+		"did:web:169.254.169.254",
+		"did:web:100.64.0.1",
+	}
+	for _, did := range tests {
+		t.Run(did, func(t *testing.T) {
+			_, err := NewWebResolver(time.Second, time.Minute).Resolve(context.Background(), did)
+			if !errors.Is(err, netguard.ErrForbiddenDestination) {
+				t.Errorf("Resolve(%q) error = %v, want %v", did, err, netguard.ErrForbiddenDestination)
+			}
+		})
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("document server got %d requests, want none", n)
+	}
+}
+
+func TestWebResolverResolve(t *testing.T) {
+	var hits atomic.Int32
+	var body string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+	u, _ := url.Parse(srv.URL)
+	did := "did:web:" + strings.ReplaceAll(u.Host, ":", "%3A")
+
+	tests := []struct {
+		name     string
+		body     string
+		ttl      time.Duration
+		wantErr  bool
+		wantHits int32
+	}{
+		{name: "cached", body: `{"id":"` + did + `"}`, ttl: time.Minute, wantHits: 1},
+		{name: "caching disabled", body: `{"id":"` + did + `"}`, wantHits: 2},
+		{name: "document for another DID", body: `{"id":"did:web:example.com"}`, ttl: time.Minute, wantErr: true, wantHits: 2},
+		{name: "oversized document", body: `{"id":"` + did + `","padding":"` + strings.Repeat("x", maxDocumentBytes) + `"}`, ttl: time.Minute, wantErr: true, wantHits: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits.Store(0)
+			body = tt.body
+			r := NewWebResolver(time.Second, tt.ttl)
+			// The test server listens on loopback, which the guarded client refuses
+			r.client = srv.Client()
+
+			for i := 0; i < 2; i++ {
+				doc, err := r.Resolve(context.Background(), did)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("Resolve() error = %v, want error %v", err, tt.wantErr)
+				}
+				if err == nil && doc.ID != did {
+					t.Errorf("Resolve() id = %q, want %q", doc.ID, did)
+				}
+			}
+			if n := hits.Load(); n != tt.wantHits {
+				t.Errorf("document server got %d requests, want %d", n, tt.wantHits)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uigs/ingestion/internal/extract"
+)
+
+// maxDirectoryResponseBytes bounds the size of a directory lookup response.
+const maxDirectoryResponseBytes = 64 * 1024
+
+// Issuer is the directory's description of a credential issuer.
+type Issuer struct {
+	ID               string `json:"id"`
+	OrganizationName string `json:"organization_name"`
+}
+
+type directoryEntry struct {
+	issuer    *Issuer // nil when the directory does not know the issuer
+	expiresAt time.Time
+}
+
+// DirectoryEnricher resolves the payload's issuer through an external
+// directory service at GET {baseURL}/issuers/{did}. Lookups, including
+// misses, are cached for the configured TTL.
+type DirectoryEnricher struct {
+	baseURL string
+	client  *http.Client
+	ttl     time.Duration
+
+	mu    sync.Mutex
+	cache map[string]directoryEntry
+}
+
+// NewDirectoryEnricher creates an enricher backed by the directory at baseURL.
+func NewDirectoryEnricher(baseURL string, ttl time.Duration) *DirectoryEnricher {
+	return &DirectoryEnricher{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{},
+		ttl:     ttl,
+		cache:   make(map[string]directoryEntry),
+	}
+}
+
+// Enrich adds {"issuer": {...}} when the payload names an issuer known to
+// the directory.
+func (d *DirectoryEnricher) Enrich(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error) {
+	issuerID := extract.Issuer(payload)
+	if issuerID == "" {
+		return nil, nil
+	}
+
+	issuer, err := d.lookup(ctx, issuerID)
+	if err != nil || issuer == nil {
+		return nil, err
+	}
+	return map[string]interface{}{"issuer": issuer}, nil
+}
+
+func (d *DirectoryEnricher) lookup(ctx context.Context, issuerID string) (*Issuer, error) {
+	d.mu.Lock()
+	entry, ok := d.cache[issuerID]
+	d.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.issuer, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.baseURL+"/issuers/"+url.PathEscape(issuerID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid directory URL: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("directory lookup failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var issuer *Issuer
+	switch resp.StatusCode {
+	case http.StatusOK:
+		issuer = &Issuer{}
+		if err := json.NewDecoder(io.LimitReader(resp.Body, maxDirectoryResponseBytes)).Decode(issuer); err != nil {
+			return nil, fmt.Errorf("invalid directory response: %w", err)
+		}
+		issuer.ID = issuerID
+	case http.StatusNotFound:
+	default:
+		return nil, fmt.Errorf("directory lookup returned %d", resp.StatusCode)
+	}
+
+	d.mu.Lock()
+	d.cache[issuerID] = directoryEntry{issuer: issuer, expiresAt: time.Now().Add(d.ttl)}
+	d.mu.Unlock()
+	return issuer, nil
+}
@@ -0,0 +1,70 @@
+// Package enrich adds data from external sources to ingested events, such as
+// the organization name behind an issuer DID, so consumers need not look it up.
+package enrich
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// Enricher derives additional data for a payload. It returns nil when it has
+// nothing to add.
+type Enricher interface {
+	Enrich(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, error)
+}
+
+// Step runs an enricher during normalization for the enabled source types,
+// bounding each call with a timeout.
+type Step struct {
+	enricher    Enricher
+	timeout     time.Duration
+	sourceTypes map[models.SourceType]bool
+}
+
+// NewStep creates an enrichment step for the given source types.
+func NewStep(enricher Enricher, timeout time.Duration, sourceTypes []models.SourceType) *Step {
+	enabled := make(map[models.SourceType]bool, len(sourceTypes))
+	for _, t := range sourceTypes {
+		enabled[t] = true
+	}
+	return &Step{
+		enricher:    enricher,
+		timeout:     timeout,
+		sourceTypes: enabled,
+	}
+}
+
+// Enabled reports whether events of sourceType are enriched.
+func (s *Step) Enabled(sourceType models.SourceType) bool {
+	return s.sourceTypes[sourceType]
+}
+
+// Apply enriches payload and returns the enrichment as JSON, or nil when the
+// source type is not enabled or there is nothing to add. Callers are expected
+// to fail open: an error means the event is stored without enrichment.
+func (s *Step) Apply(ctx context.Context, sourceType models.SourceType, payload map[string]interface{}) ([]byte, error) {
+	if !s.Enabled(sourceType) {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	data, err := s.enricher.Enrich(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode enrichment: %w", err)
+	}
+	return encoded, nil
+}
@@ -0,0 +1,143 @@
+// Package extract pulls normalized fields out of ingested payloads.
+package extract
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// FieldError reports a field that is present in the payload but could not
+// be extracted.
+type FieldError struct {
+	Field string
+	Value interface{}
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("cannot extract %s from %v", e.Field, e.Value)
+}
+
+// Dates extracts the issuance and expiry dates of a payload, normalized to
+// UTC. Credentials use issuanceDate/validFrom and expirationDate/validUntil;
+// a presentation takes the earliest expiry of its embedded credentials; OIDC
+// tokens use the iat and exp claims. Missing or unparseable dates are left
+// unset. It returns nil when no dates are found.
+func Dates(sourceType models.SourceType, payload map[string]interface{}) *models.ExtractedDates {
+	dates, _ := ParseDates(sourceType, payload)
+	return dates
+}
+
+// ParseDates is like Dates, but also returns a *FieldError for the first
+// date that is present but cannot be parsed. The dates that could be
+// extracted are returned either way.
+func ParseDates(sourceType models.SourceType, payload map[string]interface{}) (*models.ExtractedDates, error) {
+	var x extractor
+	var dates models.ExtractedDates
+	switch sourceType {
+	case models.SourceTypeVC:
+		dates = x.credentialDates(payload, "")
+	case models.SourceTypeOIDC:
+		dates.IssuedAt = x.unixTimestamp(payload, "iat")
+		dates.ExpiresAt = x.unixTimestamp(payload, "exp")
+	}
+	if dates.IsZero() {
+		return nil, x.err
+	}
+	return &dates, x.err
+}
+
+// extractor keeps the first extraction error.
+type extractor struct {
+	err error
+}
+
+func (x *extractor) fail(field string, value interface{}) {
+	if x.err == nil {
+		x.err = &FieldError{Field: field, Value: value}
+	}
+}
+
+func (x *extractor) credentialDates(payload map[string]interface{}, prefix string) models.ExtractedDates {
+	var embedded []map[string]interface{}
+	var paths []string
+	switch vc := payload["verifiableCredential"].(type) {
+	case map[string]interface{}:
+		embedded = append(embedded, vc)
+		paths = append(paths, prefix+"verifiableCredential.")
+	case []interface{}:
+		for i, item := range vc {
+			if m, ok := item.(map[string]interface{}); ok {
+				embedded = append(embedded, m)
+				paths = append(paths, fmt.Sprintf("%sverifiableCredential[%d].", prefix, i))
+			}
+		}
+	}
+
+	if len(embedded) == 0 {
+		return models.ExtractedDates{
+			IssuedAt:  x.firstTimestamp(payload, prefix, "issuanceDate", "validFrom"),
+			ExpiresAt: x.firstTimestamp(payload, prefix, "expirationDate", "validUntil"),
+		}
+	}
+
+	var dates models.ExtractedDates
+	for i, vc := range embedded {
+		d := x.credentialDates(vc, paths[i])
+		if dates.IssuedAt == nil {
+			dates.IssuedAt = d.IssuedAt
+		}
+		if d.ExpiresAt != nil && (dates.ExpiresAt == nil || d.ExpiresAt.UTC.Before(dates.ExpiresAt.UTC)) {
+			dates.ExpiresAt = d.ExpiresAt
+		}
+	}
+	return dates
+}
+
+// firstTimestamp parses the first of keys present as an RFC 3339 date.
+func (x *extractor) firstTimestamp(payload map[string]interface{}, prefix string, keys ...string) *models.Timestamp {
+	for _, key := range keys {
+		raw, present := payload[key]
+		if !present || raw == "" {
+			continue
+		}
+		value, _ := raw.(string)
+		ts := Normalize(value)
+		if ts == nil {
+			x.fail(prefix+key, raw)
+		}
+		return ts
+	}
+	return nil
+}
+
+// Normalize parses an RFC 3339 timestamp and converts it to UTC, keeping the
+// original offset. It returns nil if value is not a valid timestamp.
+func Normalize(value string) *models.Timestamp {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil
+	}
+	return &models.Timestamp{
+		UTC:            t.UTC(),
+		OriginalOffset: t.Format("Z07:00"),
+	}
+}
+
+// unixTimestamp converts a JWT NumericDate claim, which is always UTC.
+func (x *extractor) unixTimestamp(payload map[string]interface{}, key string) *models.Timestamp {
+	raw, present := payload[key]
+	if !present {
+		return nil
+	}
+	seconds, ok := raw.(float64)
+	if !ok || seconds <= 0 {
+		x.fail(key, raw)
+		return nil
+	}
+	return &models.Timestamp{
+		UTC:            time.Unix(int64(seconds), 0).UTC(),
+		OriginalOffset: "Z",
+	}
+}
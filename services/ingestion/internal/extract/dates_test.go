@@ -0,0 +1,136 @@
+package extract
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		value      string
+		wantUTC    string
+		wantOffset string
+	}{
+		{value: "2024-03-01T10:00:00Z", wantUTC: "2024-03-01T10:00:00Z", wantOffset: "Z"},
+		{value: "2024-03-01T10:00:00+05:30", wantUTC: "2024-03-01T04:30:00Z", wantOffset: "+05:30"},
+		{value: "2024-03-01T01:00:00-08:00", wantUTC: "2024-03-01T09:00:00Z", wantOffset: "-08:00"},
+		{value: "2024-03-01T23:30:00.5-01:00", wantUTC: "2024-03-02T00:30:00.5Z", wantOffset: "-01:00"},
+		{value: "2024-03-01"},
+		{value: "yesterday"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			ts := Normalize(tt.value)
+			if tt.wantUTC == "" {
+				if ts != nil {
+					t.Fatalf("Normalize(%q) = %+v, want nil", tt.value, ts)
+				}
+				return
+			}
+			if ts == nil {
+				t.Fatalf("Normalize(%q) = nil", tt.value)
+			}
+			if got := ts.UTC.Format(time.RFC3339Nano); got != tt.wantUTC {
+				t.Errorf("UTC = %s, want %s", got, tt.wantUTC)
+			}
+			if ts.UTC.Location() != time.UTC {
+				t.Errorf("UTC is in %s", ts.UTC.Location())
+			}
+			if ts.OriginalOffset != tt.wantOffset {
+				t.Errorf("OriginalOffset = %q, want %q", ts.OriginalOffset, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestParseDates(t *testing.T) {
+	tests := []struct {
+		name         string
+		sourceType   models.SourceType
+		payload      map[string]interface{}
+		wantIssued   string
+		wantExpires  string
+		wantErrField string
+	}{
+		{
+			name:        "credential with offsets",
+			sourceType:  models.SourceTypeVC,
+			payload:     map[string]interface{}{"issuanceDate": "2024-01-01T09:00:00+09:00", "expirationDate": "2025-01-01T00:00:00-05:00"},
+			wantIssued:  "2024-01-01T00:00:00Z",
+			wantExpires: "2025-01-01T05:00:00Z",
+		},
+		{
+			name:        "data model 2.0 names",
+			sourceType:  models.SourceTypeVC,
+			payload:     map[string]interface{}{"validFrom": "2024-01-01T00:00:00Z", "validUntil": "2025-01-01T00:00:00Z"},
+			wantIssued:  "2024-01-01T00:00:00Z",
+			wantExpires: "2025-01-01T00:00:00Z",
+		},
+		{
+			name:       "presentation takes the earliest expiry",
+			sourceType: models.SourceTypeVC,
+			payload: map[string]interface{}{"verifiableCredential": []interface{}{
+				map[string]interface{}{"issuanceDate": "2024-01-01T00:00:00Z", "expirationDate": "2026-01-01T00:00:00Z"},
+				map[string]interface{}{"expirationDate": "2025-06-01T02:00:00+02:00"},
+			}},
+			wantIssued:  "2024-01-01T00:00:00Z",
+			wantExpires: "2025-06-01T00:00:00Z",
+		},
+		{
+			name:        "OIDC claims",
+			sourceType:  models.SourceTypeOIDC,
+			payload:     map[string]interface{}{"iat": float64(1704067200), "exp": float64(1704070800)},
+			wantIssued:  "2024-01-01T00:00:00Z",
+			wantExpires: "2024-01-01T01:00:00Z",
+		},
+		{
+			name:         "unparseable expiry",
+			sourceType:   models.SourceTypeVC,
+			payload:      map[string]interface{}{"issuanceDate": "2024-01-01T00:00:00Z", "expirationDate": "soon"},
+			wantIssued:   "2024-01-01T00:00:00Z",
+			wantErrField: "expirationDate",
+		},
+		{
+			name:         "unparseable embedded date",
+			sourceType:   models.SourceTypeVC,
+			payload:      map[string]interface{}{"verifiableCredential": []interface{}{map[string]interface{}{"issuanceDate": 20240101.0}}},
+			wantErrField: "verifiableCredential[0].issuanceDate",
+		},
+		{
+			name:       "no dates",
+			sourceType: models.SourceTypeVC,
+			payload:    map[string]interface{}{"id": "urn:uuid:1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dates, err := ParseDates(tt.sourceType, tt.payload)
+
+			var fieldErr *FieldError
+			if tt.wantErrField != "" {
+				if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantErrField {
+					t.Fatalf("error = %v, want a FieldError for %s", err, tt.wantErrField)
+				}
+			} else if err != nil {
+				t.Fatalf("ParseDates: %v", err)
+			}
+
+			if got := format(dates, func(d *models.ExtractedDates) *models.Timestamp { return d.IssuedAt }); got != tt.wantIssued {
+				t.Errorf("IssuedAt = %q, want %q", got, tt.wantIssued)
+			}
+			if got := format(dates, func(d *models.ExtractedDates) *models.Timestamp { return d.ExpiresAt }); got != tt.wantExpires {
+				t.Errorf("ExpiresAt = %q, want %q", got, tt.wantExpires)
+			}
+		})
+	}
+}
+
+func format(dates *models.ExtractedDates, field func(*models.ExtractedDates) *models.Timestamp) string {
+	if dates == nil || field(dates) == nil {
+		return ""
+	}
+	return field(dates).UTC.Format(time.RFC3339)
+}
@@ -0,0 +1,32 @@
+package extract
+
+// Issuer returns the issuer identifier of a credential ("issuer", either a
+// string or an object with an "id"), the first embedded credential of a
+// presentation, or an OIDC token ("iss").
+func Issuer(payload map[string]interface{}) string {
+	switch issuer := payload["issuer"].(type) {
+	case string:
+		return issuer
+	case map[string]interface{}:
+		if id, ok := issuer["id"].(string); ok {
+			return id
+		}
+	}
+	if iss, ok := payload["iss"].(string); ok {
+		return iss
+	}
+
+	switch embedded := payload["verifiableCredential"].(type) {
+	case map[string]interface{}:
+		return Issuer(embedded)
+	case []interface{}:
+		for _, item := range embedded {
+			if vc, ok := item.(map[string]interface{}); ok {
+				if id := Issuer(vc); id != "" {
+					return id
+				}
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+// Package forward relays write requests from a replica region to the primary region.
+package forward
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ForwardedHeader marks a request that has already been forwarded once.
+// A replica that receives a marked request refuses to forward it again,
+// which guards against forwarding loops between misconfigured regions.
+const ForwardedHeader = "X-UIGS-Forwarded"
+
+// ErrForwardLoop is returned when a request has already been forwarded.
+var ErrForwardLoop = errors.New("request was already forwarded")
+
+// forwardedHeaders are copied verbatim from the original request so the
+// primary sees the same credentials and idempotency key as the replica did.
+var forwardedHeaders = []string{
+	"Authorization",
+	"Idempotency-Key",
+	"Durability",
+	"Content-Type",
+	"Content-Encoding",
+	"X-Request-ID",
+	"Traceparent",
+	"Tracestate",
+}
+
+// Response is the primary region's reply to a forwarded request.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Forwarder sends write requests to the primary region's ingestion endpoint.
+type Forwarder struct {
+	target *url.URL
+	client *http.Client
+}
+
+// New creates a forwarder targeting the given primary ingestion base URL.
+func New(primaryURL string, timeout time.Duration) (*Forwarder, error) {
+	if primaryURL == "" {
+		return nil, errors.New("primary ingest URL is required")
+	}
+
+	target, err := url.Parse(primaryURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse primary ingest URL: %w", err)
+	}
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported primary ingest URL scheme %q", target.Scheme)
+	}
+
+	return &Forwarder{
+		target: target,
+		client: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Target returns the primary region base URL.
+func (f *Forwarder) Target() string {
+	return f.target.String()
+}
+
+// Forward replays the request against the primary region with the given body.
+// The original path and query string are preserved.
+func (f *Forwarder) Forward(ctx context.Context, r *http.Request, body []byte) (*Response, error) {
+	if r.Header.Get(ForwardedHeader) != "" {
+		return nil, ErrForwardLoop
+	}
+
+	dest := *f.target
+	dest.Path = strings.TrimSuffix(f.target.Path, "/") + r.URL.Path
+	dest.RawQuery = r.URL.RawQuery
+
+	req, err := http.NewRequestWithContext(ctx, r.Method, dest.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build forward request: %w", err)
+	}
+
+	for _, name := range forwardedHeaders {
+		if value := r.Header.Get(name); value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+	req.Header.Set(ForwardedHeader, "1")
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		req.Header.Set("X-Forwarded-For", host)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to forward request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read forward response: %w", err)
+	}
+
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       respBody,
+	}, nil
+}
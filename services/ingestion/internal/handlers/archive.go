@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/uigs/ingestion/internal/archive"
+)
+
+// ArchiveHandler exposes cold-storage archive operations to administrators.
+type ArchiveHandler struct {
+	worker *archive.Worker
+	logger *slog.Logger
+}
+
+// NewArchiveHandler creates an archive handler.
+func NewArchiveHandler(worker *archive.Worker, logger *slog.Logger) *ArchiveHandler {
+	return &ArchiveHandler{
+		worker: worker,
+		logger: logger,
+	}
+}
+
+// HandleRestoreArchive restores an archived batch of events into Postgres.
+// POST /api/v1/admin/archives/:id/restore
+func (h *ArchiveHandler) HandleRestoreArchive(c *gin.Context) {
+	archiveID := c.Param("id")
+	if _, err := uuid.Parse(archiveID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Archive not found",
+		})
+		return
+	}
+
+	restored, err := h.worker.Restore(c.Request.Context(), archiveID)
+	if err != nil {
+		if errors.Is(err, archive.ErrArchiveNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error":   "not_found",
+				"message": "Archive not found",
+			})
+			return
+		}
+		if errors.Is(err, archive.ErrAlreadyRestored) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":   "already_restored",
+				"message": "Archive has already been restored",
+			})
+			return
+		}
+		h.logger.Error("Failed to restore archive", "error", err, "archive_id", archiveID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "restore_error",
+			"message": "Failed to restore archive",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"archive_id": archiveID,
+		"restored":   restored,
+	})
+}
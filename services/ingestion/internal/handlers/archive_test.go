@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/archive"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// fakeArchiveRepo serves archive records from memory.
+type fakeArchiveRepo struct {
+	repository.ArchiveRepository
+	archives map[string]*models.ArchiveRecord
+}
+
+func (r *fakeArchiveRepo) GetArchive(_ context.Context, archiveID string) (*models.ArchiveRecord, error) {
+	return r.archives[archiveID], nil
+}
+
+func TestHandleRestoreArchiveErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	restoredAt := time.Now()
+	repo := &fakeArchiveRepo{archives: map[string]*models.ArchiveRecord{
+		"5b0a6a4e-4c1f-4f7e-9a55-1f9a1b0c2d3e": {ArchiveID: "5b0a6a4e-4c1f-4f7e-9a55-1f9a1b0c2d3e", RestoredAt: &restoredAt},
+	}}
+	h := NewArchiveHandler(archive.NewWorker(repo, nil, archive.WorkerConfig{}, discardLogger()), discardLogger())
+
+	tests := []struct {
+		name       string
+		archiveID  string
+		wantStatus int
+		wantError  string
+	}{
+		{name: "malformed ID", archiveID: "not-a-uuid", wantStatus: http.StatusNotFound, wantError: "not_found"},
+		{name: "unknown archive", archiveID: "0e6f3c1a-2b7d-4e8f-9a0b-1c2d3e4f5a6b", wantStatus: http.StatusNotFound, wantError: "not_found"},
+		{name: "already restored", archiveID: "5b0a6a4e-4c1f-4f7e-9a55-1f9a1b0c2d3e", wantStatus: http.StatusConflict, wantError: "already_restored"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/archives/:id/restore", h.HandleRestoreArchive)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/archives/"+tt.archiveID+"/restore", nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if !jsonHasError(w.Body.Bytes(), tt.wantError) {
+				t.Errorf("body = %s, want error %q", w.Body, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/scan"
+)
+
+// maxAttachmentFilenameLength matches the event_attachments.filename column.
+const maxAttachmentFilenameLength = 255
+
+// multipartOverhead allows for the multipart framing around an attachment.
+const multipartOverhead = 64 << 10
+
+// HandleUploadAttachment stores a file sent as the "file" field of a
+// multipart form against one of the caller's events. The content is scanned
+// first; infected content is rejected with 422 or, when configured,
+// quarantined and answered with 202.
+// POST /api/v1/events/:id/attachments
+func (h *IngestHandler) HandleUploadAttachment(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxAttachmentBytes+multipartOverhead)
+
+	// Replica regions never write locally
+	if h.forwarder != nil {
+		forwardWrite(c, h.forwarder, h.logger)
+		return
+	}
+
+	event, ok := h.ownedEvent(c)
+	if !ok {
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.attachmentTooLarge(c)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "Request must be a multipart form with a file field",
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, h.maxAttachmentBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "Failed to read attachment",
+		})
+		return
+	}
+	if int64(len(data)) > h.maxAttachmentBytes {
+		h.attachmentTooLarge(c)
+		return
+	}
+
+	result, err := h.scanner.Check(c.Request.Context(), data)
+	if err != nil {
+		h.logger.Error("Attachment scan failed", "error", err, "event_id", event.EventID)
+		c.Header("Retry-After", "30")
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "scan_unavailable",
+			"message": "Attachment could not be scanned, try again later",
+		})
+		return
+	}
+
+	filename := filepath.Base(filepath.Clean("/" + header.Filename))
+	if len(filename) > maxAttachmentFilenameLength {
+		filename = filename[:maxAttachmentFilenameLength]
+	}
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	attachment := &models.Attachment{
+		AttachmentID:  uuid.New().String(),
+		EventID:       event.EventID,
+		UserID:        event.UserID,
+		Filename:      filename,
+		ContentType:   contentType,
+		SizeBytes:     int64(len(data)),
+		SHA256:        calculateChecksum(data),
+		ScanStatus:    result.Status,
+		ScanSignature: result.Signature,
+		ScanError:     result.Error,
+		ScannedAt:     &result.ScannedAt,
+	}
+
+	infected := result.Status == scan.StatusInfected
+	content := data
+	if infected && h.infectedAttachments == models.AttachmentInfectedReject {
+		// Keep the scan result, not the content
+		content = nil
+	}
+	if err := h.attachments.CreateAttachment(c.Request.Context(), attachment, content); err != nil {
+		h.logger.Error("Failed to store attachment", "error", err, "event_id", event.EventID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to store attachment",
+		})
+		return
+	}
+
+	switch {
+	case !infected:
+		if result.Error != "" {
+			h.logger.Warn("Attachment stored unscanned", "error", result.Error,
+				"event_id", event.EventID, "attachment_id", attachment.AttachmentID)
+		}
+		c.JSON(http.StatusCreated, attachment)
+	case attachment.Stored:
+		h.logger.Warn("Infected attachment quarantined", "signature", result.Signature,
+			"event_id", event.EventID, "attachment_id", attachment.AttachmentID)
+		c.JSON(http.StatusAccepted, attachment)
+	default:
+		h.logger.Warn("Infected attachment rejected", "signature", result.Signature,
+			"event_id", event.EventID, "attachment_id", attachment.AttachmentID)
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "infected_attachment",
+			"message": "Attachment content was flagged by the malware scanner",
+		})
+	}
+}
+
+// HandleListAttachments lists the attachments of one of the caller's events
+// with their scan results.
+// GET /api/v1/events/:id/attachments
+func (h *IngestHandler) HandleListAttachments(c *gin.Context) {
+	event, ok := h.ownedEvent(c)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachments.ListAttachments(c.Request.Context(), event.EventID)
+	if err != nil {
+		h.logger.Error("Failed to list attachments", "error", err, "event_id", event.EventID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to retrieve attachments",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"attachments": attachments,
+		"count":       len(attachments),
+	})
+}
+
+// HandleDownloadAttachment returns an attachment's content. Infected content
+// is never served.
+// GET /api/v1/events/:id/attachments/:attachment_id
+func (h *IngestHandler) HandleDownloadAttachment(c *gin.Context) {
+	event, ok := h.ownedEvent(c)
+	if !ok {
+		return
+	}
+
+	attachment, content, err := h.attachments.GetAttachment(c.Request.Context(), event.EventID, c.Param("attachment_id"))
+	if err != nil {
+		h.logger.Error("Failed to get attachment", "error", err, "event_id", event.EventID)
+	}
+	if attachment == nil || !attachment.Stored {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Attachment not found",
+		})
+		return
+	}
+	if attachment.ScanStatus == scan.StatusInfected {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "attachment_quarantined",
+			"message": "Attachment content was flagged by the malware scanner",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+strconv.Quote(attachment.Filename))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(http.StatusOK, attachment.ContentType, content)
+}
+
+// ownedEvent loads the live event in the path, responding with 404 unless
+// the caller owns it.
+func (h *IngestHandler) ownedEvent(c *gin.Context) (*models.IngestionEvent, bool) {
+	if h.attachments == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Attachments are not enabled",
+		})
+		return nil, false
+	}
+
+	event, err := h.repo.GetEventByID(c.Request.Context(), middleware.TenantID(c), c.Param("id"))
+	if err != nil || event.DeletedAt != nil || event.UserID != currentUserID(c) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Event not found",
+		})
+		return nil, false
+	}
+	return event, true
+}
+
+func (h *IngestHandler) attachmentTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":   "attachment_too_large",
+		"message": "Attachment exceeds the maximum size of " + strconv.FormatInt(h.maxAttachmentBytes, 10) + " bytes",
+	})
+}
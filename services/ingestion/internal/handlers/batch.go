@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// HandleIngestBatch processes several ingestion items in one transaction.
+// POST /api/v1/ingest/batch
+//
+// By default the batch is all-or-nothing: any rejected or failed item
+// discards the whole batch. With "partial": true each item is committed
+// under its own savepoint, so failures are isolated to their items.
+func (h *IngestHandler) HandleIngestBatch(c *gin.Context) {
+	// Replica regions never write locally
+	if h.forwarder != nil {
+		forwardWrite(c, h.forwarder, h.logger)
+		return
+	}
+
+	var req models.BatchIngestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid batch request body", "error", err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	if name := c.Query("preset"); name != "" {
+		preset, ierr := h.loadPreset(c, name)
+		if ierr != nil {
+			ierr.respond(c)
+			return
+		}
+		for i := range req.Items {
+			preset.Apply(&req.Items[i])
+		}
+	}
+
+	userID := currentUserID(c)
+	tenantID := middleware.TenantID(c)
+	ctx := c.Request.Context()
+
+	results := make([]models.BatchItemResult, len(req.Items))
+	events := make([]*models.IngestionEvent, 0, len(req.Items))
+	indexes := make([]int, 0, len(req.Items))
+	quarantined := make(map[int]quarantinedItem)
+	rejected := 0
+	for i := range req.Items {
+		results[i].Index = i
+		ierr := decodeCredentialJWT(&req.Items[i])
+		if ierr == nil {
+			ierr = checkOccurredAtAllowed(c, &req.Items[i])
+		}
+		if ierr == nil && req.Items[i].CallbackURL != "" {
+			ierr = &ingestError{
+				status:  http.StatusBadRequest,
+				code:    "callback_not_supported",
+				message: "callback_url is not supported in batches",
+				field:   "callback_url",
+			}
+		}
+		var event *models.IngestionEvent
+		if ierr == nil {
+			event, ierr = h.prepareEvent(ctx, userID, tenantID, &req.Items[i])
+		}
+		if ierr != nil && ierr.quarantine {
+			results[i].EventID = event.EventID
+			quarantined[i] = quarantinedItem{event: event, err: ierr}
+			continue
+		}
+		if ierr != nil {
+			results[i].Status = models.BatchItemRejected
+			results[i].Error = ierr.code
+			results[i].Message = ierr.message
+			results[i].Field = ierr.field
+			rejected++
+			continue
+		}
+		results[i].EventID = event.EventID
+		events = append(events, event)
+		indexes = append(indexes, i)
+	}
+
+	// An all-or-nothing batch with rejected items is never written
+	if !req.Partial && rejected > 0 {
+		for _, i := range indexes {
+			results[i].Status = models.BatchItemRolledBack
+		}
+		for i := range quarantined {
+			results[i].Status = models.BatchItemRolledBack
+		}
+		h.respondBatch(c, req.Partial, results)
+		return
+	}
+
+	var itemErrs []error
+	if len(events) > 0 {
+		var err error
+		itemErrs, err = h.repo.CreateEvents(ctx, events, req.Partial)
+		if err != nil && !errors.Is(err, repository.ErrBatchRolledBack) {
+			h.logger.Error("Failed to store batch", "error", err, "items", len(events))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "storage_error",
+				"message": "Failed to store batch",
+			})
+			return
+		}
+		if err != nil {
+			for _, i := range indexes {
+				results[i].Status = models.BatchItemRolledBack
+			}
+			for i := range quarantined {
+				results[i].Status = models.BatchItemRolledBack
+			}
+		}
+	}
+
+	// Quarantined items are held once the batch is known to be kept
+	for i, item := range quarantined {
+		if results[i].Status == models.BatchItemRolledBack {
+			continue
+		}
+		q, err := h.quarantineEvent(ctx, item.event, item.err)
+		if err != nil {
+			h.logger.Error("Failed to quarantine batch item", "error", err, "index", i)
+			results[i].Status = models.BatchItemFailed
+			results[i].Error = "storage_error"
+			results[i].Message = "Failed to store event"
+			continue
+		}
+		results[i].Status = models.BatchItemQuarantined
+		results[i].Message = item.err.message
+		results[i].Field = item.err.field
+		results[i].QuarantineID = q.QuarantineID
+	}
+
+	for n, event := range events {
+		i := indexes[n]
+		if itemErrs[n] != nil {
+			h.logger.Warn("Failed to store batch item", "error", itemErrs[n], "index", i)
+			results[i].Status = models.BatchItemFailed
+			results[i].Error = "storage_error"
+			results[i].Message = "Failed to store event"
+			continue
+		}
+		if results[i].Status == models.BatchItemRolledBack {
+			continue
+		}
+
+		results[i].Status = models.BatchItemCommitted
+		results[i].Queued, results[i].QueueReason = h.publishEvent(ctx, event, req.Items[i].Payload)
+	}
+
+	h.respondBatch(c, req.Partial, results)
+}
+
+// quarantinedItem is a batch item to quarantine if the batch is kept.
+type quarantinedItem struct {
+	event *models.IngestionEvent
+	err   *ingestError
+}
+
+// respondBatch writes the batch outcome: 201 when every item was committed,
+// 207 when only some were or some were quarantined, and 422 when none were
+// accepted.
+func (h *IngestHandler) respondBatch(c *gin.Context, partial bool, results []models.BatchItemResult) {
+	resp := models.BatchIngestionResponse{Partial: partial, Items: results}
+	for _, r := range results {
+		switch r.Status {
+		case models.BatchItemCommitted:
+			resp.Committed++
+		case models.BatchItemQuarantined:
+			resp.Quarantined++
+		default:
+			resp.Failed++
+		}
+	}
+
+	h.logger.Info("Batch ingested",
+		"user_id", currentUserID(c),
+		"partial", partial,
+		"committed", resp.Committed,
+		"failed", resp.Failed,
+		"quarantined", resp.Quarantined,
+	)
+
+	status := http.StatusCreated
+	switch {
+	case resp.Committed == 0 && resp.Quarantined == 0:
+		status = http.StatusUnprocessableEntity
+	case resp.Failed > 0 || resp.Quarantined > 0:
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, resp)
+}
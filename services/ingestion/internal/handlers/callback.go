@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/webhook"
+)
+
+// checkCallback validates the request's callback_url, if any, before the
+// event is stored, so that an unusable callback rejects the request rather
+// than being dropped after it.
+func (h *IngestHandler) checkCallback(ctx context.Context, req *models.IngestionRequest) *ingestError {
+	if req.CallbackURL == "" {
+		return nil
+	}
+	if h.callbacks == nil {
+		return &ingestError{
+			status:  http.StatusBadRequest,
+			code:    "callback_not_supported",
+			message: "Callbacks are not enabled",
+			field:   "callback_url",
+		}
+	}
+
+	err := webhook.ValidateCallbackURL(ctx, req.CallbackURL, h.callbacks.AllowPrivate())
+	if err != nil {
+		code := "invalid_callback_url"
+		if errors.Is(err, webhook.ErrForbiddenDestination) {
+			code = "forbidden_callback_url"
+		}
+		return &ingestError{
+			status:  http.StatusUnprocessableEntity,
+			code:    code,
+			message: err.Error(),
+			field:   "callback_url",
+		}
+	}
+	return nil
+}
+
+// sendCallback queues the ingestion response for delivery to url. The
+// dispatcher retries failed deliveries with backoff; a full queue is logged
+// and never fails the request, whose event is already stored.
+func (h *IngestHandler) sendCallback(ctx context.Context, url string, resp *models.IngestionResponse) {
+	err := h.callbacks.Enqueue(webhook.Delivery{
+		URL:     url,
+		Secret:  h.callbackSecret,
+		Event:   models.CallbackEventIngested,
+		Payload: resp,
+	})
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to queue ingestion callback", "error", err, "event_id", resp.EventID)
+	}
+}
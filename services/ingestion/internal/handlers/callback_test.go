@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/webhook"
+)
+
+func TestHandleIngestCallbackURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := func(callbackURL string) string {
+		b, _ := json.Marshal(map[string]interface{}{
+			"source_type":  "MANUAL",
+			"payload":      map[string]interface{}{"note": "hello"},
+			"callback_url": callbackURL,
+		})
+		return string(b)
+	}
+
+	tests := []struct {
+		name        string
+		callbacks   bool
+		callbackURL string
+		queueSize   int
+		wantStatus  int
+		wantError   string
+		wantStored  bool
+	}{
+		{name: "public https callback", callbacks: true, callbackURL: "https://93.184.216.34/cb", queueSize: 1, wantStatus: http.StatusCreated, wantStored: true},
+		{name: "full callback queue still accepts", callbacks: true, callbackURL: "https://93.184.216.34/cb", wantStatus: http.StatusCreated, wantStored: true},
+		{name: "no callback", callbacks: true, wantStatus: http.StatusCreated, wantStored: true},
+		{name: "callbacks disabled", callbackURL: "https://93.184.216.34/cb", wantStatus: http.StatusBadRequest, wantError: "callback_not_supported"},
+		{name: "plain http", callbacks: true, callbackURL: "http://93.184.216.34/cb", wantStatus: http.StatusUnprocessableEntity, wantError: "invalid_callback_url"},
+		{name: "loopback", callbacks: true, callbackURL: "https://127.0.0.1/cb", wantStatus: http.StatusUnprocessableEntity, wantError: "forbidden_callback_url"},
+		{name: "cloud metadata", callbacks: true, callbackURL: "https://169.254.169.254/latest", wantStatus: http.StatusUnprocessableEntity, wantError: "forbidden_callback_url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeEventRepo{}
+			var opts []IngestOption
+			if tt.callbacks {
+				// Not started, so accepted callbacks stay queued
+				d := webhook.NewDispatcher(webhook.Config{Timeout: time.Second, MaxAttempts: 1, QueueSize: tt.queueSize}, discardLogger())
+				opts = append(opts, WithCallbacks(d, "whsec_test"))
+			}
+			h := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger(), opts...)
+			w := ingest(h, "alice", body(tt.callbackURL), nil)
+			h.Wait()
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantError != "" && !jsonHasError(w.Body.Bytes(), tt.wantError) {
+				t.Errorf("body = %s, want error %q", w.Body, tt.wantError)
+			}
+			if got := len(repo.events) == 1; got != tt.wantStored {
+				t.Errorf("stored = %v, want %v", got, tt.wantStored)
+			}
+		})
+	}
+}
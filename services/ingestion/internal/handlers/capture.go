@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/capture"
+)
+
+// maxCaptureCount bounds how many requests a single arm may capture.
+const maxCaptureCount = 100
+
+// CaptureHandler exposes debug request captures to administrators.
+type CaptureHandler struct {
+	store *capture.Store
+}
+
+// NewCaptureHandler creates a capture handler.
+func NewCaptureHandler(store *capture.Store) *CaptureHandler {
+	return &CaptureHandler{store: store}
+}
+
+// armCaptureRequest arms capture for the next Count requests matching Key.
+// Key is matched against the authenticated user ID and the X-Debug-Capture header.
+type armCaptureRequest struct {
+	Key   string `json:"key" binding:"required"`
+	Count int    `json:"count" binding:"required,min=1"`
+}
+
+// HandleArmCapture arms capture for a user or debug client key.
+// POST /api/v1/admin/captures
+func (h *CaptureHandler) HandleArmCapture(c *gin.Context) {
+	var req armCaptureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	if req.Count > maxCaptureCount {
+		req.Count = maxCaptureCount
+	}
+
+	c.JSON(http.StatusCreated, h.store.Arm(req.Key, req.Count))
+}
+
+// HandleListCaptures returns the unexpired captures and armed keys.
+// GET /api/v1/admin/captures
+func (h *CaptureHandler) HandleListCaptures(c *gin.Context) {
+	captures, arms := h.store.List()
+	c.JSON(http.StatusOK, gin.H{
+		"captures": captures,
+		"armed":    arms,
+		"count":    len(captures),
+	})
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/challenge"
+)
+
+// ChallengeHandler mints presentation challenges.
+type ChallengeHandler struct {
+	store  challenge.Store
+	logger *slog.Logger
+}
+
+// NewChallengeHandler creates a challenge handler.
+func NewChallengeHandler(store challenge.Store, logger *slog.Logger) *ChallengeHandler {
+	return &ChallengeHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// createChallengeRequest names the relying-party domain the presentation targets.
+type createChallengeRequest struct {
+	Domain string `json:"domain" binding:"required"`
+}
+
+// HandleCreateChallenge issues a challenge the caller must embed in the proof
+// of the presentation it submits next.
+// POST /api/v1/challenges
+func (h *ChallengeHandler) HandleCreateChallenge(c *gin.Context) {
+	var req createChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	ch, err := h.store.Issue(c.Request.Context(), currentUserID(c), req.Domain)
+	if err != nil {
+		h.logger.Error("Failed to issue challenge", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to issue challenge",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ch)
+}
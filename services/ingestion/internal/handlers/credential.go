@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/challenge"
+	"github.com/uigs/ingestion/internal/credstatus"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// ingestError is a client-facing rejection raised while checking a payload.
+type ingestError struct {
+	status  int
+	code    string
+	message string
+	field   string
+
+	// quarantine marks an extraction failure whose event is to be held
+	// for review rather than rejected
+	quarantine bool
+}
+
+func (e *ingestError) Error() string {
+	return e.message
+}
+
+// respond writes the error using the standard error envelope.
+func (e *ingestError) respond(c *gin.Context) {
+	body := gin.H{
+		"error":   e.code,
+		"message": e.message,
+	}
+	if e.field != "" {
+		body["field"] = e.field
+	}
+	if e.status == http.StatusServiceUnavailable {
+		c.Header("Retry-After", "1")
+	}
+	c.JSON(e.status, body)
+}
+
+// checkPresentation verifies that a Verifiable Presentation is bound to a
+// fresh, unused challenge previously issued to this user for the stated
+// domain. It returns the presentation proof, whose challenge is consumed
+// with consumeChallenge once the presentation is accepted. Payloads that are
+// not presentations pass through unchanged.
+func (h *IngestHandler) checkPresentation(ctx context.Context, userID string, payload map[string]interface{}) (*models.Proof, *ingestError) {
+	if !hasType(payload, "VerifiablePresentation") {
+		return nil, nil
+	}
+
+	var vp models.VerifiablePresentation
+	if err := decodePayload(payload, &vp); err != nil {
+		return nil, &ingestError{status: http.StatusUnprocessableEntity, code: "invalid_presentation", message: "Malformed presentation: " + err.Error()}
+	}
+	if _, ierr := credentialsIn(payload); ierr != nil {
+		return nil, ierr
+	}
+	if vp.Proof == nil || vp.Proof.Challenge == "" || vp.Proof.Domain == "" {
+		return nil, &ingestError{status: http.StatusUnprocessableEntity, code: "invalid_presentation", message: "Presentation proof must include a challenge and domain"}
+	}
+	if ierr := h.checkProofCreated(vp.Proof); ierr != nil {
+		return nil, ierr
+	}
+
+	err := h.challenges.Check(ctx, userID, vp.Proof.Domain, vp.Proof.Challenge)
+	if ierr := h.challengeError(err); ierr != nil {
+		return nil, ierr
+	}
+	return vp.Proof, nil
+}
+
+// consumeChallenge marks the challenge of an accepted presentation as used,
+// so the presentation cannot be replayed. A concurrent submission of the
+// same presentation loses with challenge_consumed.
+func (h *IngestHandler) consumeChallenge(ctx context.Context, userID string, vpProof *models.Proof) *ingestError {
+	if vpProof == nil {
+		return nil
+	}
+	return h.challengeError(h.challenges.Consume(ctx, userID, vpProof.Domain, vpProof.Challenge))
+}
+
+// challengeError maps a challenge store error to its client-facing rejection.
+func (h *IngestHandler) challengeError(err error) *ingestError {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, challenge.ErrChallengeExpired):
+		return &ingestError{status: http.StatusUnprocessableEntity, code: "challenge_expired", message: "Presentation challenge was issued too long ago, request a new one", field: "proof.challenge"}
+	case errors.Is(err, challenge.ErrChallengeConsumed):
+		return &ingestError{status: http.StatusUnprocessableEntity, code: "challenge_consumed", message: "Presentation challenge was already used", field: "proof.challenge"}
+	case errors.Is(err, challenge.ErrUnknownChallenge):
+		return &ingestError{status: http.StatusUnprocessableEntity, code: "invalid_challenge", message: "Presentation challenge was not issued to this user for this domain", field: "proof.challenge"}
+	default:
+		h.logger.Error("Failed to check challenge", "error", err)
+		return &ingestError{status: http.StatusInternalServerError, code: "internal_error", message: "Failed to verify presentation challenge"}
+	}
+}
+
+// checkCredentials runs the configured verification checks on the credential
+// in the payload, or on each credential embedded in a presentation.
+func (h *IngestHandler) checkCredentials(ctx context.Context, payload map[string]interface{}) *ingestError {
+	creds, ierr := credentialsIn(payload)
+	if ierr != nil {
+		return ierr
+	}
+	for _, raw := range creds {
+		var vc models.VerifiableCredential
+		if err := decodePayload(raw, &vc); err != nil {
+			return &ingestError{status: http.StatusUnprocessableEntity, code: "invalid_credential", message: "Malformed credential: " + err.Error()}
+		}
+
+		if ierr := h.checkValidity(&vc); ierr != nil {
+			return ierr
+		}
+		if ierr := h.checkStatus(ctx, &vc); ierr != nil {
+			return ierr
+		}
+		if ierr := h.checkProof(ctx, raw); ierr != nil {
+			return ierr
+		}
+		if ierr := h.checkSignatures(ctx, raw, &vc); ierr != nil {
+			return ierr
+		}
+	}
+	return nil
+}
+
+// checkStatus rejects credentials the issuer reports as revoked or suspended.
+func (h *IngestHandler) checkStatus(ctx context.Context, vc *models.VerifiableCredential) *ingestError {
+	if h.statusChecker == nil || vc.CredentialStatus == nil {
+		return nil
+	}
+
+	result, err := h.statusChecker.Check(ctx, vc.CredentialStatus)
+	switch {
+	case errors.Is(err, credstatus.ErrUnavailable):
+		h.logger.Warn("Credential status unavailable", "error", err, "issuer", vc.GetIssuerID())
+		return &ingestError{status: http.StatusServiceUnavailable, code: "status_unavailable", message: "Credential status could not be determined, retry later"}
+	case err != nil:
+		return &ingestError{status: http.StatusUnprocessableEntity, code: "invalid_credential_status", message: "Credential status check failed: " + err.Error()}
+	}
+
+	if result.Assumed {
+		h.logger.Warn("Credential status unavailable, failing open", "issuer", vc.GetIssuerID(), "status_type", result.Type)
+	}
+	if result.Status != credstatus.StatusActive {
+		return &ingestError{status: http.StatusUnprocessableEntity, code: "credential_" + result.Status, message: "Credential has been " + result.Status + " by its issuer"}
+	}
+	return nil
+}
+
+// credentialsIn returns the credentials carried by a payload: the embedded
+// credentials of a presentation, or the payload itself otherwise. Embedded
+// VC-JWTs are decoded to their credentials. A presentation without
+// credentials, or with an entry that is neither a credential object nor a
+// VC-JWT, is rejected, so that no credential it carries escapes the checks.
+func credentialsIn(payload map[string]interface{}) ([]map[string]interface{}, *ingestError) {
+	if !hasType(payload, "VerifiablePresentation") {
+		return []map[string]interface{}{payload}, nil
+	}
+
+	var entries []interface{}
+	switch embedded := payload["verifiableCredential"].(type) {
+	case []interface{}:
+		entries = embedded
+	case nil:
+	default:
+		entries = []interface{}{embedded}
+	}
+	if len(entries) == 0 {
+		return nil, &ingestError{status: http.StatusUnprocessableEntity, code: "invalid_presentation", message: "Presentation carries no credentials", field: "verifiableCredential"}
+	}
+
+	creds := make([]map[string]interface{}, 0, len(entries))
+	for i, entry := range entries {
+		switch vc := entry.(type) {
+		case map[string]interface{}:
+			creds = append(creds, vc)
+		case string:
+			claims, err := models.DecodeVCJWTClaims(vc)
+			if err != nil {
+				return nil, &ingestError{status: http.StatusUnprocessableEntity, code: "invalid_presentation", message: fmt.Sprintf("Presentation credential %d: %v", i, err), field: "verifiableCredential"}
+			}
+			creds = append(creds, claims)
+		default:
+			return nil, &ingestError{status: http.StatusUnprocessableEntity, code: "invalid_presentation", message: fmt.Sprintf("Presentation credential %d must be an object or a VC-JWT", i), field: "verifiableCredential"}
+		}
+	}
+	return creds, nil
+}
+
+// hasType reports whether the payload's "type" field, which may be a string
+// or an array of strings, contains want.
+func hasType(payload map[string]interface{}, want string) bool {
+	switch t := payload["type"].(type) {
+	case string:
+		return t == want
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodePayload converts a generic JSON payload into a typed model.
+func decodePayload(payload map[string]interface{}, out interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// checkSubjects rejects credentials about someone other than the ingesting
+// user, when subject binding is enabled for the tenant. Each credential's
+// credentialSubject.id must be one of the user's registered identifiers.
+func (h *IngestHandler) checkSubjects(ctx context.Context, userID, tenantID string, payload map[string]interface{}) *ingestError {
+	if h.subjects == nil || (!h.subjectAllTenants && !h.subjectTenants[tenantID]) {
+		return nil
+	}
+
+	allowed, err := h.subjects.ListSubjectIDs(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to load subject identifiers", "error", err, "user_id", userID)
+		return &ingestError{status: http.StatusInternalServerError, code: "internal_error", message: "Failed to verify credential subject"}
+	}
+
+	creds, ierr := credentialsIn(payload)
+	if ierr != nil {
+		return ierr
+	}
+	for _, vc := range creds {
+		subject, _ := vc["credentialSubject"].(map[string]interface{})
+		id, _ := subject["id"].(string)
+		if id == "" {
+			return &ingestError{
+				status:  http.StatusUnprocessableEntity,
+				code:    "subject_mismatch",
+				message: "Credential has no credentialSubject.id to bind to the user",
+				field:   "credentialSubject.id",
+			}
+		}
+		if !slices.Contains(allowed, id) {
+			return &ingestError{
+				status:  http.StatusUnprocessableEntity,
+				code:    "subject_mismatch",
+				message: "Credential subject " + id + " is not registered for this user",
+				field:   "credentialSubject.id",
+			}
+		}
+	}
+	return nil
+}
+
+// checkIssuers rejects credentials whose issuer is not on the allowlist,
+// when one is configured. The issuer may be given as a string or as an
+// object with an id.
+func (h *IngestHandler) checkIssuers(payload map[string]interface{}) *ingestError {
+	if len(h.allowedIssuers) == 0 {
+		return nil
+	}
+
+	creds, ierr := credentialsIn(payload)
+	if ierr != nil {
+		return ierr
+	}
+	for _, raw := range creds {
+		var vc models.VerifiableCredential
+		if err := decodePayload(raw, &vc); err != nil {
+			return &ingestError{status: http.StatusUnprocessableEntity, code: "invalid_credential", message: "Malformed credential: " + err.Error()}
+		}
+		issuer := vc.GetIssuerID()
+		if !h.allowedIssuers[issuer] {
+			h.logger.Warn("Credential from issuer not on allowlist rejected", "issuer", issuer)
+			return &ingestError{
+				status:  http.StatusForbidden,
+				code:    "issuer_not_allowed",
+				message: "Credential issuer " + strconv.Quote(issuer) + " is not allowed",
+				field:   "issuer",
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func vcJWT(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "eyJhbGciOiJFUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(raw) + ".c2ln"
+}
+
+func TestCredentialsIn(t *testing.T) {
+	vc := map[string]interface{}{"type": []interface{}{"VerifiableCredential"}, "issuer": "did:example:issuer"}
+	token := vcJWT(t, map[string]interface{}{"iss": "did:example:issuer", "vc": vc})
+
+	tests := []struct {
+		name      string
+		payload   map[string]interface{}
+		wantCount int
+		wantCode  string
+	}{
+		{
+			name:      "bare credential",
+			payload:   vc,
+			wantCount: 1,
+		},
+		{
+			name:      "presentation with one object",
+			payload:   map[string]interface{}{"type": "VerifiablePresentation", "verifiableCredential": vc},
+			wantCount: 1,
+		},
+		{
+			name:      "presentation with an object and a VC-JWT",
+			payload:   map[string]interface{}{"type": []interface{}{"VerifiablePresentation"}, "verifiableCredential": []interface{}{vc, token}},
+			wantCount: 2,
+		},
+		{
+			name:     "presentation without credentials",
+			payload:  map[string]interface{}{"type": "VerifiablePresentation"},
+			wantCode: "invalid_presentation",
+		},
+		{
+			name:     "presentation with an empty list",
+			payload:  map[string]interface{}{"type": "VerifiablePresentation", "verifiableCredential": []interface{}{}},
+			wantCode: "invalid_presentation",
+		},
+		{
+			name:     "presentation with a non-object entry",
+			payload:  map[string]interface{}{"type": "VerifiablePresentation", "verifiableCredential": []interface{}{vc, 42.0}},
+			wantCode: "invalid_presentation",
+		},
+		{
+			name:     "presentation with a malformed VC-JWT",
+			payload:  map[string]interface{}{"type": "VerifiablePresentation", "verifiableCredential": []interface{}{"not-a-jwt"}},
+			wantCode: "invalid_presentation",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			creds, ierr := credentialsIn(tt.payload)
+			if tt.wantCode != "" {
+				if ierr == nil || ierr.code != tt.wantCode || ierr.status != http.StatusUnprocessableEntity {
+					t.Fatalf("credentialsIn error = %+v, want 422 %s", ierr, tt.wantCode)
+				}
+				return
+			}
+			if ierr != nil {
+				t.Fatalf("credentialsIn: %+v", ierr)
+			}
+			if len(creds) != tt.wantCount {
+				t.Fatalf("got %d credentials, want %d", len(creds), tt.wantCount)
+			}
+			for _, c := range creds {
+				if c["issuer"] != "did:example:issuer" {
+					t.Errorf("credential issuer = %v", c["issuer"])
+				}
+			}
+		})
+	}
+}
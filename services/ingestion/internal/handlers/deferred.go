@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// ErrVerificationUnavailable is returned by VerifyDeferred when the checks
+// could not complete, so the event stays deferred and is retried.
+var ErrVerificationUnavailable = errors.New("verification unavailable")
+
+// checkDeferred marks verification status changes made by the deferred
+// verification worker.
+const checkDeferred = "deferred_verification"
+
+// acquireVerification takes a verification slot when a limit is configured.
+// With deferred verification it waits only up to the deferral threshold and
+// reports that the credential checks are to be deferred instead of failing.
+// The returned release function must always be called.
+func (h *IngestHandler) acquireVerification(ctx context.Context) (func(), bool, *ingestError) {
+	if h.verifyLimit == nil {
+		return func() {}, false, nil
+	}
+
+	if h.deferVerification {
+		release, err := h.verifyLimit.AcquireWithin(ctx, h.deferAfter)
+		if err != nil {
+			return func() {}, true, nil
+		}
+		return release, false, nil
+	}
+
+	release, err := h.verifyLimit.Acquire(ctx)
+	if err != nil {
+		return nil, false, &ingestError{
+			status:  http.StatusServiceUnavailable,
+			code:    "verification_overloaded",
+			message: "Too many credentials are being verified, retry later",
+		}
+	}
+	return release, false, nil
+}
+
+// canDefer reports whether a failed credential check may be retried in the
+// background instead of rejecting the request: deferred verification is on
+// and the failure is a transient one, such as an unreachable status list.
+func (h *IngestHandler) canDefer(ierr *ingestError) bool {
+	return h.deferVerification && ierr.status >= http.StatusInternalServerError
+}
+
+// VerifyDeferred runs the credential checks an event was stored without and
+// records the outcome. An owner who opted in is sent a
+// verification.status_changed webhook when the credential turns out not to
+// be valid. Errors wrapping ErrVerificationUnavailable leave the event
+// deferred.
+func (h *IngestHandler) VerifyDeferred(ctx context.Context, event *models.IngestionEvent) error {
+	status := models.VerificationStatusVerified
+	var reason string
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(event.RawPayload, &payload); err != nil {
+		status, reason = models.VerificationStatusInvalid, "invalid_credential"
+	} else if ierr := h.verifyDeferredPayload(ctx, payload); ierr != nil {
+		if ierr.status >= http.StatusInternalServerError {
+			return fmt.Errorf("%w: %s", ErrVerificationUnavailable, ierr.message)
+		}
+		status, reason = deferredFailureStatus(ierr.code), ierr.code
+	}
+
+	now := time.Now().UTC()
+	if err := h.repo.UpdateVerificationStatus(ctx, event.EventID, status, now); err != nil {
+		return err
+	}
+	if status == models.VerificationStatusVerified {
+		return nil
+	}
+
+	h.logger.Warn("Deferred credential failed verification",
+		"event_id", event.EventID,
+		"verification_status", status,
+		"reason", reason,
+	)
+	h.notifyVerificationChange(ctx, models.VerificationChange{
+		EventID:   event.EventID,
+		UserID:    event.UserID,
+		Check:     checkDeferred,
+		OldStatus: models.VerificationStatusDeferred,
+		NewStatus: status,
+		Reason:    reason,
+		ChangedAt: now,
+	})
+	return nil
+}
+
+// verifyDeferredPayload runs the credential checks under a verification
+// slot, so background work yields to ingestion when the limit is reached.
+func (h *IngestHandler) verifyDeferredPayload(ctx context.Context, payload map[string]interface{}) *ingestError {
+	if h.verifyLimit != nil {
+		release, err := h.verifyLimit.Acquire(ctx)
+		if err != nil {
+			return &ingestError{status: http.StatusServiceUnavailable, code: "verification_overloaded", message: "no verification slot available"}
+		}
+		defer release()
+	}
+	return h.checkCredentials(ctx, payload)
+}
+
+// deferredFailureStatus maps a credential check rejection to the
+// verification status recorded for it.
+func deferredFailureStatus(code string) string {
+	switch code {
+	case "credential_expired":
+		return models.VerificationStatusExpired
+	case "credential_not_yet_valid":
+		return models.VerificationStatusNotYetValid
+	case "credential_revoked":
+		return models.VerificationStatusRevoked
+	case "credential_suspended":
+		return models.VerificationStatusSuspended
+	default:
+		return models.VerificationStatusInvalid
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// DeletionHandler soft-deletes and restores events.
+type DeletionHandler struct {
+	repo   repository.DeletionRepository
+	grace  time.Duration
+	logger *slog.Logger
+}
+
+// NewDeletionHandler creates a deletion handler. Deleted events can be
+// restored for the grace period.
+func NewDeletionHandler(repo repository.DeletionRepository, grace time.Duration, logger *slog.Logger) *DeletionHandler {
+	return &DeletionHandler{
+		repo:   repo,
+		grace:  grace,
+		logger: logger,
+	}
+}
+
+// HandleDeleteEvent soft-deletes an event. Owner or admin only.
+// DELETE /api/v1/events/:id
+func (h *DeletionHandler) HandleDeleteEvent(c *gin.Context) {
+	event, ok := h.authorizedEvent(c)
+	if !ok {
+		return
+	}
+	if event.DeletedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Event not found",
+		})
+		return
+	}
+
+	if _, err := h.repo.SoftDeleteEvent(c.Request.Context(), event.EventID); err != nil {
+		h.logger.Error("Failed to delete event", "error", err, "event_id", event.EventID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to delete event",
+		})
+		return
+	}
+
+	h.logger.Info("Event deleted", "event_id", event.EventID, "by_admin", c.GetBool(middleware.ContextKeyIsAdmin))
+	c.JSON(http.StatusOK, gin.H{
+		"event_id":      event.EventID,
+		"restore_until": time.Now().UTC().Add(h.grace),
+	})
+}
+
+// HandleDeleteEventsBefore deletes every event of the caller created before
+// the before query parameter, which must be an RFC 3339 time in the past.
+// Admins may pass hard=true to remove the events instead of soft-deleting
+// them.
+// DELETE /api/v1/events
+func (h *DeletionHandler) HandleDeleteEventsBefore(c *gin.Context) {
+	raw := c.Query("before")
+	if raw == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "before is required",
+		})
+		return
+	}
+	cutoff, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "before must be an RFC 3339 time",
+		})
+		return
+	}
+	now := time.Now().UTC()
+	if !cutoff.Before(now) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "before must be in the past",
+		})
+		return
+	}
+
+	hard := c.Query("hard") == "true"
+	isAdmin := c.GetBool(middleware.ContextKeyIsAdmin)
+	if hard && !isAdmin {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Only admin callers may hard-delete events",
+		})
+		return
+	}
+
+	userID := currentUserID(c)
+	deleted, err := h.repo.DeleteEventsBefore(c.Request.Context(), middleware.TenantID(c), userID, cutoff, hard)
+	if err != nil {
+		h.logger.Error("Failed to delete events", "error", err, "user_id", userID)
+		respondStorageError(c, err, "internal_error", "Failed to delete events")
+		return
+	}
+
+	h.logger.Info("Events deleted",
+		"user_id", userID,
+		"before", cutoff,
+		"deleted", deleted,
+		"hard", hard,
+		"by_admin", isAdmin,
+	)
+	resp := gin.H{
+		"deleted": deleted,
+		"before":  cutoff,
+		"hard":    hard,
+	}
+	if !hard {
+		resp["restore_until"] = now.Add(h.grace)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// HandleRestoreEvent undoes a soft delete within the grace period. Owner or
+// admin only. Returns 410 once the grace period has passed.
+// POST /api/v1/events/:id/restore
+func (h *DeletionHandler) HandleRestoreEvent(c *gin.Context) {
+	event, ok := h.authorizedEvent(c)
+	if !ok {
+		return
+	}
+	if event.DeletedAt == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "not_deleted",
+			"message": "Event is not deleted",
+		})
+		return
+	}
+
+	deletedAfter := time.Now().UTC().Add(-h.grace)
+	restored, err := h.repo.UndeleteEvent(c.Request.Context(), event.EventID, deletedAfter)
+	if err != nil {
+		h.logger.Error("Failed to restore event", "error", err, "event_id", event.EventID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to restore event",
+		})
+		return
+	}
+	if !restored {
+		c.JSON(http.StatusGone, gin.H{
+			"error":   "restore_window_expired",
+			"message": "The restore window for this event has passed",
+		})
+		return
+	}
+
+	h.logger.Info("Event restored", "event_id", event.EventID, "by_admin", c.GetBool(middleware.ContextKeyIsAdmin))
+	event.DeletedAt = nil
+	c.JSON(http.StatusOK, event)
+}
+
+// authorizedEvent loads the event in the path and checks the caller owns it
+// or is an admin, responding with 404 otherwise so other users' and
+// tenants' event IDs are not disclosed. Admins reach every tenant.
+func (h *DeletionHandler) authorizedEvent(c *gin.Context) (*models.IngestionEvent, bool) {
+	event, err := h.repo.GetEventByID(c.Request.Context(), readTenantID(c), c.Param("id"))
+	if err != nil || (event.UserID != currentUserID(c) && !c.GetBool(middleware.ContextKeyIsAdmin)) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Event not found",
+		})
+		return nil, false
+	}
+	return event, true
+}
+
+// includeDeleted reads the include_deleted query parameter, with which admin
+// callers also see soft-deleted events, for example to audit an erasure.
+// Other callers get 403 and ok is false.
+func includeDeleted(c *gin.Context) (include, ok bool) {
+	if c.Query("include_deleted") != "true" {
+		return false, true
+	}
+	if !c.GetBool(middleware.ContextKeyIsAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Only admin callers may use include_deleted",
+		})
+		return false, false
+	}
+	return true, true
+}
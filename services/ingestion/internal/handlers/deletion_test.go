@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// fakeDeletionRepo soft-deletes the events of a fakeEventRepo.
+type fakeDeletionRepo struct {
+	repository.DeletionRepository
+	events *fakeEventRepo
+	err    error
+}
+
+func (r *fakeDeletionRepo) GetEventByID(ctx context.Context, tenantID, eventID string) (*models.IngestionEvent, error) {
+	return r.events.GetEventByID(ctx, tenantID, eventID)
+}
+
+func (r *fakeDeletionRepo) SoftDeleteEvent(_ context.Context, eventID string) (bool, error) {
+	r.events.mu.Lock()
+	defer r.events.mu.Unlock()
+	event, ok := r.events.events[eventID]
+	if !ok || event.DeletedAt != nil {
+		return false, nil
+	}
+	now := time.Now().UTC()
+	event.DeletedAt = &now
+	return true, nil
+}
+
+func (r *fakeDeletionRepo) DeleteEventsBefore(_ context.Context, tenantID, userID string, cutoff time.Time, hard bool) (int64, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	r.events.mu.Lock()
+	defer r.events.mu.Unlock()
+	var deleted int64
+	for id, event := range r.events.events {
+		switch {
+		case event.UserID != userID,
+			tenantID != "" && event.TenantID != tenantID,
+			!event.CreatedAt.Before(cutoff),
+			!hard && event.DeletedAt != nil:
+			continue
+		}
+		if hard {
+			delete(r.events.events, id)
+		} else {
+			now := time.Now().UTC()
+			event.DeletedAt = &now
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// as runs handler for a request from userID, as an admin if admin is set.
+func as(userID string, admin bool, method, target string, route string, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextKeyUserID, userID)
+		if admin {
+			c.Set(middleware.ContextKeyIsAdmin, true)
+		}
+	})
+	r.Handle(method, route, handler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+	return w
+}
+
+func TestSoftDeletedEventReads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := &fakeEventRepo{events: map[string]*models.IngestionEvent{
+		"evt-1": {EventID: "evt-1", UserID: "alice", TenantID: middleware.DefaultTenant, SourceType: models.SourceTypeManual, OccurredAt: time.Now()},
+	}}
+	deletion := NewDeletionHandler(&fakeDeletionRepo{events: repo}, time.Hour, discardLogger())
+	ingestion := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger())
+
+	if w := as("bob", false, http.MethodDelete, "/events/evt-1", "/events/:id", deletion.HandleDeleteEvent); w.Code != http.StatusNotFound {
+		t.Fatalf("delete by another user: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := as("alice", false, http.MethodDelete, "/events/evt-1", "/events/:id", deletion.HandleDeleteEvent); w.Code != http.StatusOK {
+		t.Fatalf("delete by the owner: status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if w := as("alice", false, http.MethodDelete, "/events/evt-1", "/events/:id", deletion.HandleDeleteEvent); w.Code != http.StatusNotFound {
+		t.Fatalf("second delete: status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	tests := []struct {
+		name       string
+		userID     string
+		admin      bool
+		target     string
+		list       bool
+		wantStatus int
+		wantListed bool
+	}{
+		{name: "owner read", userID: "alice", target: "/events/evt-1", wantStatus: http.StatusNotFound},
+		{name: "admin read", userID: "admin", admin: true, target: "/events/evt-1", wantStatus: http.StatusNotFound},
+		{name: "owner read with include_deleted", userID: "alice", target: "/events/evt-1?include_deleted=true", wantStatus: http.StatusForbidden},
+		{name: "admin read with include_deleted", userID: "admin", admin: true, target: "/events/evt-1?include_deleted=true", wantStatus: http.StatusOK},
+		{name: "owner listing", userID: "alice", target: "/events", list: true, wantStatus: http.StatusOK},
+		{name: "owner listing with include_deleted", userID: "alice", target: "/events?include_deleted=true", list: true, wantStatus: http.StatusForbidden},
+		{name: "admin listing with include_deleted", userID: "alice", admin: true, target: "/events?include_deleted=true", list: true, wantStatus: http.StatusOK, wantListed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, handler := "/events/:id", gin.HandlerFunc(ingestion.HandleGetEvent)
+			if tt.list {
+				route, handler = "/events", ingestion.HandleGetUserEvents
+			}
+			w := as(tt.userID, tt.admin, http.MethodGet, tt.target, route, handler)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			if !tt.list {
+				var event models.IngestionEvent
+				if err := json.Unmarshal(w.Body.Bytes(), &event); err != nil {
+					t.Fatal(err)
+				}
+				if event.DeletedAt == nil {
+					t.Error("deleted event read without deleted_at")
+				}
+				return
+			}
+			var page struct {
+				Count int `json:"count"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatal(err)
+			}
+			if listed := page.Count == 1; listed != tt.wantListed {
+				t.Errorf("deleted event listed = %v, want %v", listed, tt.wantListed)
+			}
+		})
+	}
+}
+
+func TestHandleDeleteEventsBefore(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cutoff := time.Now().UTC().Truncate(time.Second).Add(-24 * time.Hour)
+	deletedAt := cutoff.Add(-time.Hour)
+
+	tests := []struct {
+		name        string
+		admin       bool
+		query       url.Values
+		err         error
+		wantStatus  int
+		wantError   string
+		wantDeleted int64
+		wantKept    []string
+	}{
+		{
+			name:        "soft delete",
+			query:       url.Values{"before": {cutoff.Format(time.RFC3339)}},
+			wantStatus:  http.StatusOK,
+			wantDeleted: 2,
+			wantKept:    []string{"at-cutoff", "bob-old", "newer"},
+		},
+		{
+			name:        "hard delete by an admin",
+			admin:       true,
+			query:       url.Values{"before": {cutoff.Format(time.RFC3339)}, "hard": {"true"}},
+			wantStatus:  http.StatusOK,
+			wantDeleted: 3,
+			wantKept:    []string{"at-cutoff", "bob-old", "newer"},
+		},
+		{
+			name:        "cutoff in another zone",
+			query:       url.Values{"before": {cutoff.In(time.FixedZone("CEST", 2*60*60)).Format(time.RFC3339)}},
+			wantStatus:  http.StatusOK,
+			wantDeleted: 2,
+			wantKept:    []string{"at-cutoff", "bob-old", "newer"},
+		},
+		{
+			name:       "hard delete by a user",
+			query:      url.Values{"before": {cutoff.Format(time.RFC3339)}, "hard": {"true"}},
+			wantStatus: http.StatusForbidden,
+			wantError:  "forbidden",
+		},
+		{name: "no cutoff", wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+		{name: "cutoff not RFC 3339", query: url.Values{"before": {"2026-01-01"}}, wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+		{
+			name:       "cutoff in the future",
+			query:      url.Values{"before": {time.Now().Add(time.Hour).Format(time.RFC3339)}},
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid_request",
+		},
+		{
+			name:       "storage error",
+			query:      url.Values{"before": {cutoff.Format(time.RFC3339)}},
+			err:        errors.New("connection reset"),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "internal_error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := func(id, userID string, createdAt time.Time) *models.IngestionEvent {
+				return &models.IngestionEvent{EventID: id, UserID: userID, TenantID: middleware.DefaultTenant, CreatedAt: createdAt}
+			}
+			repo := &fakeEventRepo{events: map[string]*models.IngestionEvent{
+				"old":       event("old", "alice", cutoff.Add(-48*time.Hour)),
+				"just-old":  event("just-old", "alice", cutoff.Add(-time.Microsecond)),
+				"at-cutoff": event("at-cutoff", "alice", cutoff),
+				"newer":     event("newer", "alice", cutoff.Add(time.Hour)),
+				"gone":      event("gone", "alice", cutoff.Add(-72*time.Hour)),
+				"bob-old":   event("bob-old", "bob", cutoff.Add(-48*time.Hour)),
+			}}
+			repo.events["gone"].DeletedAt = &deletedAt
+			h := NewDeletionHandler(&fakeDeletionRepo{events: repo, err: tt.err}, time.Hour, discardLogger())
+
+			w := as("alice", tt.admin, http.MethodDelete, "/events?"+tt.query.Encode(), "/events", h.HandleDeleteEventsBefore)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantError != "" {
+				if !jsonHasError(w.Body.Bytes(), tt.wantError) {
+					t.Errorf("body = %s, want error %q", w.Body, tt.wantError)
+				}
+				return
+			}
+			var resp struct {
+				Deleted      int64      `json:"deleted"`
+				Hard         bool       `json:"hard"`
+				RestoreUntil *time.Time `json:"restore_until"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Deleted != tt.wantDeleted {
+				t.Errorf("deleted = %d, want %d", resp.Deleted, tt.wantDeleted)
+			}
+			if (resp.RestoreUntil == nil) != resp.Hard {
+				t.Errorf("restore_until = %v with hard = %v, want it only for soft deletion", resp.RestoreUntil, resp.Hard)
+			}
+			var kept []string
+			for id, e := range repo.events {
+				if e.DeletedAt == nil {
+					kept = append(kept, id)
+				}
+			}
+			slices.Sort(kept)
+			if !slices.Equal(kept, tt.wantKept) {
+				t.Errorf("events left = %v, want %v", kept, tt.wantKept)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/queue"
+)
+
+// Limits on the dead-letter messages handled per request.
+const (
+	defaultDLQPeekLimit    = 50
+	maxDLQPeekLimit        = 500
+	defaultDLQRequeueLimit = 100
+	maxDLQRequeueLimit     = 1000
+)
+
+// DLQHandler lets admins inspect the dead-letter queue and move its
+// messages back to the graph engine queue.
+type DLQHandler struct {
+	dlq    *queue.DLQManager
+	logger *slog.Logger
+}
+
+// NewDLQHandler creates a dead-letter queue handler.
+func NewDLQHandler(dlq *queue.DLQManager, logger *slog.Logger) *DLQHandler {
+	return &DLQHandler{dlq: dlq, logger: logger}
+}
+
+// HandlePeek returns messages from the head of the dead-letter queue
+// without removing them.
+// GET /api/v1/admin/dlq
+func (h *DLQHandler) HandlePeek(c *gin.Context) {
+	limit, ok := dlqLimit(c, defaultDLQPeekLimit, maxDLQPeekLimit)
+	if !ok {
+		return
+	}
+
+	messages, err := h.dlq.Peek(c.Request.Context(), limit)
+	if err != nil {
+		h.logger.Error("Failed to read dead-letter queue", "error", err)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "dlq_unavailable",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"messages": messages,
+		"count":    len(messages),
+	})
+}
+
+// HandleRequeue moves messages from the dead-letter queue back to the graph
+// engine queue. When a move fails, the messages moved before it are
+// reported with the error.
+// POST /api/v1/admin/dlq/requeue
+func (h *DLQHandler) HandleRequeue(c *gin.Context) {
+	limit, ok := dlqLimit(c, defaultDLQRequeueLimit, maxDLQRequeueLimit)
+	if !ok {
+		return
+	}
+
+	requeued, err := h.dlq.Requeue(c.Request.Context(), limit)
+	if err != nil {
+		h.logger.Error("Failed to requeue dead-lettered messages", "error", err, "requeued", requeued)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":    "requeue_failed",
+			"message":  err.Error(),
+			"requeued": requeued,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"requeued": requeued,
+	})
+}
+
+// dlqLimit reads the limit query parameter, responding with 400 if it is
+// not between 1 and maxLimit.
+func dlqLimit(c *gin.Context, defaultLimit, maxLimit int) (int, bool) {
+	s := c.Query("limit")
+	if s == "" {
+		return defaultLimit, true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 || n > maxLimit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": fmt.Sprintf("limit must be between 1 and %d", maxLimit),
+		})
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/uigs/ingestion/internal/queue"
+)
+
+// emptyDLQChannel is a channel on an empty dead-letter queue.
+type emptyDLQChannel struct {
+	queue.DLQChannel
+}
+
+func (emptyDLQChannel) Get(string, bool) (amqp.Delivery, bool, error) {
+	return amqp.Delivery{}, false, nil
+}
+
+func (emptyDLQChannel) Confirm(bool) error { return nil }
+
+func (emptyDLQChannel) NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation {
+	return confirm
+}
+
+func (emptyDLQChannel) Close() error { return nil }
+
+func TestDLQHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	open := func(context.Context) (queue.DLQChannel, error) { return emptyDLQChannel{}, nil }
+	unavailable := func(context.Context) (queue.DLQChannel, error) { return nil, errors.New("not connected") }
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		open       func(context.Context) (queue.DLQChannel, error)
+		wantStatus int
+		wantBody   string
+		wantError  string
+	}{
+		{name: "peek", method: http.MethodGet, target: "/dlq", open: open, wantStatus: http.StatusOK, wantBody: `{"count":0,"messages":[]}`},
+		{name: "peek with a limit", method: http.MethodGet, target: "/dlq?limit=500", open: open, wantStatus: http.StatusOK, wantBody: `{"count":0,"messages":[]}`},
+		{name: "peek limit too large", method: http.MethodGet, target: "/dlq?limit=501", open: open, wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+		{name: "peek limit zero", method: http.MethodGet, target: "/dlq?limit=0", open: open, wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+		{name: "peek while disconnected", method: http.MethodGet, target: "/dlq", open: unavailable, wantStatus: http.StatusBadGateway, wantError: "dlq_unavailable"},
+		{name: "requeue", method: http.MethodPost, target: "/dlq/requeue", open: open, wantStatus: http.StatusOK, wantBody: `{"requeued":0}`},
+		{name: "requeue limit not a number", method: http.MethodPost, target: "/dlq/requeue?limit=all", open: open, wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+		{name: "requeue limit too large", method: http.MethodPost, target: "/dlq/requeue?limit=1001", open: open, wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+		{name: "requeue while disconnected", method: http.MethodPost, target: "/dlq/requeue", open: unavailable, wantStatus: http.StatusBadGateway, wantError: "requeue_failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewDLQHandler(queue.NewDLQManager(tt.open, discardLogger()), discardLogger())
+			route, handler := "/dlq", gin.HandlerFunc(h.HandlePeek)
+			if tt.method == http.MethodPost {
+				route, handler = "/dlq/requeue", h.HandleRequeue
+			}
+
+			w := as("admin", true, tt.method, tt.target, route, handler)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantBody != "" && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", w.Body, tt.wantBody)
+			}
+			if tt.wantError != "" && !jsonHasError(w.Body.Bytes(), tt.wantError) {
+				t.Errorf("body = %s, want error %q", w.Body, tt.wantError)
+			}
+		})
+	}
+}
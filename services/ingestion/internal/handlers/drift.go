@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/validation"
+)
+
+// DriftHandler reports payload fields producers send that the schemas do
+// not declare.
+type DriftHandler struct {
+	detector *validation.DriftDetector
+}
+
+// NewDriftHandler creates a schema drift handler.
+func NewDriftHandler(detector *validation.DriftDetector) *DriftHandler {
+	return &DriftHandler{detector: detector}
+}
+
+// HandleGetSchemaDrift returns the undeclared fields seen per source type.
+// GET /api/v1/admin/schema-drift
+func (h *DriftHandler) HandleGetSchemaDrift(c *gin.Context) {
+	reports := h.detector.Report()
+	c.JSON(http.StatusOK, gin.H{
+		"source_types": reports,
+		"count":        len(reports),
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// collidingEventRepo stores the first event and fails every later insert the
+// way CreateEvent does when the event ID is already stored, as a UUID
+// collision or a replayed insert would.
+type collidingEventRepo struct {
+	fakeEventRepo
+}
+
+func (r *collidingEventRepo) CreateEvent(ctx context.Context, event *models.IngestionEvent) error {
+	r.mu.Lock()
+	stored, err := len(r.events), r.createErr
+	r.mu.Unlock()
+	if err == nil && stored > 0 {
+		return fmt.Errorf("%w: %s", repository.ErrDuplicateEvent, event.EventID)
+	}
+	return r.fakeEventRepo.CreateEvent(ctx, event)
+}
+
+func TestHandleIngestDuplicateEventID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const body = `{"source_type":"MANUAL","payload":{"note":"hello"}}`
+
+	tests := []struct {
+		name       string
+		createErr  error
+		wantStatus int
+		wantError  string
+	}{
+		{name: "duplicate event ID", wantStatus: http.StatusConflict, wantError: "duplicate_event"},
+		{name: "other storage error", createErr: fmt.Errorf("failed to insert event: %w", context.Canceled), wantStatus: http.StatusInternalServerError, wantError: "storage_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &collidingEventRepo{}
+			h := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger())
+
+			first := ingest(h, "alice", body, nil)
+			h.Wait()
+			if first.Code != http.StatusCreated {
+				t.Fatalf("first insert: status = %d, want %d: %s", first.Code, http.StatusCreated, first.Body)
+			}
+
+			repo.createErr = tt.createErr
+			w := ingest(h, "alice", body, nil)
+			h.Wait()
+			if w.Code != tt.wantStatus {
+				t.Fatalf("second insert: status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if !jsonHasError(w.Body.Bytes(), tt.wantError) {
+				t.Errorf("body = %s, want error %q", w.Body, tt.wantError)
+			}
+			if len(repo.events) != 1 {
+				t.Errorf("stored %d events, want 1", len(repo.events))
+			}
+			if tt.wantStatus != http.StatusConflict {
+				return
+			}
+			var resp struct {
+				EventID string `json:"event_id"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.EventID == "" {
+				t.Error("event_id is empty, want the conflicting event reference")
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/cursor"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// GetEventsFiltered applies filter to the stored events of userID.
+func (r *fakeEventRepo) GetEventsFiltered(_ context.Context, userID string, filter repository.EventFilter) ([]models.IngestionEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []models.IngestionEvent
+	for _, e := range r.events {
+		switch {
+		case e.UserID != userID,
+			filter.TenantID != "" && e.TenantID != filter.TenantID,
+			e.DeletedAt != nil && !filter.IncludeDeleted,
+			filter.SourceType != "" && e.SourceType != filter.SourceType,
+			filter.From != nil && e.OccurredAt.Before(*filter.From),
+			filter.To != nil && !e.OccurredAt.Before(*filter.To),
+			filter.After != nil && !before(e, *filter.After):
+			continue
+		}
+		event := *e
+		if filter.OmitPayload {
+			event.RawPayload, event.CredentialJWT = nil, ""
+		}
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool { return !before(&events[i], cursor.New(events[j].CreatedAt, events[j].EventID)) })
+	if len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
+
+// before reports whether e comes before pos in (created_at, event_id) order.
+func before(e *models.IngestionEvent, pos cursor.Cursor) bool {
+	if !e.CreatedAt.Equal(pos.CreatedAt) {
+		return e.CreatedAt.Before(pos.CreatedAt)
+	}
+	return e.EventID < pos.EventID
+}
+
+func TestHandleGetUserEventsFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	event := func(id string, sourceType models.SourceType, age time.Duration) *models.IngestionEvent {
+		return &models.IngestionEvent{EventID: id, UserID: "alice", TenantID: middleware.DefaultTenant, SourceType: sourceType, CreatedAt: now.Add(-age), OccurredAt: now.Add(-age)}
+	}
+	repo := &fakeEventRepo{events: map[string]*models.IngestionEvent{
+		"vc-new":     event("vc-new", models.SourceTypeVC, time.Hour),
+		"vc-old":     event("vc-old", models.SourceTypeVC, 48*time.Hour),
+		"oidc-new":   event("oidc-new", models.SourceTypeOIDC, 2*time.Hour),
+		"manual-old": event("manual-old", models.SourceTypeManual, 72*time.Hour),
+		"bob-vc": {
+			EventID: "bob-vc", UserID: "bob", TenantID: middleware.DefaultTenant, SourceType: models.SourceTypeVC, CreatedAt: now, OccurredAt: now,
+		},
+	}}
+	dayAgo := now.Add(-24 * time.Hour).Format(time.RFC3339)
+	twoDaysAgo := now.Add(-60 * time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name       string
+		query      url.Values
+		wantStatus int
+		wantIDs    []string
+	}{
+		{name: "no filter", wantStatus: http.StatusOK, wantIDs: []string{"vc-new", "oidc-new", "vc-old", "manual-old"}},
+		{name: "source type", query: url.Values{"source_type": {"VC"}}, wantStatus: http.StatusOK, wantIDs: []string{"vc-new", "vc-old"}},
+		{name: "source type in lower case", query: url.Values{"source_type": {"oidc"}}, wantStatus: http.StatusOK, wantIDs: []string{"oidc-new"}},
+		{name: "from", query: url.Values{"from": {dayAgo}}, wantStatus: http.StatusOK, wantIDs: []string{"vc-new", "oidc-new"}},
+		{name: "to", query: url.Values{"to": {dayAgo}}, wantStatus: http.StatusOK, wantIDs: []string{"vc-old", "manual-old"}},
+		{name: "from and to", query: url.Values{"from": {twoDaysAgo}, "to": {dayAgo}}, wantStatus: http.StatusOK, wantIDs: []string{"vc-old"}},
+		{name: "source type and from", query: url.Values{"source_type": {"VC"}, "from": {dayAgo}}, wantStatus: http.StatusOK, wantIDs: []string{"vc-new"}},
+		{name: "every filter", query: url.Values{"source_type": {"VC"}, "from": {twoDaysAgo}, "to": {dayAgo}}, wantStatus: http.StatusOK, wantIDs: []string{"vc-old"}},
+		{name: "empty result", query: url.Values{"source_type": {"MANUAL"}, "from": {dayAgo}}, wantStatus: http.StatusOK, wantIDs: nil},
+		{name: "unknown source type", query: url.Values{"source_type": {"SAML"}}, wantStatus: http.StatusBadRequest},
+		{name: "from not RFC 3339", query: url.Values{"from": {"2026-03-01"}}, wantStatus: http.StatusBadRequest},
+		{name: "to not RFC 3339", query: url.Values{"to": {"yesterday"}}, wantStatus: http.StatusBadRequest},
+		{name: "from after to", query: url.Values{"from": {dayAgo}, "to": {twoDaysAgo}}, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger())
+			r := gin.New()
+			r.Use(func(c *gin.Context) { c.Set(middleware.ContextKeyUserID, "alice") })
+			r.GET("/events", h.HandleGetUserEvents)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events?"+tt.query.Encode(), nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				if !jsonHasError(w.Body.Bytes(), "invalid_filter") {
+					t.Errorf("body = %s, want invalid_filter", w.Body)
+				}
+				return
+			}
+			var resp struct {
+				Events []models.IngestionEvent `json:"events"`
+				Count  int                     `json:"count"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, e := range resp.Events {
+				ids = append(ids, e.EventID)
+			}
+			if len(ids) != len(tt.wantIDs) || resp.Count != len(tt.wantIDs) {
+				t.Fatalf("events = %v (count %d), want %v", ids, resp.Count, tt.wantIDs)
+			}
+			for i := range ids {
+				if ids[i] != tt.wantIDs[i] {
+					t.Errorf("events = %v, want %v", ids, tt.wantIDs)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestHandleGetUserEventsPaging(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stored := time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	repo := &fakeEventRepo{events: map[string]*models.IngestionEvent{}}
+	// Events stored in ID order; the two last ones share a created_at, and
+	// each was backfilled further into the past than the one before
+	var wantIDs []string
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("00000000-0000-0000-0000-00000000000%d", i)
+		created := stored.Add(time.Duration(min(i, 3)) * time.Minute)
+		repo.events[id] = &models.IngestionEvent{
+			EventID: id, UserID: "alice", TenantID: middleware.DefaultTenant, SourceType: models.SourceTypeVC,
+			CreatedAt: created, OccurredAt: stored.Add(-time.Duration(i) * 24 * time.Hour),
+		}
+		wantIDs = append([]string{id}, wantIDs...)
+	}
+
+	h := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger())
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set(middleware.ContextKeyUserID, "alice") })
+	r.GET("/events", h.HandleGetUserEvents)
+	get := func(query url.Values) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/events?"+query.Encode(), nil))
+		return w
+	}
+
+	t.Run("pages in created_at order", func(t *testing.T) {
+		var ids []string
+		query := url.Values{"limit": {"2"}}
+		for pages := 0; ; pages++ {
+			if pages > len(wantIDs) {
+				t.Fatal("next_cursor never became empty")
+			}
+			w := get(query)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+			}
+			var resp struct {
+				Events     []models.IngestionEvent `json:"events"`
+				NextCursor string                  `json:"next_cursor"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range resp.Events {
+				ids = append(ids, e.EventID)
+			}
+			if resp.NextCursor == "" {
+				break
+			}
+			query.Set("cursor", resp.NextCursor)
+		}
+		if strings.Join(ids, ",") != strings.Join(wantIDs, ",") {
+			t.Errorf("events = %v, want %v", ids, wantIDs)
+		}
+	})
+
+	tests := []struct {
+		name      string
+		query     url.Values
+		wantError string
+	}{
+		{name: "limit zero", query: url.Values{"limit": {"0"}}, wantError: "invalid_request"},
+		{name: "limit over the maximum", query: url.Values{"limit": {"1001"}}, wantError: "invalid_request"},
+		{name: "limit not a number", query: url.Values{"limit": {"ten"}}, wantError: "invalid_request"},
+		{name: "cursor without an event ID", query: url.Values{"cursor": {"1760520600000000"}}, wantError: "invalid_cursor"},
+		{name: "cursor with a bad time", query: url.Values{"cursor": {"soon_00000000-0000-0000-0000-000000000001"}}, wantError: "invalid_cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(tt.query)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusBadRequest, w.Body)
+			}
+			if !jsonHasError(w.Body.Bytes(), tt.wantError) {
+				t.Errorf("body = %s, want %s", w.Body, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestHandleGetUserEventsFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	payload := []byte(`{"credentialSubject":{"id":"did:example:alice"}}`)
+	repo := &fakeEventRepo{events: map[string]*models.IngestionEvent{
+		"evt-1": {EventID: "evt-1", UserID: "alice", TenantID: middleware.DefaultTenant, SourceType: models.SourceTypeVC, RawPayload: payload},
+	}}
+
+	tests := []struct {
+		name        string
+		path        string
+		query       url.Values
+		wantStatus  int
+		wantPayload bool
+	}{
+		{name: "listing leaves the payload out", path: "/events", wantStatus: http.StatusOK},
+		{name: "listing with the payload requested", path: "/events", query: url.Values{"fields": {"raw_payload"}}, wantStatus: http.StatusOK, wantPayload: true},
+		{name: "field list with spaces", path: "/events", query: url.Values{"fields": {" raw_payload "}}, wantStatus: http.StatusOK, wantPayload: true},
+		{name: "unknown field", path: "/events", query: url.Values{"fields": {"raw_payload,checksum"}}, wantStatus: http.StatusBadRequest},
+		{name: "single event keeps the payload", path: "/events/evt-1", wantStatus: http.StatusOK, wantPayload: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger())
+			r := gin.New()
+			r.Use(func(c *gin.Context) { c.Set(middleware.ContextKeyUserID, "alice") })
+			r.GET("/events", h.HandleGetUserEvents)
+			r.GET("/events/:id", h.HandleGetEvent)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path+"?"+tt.query.Encode(), nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				if !jsonHasError(w.Body.Bytes(), "invalid_request") {
+					t.Errorf("body = %s, want invalid_request", w.Body)
+				}
+				return
+			}
+			if got := strings.Contains(w.Body.String(), `"raw_payload"`); got != tt.wantPayload {
+				t.Errorf("response has raw_payload = %v, want %v: %s", got, tt.wantPayload, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// exportFlushEvery is the number of exported events between flushes.
+const exportFlushEvery = 100
+
+// ExportHandler exports all of a user's data for portability requests.
+type ExportHandler struct {
+	repo         repository.ExportRepository
+	queryTimeout time.Duration
+	logger       *slog.Logger
+}
+
+// NewExportHandler creates an export handler. An export's query may run for
+// queryTimeout rather than the repository's shorter default; 0 leaves it
+// unbounded.
+func NewExportHandler(repo repository.ExportRepository, queryTimeout time.Duration, logger *slog.Logger) *ExportHandler {
+	return &ExportHandler{repo: repo, queryTimeout: queryTimeout, logger: logger}
+}
+
+// exportedEvent is one line of a user export. Payloads are embedded as JSON
+// rather than base64.
+type exportedEvent struct {
+	models.IngestionEvent
+	RawPayload        json.RawMessage `json:"raw_payload"`
+	Enrichment        json.RawMessage `json:"enrichment,omitempty"`
+	NormalizedPayload json.RawMessage `json:"normalized_payload,omitempty"`
+}
+
+// HandleExport streams every event of the current user, soft-deleted ones
+// included, as newline-delimited JSON with decoded payloads and checksums.
+// GET /api/v1/export
+func (h *ExportHandler) HandleExport(c *gin.Context) {
+	userID := currentUserID(c)
+
+	// Large exports outlive the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to clear export write deadline", "error", err)
+	}
+
+	// The response starts with the first event, so a failing query can
+	// still be reported with a status code
+	started := false
+	start := func() {
+		c.Header("Content-Type", "application/x-ndjson")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="uigs-export-%s-%s.ndjson"`,
+			userID, time.Now().UTC().Format("20060102T150405Z")))
+		c.Status(http.StatusOK)
+		started = true
+	}
+
+	count := 0
+	enc := json.NewEncoder(c.Writer)
+	ctx := repository.WithQueryTimeout(c.Request.Context(), h.queryTimeout)
+	err := h.repo.StreamEventsByUser(ctx, userID, func(event models.IngestionEvent) error {
+		if !started {
+			start()
+		}
+		if err := enc.Encode(exportedEvent{
+			IngestionEvent:    event,
+			RawPayload:        event.RawPayload,
+			Enrichment:        event.Enrichment,
+			NormalizedPayload: event.NormalizedPayload,
+		}); err != nil {
+			return err
+		}
+		if count++; count%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil && !started {
+		h.logger.Error("Failed to export user data", "error", err, "user_id", userID)
+		respondStorageError(c, err, "internal_error", "Failed to export user data")
+		return
+	}
+	if err != nil {
+		// The client sees the export end early
+		h.logger.Error("User data export aborted", "error", err, "user_id", userID, "exported", count)
+		return
+	}
+	if !started {
+		start()
+	}
+
+	h.logger.Info("User data exported", "user_id", userID, "events", count)
+}
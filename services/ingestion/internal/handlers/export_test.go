@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// fakeExportRepo streams events, failing with err after failAfter of them
+// when err is set.
+type fakeExportRepo struct {
+	events    []models.IngestionEvent
+	err       error
+	failAfter int
+}
+
+func (r *fakeExportRepo) StreamEventsByUser(_ context.Context, userID string, fn func(models.IngestionEvent) error) error {
+	for i, event := range r.events {
+		if r.err != nil && i == r.failAfter {
+			return r.err
+		}
+		if event.UserID != userID {
+			continue
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestHandleExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	events := func(n int) []models.IngestionEvent {
+		var events []models.IngestionEvent
+		for i := 0; i < n; i++ {
+			payload := fmt.Sprintf(`{"n":%d}`, i)
+			events = append(events, models.IngestionEvent{
+				EventID:    fmt.Sprintf("evt-%d", i),
+				UserID:     "alice",
+				RawPayload: []byte(payload),
+				Checksum:   calculateChecksum([]byte(payload)),
+			})
+		}
+		return append(events, models.IngestionEvent{EventID: "bob-evt", UserID: "bob", RawPayload: []byte(`{}`)})
+	}
+
+	tests := []struct {
+		name       string
+		repo       *fakeExportRepo
+		wantStatus int
+		wantLines  int
+	}{
+		{name: "no events", repo: &fakeExportRepo{}, wantStatus: http.StatusOK},
+		{name: "one event", repo: &fakeExportRepo{events: events(1)}, wantStatus: http.StatusOK, wantLines: 1},
+		{name: "more events than a flush", repo: &fakeExportRepo{events: events(2*exportFlushEvery + 1)}, wantStatus: http.StatusOK, wantLines: 2*exportFlushEvery + 1},
+		{name: "query fails before the first event", repo: &fakeExportRepo{events: events(3), err: errors.New("connection reset")}, wantStatus: http.StatusInternalServerError},
+		{name: "query fails midway", repo: &fakeExportRepo{events: events(3), err: errors.New("connection reset"), failAfter: 2}, wantStatus: http.StatusOK, wantLines: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewExportHandler(tt.repo, 0, discardLogger())
+			w := as("alice", false, http.MethodGet, "/export", "/export", h.HandleExport)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Content-Disposition"); !strings.HasPrefix(got, `attachment; filename="uigs-export-alice-`) {
+				t.Errorf("Content-Disposition = %q", got)
+			}
+
+			lines := 0
+			scanner := bufio.NewScanner(w.Body)
+			for scanner.Scan() {
+				var line struct {
+					EventID    string          `json:"event_id"`
+					UserID     string          `json:"user_id"`
+					RawPayload json.RawMessage `json:"raw_payload"`
+					Checksum   string          `json:"checksum"`
+				}
+				if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+					t.Fatalf("line %d is not JSON: %v", lines+1, err)
+				}
+				if line.UserID != "alice" {
+					t.Errorf("exported event %s of user %q", line.EventID, line.UserID)
+				}
+				if line.Checksum != calculateChecksum(line.RawPayload) {
+					t.Errorf("event %s: payload %s does not match its checksum", line.EventID, line.RawPayload)
+				}
+				lines++
+			}
+			if lines != tt.wantLines {
+				t.Errorf("exported %d lines, want %d", lines, tt.wantLines)
+			}
+		})
+	}
+}
@@ -2,36 +2,159 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"runtime"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/queue"
+	"github.com/uigs/ingestion/internal/repository"
+	"github.com/uigs/ingestion/internal/validation"
 )
 
+// startedAt is when the process started, for the reported uptime.
+var startedAt = time.Now()
+
 // HealthResponse represents the health check response.
 type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Version   string    `json:"version"`
-	Service   string    `json:"service"`
+	Status    string        `json:"status"`
+	Timestamp time.Time     `json:"timestamp"`
+	Version   string        `json:"version"`
+	Service   string        `json:"service"`
+	Details   HealthDetails `json:"details"`
+}
+
+// HealthDetails describes the running process and its dependencies.
+type HealthDetails struct {
+	StartedAt     time.Time `json:"started_at"`
+	UptimeSeconds float64   `json:"uptime_seconds"`
+	GoVersion     string    `json:"go_version"`
+	// PostgresVersion is empty until the server version could be read
+	PostgresVersion string `json:"postgres_version,omitempty"`
+	// QueueState is connected or disconnected, with QueueError saying why
+	QueueState string `json:"queue_state"`
+	QueueError string `json:"queue_error,omitempty"`
+}
+
+// HealthHandler reports the health of the service.
+type HealthHandler struct {
+	repo      repository.EventRepository
+	publisher queue.Publisher
+
+	mu              sync.Mutex
+	postgresVersion string
+}
+
+// NewHealthHandler creates a health handler.
+func NewHealthHandler(repo repository.EventRepository, publisher queue.Publisher) *HealthHandler {
+	return &HealthHandler{repo: repo, publisher: publisher}
 }
 
-// HandleHealth returns the health status of the service.
+// HandleHealth returns the health status of the service, with its uptime
+// and dependency versions under details. The status stays healthy while
+// the process serves requests, so probes can rely on it alone; use /ready
+// to gate traffic on dependencies.
 // GET /health
-func HandleHealth(c *gin.Context) {
+func (h *HealthHandler) HandleHealth(c *gin.Context) {
+	details := HealthDetails{
+		StartedAt:       startedAt.UTC(),
+		UptimeSeconds:   time.Since(startedAt).Seconds(),
+		GoVersion:       runtime.Version(),
+		PostgresVersion: h.serverVersion(c.Request.Context()),
+		QueueState:      "connected",
+	}
+	if err := h.publisher.Healthy(); err != nil {
+		details.QueueState = "disconnected"
+		details.QueueError = err.Error()
+	}
+
 	c.JSON(http.StatusOK, HealthResponse{
 		Status:    "healthy",
 		Timestamp: time.Now().UTC(),
 		Version:   "1.0.0",
 		Service:   "ingestion-service",
+		Details:   details,
 	})
 }
 
-// HandleReadiness returns the readiness status of the service.
+// serverVersion returns the Postgres server version, querying it on first
+// use and again after a failed query.
+func (h *HealthHandler) serverVersion(ctx context.Context) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.postgresVersion != "" {
+		return h.postgresVersion
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+	version, err := h.repo.ServerVersion(ctx)
+	if err != nil {
+		return ""
+	}
+	h.postgresVersion = version
+	return version
+}
+
+// readinessTimeout bounds each dependency check of a readiness probe.
+const readinessTimeout = 2 * time.Second
+
+// ReadinessHandler reports whether the service is ready to receive traffic.
+type ReadinessHandler struct {
+	repo         repository.EventRepository
+	publisher    queue.Publisher
+	schemaStatus validation.LoadStatus
+}
+
+// NewReadinessHandler creates a readiness handler that checks the database
+// and the message queue.
+func NewReadinessHandler(repo repository.EventRepository, publisher queue.Publisher, schemaStatus validation.LoadStatus) *ReadinessHandler {
+	return &ReadinessHandler{repo: repo, publisher: publisher, schemaStatus: schemaStatus}
+}
+
+// HandleReadiness returns the readiness status of the service. It responds
+// 503 when the database or the message queue is unreachable.
 // GET /ready
-func HandleReadiness(c *gin.Context) {
-	// TODO: Add checks for database and queue connectivity
-	c.JSON(http.StatusOK, gin.H{
-		"status": "ready",
+func (h *ReadinessHandler) HandleReadiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+	defer cancel()
+
+	ready := true
+	dependency := func(err error) gin.H {
+		if err != nil {
+			ready = false
+			return gin.H{"status": "unavailable", "error": err.Error()}
+		}
+		return gin.H{"status": "ok"}
+	}
+	database := dependency(h.repo.Healthy(ctx))
+	messageQueue := dependency(h.publisher.Healthy())
+
+	// A schema load failure in lenient mode does not block traffic, but it is
+	// surfaced here so that disabled validation never goes unnoticed.
+	schemas := gin.H{
+		"status": "disabled",
+		"detail": h.schemaStatus,
+	}
+	switch {
+	case h.schemaStatus.Enabled && h.schemaStatus.Loaded:
+		schemas["status"] = "ok"
+	case h.schemaStatus.Enabled:
+		schemas["status"] = "degraded"
+	}
+
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": gin.H{
+			"database": database,
+			"queue":    messageQueue,
+			"schemas":  schemas,
+		},
 	})
 }
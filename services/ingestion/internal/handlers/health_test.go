@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/repository"
+	"github.com/uigs/ingestion/internal/validation"
+)
+
+// fakePingRepo is a database whose ping fails with err.
+type fakePingRepo struct {
+	repository.EventRepository
+	err error
+}
+
+func (r *fakePingRepo) Healthy(context.Context) error { return r.err }
+
+// fakeVersionRepo reports a Postgres server version, failing while err is
+// set, and counts the queries.
+type fakeVersionRepo struct {
+	repository.EventRepository
+	version string
+	err     error
+	calls   int
+}
+
+func (r *fakeVersionRepo) ServerVersion(context.Context) (string, error) {
+	r.calls++
+	return r.version, r.err
+}
+
+// getHealth serves GET /health and decodes its response.
+func getHealth(t *testing.T, h *HealthHandler) HealthResponse {
+	t.Helper()
+	r := gin.New()
+	r.GET("/health", h.HandleHealth)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	var resp HealthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestHandleHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		versionErr     error
+		queueErr       error
+		wantPostgres   string
+		wantQueries    int
+		wantQueueState string
+	}{
+		{name: "all dependencies up", wantPostgres: "16.2", wantQueries: 1, wantQueueState: "connected"},
+		{name: "version query fails", versionErr: errors.New("connection refused"), wantQueries: 2, wantQueueState: "connected"},
+		{name: "queue disconnected", queueErr: errors.New("reconnecting"), wantPostgres: "16.2", wantQueries: 1, wantQueueState: "disconnected"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeVersionRepo{version: "16.2", err: tt.versionErr}
+			h := NewHealthHandler(repo, &fakePublisher{err: tt.queueErr})
+
+			first := getHealth(t, h)
+			time.Sleep(10 * time.Millisecond)
+			second := getHealth(t, h)
+
+			if second.Status != "healthy" {
+				t.Errorf("status = %q, want healthy", second.Status)
+			}
+			if second.Details.UptimeSeconds <= first.Details.UptimeSeconds {
+				t.Errorf("uptime went from %v to %v, want it to increase", first.Details.UptimeSeconds, second.Details.UptimeSeconds)
+			}
+			if !second.Details.StartedAt.Equal(first.Details.StartedAt) || second.Details.StartedAt.IsZero() {
+				t.Errorf("started_at = %v then %v, want one fixed start time", first.Details.StartedAt, second.Details.StartedAt)
+			}
+			if second.Details.GoVersion != runtime.Version() {
+				t.Errorf("go_version = %q, want %q", second.Details.GoVersion, runtime.Version())
+			}
+			if second.Details.PostgresVersion != tt.wantPostgres {
+				t.Errorf("postgres_version = %q, want %q", second.Details.PostgresVersion, tt.wantPostgres)
+			}
+			if repo.calls != tt.wantQueries {
+				t.Errorf("queried the server version %d times, want %d", repo.calls, tt.wantQueries)
+			}
+			if second.Details.QueueState != tt.wantQueueState {
+				t.Errorf("queue_state = %q, want %q", second.Details.QueueState, tt.wantQueueState)
+			}
+			if tt.queueErr != nil && second.Details.QueueError != tt.queueErr.Error() {
+				t.Errorf("queue_error = %q, want %q", second.Details.QueueError, tt.queueErr)
+			}
+		})
+	}
+}
+
+func TestHandleReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		pingErr      error
+		queueErr     error
+		schemas      validation.LoadStatus
+		wantStatus   int
+		wantDatabase string
+		wantQueue    string
+		wantSchemas  string
+	}{
+		{name: "all healthy", wantStatus: http.StatusOK, wantDatabase: "ok", wantQueue: "ok", wantSchemas: "disabled"},
+		{name: "failing ping", pingErr: errors.New("failed to ping database: connection refused"), wantStatus: http.StatusServiceUnavailable, wantDatabase: "unavailable", wantQueue: "ok", wantSchemas: "disabled"},
+		{name: "queue reconnecting", queueErr: errors.New("reconnecting"), wantStatus: http.StatusServiceUnavailable, wantDatabase: "ok", wantQueue: "unavailable", wantSchemas: "disabled"},
+		{name: "both down", pingErr: errors.New("timeout"), queueErr: errors.New("closed"), wantStatus: http.StatusServiceUnavailable, wantDatabase: "unavailable", wantQueue: "unavailable", wantSchemas: "disabled"},
+		{name: "degraded schemas stay ready", schemas: validation.LoadStatus{Enabled: true}, wantStatus: http.StatusOK, wantDatabase: "ok", wantQueue: "ok", wantSchemas: "degraded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewReadinessHandler(&fakePingRepo{err: tt.pingErr}, &fakePublisher{err: tt.queueErr}, tt.schemas)
+			r := gin.New()
+			r.GET("/ready", h.HandleReadiness)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			var resp struct {
+				Status string `json:"status"`
+				Checks map[string]struct {
+					Status string `json:"status"`
+					Error  string `json:"error"`
+				} `json:"checks"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			wantReady := "ready"
+			if tt.wantStatus != http.StatusOK {
+				wantReady = "not_ready"
+			}
+			if resp.Status != wantReady {
+				t.Errorf("status = %q, want %q", resp.Status, wantReady)
+			}
+			for check, want := range map[string]string{"database": tt.wantDatabase, "queue": tt.wantQueue, "schemas": tt.wantSchemas} {
+				if got := resp.Checks[check].Status; got != want {
+					t.Errorf("%s check = %q, want %q", check, got, want)
+				}
+			}
+			if tt.pingErr != nil && resp.Checks["database"].Error != tt.pingErr.Error() {
+				t.Errorf("database error = %q, want %q", resp.Checks["database"].Error, tt.pingErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// idempotencyKeyHeader carries the client's key for safely retrying an
+// ingestion.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength matches the idempotency_key column.
+const maxIdempotencyKeyLength = 255
+
+// idempotencyScopeFor returns the namespace an idempotency key from userID
+// is unique in under the configured scope.
+func (h *IngestHandler) idempotencyScopeFor(userID string, sourceType models.SourceType) string {
+	switch h.idempotencyScope {
+	case models.IdempotencyScopeGlobal:
+		return "*"
+	case models.IdempotencyScopeUserSourceType:
+		return userID + "/" + string(sourceType)
+	default:
+		return userID
+	}
+}
+
+// payloadChecksum returns the checksum an event stored for payload would
+// have, to compare a retried request with the original.
+func payloadChecksum(payload map[string]interface{}) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return calculateChecksum(data), nil
+}
+
+// lookupIdempotent returns the event holding key within scope, or nil if
+// there is none. A key held for longer than the TTL is released instead, so
+// the request is stored as a new event.
+func (h *IngestHandler) lookupIdempotent(ctx context.Context, scope, key string) (*models.IngestionEvent, error) {
+	existing, err := h.idempotency.GetEventByIdempotencyKey(ctx, scope, key)
+	if err != nil || existing == nil || h.idempotencyTTL <= 0 {
+		return existing, err
+	}
+	if !h.clock.OlderThan(existing.CreatedAt, h.idempotencyTTL) {
+		return existing, nil
+	}
+	cutoff := h.clock.Now().Add(-h.idempotencyTTL - h.clock.Skew())
+	if err := h.idempotency.ReleaseIdempotencyKey(ctx, scope, key, cutoff); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// replayIdempotent answers a request whose idempotency key is already held
+// by an event: with the original event when the payload matches, with 409
+// when the key was used for a different payload. It reports whether a
+// response was written.
+func (h *IngestHandler) replayIdempotent(c *gin.Context, scope, key, checksum string) bool {
+	existing, err := h.lookupIdempotent(c.Request.Context(), scope, key)
+	if err != nil {
+		h.logger.Error("Failed to look up idempotency key", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to check idempotency key",
+		})
+		return true
+	}
+	if existing == nil {
+		return false
+	}
+
+	if existing.Checksum != checksum {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "idempotency_key_conflict",
+			"message": "Idempotency key was already used for a different payload",
+		})
+		return true
+	}
+
+	resp := models.IngestionResponse{
+		EventID:   existing.EventID,
+		Status:    "accepted",
+		Message:   "Duplicate request, returning the original event",
+		CreatedAt: existing.CreatedAt,
+	}
+	h.signReceipt(c.Request.Context(), &resp, existing)
+	c.Header("Idempotent-Replayed", "true")
+	c.JSON(http.StatusOK, resp)
+	return true
+}
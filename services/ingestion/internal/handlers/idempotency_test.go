@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+func (r *fakeEventRepo) GetEventByIdempotencyKey(_ context.Context, scope, key string) (*models.IngestionEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range r.events {
+		if event.IdempotencyScope == scope && event.IdempotencyKey == key {
+			return event, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeEventRepo) ReleaseIdempotencyKey(_ context.Context, scope, key string, before time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, event := range r.events {
+		if event.IdempotencyScope == scope && event.IdempotencyKey == key && event.CreatedAt.Before(before) {
+			event.IdempotencyKey, event.IdempotencyScope = "", ""
+		}
+	}
+	return nil
+}
+
+func TestHandleIngestIdempotencyKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const (
+		body  = `{"source_type":"MANUAL","payload":{"note":"hello"}}`
+		other = `{"source_type":"MANUAL","payload":{"note":"goodbye"}}`
+	)
+
+	type request struct {
+		user string
+		key  string
+		body string
+	}
+	tests := []struct {
+		name string
+		// seedAge stores an event holding key "k1" for alice that old first
+		seedAge    time.Duration
+		requests   []request
+		wantStatus []int
+		wantEvents int
+		// wantSameID is whether the last response names the first event
+		wantSameID bool
+	}{
+		{
+			name:       "retry returns the original event",
+			requests:   []request{{"alice", "k1", body}, {"alice", "k1", body}},
+			wantStatus: []int{http.StatusCreated, http.StatusOK},
+			wantEvents: 1,
+			wantSameID: true,
+		},
+		{
+			name:       "key reused for another payload",
+			requests:   []request{{"alice", "k1", body}, {"alice", "k1", other}},
+			wantStatus: []int{http.StatusCreated, http.StatusConflict},
+			wantEvents: 1,
+		},
+		{
+			name:       "keys are scoped per user",
+			requests:   []request{{"alice", "k1", body}, {"bob", "k1", body}},
+			wantStatus: []int{http.StatusCreated, http.StatusCreated},
+			wantEvents: 2,
+		},
+		{
+			name:       "requests without a key are not deduplicated",
+			requests:   []request{{"alice", "", body}, {"alice", "", body}},
+			wantStatus: []int{http.StatusCreated, http.StatusCreated},
+			wantEvents: 2,
+		},
+		{
+			name:       "key within the TTL is replayed",
+			seedAge:    time.Hour,
+			requests:   []request{{"alice", "k1", body}},
+			wantStatus: []int{http.StatusOK},
+			wantEvents: 1,
+		},
+		{
+			name:       "key past the TTL is released",
+			seedAge:    48 * time.Hour,
+			requests:   []request{{"alice", "k1", body}},
+			wantStatus: []int{http.StatusCreated},
+			wantEvents: 2,
+		},
+		{
+			name:       "overlong key",
+			requests:   []request{{"alice", strings.Repeat("k", 256), body}},
+			wantStatus: []int{http.StatusBadRequest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeEventRepo{}
+			if tt.seedAge > 0 {
+				checksum, _ := payloadChecksum(map[string]interface{}{"note": "hello"})
+				repo.CreateEvent(context.Background(), &models.IngestionEvent{
+					EventID:          "seeded",
+					UserID:           "alice",
+					Checksum:         checksum,
+					CreatedAt:        time.Now().Add(-tt.seedAge),
+					IdempotencyKey:   "k1",
+					IdempotencyScope: "alice",
+				})
+			}
+			h := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger(),
+				WithIdempotency(repo, models.IdempotencyScopeUser, 24*time.Hour))
+
+			var firstID string
+			var last models.IngestionResponse
+			for i, req := range tt.requests {
+				header := http.Header{}
+				if req.key != "" {
+					header.Set(idempotencyKeyHeader, req.key)
+				}
+				w := ingest(h, req.user, req.body, header)
+				if w.Code != tt.wantStatus[i] {
+					t.Fatalf("request %d: status = %d, want %d: %s", i, w.Code, tt.wantStatus[i], w.Body)
+				}
+				last = models.IngestionResponse{}
+				json.Unmarshal(w.Body.Bytes(), &last)
+				if i == 0 {
+					firstID = last.EventID
+				}
+				if w.Code == http.StatusOK && w.Header().Get("Idempotent-Replayed") != "true" {
+					t.Errorf("request %d: replay is not marked Idempotent-Replayed", i)
+				}
+			}
+			h.Wait()
+
+			if got := len(repo.events); got != tt.wantEvents {
+				t.Errorf("stored %d events, want %d", got, tt.wantEvents)
+			}
+			if tt.wantSameID && last.EventID != firstID {
+				t.Errorf("replay returned event %s, want %s", last.EventID, firstID)
+			}
+		})
+	}
+}
+
+func TestHandleIngestMarksIdempotentRequestsForPadding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const body = `{"source_type":"MANUAL","payload":{"note":"hello"}}`
+
+	tests := []struct {
+		name        string
+		idempotency bool
+		key         string
+		wantPadded  bool
+	}{
+		{name: "request with a key", idempotency: true, key: "k1", wantPadded: true},
+		{name: "request without a key", idempotency: true},
+		{name: "idempotency disabled", key: "k1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeEventRepo{}
+			var opts []IngestOption
+			if tt.idempotency {
+				opts = append(opts, WithIdempotency(repo, models.IdempotencyScopeUser, 24*time.Hour))
+			}
+			h := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger(), opts...)
+
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set(middleware.ContextKeyUserID, "alice")
+				c.Next()
+				if got := c.GetBool(middleware.ContextKeyPadResponse); got != tt.wantPadded {
+					t.Errorf("padded = %v, want %v", got, tt.wantPadded)
+				}
+			})
+			r.POST("/ingest", h.HandleIngest)
+
+			// The first request stores the event and the second replays it;
+			// both must be marked alike
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest(http.MethodPost, "/ingest", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				if tt.key != "" {
+					req.Header.Set(idempotencyKeyHeader, tt.key)
+				}
+				r.ServeHTTP(httptest.NewRecorder(), req)
+			}
+		})
+	}
+}
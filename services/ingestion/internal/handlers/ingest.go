@@ -2,175 +2,1318 @@
 package handlers
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/uigs/ingestion/internal/admission"
+	"github.com/uigs/ingestion/internal/challenge"
+	"github.com/uigs/ingestion/internal/credstatus"
+	"github.com/uigs/ingestion/internal/cursor"
+	"github.com/uigs/ingestion/internal/enrich"
+	"github.com/uigs/ingestion/internal/extract"
+	"github.com/uigs/ingestion/internal/forward"
+	"github.com/uigs/ingestion/internal/issuerrate"
+	"github.com/uigs/ingestion/internal/middleware"
 	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/oidc"
+	"github.com/uigs/ingestion/internal/proof"
 	"github.com/uigs/ingestion/internal/queue"
+	"github.com/uigs/ingestion/internal/receipt"
+	"github.com/uigs/ingestion/internal/redact"
 	"github.com/uigs/ingestion/internal/repository"
+	"github.com/uigs/ingestion/internal/scan"
+	"github.com/uigs/ingestion/internal/slo"
+	"github.com/uigs/ingestion/internal/timecheck"
+	"github.com/uigs/ingestion/internal/validation"
+	"github.com/uigs/ingestion/internal/vcmodel"
+	"github.com/uigs/ingestion/internal/webhook"
+)
+
+// durabilityHeader selects the point at which an ingestion is acknowledged.
+const durabilityHeader = "Durability"
+
+// backgroundPublishTimeout bounds a publish made after the response was sent.
+const backgroundPublishTimeout = 10 * time.Second
+
+// Page sizes for listing a user's events.
+const (
+	defaultEventPageSize = 100
+	maxEventPageSize     = 1000
 )
 
 // IngestHandler handles credential ingestion requests.
 type IngestHandler struct {
-	repo   repository.EventRepository
-	queue  queue.Publisher
-	logger *slog.Logger
+	repo        repository.EventRepository
+	queue       queue.Publisher
+	logger      *slog.Logger
+	redactor    *redact.Redactor
+	forwarder   *forward.Forwarder
+	schemas     *validation.Schemas
+	drift       *validation.DriftDetector
+	challenges  challenge.Store
+	publishable map[models.SourceType]bool
+	slo         *slo.Tracker
+
+	statusChecker *credstatus.Registry
+	enrichment    *enrich.Step
+	clock         timecheck.Clock
+
+	rejectFutureIssuance bool
+	asyncDelivery        bool
+
+	confirmer  queue.ConfirmPublisher
+	background sync.WaitGroup
+
+	webhooks    *webhook.Dispatcher
+	webhookRepo repository.WebhookRepository
+
+	callbacks      *webhook.Dispatcher
+	callbackSecret string
+
+	receipts *receipt.Signer
+
+	presets repository.PresetRepository
+
+	verifyLimit       *admission.Limiter
+	deferVerification bool
+	deferAfter        time.Duration
+
+	canonicalDataModel string
+
+	extractionFailure string
+	quarantine        repository.QuarantineRepository
+
+	issuerRates *issuerrate.Tracker
+
+	maxPayloadBytes     int
+	issuerPayloadLimits map[string]int
+
+	allowedIssuers map[string]bool
+
+	subjects          repository.SubjectRepository
+	subjectTenants    map[string]bool
+	subjectAllTenants bool
+
+	proofVerifier     *proof.Verifier
+	signaturePolicies proof.Policies
+	requireProof      bool
+	idTokens          *oidc.Validator
+
+	provenance         repository.ProvenanceRepository
+	provenanceMaxDepth int
+
+	idempotency      repository.IdempotencyRepository
+	idempotencyScope string
+	idempotencyTTL   time.Duration
+
+	attachments         repository.AttachmentRepository
+	scanner             *scan.Guard
+	maxAttachmentBytes  int64
+	infectedAttachments string
+}
+
+// IngestOption configures optional IngestHandler behaviour.
+type IngestOption func(*IngestHandler)
+
+// WithForwarder makes the handler relay writes to the primary region
+// instead of storing them locally. Used when running as a replica.
+func WithForwarder(f *forward.Forwarder) IngestOption {
+	return func(h *IngestHandler) {
+		h.forwarder = f
+	}
+}
+
+// WithSchemas enables JSON Schema validation of incoming payloads.
+func WithSchemas(s *validation.Schemas) IngestOption {
+	return func(h *IngestHandler) {
+		h.schemas = s
+	}
+}
+
+// WithSchemaDrift samples payloads that pass schema validation for fields
+// their schema does not declare.
+func WithSchemaDrift(d *validation.DriftDetector) IngestOption {
+	return func(h *IngestHandler) {
+		h.drift = d
+	}
+}
+
+// WithPublishableSourceTypes limits queue publishing to the given source
+// types. Events of other types are still stored but never published.
+func WithPublishableSourceTypes(types []models.SourceType) IngestOption {
+	return func(h *IngestHandler) {
+		h.publishable = make(map[models.SourceType]bool, len(types))
+		for _, t := range types {
+			h.publishable[t] = true
+		}
+	}
+}
+
+// WithSLOTracker records the latency of every successful ingestion.
+func WithSLOTracker(t *slo.Tracker) IngestOption {
+	return func(h *IngestHandler) {
+		h.slo = t
+	}
+}
+
+// WithStatusChecker enables revocation and suspension checks for VCs that
+// carry a credentialStatus entry.
+func WithStatusChecker(r *credstatus.Registry) IngestOption {
+	return func(h *IngestHandler) {
+		h.statusChecker = r
+	}
+}
+
+// WithEnrichment adds external directory data to events of the step's
+// enabled source types. Enrichment failures never reject an event.
+func WithEnrichment(s *enrich.Step) IngestOption {
+	return func(h *IngestHandler) {
+		h.enrichment = s
+	}
 }
 
-// NewIngestHandler creates a new ingest handler.
-func NewIngestHandler(repo repository.EventRepository, q queue.Publisher, logger *slog.Logger) *IngestHandler {
-	return &IngestHandler{
-		repo:   repo,
-		queue:  q,
-		logger: logger,
+// WithClock sets the clock, and so the clock-skew tolerance, used for every
+// time-based validation. The default tolerates no skew.
+func WithClock(clock timecheck.Clock) IngestOption {
+	return func(h *IngestHandler) {
+		h.clock = clock
 	}
 }
 
+// WithFutureIssuanceRejection rejects credentials whose issuanceDate is in
+// the future beyond the clock skew.
+func WithFutureIssuanceRejection() IngestOption {
+	return func(h *IngestHandler) {
+		h.rejectFutureIssuance = true
+	}
+}
+
+// WithAsyncDelivery declares that the queue publisher only buffers messages.
+// Successful publishes then leave the delivery status pending for the
+// publisher to record once the message is actually sent.
+func WithAsyncDelivery() IngestOption {
+	return func(h *IngestHandler) {
+		h.asyncDelivery = true
+	}
+}
+
+// WithConfirmPublisher enables the confirmed durability level, publishing
+// straight to the broker and waiting for its confirm.
+func WithConfirmPublisher(p queue.ConfirmPublisher) IngestOption {
+	return func(h *IngestHandler) {
+		h.confirmer = p
+	}
+}
+
+// WithVerificationWebhooks notifies event owners who opted in when
+// re-verification changes an event's verification status.
+func WithVerificationWebhooks(repo repository.WebhookRepository, d *webhook.Dispatcher) IngestOption {
+	return func(h *IngestHandler) {
+		h.webhookRepo = repo
+		h.webhooks = d
+	}
+}
+
+// WithCallbacks lets producers name a callback_url that is sent the
+// ingestion response once the event is stored, signed with secret.
+func WithCallbacks(d *webhook.Dispatcher, secret string) IngestOption {
+	return func(h *IngestHandler) {
+		h.callbacks = d
+		h.callbackSecret = secret
+	}
+}
+
+// WithReceipts adds a receipt signed by signer to the response of every
+// accepted event.
+func WithReceipts(signer *receipt.Signer) IngestOption {
+	return func(h *IngestHandler) {
+		h.receipts = signer
+	}
+}
+
+// WithPresets lets producers name a tenant preset with ?preset= to fill in
+// the source type, tags and metadata of their requests.
+func WithPresets(repo repository.PresetRepository) IngestOption {
+	return func(h *IngestHandler) {
+		h.presets = repo
+	}
+}
+
+// WithVerificationLimit bounds how many requests verify credentials at
+// once. Requests that cannot get a slot in time are rejected with 503.
+func WithVerificationLimit(l *admission.Limiter) IngestOption {
+	return func(h *IngestHandler) {
+		h.verifyLimit = l
+	}
+}
+
+// WithCanonicalDataModel converts VC payloads to the given VC Data Model
+// version before they are published. The original payload is still stored.
+func WithCanonicalDataModel(version string) IngestOption {
+	return func(h *IngestHandler) {
+		h.canonicalDataModel = version
+	}
+}
+
+// WithExtractionFailureMode sets what happens to a verified event whose
+// normalized fields cannot be extracted: it is stored anyway (ignore),
+// rejected with 422 (reject), or held in quarantine for review (quarantine).
+func WithExtractionFailureMode(mode string, repo repository.QuarantineRepository) IngestOption {
+	return func(h *IngestHandler) {
+		h.extractionFailure = mode
+		h.quarantine = repo
+	}
+}
+
+// WithRedactor sets how payloads are redacted before they are logged. By
+// default only redact.DefaultKeys are masked.
+func WithRedactor(r *redact.Redactor) IngestOption {
+	return func(h *IngestHandler) {
+		h.redactor = r
+	}
+}
+
+// WithIssuerRates tracks ingestion rates per issuer.
+func WithIssuerRates(t *issuerrate.Tracker) IngestOption {
+	return func(h *IngestHandler) {
+		h.issuerRates = t
+	}
+}
+
+// WithIssuerAllowlist accepts VCs only from the given issuers. An empty
+// list allows every issuer.
+func WithIssuerAllowlist(issuers []string) IngestOption {
+	return func(h *IngestHandler) {
+		h.allowedIssuers = make(map[string]bool, len(issuers))
+		for _, issuer := range issuers {
+			h.allowedIssuers[issuer] = true
+		}
+	}
+}
+
+// WithSubjectBinding requires, for the given tenants, that every credential's
+// credentialSubject.id is one of the identifiers registered for the
+// ingesting user. The tenant "*" enables the check for all tenants.
+func WithSubjectBinding(repo repository.SubjectRepository, tenants []string) IngestOption {
+	return func(h *IngestHandler) {
+		h.subjects = repo
+		h.subjectTenants = make(map[string]bool, len(tenants))
+		for _, t := range tenants {
+			if t == "*" {
+				h.subjectAllTenants = true
+			}
+			h.subjectTenants[t] = true
+		}
+	}
+}
+
+// WithPayloadLimits rejects payloads larger than maxBytes, or than the
+// issuer's entry in perIssuer when it has one. A limit of 0 is unlimited.
+func WithPayloadLimits(maxBytes int, perIssuer map[string]int) IngestOption {
+	return func(h *IngestHandler) {
+		h.maxPayloadBytes = maxBytes
+		h.issuerPayloadLimits = perIssuer
+	}
+}
+
+// requestEnvelopeBytes is the room left in a request body for the fields
+// around its payload, such as metadata, tags and a presentation.
+const requestEnvelopeBytes = 64 << 10
+
+// RequestBodyLimit returns the largest body an ingestion request may have
+// under the payload limits of WithPayloadLimits: the largest of them plus
+// room for the rest of the request. It returns 0 when payloads are
+// unlimited.
+func RequestBodyLimit(maxBytes int, perIssuer map[string]int) int64 {
+	if maxBytes == 0 {
+		return 0
+	}
+	largest := maxBytes
+	for _, limit := range perIssuer {
+		if limit == 0 {
+			return 0
+		}
+		largest = max(largest, limit)
+	}
+	return int64(largest) + requestEnvelopeBytes
+}
+
+// WithProofVerification requires every credential to carry a valid proof.
+func WithProofVerification(v *proof.Verifier) IngestOption {
+	return func(h *IngestHandler) {
+		h.proofVerifier = v
+		h.requireProof = true
+	}
+}
+
+// WithIDTokenValidation requires OIDC payloads to carry an id_token that v
+// accepts. The token's verified claims are stored as the normalized payload
+// and published in place of the payload as sent.
+func WithIDTokenValidation(v *oidc.Validator) IngestOption {
+	return func(h *IngestHandler) {
+		h.idTokens = v
+	}
+}
+
+// WithSignaturePolicies requires credentials of the policed types to carry
+// valid proofs from a threshold of designated issuers.
+func WithSignaturePolicies(v *proof.Verifier, policies proof.Policies) IngestOption {
+	return func(h *IngestHandler) {
+		h.proofVerifier = v
+		h.signaturePolicies = policies
+	}
+}
+
+// WithProvenanceVerification makes derived credentials prove their whole
+// parent_event_id chain, up to maxDepth ancestors, is intact and valid.
+func WithProvenanceVerification(repo repository.ProvenanceRepository, maxDepth int) IngestOption {
+	return func(h *IngestHandler) {
+		h.provenance = repo
+		h.provenanceMaxDepth = maxDepth
+	}
+}
+
+// WithIdempotency honours Idempotency-Key headers, treating keys as unique
+// within the given scope (one of the models.IdempotencyScope values). A key
+// is honoured for ttl after its event was created, or forever if ttl is 0.
+func WithIdempotency(repo repository.IdempotencyRepository, scope string, ttl time.Duration) IngestOption {
+	return func(h *IngestHandler) {
+		h.idempotency = repo
+		h.idempotencyScope = scope
+		h.idempotencyTTL = ttl
+	}
+}
+
+// WithAttachments accepts file attachments on events of up to maxBytes,
+// scanned by scanner. Infected content is handled according to
+// infectedAction (one of the models.AttachmentInfected values).
+func WithAttachments(repo repository.AttachmentRepository, scanner *scan.Guard, maxBytes int64, infectedAction string) IngestOption {
+	return func(h *IngestHandler) {
+		h.attachments = repo
+		h.scanner = scanner
+		h.maxAttachmentBytes = maxBytes
+		h.infectedAttachments = infectedAction
+	}
+}
+
+// WithDeferredVerification accepts credentials with their checks deferred,
+// instead of rejecting them, when no verification slot frees up within wait
+// or a credential status source is unavailable. Deferred events are
+// verified by a deferred.Worker.
+func WithDeferredVerification(wait time.Duration) IngestOption {
+	return func(h *IngestHandler) {
+		h.deferVerification = true
+		h.deferAfter = wait
+	}
+}
+
+// WithRegionalStore keeps everything derived from stored events in repo, the
+// database of a data-residency region: quarantined events, attachments and
+// the idempotency and provenance lookups. It must follow the options it
+// overrides.
+func WithRegionalStore(repo *repository.PostgresRepository) IngestOption {
+	return func(h *IngestHandler) {
+		if h.quarantine != nil {
+			h.quarantine = repo
+		}
+		if h.provenance != nil {
+			h.provenance = repo
+		}
+		if h.idempotency != nil {
+			h.idempotency = repo
+		}
+		if h.attachments != nil {
+			h.attachments = repo
+		}
+	}
+}
+
+// NewIngestHandler creates a new ingest handler. Presentations are checked
+// against the given challenge store.
+func NewIngestHandler(repo repository.EventRepository, q queue.Publisher, challenges challenge.Store, logger *slog.Logger, opts ...IngestOption) *IngestHandler {
+	h := &IngestHandler{
+		repo:       repo,
+		queue:      q,
+		challenges: challenges,
+		logger:     logger,
+		redactor:   redact.New(nil),
+		clock:      timecheck.New(0),
+
+		extractionFailure: models.ExtractionFailureIgnore,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
 // HandleIngest processes incoming credential ingestion requests.
 // POST /api/v1/ingest
 func (h *IngestHandler) HandleIngest(c *gin.Context) {
+	// Replica regions never write locally
+	if h.forwarder != nil {
+		forwardWrite(c, h.forwarder, h.logger)
+		return
+	}
+
+	start := time.Now()
+
+	durability := c.GetHeader(durabilityHeader)
+	if durability == "" {
+		durability = models.DurabilityStored
+	}
+	if !models.ValidDurability(durability) || (durability == models.DurabilityConfirmed && h.confirmer == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_durability",
+			"message": "Durability must be one of stored, queued or confirmed",
+		})
+		return
+	}
+
 	var req models.IngestionRequest
 
 	// Parse request body
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warn("Invalid request body", "error", err)
+		h.logger.WarnContext(c.Request.Context(), "Invalid request body", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "invalid_request",
 			"message": "Invalid request body: " + err.Error(),
 		})
 		return
 	}
-
-	// Get user ID from context (set by auth middleware)
-	// For now, use a default test user if not authenticated
-	userID := c.GetString("user_id")
-	if userID == "" {
-		userID = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11" // Default test user
+	if ierr := decodeCredentialJWT(&req); ierr != nil {
+		ierr.respond(c)
+		return
 	}
 
-	// Generate event ID
-	eventID := uuid.New().String()
+	if name := c.Query("preset"); name != "" {
+		preset, ierr := h.loadPreset(c, name)
+		if ierr != nil {
+			ierr.respond(c)
+			return
+		}
+		preset.Apply(&req)
+	}
 
-	// Marshal payload for storage
-	payloadBytes, err := json.Marshal(req.Payload)
-	if err != nil {
-		h.logger.Error("Failed to marshal payload", "error", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "internal_error",
-			"message": "Failed to process payload",
-		})
+	if ierr := checkOccurredAtAllowed(c, &req); ierr != nil {
+		ierr.respond(c)
+		return
+	}
+	if ierr := h.checkCallback(c.Request.Context(), &req); ierr != nil {
+		ierr.respond(c)
 		return
 	}
 
-	// Calculate checksum for integrity
-	checksum := calculateChecksum(payloadBytes)
+	userID := currentUserID(c)
 
-	// Create event
-	now := time.Now().UTC()
-	event := &models.IngestionEvent{
-		EventID:    eventID,
-		UserID:     userID,
-		SourceType: req.SourceType,
-		RawPayload: payloadBytes,
-		Checksum:   checksum,
-		CreatedAt:  now,
+	// A retry with a known idempotency key gets the original event back
+	idempotencyKey := c.GetHeader(idempotencyKeyHeader)
+	var idempotencyScope string
+	if idempotencyKey != "" && h.idempotency != nil {
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_idempotency_key",
+				"message": "Idempotency-Key must be at most 255 characters",
+			})
+			return
+		}
+		checksum, err := payloadChecksum(req.Payload)
+		if err != nil {
+			h.logger.ErrorContext(c.Request.Context(), "Failed to marshal payload", "error", err, "payload", h.redactor.Map(req.Payload))
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "internal_error",
+				"message": "Failed to process payload",
+			})
+			return
+		}
+		// A replay and a new insert must take equally long
+		middleware.PadResponse(c)
+		idempotencyScope = h.idempotencyScopeFor(userID, req.SourceType)
+		if h.replayIdempotent(c, idempotencyScope, idempotencyKey, checksum) {
+			return
+		}
+	}
+
+	event, ierr := h.prepareEvent(c.Request.Context(), userID, middleware.TenantID(c), &req)
+	if ierr != nil && ierr.quarantine {
+		h.respondQuarantined(c, event, ierr)
+		return
+	}
+	if ierr != nil {
+		ierr.respond(c)
+		return
+	}
+	if idempotencyScope != "" {
+		event.IdempotencyKey = idempotencyKey
+		event.IdempotencyScope = idempotencyScope
 	}
 
 	// Store in PostgreSQL
-	if err := h.repo.CreateEvent(c.Request.Context(), event); err != nil {
-		h.logger.Error("Failed to store event", "error", err, "event_id", eventID)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "storage_error",
-			"message": "Failed to store event",
+	dbStart := time.Now()
+	err := h.repo.CreateEvent(c.Request.Context(), event)
+	dbLatency := time.Since(dbStart)
+	if errors.Is(err, repository.ErrDuplicateIdempotencyKey) && h.replayIdempotent(c, idempotencyScope, idempotencyKey, event.Checksum) {
+		// A concurrent request with the same key stored its event first
+		return
+	}
+	if errors.Is(err, repository.ErrDuplicateEvent) {
+		h.logger.ErrorContext(c.Request.Context(), "Event ID already stored", "error", err, "event_id", event.EventID)
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    "duplicate_event",
+			"message":  "An event with this event ID is already stored",
+			"event_id": event.EventID,
 		})
 		return
 	}
-
-	// Publish to RabbitMQ
-	queueMsg := &models.QueueMessage{
-		EventID:    eventID,
-		UserID:     userID,
-		SourceType: req.SourceType,
-		Payload:    req.Payload,
-		Timestamp:  now,
+	if err != nil {
+		h.logger.ErrorContext(c.Request.Context(), "Failed to store event", "error", err, "event_id", event.EventID)
+		respondStorageError(c, err, "storage_error", "Failed to store event")
+		return
 	}
 
-	if err := h.queue.Publish(c.Request.Context(), queueMsg); err != nil {
-		h.logger.Error("Failed to publish event", "error", err, "event_id", eventID)
-		// Event is stored, but not published - log for retry mechanism
-		// For MVP, we'll continue and return success
+	publishStart := time.Now()
+	var queued bool
+	var queueReason string
+	switch durability {
+	case models.DurabilityStored:
+		h.publishInBackground(c.Request.Context(), event, req.Payload)
+		queueReason = "deferred"
+	case models.DurabilityQueued:
+		queued, queueReason = h.publishEvent(c.Request.Context(), event, req.Payload)
+	case models.DurabilityConfirmed:
+		queued, queueReason = h.publishConfirmed(c.Request.Context(), event, req.Payload)
 	}
+	publishLatency := time.Since(publishStart)
 
-	h.logger.Info("Event ingested successfully",
-		"event_id", eventID,
+	h.logger.InfoContext(c.Request.Context(), "Event ingested successfully",
+		"event_id", event.EventID,
 		"user_id", userID,
+		"producer_did", c.GetString("producer_did"),
 		"source_type", req.SourceType,
+		"queued", queued,
+		"durability", durability,
 	)
 
+	if h.slo != nil {
+		h.slo.Record(time.Since(start), dbLatency, publishLatency)
+	}
+
+	resp := models.IngestionResponse{
+		EventID:     event.EventID,
+		Status:      "accepted",
+		Message:     "Credential ingested successfully",
+		Queued:      queued,
+		QueueReason: queueReason,
+		Durability:  durability,
+		CreatedAt:   event.CreatedAt,
+	}
+	h.signReceipt(c.Request.Context(), &resp, event)
+	if req.CallbackURL != "" {
+		h.sendCallback(c.Request.Context(), req.CallbackURL, &resp)
+	}
+
 	// Return success response
-	c.JSON(http.StatusCreated, models.IngestionResponse{
-		EventID:   eventID,
-		Status:    "accepted",
-		Message:   "Credential ingested successfully",
-		CreatedAt: now,
-	})
+	c.JSON(http.StatusCreated, resp)
+}
+
+// prepareEvent validates and checks an ingestion request and builds the
+// event to store for it. When the event must be quarantined it returns both
+// the event and an error marked quarantine.
+func (h *IngestHandler) prepareEvent(ctx context.Context, userID, tenantID string, req *models.IngestionRequest) (*models.IngestionEvent, *ingestError) {
+	// The source type may come from a preset, so binding cannot require it
+	if req.SourceType == "" {
+		return nil, &ingestError{
+			status:  http.StatusBadRequest,
+			code:    "invalid_request",
+			message: "source_type is required",
+			field:   "source_type",
+		}
+	}
+	if ierr := h.checkOccurredAt(req); ierr != nil {
+		return nil, ierr
+	}
+
+	// Count every attempt per claimed issuer, so a burst of bad credentials
+	// from one issuer shows up too
+	issuer := extract.Issuer(req.Payload)
+	if h.issuerRates != nil {
+		h.issuerRates.Record(issuer)
+	}
+
+	// Marshal payload for storage, checking its size before any
+	// verification work is spent on it
+	payloadBytes, err := json.Marshal(req.Payload)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to marshal payload", "error", err, "payload", h.redactor.Map(req.Payload))
+		return nil, &ingestError{status: http.StatusInternalServerError, code: "internal_error", message: "Failed to process payload"}
+	}
+	if ierr := h.checkPayloadSize(issuer, len(payloadBytes)); ierr != nil {
+		return nil, ierr
+	}
+
+	// Validate payload shape for the source type
+	if h.schemas != nil {
+		if err := h.schemas.Validate(req.SourceType, req.Payload); err != nil {
+			var verr *validation.ValidationError
+			if errors.As(err, &verr) {
+				return nil, &ingestError{
+					status:  http.StatusUnprocessableEntity,
+					code:    "schema_validation_failed",
+					message: "Payload does not match schema: " + verr.Error(),
+					field:   verr.Field,
+				}
+			}
+			h.logger.ErrorContext(ctx, "Failed to validate payload", "error", err, "payload", h.redactor.Map(req.Payload))
+			return nil, &ingestError{status: http.StatusInternalServerError, code: "internal_error", message: "Failed to validate payload"}
+		}
+		if h.drift != nil {
+			h.drift.Observe(req.SourceType, req.Payload)
+		}
+	}
+
+	deferred, ierr := h.verify(ctx, userID, tenantID, req)
+	if ierr != nil {
+		return nil, ierr
+	}
+	if ierr := h.checkProvenance(ctx, userID, req.ParentEventID); ierr != nil {
+		return nil, ierr
+	}
+
+	// Generate event ID
+	eventID := uuid.New().String()
+
+	// Convert credentials to the canonical data model. The original stays
+	// in raw_payload; req.Payload becomes the canonical form so it is what
+	// gets published.
+	var dataModel string
+	var normalized []byte
+	if req.SourceType == models.SourceTypeVC {
+		dataModel = vcmodel.Detect(req.Payload)
+		if h.canonicalDataModel != "" {
+			canonical, converted, err := vcmodel.Convert(req.Payload, h.canonicalDataModel)
+			if err != nil {
+				h.logger.ErrorContext(ctx, "Failed to convert data model", "error", err, "payload", h.redactor.Map(req.Payload))
+				return nil, &ingestError{status: http.StatusInternalServerError, code: "internal_error", message: "Failed to process payload"}
+			}
+			if converted {
+				if normalized, err = json.Marshal(canonical); err != nil {
+					h.logger.ErrorContext(ctx, "Failed to marshal normalized payload", "error", err, "payload", h.redactor.Map(req.Payload))
+					return nil, &ingestError{status: http.StatusInternalServerError, code: "internal_error", message: "Failed to process payload"}
+				}
+				req.Payload = canonical
+			}
+		}
+	}
+	// verify replaced an OIDC payload with the ID token's verified claims
+	if req.SourceType == models.SourceTypeOIDC && h.idTokens != nil {
+		if normalized, err = json.Marshal(req.Payload); err != nil {
+			h.logger.ErrorContext(ctx, "Failed to marshal normalized payload", "error", err, "payload", h.redactor.Map(req.Payload))
+			return nil, &ingestError{status: http.StatusInternalServerError, code: "internal_error", message: "Failed to process payload"}
+		}
+	}
+
+	// Extract normalized fields; what happens when that fails is configured
+	dates, extractErr := extract.ParseDates(req.SourceType, req.Payload)
+	if extractErr != nil && h.extractionFailure == models.ExtractionFailureReject {
+		return nil, extractionError(extractErr)
+	}
+
+	// Enrich from external directories, storing the event without
+	// enrichment if the lookup fails
+	var enrichment []byte
+	if h.enrichment != nil {
+		enrichment, err = h.enrichment.Apply(ctx, req.SourceType, req.Payload)
+		if err != nil {
+			h.logger.WarnContext(ctx, "Event enrichment failed, continuing without it", "error", err, "event_id", eventID)
+		}
+	}
+
+	now := time.Now().UTC()
+	event := &models.IngestionEvent{
+		EventID:            eventID,
+		UserID:             userID,
+		TenantID:           tenantID,
+		SourceType:         req.SourceType,
+		RawPayload:         payloadBytes,
+		Checksum:           calculateChecksum(payloadBytes),
+		Enrichment:         enrichment,
+		CreatedAt:          now,
+		OccurredAt:         now,
+		VerificationStatus: models.VerificationStatusUnverified,
+		DeliveryStatus:     models.DeliveryStatusSkipped,
+		Dates:              dates,
+		Tags:               req.Tags,
+		Metadata:           req.Metadata,
+		DataModel:          dataModel,
+		NormalizedPayload:  normalized,
+		CredentialJWT:      req.CredentialJWT,
+	}
+	if req.ParentEventID != "" {
+		event.ParentEventID = &req.ParentEventID
+	}
+	if req.SourceType == models.SourceTypeOIDC {
+		event.OIDCClaims = parseOIDCClaims(req.Payload)
+	}
+	if req.OccurredAt != nil {
+		event.OccurredAt = req.OccurredAt.UTC()
+	}
+	switch {
+	case req.SourceType != models.SourceTypeVC:
+	case deferred:
+		event.VerificationStatus = models.VerificationStatusDeferred
+	default:
+		event.VerificationStatus = models.VerificationStatusVerified
+		event.VerifiedAt = &now
+	}
+	if h.isPublishable(req.SourceType) {
+		event.DeliveryStatus = models.DeliveryStatusPending
+	}
+	if extractErr != nil && h.extractionFailure == models.ExtractionFailureQuarantine {
+		ierr := extractionError(extractErr)
+		ierr.quarantine = true
+		return event, ierr
+	}
+	return event, nil
+}
+
+// loadPreset looks up the current tenant's preset by name.
+func (h *IngestHandler) loadPreset(c *gin.Context, name string) (*models.IngestionPreset, *ingestError) {
+	notFound := &ingestError{
+		status:  http.StatusNotFound,
+		code:    "preset_not_found",
+		message: "Preset not found: " + name,
+		field:   "preset",
+	}
+	if h.presets == nil {
+		return nil, notFound
+	}
+
+	tenantID := middleware.TenantID(c)
+	preset, err := h.presets.GetPreset(c.Request.Context(), tenantID, name)
+	if err != nil {
+		h.logger.ErrorContext(c.Request.Context(), "Failed to load preset", "error", err, "tenant_id", tenantID, "preset", name)
+		return nil, &ingestError{status: http.StatusInternalServerError, code: "internal_error", message: "Failed to load preset"}
+	}
+	if preset == nil {
+		return nil, notFound
+	}
+	return preset, nil
+}
+
+// checkPayloadSize rejects a payload over the limit that applies to its
+// issuer: the issuer's own limit if configured, else the global one.
+func (h *IngestHandler) checkPayloadSize(issuer string, size int) *ingestError {
+	limit, scope := h.maxPayloadBytes, "default"
+	if l, ok := h.issuerPayloadLimits[issuer]; ok && issuer != "" {
+		limit, scope = l, "issuer "+issuer
+	}
+	if limit == 0 || size <= limit {
+		return nil
+	}
+	return &ingestError{
+		status:  http.StatusRequestEntityTooLarge,
+		code:    "payload_too_large",
+		message: fmt.Sprintf("Payload is %d bytes, over the %d byte limit for %s", size, limit, scope),
+		field:   "payload",
+	}
+}
+
+// verify runs the source type's credential checks, holding a verification
+// slot while they run when a limit is configured. It reports whether the
+// credential checks were deferred to the background; the presentation and
+// subject checks always run.
+func (h *IngestHandler) verify(ctx context.Context, userID, tenantID string, req *models.IngestionRequest) (bool, *ingestError) {
+	if req.SourceType != models.SourceTypeVC && req.SourceType != models.SourceTypeOIDC {
+		return false, nil
+	}
+
+	release, deferred, ierr := h.acquireVerification(ctx)
+	if ierr != nil {
+		return false, ierr
+	}
+	defer release()
+
+	// Presentations must be bound to a challenge we issued
+	if req.SourceType == models.SourceTypeVC {
+		vpProof, ierr := h.checkPresentation(ctx, userID, req.Payload)
+		if ierr != nil {
+			return false, ierr
+		}
+		// Checked even when the other checks are deferred
+		if ierr := h.checkIssuers(req.Payload); ierr != nil {
+			return false, ierr
+		}
+		if !deferred {
+			if ierr := h.checkCredentials(ctx, req.Payload); ierr != nil {
+				if !h.canDefer(ierr) {
+					return false, ierr
+				}
+				deferred = true
+			}
+		}
+		if ierr := h.checkSubjects(ctx, userID, tenantID, req.Payload); ierr != nil {
+			return false, ierr
+		}
+		// Consumed only now, so a presentation rejected for another
+		// reason does not burn its challenge
+		return deferred, h.consumeChallenge(ctx, userID, vpProof)
+	}
+	if h.idTokens != nil {
+		claims, ierr := h.checkIDToken(ctx, req.Payload)
+		if ierr != nil {
+			return false, ierr
+		}
+		req.Payload = claims
+	}
+	return false, h.checkToken(req.Payload)
+}
+
+// publishEvent publishes a stored event to RabbitMQ, unless its source type
+// is stored only, and records the delivery status. It reports whether the
+// event was queued and, if not, why.
+func (h *IngestHandler) publishEvent(ctx context.Context, event *models.IngestionEvent, payload map[string]interface{}) (bool, string) {
+	if !h.isPublishable(event.SourceType) {
+		return false, "publishing_disabled_for_source_type"
+	}
+
+	queued := true
+	queueReason := ""
+	err := h.queue.Publish(ctx, queueMessage(event, payload))
+	deliveryStatus := queue.DeliveryStatus(err)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to publish event", "error", err, "event_id", event.EventID)
+		// The event is stored with its delivery failed; the outbox relay
+		// publishes it once the broker is back
+		queued = false
+		switch {
+		case errors.Is(err, queue.ErrBackpressure):
+			queueReason = "backpressure"
+		case errors.Is(err, queue.ErrDeadLettered):
+			queueReason = "dead_lettered"
+		case errors.Is(err, queue.ErrCircuitOpen):
+			queueReason = "circuit_open"
+		default:
+			queueReason = "publish_failed"
+		}
+	} else if h.asyncDelivery {
+		return queued, queueReason
+	}
+
+	if err := h.repo.UpdateDeliveryStatus(ctx, event.EventID, deliveryStatus); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to record delivery status", "error", err, "event_id", event.EventID)
+	}
+	return queued, queueReason
+}
+
+// publishConfirmed publishes an event straight to the broker, bypassing any
+// publish buffering, and waits for the broker's confirm.
+func (h *IngestHandler) publishConfirmed(ctx context.Context, event *models.IngestionEvent, payload map[string]interface{}) (bool, string) {
+	if !h.isPublishable(event.SourceType) {
+		return false, "publishing_disabled_for_source_type"
+	}
+
+	queued := true
+	queueReason := ""
+	err := h.confirmer.PublishConfirmed(ctx, queueMessage(event, payload))
+	deliveryStatus := queue.DeliveryStatus(err)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to publish event with confirm", "error", err, "event_id", event.EventID)
+		queued = false
+		queueReason = "not_confirmed"
+		if errors.Is(err, queue.ErrDeadLettered) {
+			queueReason = "dead_lettered"
+		}
+	}
+
+	if err := h.repo.UpdateDeliveryStatus(ctx, event.EventID, deliveryStatus); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to record delivery status", "error", err, "event_id", event.EventID)
+	}
+	return queued, queueReason
+}
+
+// publishInBackground publishes an event after the response has been sent.
+// The outcome is recorded in the event's delivery status. The publish stays
+// in the trace and logs of ctx's request but is not cancelled with it.
+func (h *IngestHandler) publishInBackground(ctx context.Context, event *models.IngestionEvent, payload map[string]interface{}) {
+	h.background.Add(1)
+	go func() {
+		defer h.background.Done()
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), backgroundPublishTimeout)
+		defer cancel()
+		h.publishEvent(ctx, event, payload)
+	}()
+}
+
+// Wait blocks until background publishes started for the stored durability
+// level have finished. Call it after the server has stopped accepting
+// requests and before closing the publisher.
+func (h *IngestHandler) Wait() {
+	h.background.Wait()
+}
+
+// queueMessage builds the message published for an event.
+func queueMessage(event *models.IngestionEvent, payload map[string]interface{}) *models.QueueMessage {
+	return &models.QueueMessage{
+		EventID:    event.EventID,
+		UserID:     event.UserID,
+		SourceType: event.SourceType,
+		Payload:    payload,
+		Enrichment: event.Enrichment,
+		Tags:       event.Tags,
+		Metadata:   event.Metadata,
+		DataModel:  event.DataModel,
+		Timestamp:  event.CreatedAt,
+		OccurredAt: event.OccurredAt,
+	}
+}
+
+// isPublishable reports whether events of the source type go to the queue.
+// All source types are published unless a publishable set was configured.
+func (h *IngestHandler) isPublishable(sourceType models.SourceType) bool {
+	if h.publishable == nil {
+		return true
+	}
+	return h.publishable[sourceType]
 }
 
 // HandleGetEvent retrieves an event by ID.
 // GET /api/v1/events/:id
 func (h *IngestHandler) HandleGetEvent(c *gin.Context) {
+	event, ok := h.readEvent(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, event)
+}
+
+// HandleGetEventPayload returns only the stored payload of an event, the
+// credential or claims as they were ingested, readable by whoever may read
+// the event itself.
+// GET /api/v1/events/:id/payload
+func (h *IngestHandler) HandleGetEventPayload(c *gin.Context) {
+	event, ok := h.readEvent(c)
+	if !ok {
+		return
+	}
+
+	if !json.Valid(event.RawPayload) {
+		h.logger.ErrorContext(c.Request.Context(), "Stored payload is not valid JSON", "event_id", event.EventID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "integrity_error",
+			"message": "Stored payload is not valid JSON",
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", event.RawPayload)
+}
+
+// readEvent loads the event named by the id parameter for a read, or
+// responds with why it cannot be read. Events of another tenant or another
+// user, unless the caller is an admin, and soft-deleted ones unless an admin
+// asks for them, are not found.
+func (h *IngestHandler) readEvent(c *gin.Context) (*models.IngestionEvent, bool) {
 	eventID := c.Param("id")
 	if eventID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "invalid_request",
 			"message": "Event ID is required",
 		})
-		return
+		return nil, false
 	}
 
-	event, err := h.repo.GetEventByID(c.Request.Context(), eventID)
-	if err != nil {
-		h.logger.Error("Failed to get event", "error", err, "event_id", eventID)
+	withDeleted, ok := includeDeleted(c)
+	if !ok {
+		return nil, false
+	}
+
+	event, err := h.repo.GetEventByID(c.Request.Context(), readTenantID(c), eventID)
+	if errors.Is(err, repository.ErrIntegrity) {
+		h.logger.ErrorContext(c.Request.Context(), "Stored event failed its integrity check", "error", err, "event_id", eventID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "integrity_error",
+			"message": "Stored event does not match its checksum",
+		})
+		return nil, false
+	}
+	if errors.Is(err, repository.ErrQueryTimeout) {
+		h.logger.ErrorContext(c.Request.Context(), "Failed to get event", "error", err, "event_id", eventID)
+		respondStorageError(c, err, "internal_error", "Failed to get event")
+		return nil, false
+	}
+	if err != nil || (event.DeletedAt != nil && !withDeleted) ||
+		(event.UserID != currentUserID(c) && !c.GetBool(middleware.ContextKeyIsAdmin)) {
+		if err != nil {
+			h.logger.ErrorContext(c.Request.Context(), "Failed to get event", "error", err, "event_id", eventID)
+		}
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "not_found",
 			"message": "Event not found",
 		})
-		return
+		return nil, false
 	}
-
-	c.JSON(http.StatusOK, event)
+	return event, true
 }
 
-// HandleGetUserEvents retrieves events for the current user.
+// HandleGetUserEvents retrieves a page of the current user's events, most
+// recently stored first. The next page is fetched by passing next_cursor
+// back as cursor; it is empty on the last page.
 // GET /api/v1/events
 func (h *IngestHandler) HandleGetUserEvents(c *gin.Context) {
-	userID := c.GetString("user_id")
-	if userID == "" {
-		userID = "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11" // Default test user
+	userID := currentUserID(c)
+
+	filter, limit, ok := parseEventPage(c)
+	if !ok {
+		return
 	}
+	filter.TenantID = middleware.TenantID(c)
 
-	events, err := h.repo.GetEventsByUser(c.Request.Context(), userID, 100)
+	events, err := h.repo.GetEventsFiltered(c.Request.Context(), userID, filter)
 	if err != nil {
-		h.logger.Error("Failed to get events", "error", err, "user_id", userID)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "internal_error",
-			"message": "Failed to retrieve events",
+		h.logger.ErrorContext(c.Request.Context(), "Failed to get events", "error", err, "user_id", userID)
+		respondStorageError(c, err, "internal_error", "Failed to retrieve events")
+		return
+	}
+
+	respondEventPage(c, events, limit)
+}
+
+// parseEventPage reads the limit, cursor, filter, include_deleted and
+// fields query parameters of an event listing, responding with 400 if one
+// is invalid. Listings leave raw_payload out unless fields asks for it.
+// The returned filter asks for one row more than limit, which tells
+// respondEventPage whether another page follows.
+func parseEventPage(c *gin.Context) (repository.EventFilter, int, bool) {
+	limit := defaultEventPageSize
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxEventPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": fmt.Sprintf("limit must be between 1 and %d", maxEventPageSize),
+			})
+			return repository.EventFilter{}, 0, false
+		}
+		limit = n
+	}
+	var after *cursor.Cursor
+	if s := c.Query("cursor"); s != "" {
+		parsed, err := cursor.Parse(s)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_cursor",
+				"message": err.Error(),
+			})
+			return repository.EventFilter{}, 0, false
+		}
+		after = &parsed
+	}
+	filter, err := parseEventFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_filter",
+			"message": err.Error(),
+		})
+		return filter, 0, false
+	}
+	var ok bool
+	if filter.IncludeDeleted, ok = includeDeleted(c); !ok {
+		return filter, 0, false
+	}
+	filter.OmitPayload = true
+	if raw := c.Query("fields"); raw != "" {
+		for _, field := range strings.Split(raw, ",") {
+			if strings.TrimSpace(field) != "raw_payload" {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error":   "invalid_request",
+					"message": fmt.Sprintf("unknown field %q; only raw_payload can be requested", field),
+				})
+				return filter, 0, false
+			}
+			filter.OmitPayload = false
+		}
+	}
+	filter.Limit, filter.After = limit+1, after
+	return filter, limit, true
+}
+
+// respondEventPage writes a page of at most limit events and the cursor of
+// the next page, which is empty when events holds no extra row.
+func respondEventPage(c *gin.Context, events []models.IngestionEvent, limit int) {
+	events, nextCursor := repository.TrimPage(events, limit)
+
+	c.JSON(http.StatusOK, gin.H{
+		"events":      events,
+		"count":       len(events),
+		"next_cursor": nextCursor,
+	})
+}
+
+// parseEventFilter reads the source_type, from and to query parameters of
+// an event listing. Times are RFC 3339.
+func parseEventFilter(c *gin.Context) (repository.EventFilter, error) {
+	var f repository.EventFilter
+	if raw := c.Query("source_type"); raw != "" {
+		f.SourceType = models.SourceType(strings.ToUpper(raw))
+		switch f.SourceType {
+		case models.SourceTypeVC, models.SourceTypeOIDC, models.SourceTypeManual:
+		default:
+			return f, fmt.Errorf("unknown source_type %q", raw)
+		}
+	}
+	var err error
+	if f.From, err = timeParam(c, "from"); err != nil {
+		return f, err
+	}
+	if f.To, err = timeParam(c, "to"); err != nil {
+		return f, err
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return f, fmt.Errorf("from must be before to")
+	}
+	return f, nil
+}
+
+// timeParam parses an optional RFC 3339 query parameter.
+func timeParam(c *gin.Context, name string) (*time.Time, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC 3339 time", name)
+	}
+	return &t, nil
+}
+
+// HandleGetEventStatuses returns the verification and delivery status of
+// several of the current user's events in one call.
+// POST /api/v1/events/status
+func (h *IngestHandler) HandleGetEventStatuses(c *gin.Context) {
+	var req models.EventStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": err.Error(),
 		})
 		return
 	}
 
+	for _, id := range req.EventIDs {
+		if _, err := uuid.Parse(id); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": "Invalid event ID: " + id,
+			})
+			return
+		}
+	}
+
+	userID := currentUserID(c)
+
+	statuses, err := h.repo.GetEventStatuses(c.Request.Context(), userID, req.EventIDs)
+	if err != nil {
+		h.logger.ErrorContext(c.Request.Context(), "Failed to get event statuses", "error", err, "user_id", userID)
+		respondStorageError(c, err, "internal_error", "Failed to retrieve event statuses")
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"events": events,
-		"count":  len(events),
+		"statuses": statuses,
 	})
 }
 
+// forwardWrite relays the current write request to another region's
+// ingestion endpoint and copies its response back to the client unchanged.
+func forwardWrite(c *gin.Context, f *forward.Forwarder, logger *slog.Logger) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "Failed to read request body",
+		})
+		return
+	}
+
+	resp, err := f.Forward(c.Request.Context(), c.Request, body)
+	if err != nil {
+		if errors.Is(err, forward.ErrForwardLoop) {
+			logger.Error("Refusing to re-forward request", "path", c.Request.URL.Path)
+			c.JSON(http.StatusLoopDetected, gin.H{
+				"error":   "forward_loop",
+				"message": "Request was already forwarded by another region",
+			})
+			return
+		}
+		logger.Error("Failed to forward write",
+			"error", err,
+			"target", f.Target(),
+		)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "forward_error",
+			"message": "Failed to forward request to the target region",
+		})
+		return
+	}
+
+	logger.Info("Write forwarded",
+		"path", c.Request.URL.Path,
+		"target", f.Target(),
+		"status", resp.StatusCode,
+	)
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c.Data(resp.StatusCode, contentType, resp.Body)
+}
+
+// respondStorageError answers a failed repository call: 504 timeout_error
+// when the database did not answer within the query timeout, otherwise 500
+// with code and message.
+func respondStorageError(c *gin.Context, err error, code, message string) {
+	if errors.Is(err, repository.ErrQueryTimeout) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "timeout_error",
+			"message": "The database did not answer in time",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"error":   code,
+		"message": message,
+	})
+}
+
+// currentUserID returns the user authenticated by the AuthJWT middleware.
+func currentUserID(c *gin.Context) string {
+	return c.GetString(middleware.ContextKeyUserID)
+}
+
+// readTenantID returns the tenant whose events the caller may read by ID,
+// or "" for admins, who read every tenant's.
+func readTenantID(c *gin.Context) string {
+	if c.GetBool(middleware.ContextKeyIsAdmin) {
+		return ""
+	}
+	return middleware.TenantID(c)
+}
+
 // calculateChecksum calculates SHA-256 checksum of data.
 func calculateChecksum(data []byte) string {
 	hash := sha256.Sum256(data)
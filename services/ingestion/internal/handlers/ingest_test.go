@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/queue"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// fakeEventRepo serves events from memory. Methods a test does not set up
+// panic through the nil embedded interface.
+type fakeEventRepo struct {
+	repository.EventRepository
+
+	mu        sync.Mutex
+	events    map[string]*models.IngestionEvent
+	delivery  map[string]string
+	createErr error
+}
+
+func (r *fakeEventRepo) GetEventByID(_ context.Context, tenantID, eventID string) (*models.IngestionEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event, ok := r.events[eventID]
+	if !ok || (tenantID != "" && event.TenantID != tenantID) {
+		return nil, errors.New("event not found")
+	}
+	if event.Checksum != "" && !event.VerifyIntegrity() {
+		return nil, fmt.Errorf("%w: event %s", repository.ErrIntegrity, eventID)
+	}
+	return event, nil
+}
+
+func (r *fakeEventRepo) CreateEvent(_ context.Context, event *models.IngestionEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.createErr != nil {
+		return r.createErr
+	}
+	if r.events == nil {
+		r.events = make(map[string]*models.IngestionEvent)
+	}
+	r.events[event.EventID] = event
+	return nil
+}
+
+func (r *fakeEventRepo) UpdateDeliveryStatus(_ context.Context, eventID, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.delivery == nil {
+		r.delivery = make(map[string]string)
+	}
+	r.delivery[eventID] = status
+	return nil
+}
+
+// deliveryStatus returns the delivery status recorded for an event.
+func (r *fakeEventRepo) deliveryStatus(eventID string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.delivery[eventID]
+}
+
+// fakePublisher records published messages, failing each publish with err.
+type fakePublisher struct {
+	mu        sync.Mutex
+	published []*models.QueueMessage
+	confirmed []*models.QueueMessage
+	err       error
+}
+
+func (p *fakePublisher) Publish(_ context.Context, msg *models.QueueMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.published = append(p.published, msg)
+	return nil
+}
+
+func (p *fakePublisher) PublishConfirmed(_ context.Context, msg *models.QueueMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.confirmed = append(p.confirmed, msg)
+	return nil
+}
+
+func (p *fakePublisher) Healthy() error { return p.err }
+
+func (p *fakePublisher) Close() error { return nil }
+
+// counts returns the number of plain and confirmed publishes.
+func (p *fakePublisher) counts() (int, int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.published), len(p.confirmed)
+}
+
+// ingest posts body to h.HandleIngest as userID and returns the response.
+func ingest(h *IngestHandler, userID, body string, header http.Header) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(middleware.ContextKeyUserID, userID)
+	})
+	r.POST("/ingest", h.HandleIngest)
+
+	req := httptest.NewRequest(http.MethodPost, "/ingest", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestReadEventOwnership(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := &fakeEventRepo{events: map[string]*models.IngestionEvent{
+		"evt-1": {EventID: "evt-1", UserID: "alice", TenantID: "acme", RawPayload: []byte(`{"ok":true}`)},
+	}}
+	h := NewIngestHandler(repo, nil, nil, discardLogger())
+
+	tests := []struct {
+		name       string
+		userID     string
+		tenantID   string
+		admin      bool
+		path       string
+		wantStatus int
+	}{
+		{name: "owner reads event", userID: "alice", tenantID: "acme", path: "/events/evt-1", wantStatus: http.StatusOK},
+		{name: "owner reads payload", userID: "alice", tenantID: "acme", path: "/events/evt-1/payload", wantStatus: http.StatusOK},
+		{name: "other user in tenant", userID: "bob", tenantID: "acme", path: "/events/evt-1", wantStatus: http.StatusNotFound},
+		{name: "other user reads payload", userID: "bob", tenantID: "acme", path: "/events/evt-1/payload", wantStatus: http.StatusNotFound},
+		{name: "same user other tenant", userID: "alice", tenantID: "globex", path: "/events/evt-1", wantStatus: http.StatusNotFound},
+		{name: "admin", userID: "root", tenantID: "globex", admin: true, path: "/events/evt-1/payload", wantStatus: http.StatusOK},
+		{name: "unknown event", userID: "alice", tenantID: "acme", path: "/events/evt-2", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set(middleware.ContextKeyUserID, tt.userID)
+				c.Set(middleware.ContextKeyTenantID, tt.tenantID)
+				c.Set(middleware.ContextKeyIsAdmin, tt.admin)
+			})
+			r.GET("/events/:id", h.HandleGetEvent)
+			r.GET("/events/:id/payload", h.HandleGetEventPayload)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+		})
+	}
+}
+
+// jsonHasError reports whether body is a JSON error response with code.
+func jsonHasError(body []byte, code string) bool {
+	var resp struct {
+		Error string `json:"error"`
+	}
+	return json.Unmarshal(body, &resp) == nil && resp.Error == code
+}
+
+func TestHandleIngestDurability(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const body = `{"source_type":"MANUAL","payload":{"note":"hello"}}`
+
+	tests := []struct {
+		name          string
+		durability    string
+		confirmer     bool
+		publishErr    error
+		wantStatus    int
+		wantQueued    bool
+		wantReason    string
+		wantPublished int
+		wantConfirmed int
+		wantDelivery  string
+	}{
+		{name: "default is stored", wantStatus: http.StatusCreated, wantReason: "deferred", wantPublished: 1, wantDelivery: models.DeliveryStatusQueued},
+		{name: "stored", durability: "stored", wantStatus: http.StatusCreated, wantReason: "deferred", wantPublished: 1, wantDelivery: models.DeliveryStatusQueued},
+		{name: "queued", durability: "queued", wantStatus: http.StatusCreated, wantQueued: true, wantPublished: 1, wantDelivery: models.DeliveryStatusQueued},
+		{name: "queued with broker down", durability: "queued", publishErr: errors.New("connection refused"), wantStatus: http.StatusCreated, wantReason: "publish_failed", wantDelivery: models.DeliveryStatusFailed},
+		{name: "queued with circuit open", durability: "queued", publishErr: queue.ErrCircuitOpen, wantStatus: http.StatusCreated, wantReason: "circuit_open", wantDelivery: models.DeliveryStatusFailed},
+		{name: "confirmed", durability: "confirmed", confirmer: true, wantStatus: http.StatusCreated, wantQueued: true, wantConfirmed: 1, wantDelivery: models.DeliveryStatusQueued},
+		{name: "confirmed without confirm", durability: "confirmed", confirmer: true, publishErr: errors.New("nack"), wantStatus: http.StatusCreated, wantReason: "not_confirmed", wantDelivery: models.DeliveryStatusFailed},
+		{name: "confirmed unavailable", durability: "confirmed", wantStatus: http.StatusBadRequest},
+		{name: "unknown level", durability: "durable", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeEventRepo{}
+			pub := &fakePublisher{err: tt.publishErr}
+			var opts []IngestOption
+			if tt.confirmer {
+				opts = append(opts, WithConfirmPublisher(pub))
+			}
+			h := NewIngestHandler(repo, pub, nil, discardLogger(), opts...)
+
+			header := http.Header{}
+			if tt.durability != "" {
+				header.Set(durabilityHeader, tt.durability)
+			}
+			w := ingest(h, "alice", body, header)
+			h.Wait()
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code != http.StatusCreated {
+				if !jsonHasError(w.Body.Bytes(), "invalid_durability") {
+					t.Errorf("body = %s, want invalid_durability", w.Body)
+				}
+				return
+			}
+			var resp models.IngestionResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Queued != tt.wantQueued || resp.QueueReason != tt.wantReason {
+				t.Errorf("queued = %v (%q), want %v (%q)", resp.Queued, resp.QueueReason, tt.wantQueued, tt.wantReason)
+			}
+			published, confirmed := pub.counts()
+			if published != tt.wantPublished || confirmed != tt.wantConfirmed {
+				t.Errorf("published %d, confirmed %d; want %d, %d", published, confirmed, tt.wantPublished, tt.wantConfirmed)
+			}
+			if got := repo.deliveryStatus(resp.EventID); got != tt.wantDelivery {
+				t.Errorf("delivery status = %q, want %q", got, tt.wantDelivery)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+func TestReadEventIntegrity(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	payload := []byte(`{"note":"hello"}`)
+
+	tests := []struct {
+		name       string
+		tamper     func(e *models.IngestionEvent)
+		wantStatus int
+	}{
+		{name: "intact", tamper: func(*models.IngestionEvent) {}, wantStatus: http.StatusOK},
+		{name: "tampered payload byte", tamper: func(e *models.IngestionEvent) { e.RawPayload[9] = 'j' }, wantStatus: http.StatusInternalServerError},
+		{name: "payload replaced", tamper: func(e *models.IngestionEvent) { e.RawPayload = []byte(`{"note":"bye"}`) }, wantStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &models.IngestionEvent{
+				EventID:    "evt-1",
+				UserID:     "alice",
+				TenantID:   middleware.DefaultTenant,
+				RawPayload: append([]byte(nil), payload...),
+				Checksum:   calculateChecksum(payload),
+			}
+			tt.tamper(event)
+			repo := &fakeEventRepo{events: map[string]*models.IngestionEvent{"evt-1": event}}
+			h := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger())
+
+			for _, route := range []struct {
+				path, target string
+				handler      gin.HandlerFunc
+			}{
+				{"/events/:id", "/events/evt-1", h.HandleGetEvent},
+				{"/events/:id/payload", "/events/evt-1/payload", h.HandleGetEventPayload},
+			} {
+				w := as("alice", false, http.MethodGet, route.target, route.path, route.handler)
+				if w.Code != tt.wantStatus {
+					t.Fatalf("GET %s: status = %d, want %d: %s", route.target, w.Code, tt.wantStatus, w.Body)
+				}
+				if w.Code != http.StatusOK && !jsonHasError(w.Body.Bytes(), "integrity_error") {
+					t.Errorf("GET %s: body = %s, want integrity_error", route.target, w.Body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleIngestIssuerAllowlist(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const trusted = "did:example:trusted"
+	credential := func(issuer interface{}) string {
+		body, _ := json.Marshal(map[string]interface{}{
+			"source_type": "VC",
+			"payload": map[string]interface{}{
+				"@context":          []string{"https://www.w3.org/2018/credentials/v1"},
+				"type":              []string{"VerifiableCredential"},
+				"issuer":            issuer,
+				"credentialSubject": map[string]interface{}{"name": "Alice"},
+			},
+		})
+		return string(body)
+	}
+
+	tests := []struct {
+		name       string
+		allowlist  []string
+		body       string
+		wantStatus int
+	}{
+		{name: "allowed string issuer", allowlist: []string{trusted}, body: credential(trusted), wantStatus: http.StatusCreated},
+		{name: "allowed object issuer", allowlist: []string{trusted}, body: credential(map[string]interface{}{"id": trusted, "name": "Trusted"}), wantStatus: http.StatusCreated},
+		{name: "blocked string issuer", allowlist: []string{trusted}, body: credential("did:example:other"), wantStatus: http.StatusForbidden},
+		{name: "blocked object issuer", allowlist: []string{trusted}, body: credential(map[string]interface{}{"id": "did:example:other", "name": trusted}), wantStatus: http.StatusForbidden},
+		{name: "object issuer without an id", allowlist: []string{trusted}, body: credential(map[string]interface{}{"name": trusted}), wantStatus: http.StatusForbidden},
+		{name: "empty allowlist allows all", body: credential("did:example:other"), wantStatus: http.StatusCreated},
+		{name: "MANUAL is not checked", allowlist: []string{trusted}, body: `{"source_type":"MANUAL","payload":{"issuer":"did:example:other"}}`, wantStatus: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewIngestHandler(&fakeEventRepo{}, &fakePublisher{}, nil, discardLogger(), WithIssuerAllowlist(tt.allowlist))
+			w := ingest(h, "alice", tt.body, nil)
+			h.Wait()
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus != http.StatusForbidden {
+				return
+			}
+			var resp struct {
+				Error string `json:"error"`
+				Field string `json:"field"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Error != "issuer_not_allowed" || resp.Field != "issuer" {
+				t.Errorf("error = %q on %q, want issuer_not_allowed on issuer", resp.Error, resp.Field)
+			}
+		})
+	}
+}
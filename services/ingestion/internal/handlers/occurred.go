@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// checkOccurredAtAllowed rejects a client-supplied occurred_at unless the
+// caller is an admin. Backdating is meant for migrating historical data, not
+// for users to rewrite when their credentials arrived.
+func checkOccurredAtAllowed(c *gin.Context, req *models.IngestionRequest) *ingestError {
+	if req.OccurredAt == nil || c.GetBool(middleware.ContextKeyIsAdmin) {
+		return nil
+	}
+	return &ingestError{
+		status:  http.StatusForbidden,
+		code:    "occurred_at_not_allowed",
+		message: "Only admin callers may set occurred_at",
+		field:   "occurred_at",
+	}
+}
+
+// checkOccurredAt rejects an occurred_at further in the future than the
+// clock-skew tolerance allows.
+func (h *IngestHandler) checkOccurredAt(req *models.IngestionRequest) *ingestError {
+	if req.OccurredAt == nil || !h.clock.InFuture(*req.OccurredAt) {
+		return nil
+	}
+	return &ingestError{
+		status:  http.StatusUnprocessableEntity,
+		code:    "invalid_occurred_at",
+		message: "occurred_at must not be in the future",
+		field:   "occurred_at",
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+func TestHandleIngestOccurredAt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	original := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	body := func(occurredAt string) string {
+		if occurredAt == "" {
+			return `{"source_type":"MANUAL","payload":{"note":"hello"}}`
+		}
+		return `{"source_type":"MANUAL","payload":{"note":"hello"},"occurred_at":"` + occurredAt + `"}`
+	}
+
+	tests := []struct {
+		name         string
+		admin        bool
+		body         string
+		wantStatus   int
+		wantError    string
+		wantOccurred time.Time
+	}{
+		{name: "ingestion time by default", body: body(""), wantStatus: http.StatusCreated},
+		{name: "admin backfill", admin: true, body: body(original.Format(time.RFC3339)), wantStatus: http.StatusCreated, wantOccurred: original},
+		{
+			name:         "admin backfill in another zone",
+			admin:        true,
+			body:         body(original.In(time.FixedZone("CEST", 2*60*60)).Format(time.RFC3339)),
+			wantStatus:   http.StatusCreated,
+			wantOccurred: original,
+		},
+		{name: "set by a user", body: body(original.Format(time.RFC3339)), wantStatus: http.StatusForbidden, wantError: "occurred_at_not_allowed"},
+		{
+			name:       "in the future",
+			admin:      true,
+			body:       body(time.Now().Add(time.Hour).UTC().Format(time.RFC3339)),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantError:  "invalid_occurred_at",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeEventRepo{}
+			h := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger())
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set(middleware.ContextKeyUserID, "alice")
+				c.Set(middleware.ContextKeyIsAdmin, tt.admin)
+			})
+			r.POST("/ingest", h.HandleIngest)
+
+			req := httptest.NewRequest(http.MethodPost, "/ingest", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			h.Wait()
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantError != "" {
+				if !jsonHasError(w.Body.Bytes(), tt.wantError) {
+					t.Errorf("body = %s, want error %q", w.Body, tt.wantError)
+				}
+				if len(repo.events) != 0 {
+					t.Errorf("stored %d events, want none", len(repo.events))
+				}
+				return
+			}
+			var resp models.IngestionResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			stored := repo.events[resp.EventID]
+			if stored == nil {
+				t.Fatalf("event %s was not stored", resp.EventID)
+			}
+			want := tt.wantOccurred
+			if want.IsZero() {
+				want = stored.CreatedAt
+			}
+			if !stored.OccurredAt.Equal(want) || stored.OccurredAt.Location() != time.UTC {
+				t.Errorf("stored occurred_at = %v, want %v in UTC", stored.OccurredAt, want)
+			}
+			if time.Since(stored.CreatedAt) > time.Minute {
+				t.Errorf("created_at = %v, want the ingestion time", stored.CreatedAt)
+			}
+		})
+	}
+}
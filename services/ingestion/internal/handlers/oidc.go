@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/oidc"
+)
+
+// checkIDToken validates the ID token in an OIDC payload's id_token field
+// and returns its verified claims as the payload to store and publish.
+func (h *IngestHandler) checkIDToken(ctx context.Context, payload map[string]interface{}) (map[string]interface{}, *ingestError) {
+	idToken, _ := payload["id_token"].(string)
+	if idToken == "" {
+		return nil, &ingestError{status: http.StatusUnprocessableEntity, code: "invalid_token", message: "OIDC payload must carry an id_token", field: "payload.id_token"}
+	}
+
+	claims, err := h.idTokens.Validate(ctx, idToken)
+	if errors.Is(err, oidc.ErrKeysUnavailable) {
+		h.logger.Warn("Failed to fetch OIDC issuer keys", "error", err)
+		return nil, &ingestError{status: http.StatusServiceUnavailable, code: "oidc_keys_unavailable", message: "The token issuer's signing keys could not be fetched, retry later"}
+	}
+	if err != nil {
+		return nil, &ingestError{status: http.StatusUnprocessableEntity, code: "invalid_id_token", message: err.Error(), field: "payload.id_token"}
+	}
+
+	data, err := json.Marshal(claims)
+	if err != nil {
+		h.logger.Error("Failed to marshal OIDC claims", "error", err)
+		return nil, &ingestError{status: http.StatusInternalServerError, code: "internal_error", message: "Failed to process payload"}
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		h.logger.Error("Failed to decode OIDC claims", "error", err)
+		return nil, &ingestError{status: http.StatusInternalServerError, code: "internal_error", message: "Failed to process payload"}
+	}
+	return normalized, nil
+}
+
+// parseOIDCClaims reads the claims of an OIDC payload for the oidc_claims
+// table. It returns nil when they cannot be parsed or lack iss or sub, as
+// for a payload carrying only an unvalidated id_token; such events are
+// stored without claims.
+func parseOIDCClaims(payload map[string]interface{}) *models.OIDCClaims {
+	var claims models.OIDCClaims
+	if err := decodePayload(payload, &claims); err != nil {
+		return nil
+	}
+	if claims.Issuer == "" || claims.Subject == "" {
+		return nil
+	}
+	return &claims
+}
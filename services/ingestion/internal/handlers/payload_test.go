@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandleIngestPayloadLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const limit = 1024
+
+	tests := []struct {
+		name        string
+		maxBytes    int
+		perIssuer   map[string]int
+		issuer      string
+		payloadSize int
+		wantStatus  int
+	}{
+		{name: "just under the limit", maxBytes: limit, payloadSize: limit - 1, wantStatus: http.StatusCreated},
+		{name: "at the limit", maxBytes: limit, payloadSize: limit, wantStatus: http.StatusCreated},
+		{name: "just over the limit", maxBytes: limit, payloadSize: limit + 1, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "issuer limit over the default", maxBytes: limit, perIssuer: map[string]int{"did:web:big.example": 2 * limit}, issuer: "did:web:big.example", payloadSize: limit + 1, wantStatus: http.StatusCreated},
+		{name: "just over the issuer limit", maxBytes: limit, perIssuer: map[string]int{"did:web:big.example": 2 * limit}, issuer: "did:web:big.example", payloadSize: 2*limit + 1, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "unlimited", payloadSize: 100 * limit, wantStatus: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewIngestHandler(&fakeEventRepo{}, &fakePublisher{}, nil, discardLogger(),
+				WithPayloadLimits(tt.maxBytes, tt.perIssuer))
+
+			// Pad the payload's note to marshal as payloadSize bytes
+			payload := map[string]interface{}{"note": ""}
+			if tt.issuer != "" {
+				payload["issuer"] = tt.issuer
+			}
+			empty, _ := json.Marshal(payload)
+			payload["note"] = strings.Repeat("x", tt.payloadSize-len(empty))
+			raw, _ := json.Marshal(payload)
+			body := fmt.Sprintf(`{"source_type":"MANUAL","payload":%s}`, raw)
+			w := ingest(h, "alice", body, nil)
+			h.Wait()
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code == http.StatusRequestEntityTooLarge && !jsonHasError(w.Body.Bytes(), "payload_too_large") {
+				t.Errorf("body = %s, want payload_too_large", w.Body)
+			}
+		})
+	}
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxBytes  int
+		perIssuer map[string]int
+		want      int64
+	}{
+		{name: "default limit", maxBytes: 1024, want: 1024 + requestEnvelopeBytes},
+		{name: "largest issuer limit", maxBytes: 1024, perIssuer: map[string]int{"a": 512, "b": 4096}, want: 4096 + requestEnvelopeBytes},
+		{name: "unlimited", want: 0},
+		{name: "unlimited issuer", maxBytes: 1024, perIssuer: map[string]int{"a": 0}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequestBodyLimit(tt.maxBytes, tt.perIssuer); got != tt.want {
+				t.Errorf("RequestBodyLimit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+var presetNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// PresetHandler manages the current tenant's ingestion presets.
+type PresetHandler struct {
+	repo   repository.PresetRepository
+	logger *slog.Logger
+}
+
+// NewPresetHandler creates a preset handler.
+func NewPresetHandler(repo repository.PresetRepository, logger *slog.Logger) *PresetHandler {
+	return &PresetHandler{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// HandleListPresets lists the current tenant's presets.
+// GET /api/v1/presets
+func (h *PresetHandler) HandleListPresets(c *gin.Context) {
+	tenantID := middleware.TenantID(c)
+
+	presets, err := h.repo.ListPresets(c.Request.Context(), tenantID)
+	if err != nil {
+		h.logger.Error("Failed to list presets", "error", err, "tenant_id", tenantID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to retrieve presets",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"presets": presets,
+		"count":   len(presets),
+	})
+}
+
+// HandleGetPreset returns one of the current tenant's presets.
+// GET /api/v1/presets/:name
+func (h *PresetHandler) HandleGetPreset(c *gin.Context) {
+	tenantID := middleware.TenantID(c)
+
+	preset, err := h.repo.GetPreset(c.Request.Context(), tenantID, c.Param("name"))
+	if err != nil {
+		h.logger.Error("Failed to get preset", "error", err, "tenant_id", tenantID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to retrieve preset",
+		})
+		return
+	}
+	if preset == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Preset not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, preset)
+}
+
+// HandlePutPreset creates or replaces a preset for the current tenant.
+// PUT /api/v1/presets/:name
+func (h *PresetHandler) HandlePutPreset(c *gin.Context) {
+	name := c.Param("name")
+	if !presetNamePattern.MatchString(name) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "Preset names are 1-64 letters, digits, '-' or '_'",
+		})
+		return
+	}
+
+	var req models.IngestionPresetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	preset := &models.IngestionPreset{
+		TenantID:   middleware.TenantID(c),
+		Name:       name,
+		SourceType: req.SourceType,
+		Tags:       req.Tags,
+		Metadata:   req.Metadata,
+	}
+	if preset.Tags == nil {
+		preset.Tags = []string{}
+	}
+	if preset.Metadata == nil {
+		preset.Metadata = map[string]interface{}{}
+	}
+
+	if err := h.repo.UpsertPreset(c.Request.Context(), preset); err != nil {
+		h.logger.Error("Failed to save preset", "error", err, "tenant_id", preset.TenantID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to save preset",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, preset)
+}
+
+// HandleDeletePreset removes one of the current tenant's presets.
+// DELETE /api/v1/presets/:name
+func (h *PresetHandler) HandleDeletePreset(c *gin.Context) {
+	tenantID := middleware.TenantID(c)
+
+	found, err := h.repo.DeletePreset(c.Request.Context(), tenantID, c.Param("name"))
+	if err != nil {
+		h.logger.Error("Failed to delete preset", "error", err, "tenant_id", tenantID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to delete preset",
+		})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Preset not found",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// checkProvenance verifies the derivation chain of a credential derived from
+// parentID: every ancestor up to the root must still exist, belong to the
+// ingesting user, and hold a credential that is neither revoked, suspended,
+// invalid nor expired. Chains longer than the configured depth are rejected.
+func (h *IngestHandler) checkProvenance(ctx context.Context, userID, parentID string) *ingestError {
+	if h.provenance == nil || parentID == "" {
+		return nil
+	}
+
+	chain, err := h.provenance.GetProvenanceChain(ctx, parentID, h.provenanceMaxDepth)
+	if err != nil {
+		h.logger.Error("Failed to load provenance chain", "error", err, "parent_event_id", parentID)
+		return &ingestError{status: http.StatusInternalServerError, code: "internal_error", message: "Failed to verify provenance"}
+	}
+	if len(chain) == 0 {
+		return provenanceError(http.StatusUnprocessableEntity, "provenance_broken", "Parent event "+parentID+" does not exist")
+	}
+
+	for _, link := range chain {
+		switch {
+		case link.DeletedAt != nil:
+			return provenanceError(http.StatusUnprocessableEntity, "provenance_broken", "Ancestor event "+link.EventID+" has been deleted")
+		case link.UserID != userID:
+			return provenanceError(http.StatusForbidden, "provenance_unauthorized", "Ancestor event "+link.EventID+" belongs to another user")
+		case revokedStatuses[link.VerificationStatus]:
+			return provenanceError(http.StatusUnprocessableEntity, "provenance_revoked", "Ancestor event "+link.EventID+" is "+link.VerificationStatus)
+		case link.ExpiresAt != nil && h.clock.Expired(*link.ExpiresAt):
+			return provenanceError(http.StatusUnprocessableEntity, "provenance_revoked", "Ancestor event "+link.EventID+" has expired")
+		}
+	}
+
+	last := chain[len(chain)-1]
+	if last.ParentEventID == nil {
+		return nil
+	}
+	if len(chain) >= h.provenanceMaxDepth {
+		return provenanceError(http.StatusUnprocessableEntity, "provenance_too_deep", fmt.Sprintf("Provenance chain is longer than %d events", h.provenanceMaxDepth))
+	}
+	return provenanceError(http.StatusUnprocessableEntity, "provenance_broken", "Ancestor event "+*last.ParentEventID+" does not exist")
+}
+
+// revokedStatuses are the verification statuses an ancestor may not have.
+var revokedStatuses = map[string]bool{
+	models.VerificationStatusRevoked:     true,
+	models.VerificationStatusSuspended:   true,
+	models.VerificationStatusExpired:     true,
+	models.VerificationStatusNotYetValid: true,
+	models.VerificationStatusInvalid:     true,
+}
+
+func provenanceError(status int, code, message string) *ingestError {
+	return &ingestError{status: status, code: code, message: message, field: "parent_event_id"}
+}
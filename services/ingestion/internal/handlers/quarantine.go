@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/uigs/ingestion/internal/extract"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// extractionError converts a field extraction failure to a 422.
+func extractionError(err error) *ingestError {
+	ierr := &ingestError{
+		status:  http.StatusUnprocessableEntity,
+		code:    "extraction_failed",
+		message: "Failed to extract normalized fields: " + err.Error(),
+	}
+	var ferr *extract.FieldError
+	if errors.As(err, &ferr) {
+		ierr.field = ferr.Field
+	}
+	return ierr
+}
+
+// publishedPayload returns the stored payload in the form that is
+// published: the normalized payload if there is one, else the original.
+func publishedPayload(event *models.IngestionEvent) []byte {
+	if len(event.NormalizedPayload) > 0 {
+		return event.NormalizedPayload
+	}
+	return event.RawPayload
+}
+
+// quarantineEvent holds a prepared event for review.
+func (h *IngestHandler) quarantineEvent(ctx context.Context, event *models.IngestionEvent, ierr *ingestError) (*models.QuarantinedEvent, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	q := &models.QuarantinedEvent{
+		QuarantineID: uuid.New().String(),
+		EventID:      event.EventID,
+		UserID:       event.UserID,
+		SourceType:   event.SourceType,
+		Event:        body,
+		Error:        ierr.message,
+		Field:        ierr.field,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := h.quarantine.QuarantineEvent(ctx, q); err != nil {
+		return nil, err
+	}
+
+	h.logger.Warn("Event quarantined after extraction failure",
+		"quarantine_id", q.QuarantineID,
+		"event_id", event.EventID,
+		"field", ierr.field,
+	)
+	return q, nil
+}
+
+// respondQuarantined quarantines the event and acknowledges receipt with 202.
+func (h *IngestHandler) respondQuarantined(c *gin.Context, event *models.IngestionEvent, ierr *ingestError) {
+	q, err := h.quarantineEvent(c.Request.Context(), event, ierr)
+	if err != nil {
+		h.logger.Error("Failed to quarantine event",
+			"error", err,
+			"event_id", event.EventID,
+			"payload", string(h.redactor.JSON(event.RawPayload)),
+		)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "storage_error",
+			"message": "Failed to store event",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.IngestionResponse{
+		EventID:      event.EventID,
+		Status:       "quarantined",
+		Message:      "Credential received and held for review: " + ierr.message,
+		CreatedAt:    q.CreatedAt,
+		QuarantineID: q.QuarantineID,
+	})
+}
+
+// HandleListQuarantine lists quarantined events, pending ones by default or
+// released ones with ?status=reprocessed.
+// GET /api/v1/admin/quarantine
+func (h *IngestHandler) HandleListQuarantine(c *gin.Context) {
+	reprocessed := c.Query("status") == "reprocessed"
+
+	events, err := h.quarantine.ListQuarantined(c.Request.Context(), reprocessed, 100)
+	if err != nil {
+		h.logger.Error("Failed to list quarantined events", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to retrieve quarantined events",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"count":  len(events),
+	})
+}
+
+// HandleReprocessQuarantine extracts a quarantined event's fields again,
+// typically after an extraction fix has been deployed, and on success stores
+// and publishes the event.
+// POST /api/v1/admin/quarantine/:id/reprocess
+func (h *IngestHandler) HandleReprocessQuarantine(c *gin.Context) {
+	ctx := c.Request.Context()
+	quarantineID := c.Param("id")
+
+	q, err := h.quarantine.GetQuarantined(ctx, quarantineID)
+	if err != nil {
+		h.logger.Error("Failed to get quarantined event", "error", err, "quarantine_id", quarantineID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to retrieve quarantined event",
+		})
+		return
+	}
+	if q == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Quarantined event not found",
+		})
+		return
+	}
+	if q.ReprocessedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":   "already_reprocessed",
+			"message": "Quarantined event was already reprocessed",
+		})
+		return
+	}
+
+	var event models.IngestionEvent
+	var payload map[string]interface{}
+	err = json.Unmarshal(q.Event, &event)
+	if err == nil {
+		err = json.Unmarshal(publishedPayload(&event), &payload)
+	}
+	if err != nil {
+		h.logger.Error("Failed to decode quarantined event", "error", err, "quarantine_id", quarantineID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to decode quarantined event",
+		})
+		return
+	}
+
+	dates, err := extract.ParseDates(event.SourceType, payload)
+	if err != nil {
+		ierr := extractionError(err)
+		if rerr := h.quarantine.RecordQuarantineAttempt(ctx, quarantineID, ierr.message, ierr.field); rerr != nil {
+			h.logger.Error("Failed to record quarantine attempt", "error", rerr, "quarantine_id", quarantineID)
+		}
+		ierr.respond(c)
+		return
+	}
+
+	// The event enters the log now, so stream subscribers past its original
+	// receipt time still see it
+	event.Dates = dates
+	event.CreatedAt = time.Now().UTC()
+	if event.SourceType == models.SourceTypeOIDC {
+		event.OIDCClaims = parseOIDCClaims(payload)
+	}
+	if err := h.quarantine.ReleaseQuarantined(ctx, quarantineID, &event); err != nil {
+		h.logger.Error("Failed to release quarantined event", "error", err, "quarantine_id", quarantineID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "storage_error",
+			"message": "Failed to store event",
+		})
+		return
+	}
+
+	queued, queueReason := h.publishEvent(ctx, &event, payload)
+	h.logger.Info("Quarantined event reprocessed", "quarantine_id", quarantineID, "event_id", event.EventID)
+
+	c.JSON(http.StatusCreated, models.IngestionResponse{
+		EventID:      event.EventID,
+		Status:       "accepted",
+		Message:      "Quarantined credential reprocessed",
+		Queued:       queued,
+		QueueReason:  queueReason,
+		CreatedAt:    event.CreatedAt,
+		QuarantineID: quarantineID,
+	})
+}
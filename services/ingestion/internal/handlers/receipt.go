@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/receipt"
+)
+
+// signReceipt adds a signed receipt for event to resp when receipts are
+// enabled. A receipt that cannot be signed is logged and left out; the
+// event is stored either way.
+func (h *IngestHandler) signReceipt(ctx context.Context, resp *models.IngestionResponse, event *models.IngestionEvent) {
+	if h.receipts == nil {
+		return
+	}
+	r, err := h.receipts.Sign(event)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "Failed to sign receipt", "error", err, "event_id", event.EventID)
+		return
+	}
+	resp.Receipt = &r
+}
+
+// ReceiptHandler publishes the key ingestion receipts are signed with.
+type ReceiptHandler struct {
+	signer *receipt.Signer
+}
+
+// NewReceiptHandler creates a handler for signer's public key.
+func NewReceiptHandler(signer *receipt.Signer) *ReceiptHandler {
+	return &ReceiptHandler{signer: signer}
+}
+
+// HandleGetKey returns the public key receipts are verified with.
+// GET /api/v1/receipt-key
+func (h *ReceiptHandler) HandleGetKey(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"key_id":     h.signer.KeyID(),
+		"algorithm":  receipt.Algorithm,
+		"public_key": base64.StdEncoding.EncodeToString(h.signer.PublicKey()),
+	})
+}
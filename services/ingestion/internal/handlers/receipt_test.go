@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/receipt"
+)
+
+func testReceiptSigner() *receipt.Signer {
+	return receipt.NewSigner(ed25519.NewKeyFromSeed([]byte(strings.Repeat("r", ed25519.SeedSize))), "receipts-test")
+}
+
+func TestHandleIngestReceipt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer := testReceiptSigner()
+
+	tests := []struct {
+		name        string
+		opts        []IngestOption
+		wantReceipt bool
+	}{
+		{name: "receipts enabled", opts: []IngestOption{WithReceipts(signer)}, wantReceipt: true},
+		{name: "receipts disabled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeEventRepo{}
+			h := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger(), tt.opts...)
+
+			w := ingest(h, "alice", `{"source_type":"MANUAL","payload":{"note":"hello"}}`, nil)
+			h.Wait()
+			if w.Code != http.StatusCreated {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+			}
+			var resp models.IngestionResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if (resp.Receipt != nil) != tt.wantReceipt {
+				t.Fatalf("receipt = %+v, want one %v", resp.Receipt, tt.wantReceipt)
+			}
+			if resp.Receipt == nil {
+				return
+			}
+			stored := repo.events[resp.EventID]
+			if stored == nil {
+				t.Fatalf("event %s was not stored", resp.EventID)
+			}
+			if err := receipt.Verify(signer.PublicKey(), stored, *resp.Receipt); err != nil {
+				t.Errorf("Verify() error = %v, want the receipt to verify against the stored event", err)
+			}
+			if resp.Receipt.KeyID != "receipts-test" {
+				t.Errorf("key_id = %q, want %q", resp.Receipt.KeyID, "receipts-test")
+			}
+		})
+	}
+}
+
+func TestHandleGetReceiptKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	signer := testReceiptSigner()
+	r := gin.New()
+	r.GET("/receipt-key", NewReceiptHandler(signer).HandleGetKey)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/receipt-key", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	var resp struct {
+		KeyID     string `json:"key_id"`
+		Algorithm string `json:"algorithm"`
+		PublicKey string `json:"public_key"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	key, err := base64.StdEncoding.DecodeString(resp.PublicKey)
+	if err != nil || !signer.PublicKey().Equal(ed25519.PublicKey(key)) {
+		t.Errorf("public_key = %s, want the signer's public key", resp.PublicKey)
+	}
+	if resp.KeyID != signer.KeyID() || resp.Algorithm != receipt.Algorithm {
+		t.Errorf("key = %s/%s, want %s/%s", resp.KeyID, resp.Algorithm, signer.KeyID(), receipt.Algorithm)
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/cursor"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/replay"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// defaultReplayLimit is the number of events republished per request when
+// the request does not set a limit.
+const defaultReplayLimit = 1000
+
+// ReplayHandler lets administrators republish stored events.
+type ReplayHandler struct {
+	replayer *replay.Replayer
+	repo     repository.EventRepository
+	logger   *slog.Logger
+}
+
+// NewReplayHandler creates a replay handler.
+func NewReplayHandler(replayer *replay.Replayer, repo repository.EventRepository, logger *slog.Logger) *ReplayHandler {
+	return &ReplayHandler{
+		replayer: replayer,
+		repo:     repo,
+		logger:   logger,
+	}
+}
+
+// HandleReplayEvent republishes one stored event to the queue, marked as a
+// replay, and waits for the broker's confirm.
+// POST /api/v1/events/:id/replay
+func (h *ReplayHandler) HandleReplayEvent(c *gin.Context) {
+	eventID := c.Param("id")
+
+	event, err := h.repo.GetEventByID(c.Request.Context(), "", eventID)
+	if errors.Is(err, repository.ErrIntegrity) {
+		h.logger.Error("Stored event failed its integrity check", "error", err, "event_id", eventID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "integrity_error",
+			"message": "Stored event does not match its checksum",
+		})
+		return
+	}
+	if errors.Is(err, repository.ErrQueryTimeout) {
+		h.logger.Error("Failed to get event", "error", err, "event_id", eventID)
+		respondStorageError(c, err, "internal_error", "Failed to get event")
+		return
+	}
+	if err != nil || event.DeletedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Event not found",
+		})
+		return
+	}
+
+	if err := h.replayer.ReplayEvent(c.Request.Context(), event); err != nil {
+		h.logger.Error("Failed to replay event", "error", err, "event_id", eventID)
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "publish_failed",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.logger.Info("Event replayed", "event_id", eventID)
+	c.JSON(http.StatusOK, gin.H{
+		"event_id":  eventID,
+		"published": true,
+	})
+}
+
+// HandleRepublishEvents republishes stored events in a created_at range to
+// the queue, optionally only one user's, each marked as a replay. Large
+// ranges are republished over several calls by passing the previous
+// response's next_cursor as after.
+// POST /api/v1/admin/events/republish
+func (h *ReplayHandler) HandleRepublishEvents(c *gin.Context) {
+	var req models.ReplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	if !req.To.After(req.From) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "to must be after from",
+		})
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultReplayLimit
+	}
+
+	filter := models.ReplayFilter{
+		From:       req.From,
+		To:         req.To,
+		SourceType: req.SourceType,
+		UserID:     req.UserID,
+	}
+	result, err := h.replayer.Run(c.Request.Context(), filter, req.After, req.Mode, req.Limit)
+	if err != nil {
+		if errors.Is(err, cursor.ErrInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_cursor",
+				"message": err.Error(),
+			})
+			return
+		}
+		h.logger.Error("Failed to republish events", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to republish events",
+		})
+		return
+	}
+
+	status := http.StatusOK
+	if len(result.Failures) > 0 {
+		status = http.StatusBadGateway
+	}
+	c.JSON(status, result)
+}
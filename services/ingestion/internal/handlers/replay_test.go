@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/cursor"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/replay"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// fakeReplayRepo lists stored events matching a replay filter from memory.
+type fakeReplayRepo struct {
+	events []models.IngestionEvent
+}
+
+func (r *fakeReplayRepo) ListEventsForReplay(_ context.Context, filter models.ReplayFilter, after cursor.Cursor, limit int) ([]models.IngestionEvent, error) {
+	var out []models.IngestionEvent
+	for _, e := range r.events {
+		switch {
+		case e.CreatedAt.Before(filter.From) || !e.CreatedAt.Before(filter.To):
+		case filter.SourceType != "" && e.SourceType != filter.SourceType:
+		case filter.UserID != "" && e.UserID != filter.UserID:
+		case e.CreatedAt.Before(after.CreatedAt) || (e.CreatedAt.Equal(after.CreatedAt) && e.EventID <= after.EventID):
+		default:
+			out = append(out, e)
+		}
+	}
+	slices.SortFunc(out, func(a, b models.IngestionEvent) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.EventID, b.EventID)
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// newReplayHandler returns a replay handler over events, publishing to pub.
+func newReplayHandler(t *testing.T, events []models.IngestionEvent, repo repository.EventRepository, pub *fakePublisher) *ReplayHandler {
+	t.Helper()
+	replayer, err := replay.New(&fakeReplayRepo{events: events}, pub, replay.Config{Mode: replay.ModeOrdered, BatchSize: 2}, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewReplayHandler(replayer, repo, discardLogger())
+}
+
+func TestHandleReplayEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deletedAt := time.Now()
+	stored := func() map[string]*models.IngestionEvent {
+		return map[string]*models.IngestionEvent{
+			"evt-1":   {EventID: "evt-1", UserID: "alice", SourceType: models.SourceTypeVC, RawPayload: []byte(`{"issuer":"did:example:1"}`)},
+			"deleted": {EventID: "deleted", UserID: "alice", RawPayload: []byte(`{}`), DeletedAt: &deletedAt},
+			"corrupt": {EventID: "corrupt", UserID: "alice", RawPayload: []byte(`{"a":1}`), Checksum: strings.Repeat("0", 64)},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		eventID    string
+		publishErr error
+		wantStatus int
+		wantError  string
+	}{
+		{name: "republished as a replay", eventID: "evt-1", wantStatus: http.StatusOK},
+		{name: "unknown event", eventID: "missing", wantStatus: http.StatusNotFound, wantError: "not_found"},
+		{name: "deleted event", eventID: "deleted", wantStatus: http.StatusNotFound, wantError: "not_found"},
+		{name: "payload fails its checksum", eventID: "corrupt", wantStatus: http.StatusInternalServerError, wantError: "integrity_error"},
+		{name: "publish fails", eventID: "evt-1", publishErr: errors.New("nacked"), wantStatus: http.StatusBadGateway, wantError: "publish_failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := &fakePublisher{err: tt.publishErr}
+			h := newReplayHandler(t, nil, &fakeEventRepo{events: stored()}, pub)
+			r := gin.New()
+			r.POST("/events/:id/replay", h.HandleReplayEvent)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/events/"+tt.eventID+"/replay", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantError != "" {
+				if !jsonHasError(w.Body.Bytes(), tt.wantError) {
+					t.Errorf("body = %s, want error %q", w.Body, tt.wantError)
+				}
+				return
+			}
+			if len(pub.confirmed) != 1 {
+				t.Fatalf("published %d messages, want 1", len(pub.confirmed))
+			}
+			msg := pub.confirmed[0]
+			if msg.EventID != tt.eventID || !msg.Replay || msg.Payload["issuer"] != "did:example:1" {
+				t.Errorf("published %+v, want a replay of %s with its payload", msg, tt.eventID)
+			}
+		})
+	}
+}
+
+func TestHandleRepublishEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const alice, bob = "6f1c8a52-3d4e-4b7a-9c1d-2e3f4a5b6c7d", "0b5e2f1a-7c3d-4e9f-8a6b-1c2d3e4f5a6b"
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	event := func(id, userID string, sourceType models.SourceType, hours int) models.IngestionEvent {
+		return models.IngestionEvent{
+			EventID: id, UserID: userID, SourceType: sourceType,
+			RawPayload: []byte(`{}`), CreatedAt: start.Add(time.Duration(hours) * time.Hour),
+		}
+	}
+	events := []models.IngestionEvent{
+		event("a1", alice, models.SourceTypeVC, 1),
+		event("a2", alice, models.SourceTypeOIDC, 2),
+		event("a3", alice, models.SourceTypeVC, 3),
+		event("b1", bob, models.SourceTypeVC, 2),
+		event("a-late", alice, models.SourceTypeVC, 48),
+	}
+	window := func(extra string) string {
+		return fmt.Sprintf(`{"from":%q,"to":%q%s}`, start.Format(time.RFC3339), start.Add(24*time.Hour).Format(time.RFC3339), extra)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantError  string
+		wantEvents []string
+		wantDone   bool
+	}{
+		{name: "time range", body: window(""), wantStatus: http.StatusOK, wantEvents: []string{"a1", "a2", "b1", "a3"}, wantDone: true},
+		{name: "one user", body: window(`,"user_id":"` + alice + `"`), wantStatus: http.StatusOK, wantEvents: []string{"a1", "a2", "a3"}, wantDone: true},
+		{name: "one user and source type", body: window(`,"user_id":"` + alice + `","source_type":"VC"`), wantStatus: http.StatusOK, wantEvents: []string{"a1", "a3"}, wantDone: true},
+		{name: "limited", body: window(`,"user_id":"` + alice + `","limit":2`), wantStatus: http.StatusOK, wantEvents: []string{"a1", "a2"}},
+		{name: "nothing in range", body: window(`,"user_id":"` + bob + `","source_type":"MANUAL"`), wantStatus: http.StatusOK, wantDone: true},
+		{name: "to before from", body: fmt.Sprintf(`{"from":%q,"to":%q}`, start.Format(time.RFC3339), start.Add(-time.Hour).Format(time.RFC3339)), wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+		{name: "user_id not a UUID", body: window(`,"user_id":"alice"`), wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+		{name: "bad cursor", body: window(`,"after":"nope"`), wantStatus: http.StatusBadRequest, wantError: "invalid_cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub := &fakePublisher{}
+			h := newReplayHandler(t, events, nil, pub)
+			r := gin.New()
+			r.POST("/admin/events/republish", h.HandleRepublishEvents)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/events/republish", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantError != "" {
+				if !jsonHasError(w.Body.Bytes(), tt.wantError) {
+					t.Errorf("body = %s, want error %q", w.Body, tt.wantError)
+				}
+				return
+			}
+			var resp models.ReplayResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, msg := range pub.confirmed {
+				if !msg.Replay {
+					t.Errorf("event %s was republished without the replay mark", msg.EventID)
+				}
+				got = append(got, msg.EventID)
+			}
+			if !slices.Equal(got, tt.wantEvents) {
+				t.Errorf("republished %v, want %v", got, tt.wantEvents)
+			}
+			if resp.Published != len(tt.wantEvents) || resp.Done != tt.wantDone {
+				t.Errorf("published = %d, done = %v; want %d, %v", resp.Published, resp.Done, len(tt.wantEvents), tt.wantDone)
+			}
+			if !tt.wantDone && resp.NextCursor == "" {
+				t.Error("next_cursor is empty for an unfinished run")
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/forward"
+	"github.com/uigs/ingestion/internal/middleware"
+)
+
+// ResidencyHandler routes a tenant's event requests to the ingest handler
+// backed by the database of the tenant's designated region. Tenants whose
+// region has no database on this node are forwarded to that region's
+// ingestion endpoint, or rejected when none is configured.
+type ResidencyHandler struct {
+	localRegion string
+	tenants     map[string]string
+	regions     map[string]*IngestHandler
+	forwarders  map[string]*forward.Forwarder
+	logger      *slog.Logger
+}
+
+// NewResidencyHandler creates a residency router. local serves the node's own
+// region and tenants without a designated region.
+func NewResidencyHandler(localRegion string, local *IngestHandler, tenantRegions map[string]string, logger *slog.Logger) *ResidencyHandler {
+	return &ResidencyHandler{
+		localRegion: localRegion,
+		tenants:     tenantRegions,
+		regions:     map[string]*IngestHandler{localRegion: local},
+		forwarders:  make(map[string]*forward.Forwarder),
+		logger:      logger,
+	}
+}
+
+// AddRegion serves region from this node with h, whose repository is the
+// region's database.
+func (h *ResidencyHandler) AddRegion(region string, handler *IngestHandler) {
+	h.regions[region] = handler
+}
+
+// AddForwarder relays requests for region's tenants to f.
+func (h *ResidencyHandler) AddForwarder(region string, f *forward.Forwarder) {
+	h.forwarders[region] = f
+}
+
+// Wait waits for the background publishes of every region's handler.
+func (h *ResidencyHandler) Wait() {
+	for _, handler := range h.regions {
+		handler.Wait()
+	}
+}
+
+// Route returns a gin handler running serve on the ingest handler of the
+// current tenant's region.
+func (h *ResidencyHandler) Route(serve func(*IngestHandler, *gin.Context)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		region := h.localRegion
+		if r, ok := h.tenants[middleware.TenantID(c)]; ok {
+			region = r
+		}
+
+		if handler, ok := h.regions[region]; ok {
+			serve(handler, c)
+			return
+		}
+		if f, ok := h.forwarders[region]; ok {
+			forwardWrite(c, f, h.logger)
+			return
+		}
+
+		h.logger.Warn("Refusing request outside the tenant's data residency region",
+			"tenant_id", middleware.TenantID(c),
+			"tenant_region", region,
+			"region", h.localRegion,
+		)
+		c.JSON(http.StatusMisdirectedRequest, gin.H{
+			"error":   "residency_violation",
+			"message": "Tenant data must be stored in region " + region + ", which this node does not serve",
+		})
+	}
+}
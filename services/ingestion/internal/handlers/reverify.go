@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/webhook"
+)
+
+// Checks that can change a stored credential's verification status.
+const (
+	checkValidity         = "validity"
+	checkCredentialStatus = "credential_status"
+)
+
+// HandleReverifyEvent re-runs the credential checks on a stored VC event and
+// records the new verification status. When the status changes and the
+// owner has opted in, a verification.status_changed webhook is sent.
+// POST /api/v1/admin/events/:id/reverify
+func (h *IngestHandler) HandleReverifyEvent(c *gin.Context) {
+	eventID := c.Param("id")
+	ctx := c.Request.Context()
+
+	event, err := h.repo.GetEventByID(ctx, "", eventID)
+	if err != nil {
+		h.logger.Error("Failed to get event", "error", err, "event_id", eventID)
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Event not found",
+		})
+		return
+	}
+	if event.SourceType != models.SourceTypeVC {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "not_verifiable",
+			"message": "Only VC events can be re-verified",
+		})
+		return
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(event.RawPayload, &payload); err != nil {
+		h.logger.Error("Failed to decode stored payload", "error", err, "event_id", eventID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to decode stored payload",
+		})
+		return
+	}
+
+	status, check, ierr := h.reverify(ctx, payload)
+	if ierr != nil {
+		ierr.respond(c)
+		return
+	}
+
+	now := time.Now().UTC()
+	if err := h.repo.UpdateVerificationStatus(ctx, eventID, status, now); err != nil {
+		h.logger.Error("Failed to update verification status", "error", err, "event_id", eventID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to record verification status",
+		})
+		return
+	}
+
+	changed := status != event.VerificationStatus
+	if changed {
+		h.logger.Info("Verification status changed",
+			"event_id", eventID,
+			"old_status", event.VerificationStatus,
+			"new_status", status,
+			"check", check,
+		)
+		h.notifyVerificationChange(ctx, models.VerificationChange{
+			EventID:   eventID,
+			UserID:    event.UserID,
+			Check:     check,
+			OldStatus: event.VerificationStatus,
+			NewStatus: status,
+			ChangedAt: now,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"event_id":            eventID,
+		"previous_status":     event.VerificationStatus,
+		"verification_status": status,
+		"check":               check,
+		"changed":             changed,
+		"verified_at":         now,
+	})
+}
+
+// reverify runs the validity and status checks on each stored credential and
+// returns the resulting verification status and the check that determined
+// it. Transient failures, such as an unreachable status list, are returned
+// as errors so the stored status is left unchanged.
+func (h *IngestHandler) reverify(ctx context.Context, payload map[string]interface{}) (string, string, *ingestError) {
+	creds, ierr := credentialsIn(payload)
+	if ierr != nil {
+		return models.VerificationStatusInvalid, ierr.code, nil
+	}
+	for _, raw := range creds {
+		var vc models.VerifiableCredential
+		if err := decodePayload(raw, &vc); err != nil {
+			return models.VerificationStatusInvalid, "invalid_credential", nil
+		}
+
+		if ierr := h.checkValidity(&vc); ierr != nil {
+			switch ierr.code {
+			case "credential_expired":
+				return models.VerificationStatusExpired, checkValidity, nil
+			case "credential_not_yet_valid":
+				return models.VerificationStatusNotYetValid, checkValidity, nil
+			default:
+				return models.VerificationStatusInvalid, checkValidity, nil
+			}
+		}
+
+		if ierr := h.checkStatus(ctx, &vc); ierr != nil {
+			switch {
+			case ierr.status >= http.StatusInternalServerError:
+				return "", "", ierr
+			case ierr.code == "credential_revoked":
+				return models.VerificationStatusRevoked, checkCredentialStatus, nil
+			case ierr.code == "credential_suspended":
+				return models.VerificationStatusSuspended, checkCredentialStatus, nil
+			default:
+				return models.VerificationStatusInvalid, checkCredentialStatus, nil
+			}
+		}
+	}
+	return models.VerificationStatusVerified, "", nil
+}
+
+// notifyVerificationChange queues a webhook to the event owner, if they have
+// opted in. Failures are logged and never affect the caller.
+func (h *IngestHandler) notifyVerificationChange(ctx context.Context, change models.VerificationChange) {
+	if h.webhooks == nil {
+		return
+	}
+
+	hook, err := h.webhookRepo.GetUserWebhook(ctx, change.UserID, models.WebhookEventVerificationChanged)
+	if err != nil {
+		h.logger.Error("Failed to look up verification webhook", "error", err, "user_id", change.UserID)
+		return
+	}
+	if hook == nil {
+		return
+	}
+
+	err = h.webhooks.Enqueue(webhook.Delivery{
+		URL:      hook.URL,
+		Secret:   hook.Secret,
+		Event:    models.WebhookEventVerificationChanged,
+		Payload:  change,
+		OrderKey: hook.OrderKey(),
+	})
+	if err != nil {
+		h.logger.Error("Failed to queue verification webhook", "error", err, "event_id", change.EventID)
+	}
+}
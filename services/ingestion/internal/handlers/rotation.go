@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/keyring"
+	"github.com/uigs/ingestion/internal/repository"
+	"github.com/uigs/ingestion/internal/rotation"
+)
+
+// RotationHandler lets administrators re-encrypt stored events with the
+// current encryption key and follow the progress.
+type RotationHandler struct {
+	rotator *rotation.Rotator
+	repo    repository.EncryptionRepository
+	keys    *keyring.Keyring
+	logger  *slog.Logger
+}
+
+// NewRotationHandler creates a key rotation handler.
+func NewRotationHandler(rotator *rotation.Rotator, repo repository.EncryptionRepository, keys *keyring.Keyring, logger *slog.Logger) *RotationHandler {
+	return &RotationHandler{
+		rotator: rotator,
+		repo:    repo,
+		keys:    keys,
+		logger:  logger,
+	}
+}
+
+// HandleStartRotation starts re-encrypting events with the current key.
+// POST /api/v1/admin/encryption/rotate
+func (h *RotationHandler) HandleStartRotation(c *gin.Context) {
+	status, err := h.rotator.Trigger()
+	if errors.Is(err, rotation.ErrRunning) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    "rotation_running",
+			"message":  "A key rotation is already running",
+			"rotation": status,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error":   "unavailable",
+			"message": "Key rotation is shutting down",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"rotation": status})
+}
+
+// HandleGetRotation reports the rotation status and how many events are
+// still sealed with an older key version or stored in plaintext.
+// GET /api/v1/admin/encryption/rotation
+func (h *RotationHandler) HandleGetRotation(c *gin.Context) {
+	counts, err := h.repo.CountEventsByKeyVersion(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to count events by key version", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to retrieve rotation progress",
+		})
+		return
+	}
+
+	byVersion := make(map[string]int64, len(counts))
+	var remaining int64
+	for version, n := range counts {
+		key := strconv.Itoa(version)
+		if version == 0 {
+			key = "plaintext"
+		}
+		byVersion[key] = n
+		if version != h.keys.Current() {
+			remaining += n
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"current_version":    h.keys.Current(),
+		"available_versions": h.keys.Versions(),
+		"events_by_version":  byVersion,
+		"remaining":          remaining,
+		"rotation":           h.rotator.Status(),
+	})
+}
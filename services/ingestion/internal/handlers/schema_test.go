@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/validation"
+)
+
+func TestHandleIngestSchemaValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	schemas, err := validation.Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantField  string
+	}{
+		{name: "valid MANUAL", body: `{"source_type":"MANUAL","payload":{"attributes":{"name":"Alice"}}}`, wantStatus: http.StatusCreated},
+		{name: "MANUAL without attributes", body: `{"source_type":"MANUAL","payload":{"name":"Alice"}}`, wantStatus: http.StatusUnprocessableEntity, wantField: "/attributes"},
+		{name: "OIDC without a token or claims", body: `{"source_type":"OIDC","payload":{"iss":"https://issuer.example"}}`, wantStatus: http.StatusUnprocessableEntity, wantField: "/id_token"},
+		{name: "VC without a type", body: `{"source_type":"VC","payload":{"@context":"https://www.w3.org/2018/credentials/v1","credentialSubject":{}}}`, wantStatus: http.StatusUnprocessableEntity, wantField: "/type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewIngestHandler(&fakeEventRepo{}, &fakePublisher{}, nil, discardLogger(), WithSchemas(schemas))
+			w := ingest(h, "alice", tt.body, nil)
+			h.Wait()
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantField == "" {
+				return
+			}
+			var resp struct {
+				Error string `json:"error"`
+				Field string `json:"field"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Error != "schema_validation_failed" || resp.Field != tt.wantField {
+				t.Errorf("error = %q on %q, want schema_validation_failed on %q", resp.Error, resp.Field, tt.wantField)
+			}
+		})
+	}
+}
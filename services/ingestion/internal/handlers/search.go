@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// SearchHandler lets admins search events across users.
+type SearchHandler struct {
+	repo   repository.SearchRepository
+	logger *slog.Logger
+}
+
+// NewSearchHandler creates a search handler.
+func NewSearchHandler(repo repository.SearchRepository, logger *slog.Logger) *SearchHandler {
+	return &SearchHandler{repo: repo, logger: logger}
+}
+
+// HandleSearchEvents retrieves a page of events of any user, most recently
+// stored first. It takes the query parameters of GET /api/v1/events plus
+// an optional user_id, and pages the same way.
+// GET /api/v1/admin/events
+func (h *SearchHandler) HandleSearchEvents(c *gin.Context) {
+	userID := c.Query("user_id")
+	if userID != "" {
+		if _, err := uuid.Parse(userID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_filter",
+				"message": "user_id must be a UUID",
+			})
+			return
+		}
+	}
+	filter, limit, ok := parseEventPage(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.repo.SearchEvents(c.Request.Context(), repository.AdminEventFilter{
+		UserID:      userID,
+		EventFilter: filter,
+	})
+	if err != nil {
+		h.logger.ErrorContext(c.Request.Context(), "Failed to search events", "error", err, "user_id", userID)
+		respondStorageError(c, err, "internal_error", "Failed to search events")
+		return
+	}
+
+	respondEventPage(c, events, limit)
+}
+
+// checksumPattern matches a hex-encoded SHA-256 checksum.
+var checksumPattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// HandleSearchByChecksum retrieves every event of any user whose payload
+// has the checksum in the path, for integrity audits. No match gives an
+// empty list rather than 404.
+// GET /api/v1/admin/events/by-checksum/:checksum
+func (h *SearchHandler) HandleSearchByChecksum(c *gin.Context) {
+	checksum := c.Param("checksum")
+	if !checksumPattern.MatchString(checksum) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_checksum",
+			"message": "checksum must be a SHA-256 hash of 64 hex characters",
+		})
+		return
+	}
+	checksum = strings.ToLower(checksum)
+
+	events, err := h.repo.GetEventsByChecksumGlobal(c.Request.Context(), checksum)
+	if err != nil {
+		h.logger.ErrorContext(c.Request.Context(), "Failed to search events by checksum", "error", err, "checksum", checksum)
+		respondStorageError(c, err, "internal_error", "Failed to search events")
+		return
+	}
+	if events == nil {
+		events = []models.IngestionEvent{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"count":  len(events),
+	})
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// fakeSearchRepo records the filter of the last search and returns events.
+type fakeSearchRepo struct {
+	repository.SearchRepository
+	events []models.IngestionEvent
+	err    error
+	got    *repository.AdminEventFilter
+}
+
+func (r *fakeSearchRepo) SearchEvents(_ context.Context, filter repository.AdminEventFilter) ([]models.IngestionEvent, error) {
+	r.got = &filter
+	return r.events, r.err
+}
+
+func (r *fakeSearchRepo) GetEventsByChecksumGlobal(_ context.Context, checksum string) ([]models.IngestionEvent, error) {
+	var out []models.IngestionEvent
+	for _, e := range r.events {
+		if e.Checksum == checksum {
+			out = append(out, e)
+		}
+	}
+	return out, r.err
+}
+
+func TestHandleSearchEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const userID = "6f1c8a52-3d4e-4b7a-9c1d-2e3f4a5b6c7d"
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	tests := []struct {
+		name       string
+		query      string
+		err        error
+		wantStatus int
+		wantError  string
+		wantFilter repository.AdminEventFilter
+	}{
+		{
+			name:       "every user",
+			wantStatus: http.StatusOK,
+			wantFilter: repository.AdminEventFilter{EventFilter: repository.EventFilter{Limit: defaultEventPageSize + 1, OmitPayload: true}},
+		},
+		{
+			name:       "one user",
+			query:      "user_id=" + userID,
+			wantStatus: http.StatusOK,
+			wantFilter: repository.AdminEventFilter{UserID: userID, EventFilter: repository.EventFilter{Limit: defaultEventPageSize + 1, OmitPayload: true}},
+		},
+		{
+			name:       "user, source type, window and limit",
+			query:      "user_id=" + userID + "&source_type=vc&from=" + from.Format(time.RFC3339) + "&to=" + to.Format(time.RFC3339) + "&limit=5",
+			wantStatus: http.StatusOK,
+			wantFilter: repository.AdminEventFilter{UserID: userID, EventFilter: repository.EventFilter{
+				SourceType: models.SourceTypeVC, From: &from, To: &to, Limit: 6, OmitPayload: true,
+			}},
+		},
+		{
+			name:       "source type across users with deleted events",
+			query:      "source_type=OIDC&include_deleted=true&fields=raw_payload",
+			wantStatus: http.StatusOK,
+			wantFilter: repository.AdminEventFilter{EventFilter: repository.EventFilter{
+				SourceType: models.SourceTypeOIDC, Limit: defaultEventPageSize + 1, IncludeDeleted: true,
+			}},
+		},
+		{name: "user_id not a UUID", query: "user_id=alice", wantStatus: http.StatusBadRequest, wantError: "invalid_filter"},
+		{name: "unknown source type", query: "source_type=SAML", wantStatus: http.StatusBadRequest, wantError: "invalid_filter"},
+		{name: "from after to", query: "from=" + to.Format(time.RFC3339) + "&to=" + from.Format(time.RFC3339), wantStatus: http.StatusBadRequest, wantError: "invalid_filter"},
+		{name: "limit out of range", query: "limit=0", wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+		{name: "storage failure", err: errors.New("connection refused"), wantStatus: http.StatusInternalServerError, wantError: "internal_error"},
+		{name: "query times out", err: fmt.Errorf("%w: context deadline exceeded", repository.ErrQueryTimeout), wantStatus: http.StatusGatewayTimeout, wantError: "timeout_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSearchRepo{err: tt.err}
+			h := NewSearchHandler(repo, discardLogger())
+			r := gin.New()
+			r.Use(func(c *gin.Context) { c.Set(middleware.ContextKeyIsAdmin, true) })
+			r.GET("/admin/events", h.HandleSearchEvents)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/events?"+tt.query, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantError != "" {
+				if !jsonHasError(w.Body.Bytes(), tt.wantError) {
+					t.Errorf("body = %s, want error %q", w.Body, tt.wantError)
+				}
+				return
+			}
+			if repo.got == nil {
+				t.Fatal("SearchEvents was not called")
+			}
+			if !reflect.DeepEqual(*repo.got, tt.wantFilter) {
+				t.Errorf("filter = %+v, want %+v", *repo.got, tt.wantFilter)
+			}
+		})
+	}
+}
+
+func TestHandleSearchByChecksum(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	checksum := strings.Repeat("ab", 32)
+	events := []models.IngestionEvent{
+		{EventID: "e1", UserID: "alice", Checksum: checksum},
+		{EventID: "e2", UserID: "bob", Checksum: checksum},
+		{EventID: "e3", UserID: "bob", Checksum: strings.Repeat("cd", 32)},
+	}
+
+	tests := []struct {
+		name       string
+		checksum   string
+		notAdmin   bool
+		err        error
+		wantStatus int
+		wantError  string
+		wantUsers  []string
+	}{
+		{name: "matches across users", checksum: checksum, wantStatus: http.StatusOK, wantUsers: []string{"alice", "bob"}},
+		{name: "upper case hex", checksum: strings.ToUpper(checksum), wantStatus: http.StatusOK, wantUsers: []string{"alice", "bob"}},
+		{name: "no match", checksum: strings.Repeat("0", 64), wantStatus: http.StatusOK},
+		{name: "too short", checksum: "abc", wantStatus: http.StatusBadRequest, wantError: "invalid_checksum"},
+		{name: "too long", checksum: checksum + "ab", wantStatus: http.StatusBadRequest, wantError: "invalid_checksum"},
+		{name: "not hex", checksum: strings.Repeat("z", 64), wantStatus: http.StatusBadRequest, wantError: "invalid_checksum"},
+		{name: "not an admin", checksum: checksum, notAdmin: true, wantStatus: http.StatusForbidden, wantError: "forbidden"},
+		{name: "storage error", checksum: checksum, err: errors.New("connection reset"), wantStatus: http.StatusInternalServerError, wantError: "internal_error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewSearchHandler(&fakeSearchRepo{events: events, err: tt.err}, discardLogger())
+			r := gin.New()
+			r.Use(func(c *gin.Context) { c.Set(middleware.ContextKeyIsAdmin, !tt.notAdmin) })
+			r.GET("/admin/events/by-checksum/:checksum", middleware.RequireAdmin(), h.HandleSearchByChecksum)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/events/by-checksum/"+tt.checksum, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantError != "" {
+				if !jsonHasError(w.Body.Bytes(), tt.wantError) {
+					t.Errorf("body = %s, want error %q", w.Body, tt.wantError)
+				}
+				return
+			}
+			var resp struct {
+				Events []models.IngestionEvent `json:"events"`
+				Count  int                     `json:"count"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Events == nil {
+				t.Error("events is null, want a list")
+			}
+			var users []string
+			for _, e := range resp.Events {
+				users = append(users, e.UserID)
+			}
+			if !reflect.DeepEqual(users, tt.wantUsers) || resp.Count != len(tt.wantUsers) {
+				t.Errorf("users = %v (count %d), want %v", users, resp.Count, tt.wantUsers)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// ErrRejected wraps the reason Ingest refused a request. Other errors from
+// Ingest are failures worth retrying.
+var ErrRejected = errors.New("ingestion rejected")
+
+// Ingest runs a request through the same checks and storage as HandleIngest,
+// for producers that do not come in over HTTP, and publishes the stored
+// event in the background. A request whose idempotency key already holds an
+// event returns that event. Quarantined events are returned without error.
+func (h *IngestHandler) Ingest(ctx context.Context, userID, tenantID, idempotencyKey string, req *models.IngestionRequest) (*models.IngestionEvent, error) {
+	if err := binding.Validator.ValidateStruct(req); err != nil {
+		return nil, fmt.Errorf("%w: invalid request: %v", ErrRejected, err)
+	}
+	if ierr := decodeCredentialJWT(req); ierr != nil {
+		return nil, fmt.Errorf("%w: %s: %s", ErrRejected, ierr.code, ierr.message)
+	}
+
+	var idempotencyScope string
+	if idempotencyKey != "" && h.idempotency != nil {
+		idempotencyScope = h.idempotencyScopeFor(userID, req.SourceType)
+		existing, err := h.lookupIdempotent(ctx, idempotencyScope, idempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return existing, nil
+		}
+	}
+
+	event, ierr := h.prepareEvent(ctx, userID, tenantID, req)
+	if ierr != nil && ierr.quarantine {
+		if _, err := h.quarantineEvent(ctx, event, ierr); err != nil {
+			return nil, err
+		}
+		return event, nil
+	}
+	if ierr != nil {
+		if ierr.status >= http.StatusInternalServerError {
+			return nil, ierr
+		}
+		return nil, fmt.Errorf("%w: %s: %s", ErrRejected, ierr.code, ierr.message)
+	}
+	if idempotencyScope != "" {
+		event.IdempotencyKey = idempotencyKey
+		event.IdempotencyScope = idempotencyScope
+	}
+
+	if err := h.repo.CreateEvent(ctx, event); err != nil {
+		return nil, fmt.Errorf("failed to store event: %w", err)
+	}
+	h.publishInBackground(ctx, event, req.Payload)
+	return event, nil
+}
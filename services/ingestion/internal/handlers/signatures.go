@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/proof"
+)
+
+// checkProof requires, when proof verification is on, that a credential
+// carries at least one valid proof from the key its verificationMethod names.
+func (h *IngestHandler) checkProof(ctx context.Context, raw map[string]interface{}) *ingestError {
+	if !h.requireProof {
+		return nil
+	}
+	if err := h.proofVerifier.VerifyCredential(ctx, raw); err != nil {
+		return &ingestError{
+			status:  http.StatusUnprocessableEntity,
+			code:    "invalid_proof",
+			message: "Credential proof could not be verified: " + err.Error(),
+			field:   "proof",
+		}
+	}
+	return nil
+}
+
+// checkSignatures enforces multi-issuer signature policies: a credential of
+// a policed type must carry valid proofs from at least the policy's
+// threshold of its designated issuers. A credential with several policed
+// types must satisfy each policy.
+func (h *IngestHandler) checkSignatures(ctx context.Context, raw map[string]interface{}, vc *models.VerifiableCredential) *ingestError {
+	if h.proofVerifier == nil {
+		return nil
+	}
+
+	var results []proof.Result
+	for _, credentialType := range vc.Type {
+		policy, ok := h.signaturePolicies[credentialType]
+		if !ok {
+			continue
+		}
+		if results == nil {
+			results = h.proofVerifier.Verify(ctx, raw)
+			for _, r := range results {
+				if !r.Valid {
+					h.logger.Debug("Credential proof rejected", "verification_method", r.VerificationMethod, "error", r.Error)
+				}
+			}
+		}
+
+		outcome := proof.Evaluate(credentialType, policy, results)
+		if !outcome.Satisfied() {
+			return &ingestError{
+				status: http.StatusUnprocessableEntity,
+				code:   "insufficient_signatures",
+				message: fmt.Sprintf("%s credentials require valid proofs from %d of %d designated issuers, found %d",
+					credentialType, policy.Threshold, len(policy.Issuers), len(outcome.Signers)),
+				field: "proof",
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/slo"
+)
+
+// SLOHandler reports ingestion latency SLO compliance.
+type SLOHandler struct {
+	tracker *slo.Tracker
+}
+
+// NewSLOHandler creates an SLO handler.
+func NewSLOHandler(tracker *slo.Tracker) *SLOHandler {
+	return &SLOHandler{tracker: tracker}
+}
+
+// HandleGetSLO returns the current SLO compliance with a per-stage breakdown.
+// GET /api/v1/admin/slo
+func (h *SLOHandler) HandleGetSLO(c *gin.Context) {
+	c.JSON(http.StatusOK, h.tracker.Snapshot())
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// StatsHandler summarizes a user's events.
+type StatsHandler struct {
+	repo   repository.StatsRepository
+	logger *slog.Logger
+}
+
+// NewStatsHandler creates a stats handler.
+func NewStatsHandler(repo repository.StatsRepository, logger *slog.Logger) *StatsHandler {
+	return &StatsHandler{repo: repo, logger: logger}
+}
+
+// HandleGetEventStats returns the current user's event counts by source
+// type and the earliest and latest event times.
+// GET /api/v1/events/stats
+func (h *StatsHandler) HandleGetEventStats(c *gin.Context) {
+	userID := currentUserID(c)
+
+	stats, err := h.repo.GetEventStats(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get event stats", "error", err, "user_id", userID)
+		respondStorageError(c, err, "internal_error", "Failed to get event stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
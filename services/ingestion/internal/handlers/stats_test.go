@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// fakeStatsRepo returns the stats of each user from memory.
+type fakeStatsRepo struct {
+	stats map[string]*models.EventStats
+	err   error
+}
+
+func (r *fakeStatsRepo) GetEventStats(_ context.Context, userID string) (*models.EventStats, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if stats, ok := r.stats[userID]; ok {
+		return stats, nil
+	}
+	return &models.EventStats{BySourceType: map[models.SourceType]int64{
+		models.SourceTypeVC: 0, models.SourceTypeOIDC: 0, models.SourceTypeManual: 0,
+	}}, nil
+}
+
+func TestHandleGetEventStats(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	earliest := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	latest := earliest.Add(48 * time.Hour)
+	repo := &fakeStatsRepo{stats: map[string]*models.EventStats{
+		"alice": {
+			Total:        6,
+			BySourceType: map[models.SourceType]int64{models.SourceTypeVC: 3, models.SourceTypeOIDC: 2, models.SourceTypeManual: 1},
+			Earliest:     &earliest,
+			Latest:       &latest,
+		},
+	}}
+
+	tests := []struct {
+		name       string
+		repo       *fakeStatsRepo
+		userID     string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "mixed source types",
+			repo:       repo,
+			userID:     "alice",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"total":6,"by_source_type":{"MANUAL":1,"OIDC":2,"VC":3},"earliest":"2026-01-01T00:00:00Z","latest":"2026-01-03T00:00:00Z"}`,
+		},
+		{
+			name:       "user without events",
+			repo:       repo,
+			userID:     "bob",
+			wantStatus: http.StatusOK,
+			wantBody:   `{"total":0,"by_source_type":{"MANUAL":0,"OIDC":0,"VC":0},"earliest":null,"latest":null}`,
+		},
+		{
+			name:       "query times out",
+			repo:       &fakeStatsRepo{err: fmt.Errorf("%w: context deadline exceeded", repository.ErrQueryTimeout)},
+			userID:     "alice",
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:       "query fails",
+			repo:       &fakeStatsRepo{err: errors.New("connection reset")},
+			userID:     "alice",
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewStatsHandler(tt.repo, discardLogger())
+			w := as(tt.userID, false, http.MethodGet, "/events/stats", "/events/stats", h.HandleGetEventStats)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantBody == "" {
+				return
+			}
+			var got, want interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.wantBody), &want); err != nil {
+				t.Fatal(err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("body = %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/uigs/ingestion/internal/cursor"
+	"github.com/uigs/ingestion/internal/extract"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// maxSubscriberIDLength matches stream_watermarks.subscriber_id.
+const maxSubscriberIDLength = 128
+
+// streamHeartbeat is how often an idle stream sends a keepalive comment.
+const streamHeartbeat = 15 * time.Second
+
+// maxStreamIssuerLength bounds the issuer filter of a stream.
+const maxStreamIssuerLength = 2048
+
+// StreamConfig controls real-time event delivery.
+type StreamConfig struct {
+	// PollInterval is how often new events are looked up.
+	PollInterval time.Duration
+	// SettleDelay holds back events this recent, so that a slower concurrent
+	// insert with an earlier created_at is never skipped by the cursor.
+	SettleDelay time.Duration
+	// BatchSize bounds the events read per poll.
+	BatchSize int
+}
+
+// StreamHandler delivers a user's new events as Server-Sent Events.
+type StreamHandler struct {
+	repo   repository.StreamRepository
+	cfg    StreamConfig
+	logger *slog.Logger
+}
+
+// NewStreamHandler creates a stream handler.
+func NewStreamHandler(repo repository.StreamRepository, cfg StreamConfig, logger *slog.Logger) *StreamHandler {
+	return &StreamHandler{
+		repo:   repo,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// streamEvent is the data of one SSE message.
+type streamEvent struct {
+	EventID            string            `json:"event_id"`
+	SourceType         models.SourceType `json:"source_type"`
+	CreatedAt          time.Time         `json:"created_at"`
+	VerificationStatus string            `json:"verification_status"`
+	DeliveryStatus     string            `json:"delivery_status"`
+}
+
+// streamFilter selects the events pushed to a subscriber. Empty fields
+// match every event.
+type streamFilter struct {
+	sourceTypes map[models.SourceType]bool
+	issuer      string
+}
+
+// parseStreamFilter reads the source_type (comma-separated) and issuer
+// query parameters.
+func parseStreamFilter(c *gin.Context) (streamFilter, error) {
+	var f streamFilter
+	if raw := c.Query("source_type"); raw != "" {
+		f.sourceTypes = make(map[models.SourceType]bool)
+		for _, t := range strings.Split(raw, ",") {
+			sourceType := models.SourceType(strings.ToUpper(strings.TrimSpace(t)))
+			switch sourceType {
+			case models.SourceTypeVC, models.SourceTypeOIDC, models.SourceTypeManual:
+				f.sourceTypes[sourceType] = true
+			default:
+				return f, fmt.Errorf("unknown source_type %q", t)
+			}
+		}
+	}
+
+	f.issuer = c.Query("issuer")
+	if len(f.issuer) > maxStreamIssuerLength {
+		return f, fmt.Errorf("issuer must be at most %d characters", maxStreamIssuerLength)
+	}
+	return f, nil
+}
+
+// matches reports whether event passes the filter. The issuer is read from
+// the stored payload only when an issuer filter is set.
+func (f streamFilter) matches(event *models.IngestionEvent) bool {
+	if f.sourceTypes != nil && !f.sourceTypes[event.SourceType] {
+		return false
+	}
+	if f.issuer == "" {
+		return true
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(event.RawPayload, &payload); err != nil {
+		return false
+	}
+	return extract.Issuer(payload) == f.issuer
+}
+
+// HandleStream streams the current user's events as they are ingested.
+// GET /api/v1/events/stream?subscriber=<id>&source_type=<types>&issuer=<id>
+//
+// Each message's id is the event's (created_at, event_id) cursor. A client
+// reconnecting with Last-Event-ID resumes right after that event. Otherwise
+// a named subscriber resumes from its persisted watermark, and a new one
+// starts with events ingested from now on. Events not matching the
+// source_type and issuer filters are skipped server-side; the cursor and
+// watermark still move past them.
+func (h *StreamHandler) HandleStream(c *gin.Context) {
+	userID := currentUserID(c)
+	subscriber := c.Query("subscriber")
+	if len(subscriber) > maxSubscriberIDLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": fmt.Sprintf("subscriber must be at most %d characters", maxSubscriberIDLength),
+		})
+		return
+	}
+	filter, err := parseStreamFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_filter",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	pos, ok := h.resumePosition(c, userID, subscriber)
+	if !ok {
+		return
+	}
+
+	// Streams outlive the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to clear stream write deadline", "error", err)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ctx := c.Request.Context()
+	ticker := time.NewTicker(h.cfg.PollInterval)
+	defer ticker.Stop()
+	lastWrite := time.Now()
+
+	for {
+		events, err := h.repo.ListEventsAfter(ctx, userID, pos, time.Now().Add(-h.cfg.SettleDelay), h.cfg.BatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				h.logger.Error("Failed to read events for stream", "error", err, "user_id", userID)
+			}
+			return
+		}
+
+		written := 0
+		for i := range events {
+			event := &events[i]
+			next := cursor.New(event.CreatedAt, event.EventID)
+			if filter.matches(event) {
+				if err := writeStreamEvent(c, next, *event); err != nil {
+					return
+				}
+				written++
+			}
+			pos = next
+		}
+		if written > 0 {
+			c.Writer.Flush()
+			lastWrite = time.Now()
+		}
+		if len(events) > 0 && subscriber != "" {
+			if err := h.repo.SaveWatermark(ctx, userID, subscriber, pos); err != nil && ctx.Err() == nil {
+				h.logger.Error("Failed to save stream watermark", "error", err, "subscriber", subscriber)
+			}
+		}
+		if written == 0 && time.Since(lastWrite) >= streamHeartbeat {
+			if _, err := fmt.Fprint(c.Writer, ": keepalive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+			lastWrite = time.Now()
+		}
+
+		// Drain a backlog without waiting for the next tick
+		if len(events) == h.cfg.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// resumePosition picks where the stream starts: the Last-Event-ID header,
+// then the subscriber's persisted watermark, then the current time. It
+// writes an error response and returns false when the position is invalid.
+func (h *StreamHandler) resumePosition(c *gin.Context, userID, subscriber string) (cursor.Cursor, bool) {
+	if lastID := c.GetHeader("Last-Event-ID"); lastID != "" {
+		pos, err := cursor.Parse(lastID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": "Invalid Last-Event-ID",
+			})
+			return cursor.Cursor{}, false
+		}
+		return pos, true
+	}
+
+	if subscriber != "" {
+		watermark, err := h.repo.GetWatermark(c.Request.Context(), userID, subscriber)
+		if err != nil {
+			h.logger.Error("Failed to get stream watermark", "error", err, "subscriber", subscriber)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "internal_error",
+				"message": "Failed to resume stream",
+			})
+			return cursor.Cursor{}, false
+		}
+		if watermark != nil {
+			return *watermark, true
+		}
+	}
+
+	return cursor.New(time.Now(), uuid.Nil.String()), true
+}
+
+func writeStreamEvent(c *gin.Context, pos cursor.Cursor, event models.IngestionEvent) error {
+	data, err := json.Marshal(streamEvent{
+		EventID:            event.EventID,
+		SourceType:         event.SourceType,
+		CreatedAt:          event.CreatedAt,
+		VerificationStatus: event.VerificationStatus,
+		DeliveryStatus:     event.DeliveryStatus,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %s\nevent: ingestion_event\ndata: %s\n\n", pos, data)
+	return err
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// SubjectHandler manages the credential subject identifiers bound to users.
+type SubjectHandler struct {
+	repo   repository.SubjectRepository
+	logger *slog.Logger
+}
+
+// NewSubjectHandler creates a subject handler.
+func NewSubjectHandler(repo repository.SubjectRepository, logger *slog.Logger) *SubjectHandler {
+	return &SubjectHandler{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// subjectIDsRequest replaces a user's subject identifiers.
+type subjectIDsRequest struct {
+	SubjectIDs []string `json:"subject_ids" binding:"required,max=100,dive,required,max=512"`
+}
+
+// HandleListSubjects returns the subject identifiers bound to a user.
+// GET /api/v1/admin/users/:user_id/subjects
+func (h *SubjectHandler) HandleListSubjects(c *gin.Context) {
+	userID, ok := subjectUserID(c)
+	if !ok {
+		return
+	}
+
+	ids, err := h.repo.ListSubjectIDs(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to list subject identifiers", "error", err, "user_id", userID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to retrieve subject identifiers",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":     userID,
+		"subject_ids": ids,
+	})
+}
+
+// HandlePutSubjects replaces the subject identifiers bound to a user.
+// PUT /api/v1/admin/users/:user_id/subjects
+func (h *SubjectHandler) HandlePutSubjects(c *gin.Context) {
+	userID, ok := subjectUserID(c)
+	if !ok {
+		return
+	}
+
+	var req subjectIDsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "validation_error",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := h.repo.ReplaceSubjectIDs(c.Request.Context(), userID, req.SubjectIDs); err != nil {
+		h.logger.Error("Failed to replace subject identifiers", "error", err, "user_id", userID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to save subject identifiers",
+		})
+		return
+	}
+
+	h.logger.Info("Subject identifiers updated", "user_id", userID, "count", len(req.SubjectIDs))
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":     userID,
+		"subject_ids": req.SubjectIDs,
+	})
+}
+
+// subjectUserID reads and validates the :user_id path parameter.
+func subjectUserID(c *gin.Context) (string, bool) {
+	userID := c.Param("user_id")
+	if _, err := uuid.Parse(userID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_user_id",
+			"message": "user_id must be a UUID",
+		})
+		return "", false
+	}
+	return userID, true
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+func TestHandleIngestStoresTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		tenantID   string
+		wantTenant string
+	}{
+		{name: "tenant from the token", tenantID: "acme", wantTenant: "acme"},
+		{name: "no tenant", wantTenant: middleware.DefaultTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeEventRepo{}
+			h := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger())
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set(middleware.ContextKeyUserID, "alice")
+				if tt.tenantID != "" {
+					c.Set(middleware.ContextKeyTenantID, tt.tenantID)
+				}
+			})
+			r.POST("/ingest", h.HandleIngest)
+
+			req := httptest.NewRequest(http.MethodPost, "/ingest", strings.NewReader(`{"source_type":"MANUAL","payload":{"note":"hello"}}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			h.Wait()
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+			}
+			for _, event := range repo.events {
+				if event.TenantID != tt.wantTenant {
+					t.Errorf("tenant = %q, want %q", event.TenantID, tt.wantTenant)
+				}
+			}
+		})
+	}
+}
+
+func TestCrossTenantAccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now().UTC()
+	newRepo := func() *fakeEventRepo {
+		return &fakeEventRepo{events: map[string]*models.IngestionEvent{
+			"acme-evt":   {EventID: "acme-evt", UserID: "alice", TenantID: "acme", RawPayload: []byte(`{}`), OccurredAt: now},
+			"globex-evt": {EventID: "globex-evt", UserID: "alice", TenantID: "globex", RawPayload: []byte(`{}`), OccurredAt: now},
+		}}
+	}
+
+	tests := []struct {
+		name       string
+		tenantID   string
+		admin      bool
+		method     string
+		path       string
+		wantStatus int
+		wantEvents []string
+	}{
+		{name: "read own tenant's event", tenantID: "acme", method: http.MethodGet, path: "/events/acme-evt", wantStatus: http.StatusOK},
+		{name: "read another tenant's event", tenantID: "acme", method: http.MethodGet, path: "/events/globex-evt", wantStatus: http.StatusNotFound},
+		{name: "read another tenant's payload", tenantID: "acme", method: http.MethodGet, path: "/events/globex-evt/payload", wantStatus: http.StatusNotFound},
+		{name: "delete another tenant's event", tenantID: "acme", method: http.MethodDelete, path: "/events/globex-evt", wantStatus: http.StatusNotFound},
+		{name: "default tenant reads nothing of acme", method: http.MethodGet, path: "/events/acme-evt", wantStatus: http.StatusNotFound},
+		{name: "admin reads every tenant", tenantID: "acme", admin: true, method: http.MethodGet, path: "/events/globex-evt", wantStatus: http.StatusOK},
+		{name: "list own tenant's events", tenantID: "acme", method: http.MethodGet, path: "/events", wantStatus: http.StatusOK, wantEvents: []string{"acme-evt"}},
+		{name: "list in a tenant without events", tenantID: "initech", method: http.MethodGet, path: "/events", wantStatus: http.StatusOK, wantEvents: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo()
+			h := NewIngestHandler(repo, nil, nil, discardLogger())
+			deletion := NewDeletionHandler(&fakeDeletionRepo{events: repo}, time.Hour, discardLogger())
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set(middleware.ContextKeyUserID, "alice")
+				if tt.tenantID != "" {
+					c.Set(middleware.ContextKeyTenantID, tt.tenantID)
+				}
+				c.Set(middleware.ContextKeyIsAdmin, tt.admin)
+			})
+			r.GET("/events", h.HandleGetUserEvents)
+			r.GET("/events/:id", h.HandleGetEvent)
+			r.GET("/events/:id/payload", h.HandleGetEventPayload)
+			r.DELETE("/events/:id", deletion.HandleDeleteEvent)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.method == http.MethodDelete && repo.events["globex-evt"].DeletedAt != nil {
+				t.Error("another tenant's event was deleted")
+			}
+			if tt.wantEvents == nil {
+				return
+			}
+			var resp struct {
+				Events []models.IngestionEvent `json:"events"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, e := range resp.Events {
+				got = append(got, e.EventID)
+			}
+			if !slices.Equal(got, tt.wantEvents) {
+				t.Errorf("listed %v, want %v", got, tt.wantEvents)
+			}
+		})
+	}
+}
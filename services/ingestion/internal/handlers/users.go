@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// Page sizes for listing users.
+const (
+	defaultUserPageSize = 100
+	maxUserPageSize     = 1000
+)
+
+// UserHandler lets admins enumerate the users who have ingested events.
+type UserHandler struct {
+	repo   repository.UserRepository
+	logger *slog.Logger
+}
+
+// NewUserHandler creates a user handler.
+func NewUserHandler(repo repository.UserRepository, logger *slog.Logger) *UserHandler {
+	return &UserHandler{repo: repo, logger: logger}
+}
+
+// HandleListUsers retrieves a page of users with events, in user_id order,
+// with their event counts and last activity. The next page is fetched by
+// passing next_cursor back as cursor; it is empty on the last page.
+// GET /api/v1/admin/users
+func (h *UserHandler) HandleListUsers(c *gin.Context) {
+	limit := defaultUserPageSize
+	if s := c.Query("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxUserPageSize {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": fmt.Sprintf("limit must be between 1 and %d", maxUserPageSize),
+			})
+			return
+		}
+		limit = n
+	}
+	after := c.Query("cursor")
+	if after != "" {
+		if _, err := uuid.Parse(after); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_cursor",
+				"message": "cursor must be a user ID",
+			})
+			return
+		}
+	}
+
+	// One extra row tells whether another page follows
+	users, err := h.repo.ListUsers(c.Request.Context(), limit+1, after)
+	if err != nil {
+		h.logger.ErrorContext(c.Request.Context(), "Failed to list users", "error", err)
+		respondStorageError(c, err, "internal_error", "Failed to list users")
+		return
+	}
+
+	var nextCursor string
+	if len(users) > limit {
+		users = users[:limit]
+		nextCursor = users[limit-1].UserID
+	}
+	if users == nil {
+		users = []models.UserActivity{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":       users,
+		"count":       len(users),
+		"next_cursor": nextCursor,
+	})
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// fakeUserRepo pages through users held in user_id order.
+type fakeUserRepo struct {
+	users []models.UserActivity
+}
+
+func (r *fakeUserRepo) ListUsers(_ context.Context, limit int, cursor string) ([]models.UserActivity, error) {
+	var page []models.UserActivity
+	for _, u := range r.users {
+		if u.UserID > cursor && len(page) < limit {
+			page = append(page, u)
+		}
+	}
+	return page, nil
+}
+
+// listUsers requests a page of users, as an admin if admin is set.
+func listUsers(h *UserHandler, admin bool, query url.Values) *httptest.ResponseRecorder {
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set(middleware.ContextKeyIsAdmin, admin) })
+	r.GET("/admin/users", middleware.RequireAdmin(), h.HandleListUsers)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users?"+query.Encode(), nil))
+	return w
+}
+
+type userPage struct {
+	Users      []models.UserActivity `json:"users"`
+	Count      int                   `json:"count"`
+	NextCursor string                `json:"next_cursor"`
+}
+
+func TestHandleListUsersPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var users []models.UserActivity
+	for i := 1; i <= 5; i++ {
+		users = append(users, models.UserActivity{
+			UserID:         fmt.Sprintf("00000000-0000-4000-8000-%012d", i),
+			EventCount:     int64(i),
+			LastActivityAt: time.Date(2026, 1, i, 0, 0, 0, 0, time.UTC),
+		})
+	}
+
+	tests := []struct {
+		name      string
+		users     []models.UserActivity
+		limit     string
+		wantPages [][]int
+	}{
+		{name: "several pages", users: users, limit: "2", wantPages: [][]int{{1, 2}, {3, 4}, {5}}},
+		{name: "pages that divide evenly", users: users[:4], limit: "2", wantPages: [][]int{{1, 2}, {3, 4}}},
+		{name: "one page", users: users, limit: "10", wantPages: [][]int{{1, 2, 3, 4, 5}}},
+		{name: "default page size", users: users, wantPages: [][]int{{1, 2, 3, 4, 5}}},
+		{name: "no users", wantPages: [][]int{{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewUserHandler(&fakeUserRepo{users: tt.users}, discardLogger())
+			query := url.Values{}
+			if tt.limit != "" {
+				query.Set("limit", tt.limit)
+			}
+
+			for i, want := range tt.wantPages {
+				w := listUsers(h, true, query)
+				if w.Code != http.StatusOK {
+					t.Fatalf("page %d: status = %d, want %d: %s", i, w.Code, http.StatusOK, w.Body)
+				}
+				var page userPage
+				if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+					t.Fatal(err)
+				}
+				if page.Users == nil {
+					t.Fatalf("page %d: users is null, want a list", i)
+				}
+				got := []int{}
+				for _, u := range page.Users {
+					got = append(got, int(u.EventCount))
+				}
+				if !slices.Equal(got, want) || page.Count != len(want) {
+					t.Fatalf("page %d = %v (count %d), want %v", i, got, page.Count, want)
+				}
+				last := i == len(tt.wantPages)-1
+				if (page.NextCursor == "") != last {
+					t.Fatalf("page %d: next_cursor = %q on the last page %v", i, page.NextCursor, last)
+				}
+				query.Set("cursor", page.NextCursor)
+			}
+		})
+	}
+}
+
+func TestHandleListUsersRejects(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		admin      bool
+		query      url.Values
+		wantStatus int
+		wantError  string
+	}{
+		{name: "not an admin", wantStatus: http.StatusForbidden, wantError: "forbidden"},
+		{name: "limit too large", admin: true, query: url.Values{"limit": {"1001"}}, wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+		{name: "limit not a number", admin: true, query: url.Values{"limit": {"ten"}}, wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+		{name: "cursor not a user ID", admin: true, query: url.Values{"cursor": {"alice"}}, wantStatus: http.StatusBadRequest, wantError: "invalid_cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := listUsers(NewUserHandler(&fakeUserRepo{}, discardLogger()), tt.admin, tt.query)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if !jsonHasError(w.Body.Bytes(), tt.wantError) {
+				t.Errorf("body = %s, want error %q", w.Body, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/timecheck"
+)
+
+// at formats a time offset from now as an RFC 3339 date.
+func at(offset time.Duration) string {
+	return time.Now().Add(offset).UTC().Format(time.RFC3339)
+}
+
+func TestCheckValiditySkew(t *testing.T) {
+	h := NewIngestHandler(nil, nil, nil, discardLogger(), WithClock(timecheck.New(5*time.Minute)))
+
+	tests := []struct {
+		name     string
+		vc       models.VerifiableCredential
+		wantCode string
+	}{
+		{name: "expired within skew", vc: models.VerifiableCredential{ExpirationDate: at(-4 * time.Minute)}},
+		{name: "expired beyond skew", vc: models.VerifiableCredential{ExpirationDate: at(-6 * time.Minute)}, wantCode: "credential_expired"},
+		{name: "validUntil beyond skew", vc: models.VerifiableCredential{ValidUntil: at(-time.Hour)}, wantCode: "credential_expired"},
+		{name: "valid from within skew", vc: models.VerifiableCredential{ValidFrom: at(4 * time.Minute)}},
+		{name: "valid from beyond skew", vc: models.VerifiableCredential{ValidFrom: at(6 * time.Minute)}, wantCode: "credential_not_yet_valid"},
+		{name: "proof created within skew", vc: models.VerifiableCredential{Proof: models.ProofSet{{Created: at(4 * time.Minute)}}}},
+		{name: "proof created beyond skew", vc: models.VerifiableCredential{Proof: models.ProofSet{{Created: at(6 * time.Minute)}}}, wantCode: "invalid_proof"},
+		{name: "unparseable dates are left to schema validation", vc: models.VerifiableCredential{ExpirationDate: "last week"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertCode(t, h.checkValidity(&tt.vc), tt.wantCode)
+		})
+	}
+}
+
+func TestCheckTokenSkew(t *testing.T) {
+	h := NewIngestHandler(nil, nil, nil, discardLogger(), WithClock(timecheck.New(5*time.Minute)))
+	unix := func(offset time.Duration) float64 { return float64(time.Now().Add(offset).Unix()) }
+
+	tests := []struct {
+		name     string
+		payload  map[string]interface{}
+		wantCode string
+	}{
+		{name: "exp within skew", payload: map[string]interface{}{"exp": unix(-4 * time.Minute)}},
+		{name: "exp beyond skew", payload: map[string]interface{}{"exp": unix(-6 * time.Minute)}, wantCode: "token_expired"},
+		{name: "nbf within skew", payload: map[string]interface{}{"nbf": unix(4 * time.Minute)}},
+		{name: "nbf beyond skew", payload: map[string]interface{}{"nbf": unix(6 * time.Minute)}, wantCode: "token_not_yet_valid"},
+		{name: "iat within skew", payload: map[string]interface{}{"iat": unix(4 * time.Minute)}},
+		{name: "iat beyond skew", payload: map[string]interface{}{"iat": unix(6 * time.Minute)}, wantCode: "invalid_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertCode(t, h.checkToken(tt.payload), tt.wantCode)
+		})
+	}
+}
+
+// assertCode fails unless ierr carries wantCode, or is nil when wantCode is
+// empty.
+func assertCode(t *testing.T, ierr *ingestError, wantCode string) {
+	t.Helper()
+	switch {
+	case wantCode == "" && ierr != nil:
+		t.Fatalf("unexpected error %s: %s", ierr.code, ierr.message)
+	case wantCode != "" && ierr == nil:
+		t.Fatalf("got no error, want %s", wantCode)
+	case wantCode != "" && ierr.code != wantCode:
+		t.Fatalf("error = %s, want %s", ierr.code, wantCode)
+	}
+}
+
+func TestCheckValidityFutureIssuance(t *testing.T) {
+	clock := timecheck.New(5 * time.Minute)
+
+	tests := []struct {
+		name     string
+		reject   bool
+		issued   string
+		wantCode string
+	}{
+		{name: "past issuance", reject: true, issued: at(-time.Hour)},
+		{name: "near future within skew", reject: true, issued: at(4 * time.Minute)},
+		{name: "near future beyond skew", reject: true, issued: at(6 * time.Minute), wantCode: "credential_issued_in_future"},
+		{name: "far future", reject: true, issued: at(365 * 24 * time.Hour), wantCode: "credential_issued_in_future"},
+		{name: "far future with the check disabled", reject: false, issued: at(365 * 24 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []IngestOption{WithClock(clock)}
+			if tt.reject {
+				opts = append(opts, WithFutureIssuanceRejection())
+			}
+			h := NewIngestHandler(nil, nil, nil, discardLogger(), opts...)
+
+			ierr := h.checkValidity(&models.VerifiableCredential{IssuanceDate: tt.issued})
+			assertCode(t, ierr, tt.wantCode)
+			if ierr != nil && (ierr.field != "issuanceDate" || !strings.Contains(ierr.message, tt.issued)) {
+				t.Errorf("error names field %q with message %q, want issuanceDate and the offending date", ierr.field, ierr.message)
+			}
+		})
+	}
+}
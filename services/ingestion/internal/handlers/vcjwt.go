@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// decodeCredentialJWT replaces a VC-JWT payload with the credential it
+// carries, so it is checked and stored like any other VC. The token itself
+// is kept in req.CredentialJWT. The source type defaults to VC.
+func decodeCredentialJWT(req *models.IngestionRequest) *ingestError {
+	if req.CredentialJWT == "" {
+		return nil
+	}
+	if req.SourceType != "" && req.SourceType != models.SourceTypeVC {
+		return &ingestError{
+			status:  http.StatusUnprocessableEntity,
+			code:    "invalid_vc_jwt",
+			message: "A JWT payload must be a VC",
+			field:   "payload",
+		}
+	}
+
+	credential, err := models.DecodeVCJWTClaims(req.CredentialJWT)
+	if err != nil {
+		return &ingestError{
+			status:  http.StatusUnprocessableEntity,
+			code:    "invalid_vc_jwt",
+			message: err.Error(),
+			field:   "payload",
+		}
+	}
+	req.SourceType = models.SourceTypeVC
+	req.Payload = credential
+	return nil
+}
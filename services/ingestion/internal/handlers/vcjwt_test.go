@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+func TestHandleIngestVCJWT(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	token := vcJWT(t, map[string]interface{}{
+		"iss": "did:example:issuer",
+		"sub": "did:example:alice",
+		"vc": map[string]interface{}{
+			"@context":          []interface{}{"https://www.w3.org/2018/credentials/v1"},
+			"type":              []interface{}{"VerifiableCredential"},
+			"credentialSubject": map[string]interface{}{"name": "Alice"},
+		},
+	})
+	body := func(sourceType, payload string) string {
+		b, _ := json.Marshal(map[string]interface{}{"source_type": sourceType, "payload": payload})
+		return string(b)
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{name: "VC-JWT", body: body("VC", token), wantStatus: http.StatusCreated},
+		{name: "VC-JWT without a source type", body: `{"payload":"` + token + `"}`, wantStatus: http.StatusCreated},
+		{name: "VC-JWT as OIDC", body: body("OIDC", token), wantStatus: http.StatusUnprocessableEntity, wantError: "invalid_vc_jwt"},
+		{name: "two segments", body: body("VC", "eyJhbGciOiJFUzI1NiJ9.e30"), wantStatus: http.StatusUnprocessableEntity, wantError: "invalid_vc_jwt"},
+		{name: "not a JWT", body: body("VC", "hello"), wantStatus: http.StatusUnprocessableEntity, wantError: "invalid_vc_jwt"},
+		{name: "JWT without a vc claim", body: body("VC", vcJWT(t, map[string]interface{}{"iss": "did:example:issuer"})), wantStatus: http.StatusUnprocessableEntity, wantError: "invalid_vc_jwt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeEventRepo{}
+			h := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger())
+			w := ingest(h, "alice", tt.body, nil)
+			h.Wait()
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantError != "" {
+				if !jsonHasError(w.Body.Bytes(), tt.wantError) {
+					t.Errorf("body = %s, want error %q", w.Body, tt.wantError)
+				}
+				return
+			}
+			if len(repo.events) != 1 {
+				t.Fatalf("stored %d events, want 1", len(repo.events))
+			}
+			for _, event := range repo.events {
+				if event.CredentialJWT != token {
+					t.Errorf("credential_jwt = %q, want the submitted token", event.CredentialJWT)
+				}
+				if event.SourceType != models.SourceTypeVC {
+					t.Errorf("source_type = %q, want VC", event.SourceType)
+				}
+				var vc models.VerifiableCredential
+				if err := json.Unmarshal(event.RawPayload, &vc); err != nil {
+					t.Fatalf("stored payload is not a credential: %v", err)
+				}
+				if vc.GetIssuerID() != "did:example:issuer" || vc.CredentialSubject["id"] != "did:example:alice" {
+					t.Errorf("stored credential = %s, want the decoded vc claim", event.RawPayload)
+				}
+			}
+		})
+	}
+}
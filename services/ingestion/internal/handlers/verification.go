@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// checkDownstream is the check recorded for statuses reported by
+// downstream verifiers.
+const checkDownstream = "downstream"
+
+// reportableStatuses are the verification statuses downstream verifiers
+// may record on an event.
+var reportableStatuses = map[string]bool{
+	models.VerificationStatusVerified: true,
+	models.VerificationStatusFailed:   true,
+	models.VerificationStatusPending:  true,
+}
+
+// verificationUpdate is the body of HandleUpdateVerification.
+type verificationUpdate struct {
+	VerificationStatus string `json:"verification_status"`
+}
+
+// HandleUpdateVerification records the outcome of a downstream verification
+// on one of the current user's events. When the status changes and the
+// owner has opted in, a verification.status_changed webhook is sent.
+// PATCH /api/v1/events/:id
+func (h *IngestHandler) HandleUpdateVerification(c *gin.Context) {
+	eventID := c.Param("id")
+	ctx := c.Request.Context()
+
+	var req verificationUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	if !reportableStatuses[req.VerificationStatus] {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_status",
+			"message": "verification_status must be one of verified, failed or pending",
+		})
+		return
+	}
+
+	event, err := h.repo.GetEventByID(ctx, readTenantID(c), eventID)
+	if errors.Is(err, repository.ErrIntegrity) {
+		h.logger.ErrorContext(ctx, "Stored event failed its integrity check", "error", err, "event_id", eventID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "integrity_error",
+			"message": "Stored event does not match its checksum",
+		})
+		return
+	}
+	if err != nil || event.DeletedAt != nil {
+		if err != nil {
+			h.logger.ErrorContext(ctx, "Failed to get event", "error", err, "event_id", eventID)
+		}
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Event not found",
+		})
+		return
+	}
+	if event.UserID != currentUserID(c) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   "forbidden",
+			"message": "Only the event's owner can update it",
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	if err := h.repo.UpdateVerificationStatus(ctx, eventID, req.VerificationStatus, now); err != nil {
+		h.logger.ErrorContext(ctx, "Failed to update verification status", "error", err, "event_id", eventID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to record verification status",
+		})
+		return
+	}
+
+	if req.VerificationStatus != event.VerificationStatus {
+		h.logger.InfoContext(ctx, "Verification status changed",
+			"event_id", eventID,
+			"old_status", event.VerificationStatus,
+			"new_status", req.VerificationStatus,
+			"check", checkDownstream,
+		)
+		h.notifyVerificationChange(ctx, models.VerificationChange{
+			EventID:   eventID,
+			UserID:    event.UserID,
+			Check:     checkDownstream,
+			OldStatus: event.VerificationStatus,
+			NewStatus: req.VerificationStatus,
+			ChangedAt: now,
+		})
+	}
+
+	event.VerificationStatus = req.VerificationStatus
+	event.VerifiedAt = &now
+	c.JSON(http.StatusOK, event)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/middleware"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+func (r *fakeEventRepo) UpdateVerificationStatus(_ context.Context, eventID, status string, verifiedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event := r.events[eventID]
+	event.VerificationStatus = status
+	event.VerifiedAt = &verifiedAt
+	return nil
+}
+
+func TestHandleUpdateVerification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		userID     string
+		tenantID   string
+		body       string
+		wantStatus int
+		wantError  string
+		wantStored string
+	}{
+		{name: "owner records verified", userID: "alice", body: `{"verification_status":"verified"}`, wantStatus: http.StatusOK, wantStored: models.VerificationStatusVerified},
+		{name: "owner records failed", userID: "alice", body: `{"verification_status":"failed"}`, wantStatus: http.StatusOK, wantStored: models.VerificationStatusFailed},
+		{name: "owner records pending", userID: "alice", body: `{"verification_status":"pending"}`, wantStatus: http.StatusOK, wantStored: models.VerificationStatusPending},
+		{name: "another user", userID: "bob", body: `{"verification_status":"verified"}`, wantStatus: http.StatusForbidden, wantError: "forbidden"},
+		{name: "another tenant", userID: "alice", tenantID: "globex", body: `{"verification_status":"verified"}`, wantStatus: http.StatusNotFound, wantError: "not_found"},
+		{name: "unknown status", userID: "alice", body: `{"verification_status":"trusted"}`, wantStatus: http.StatusBadRequest, wantError: "invalid_status"},
+		{name: "status in upper case", userID: "alice", body: `{"verification_status":"VERIFIED"}`, wantStatus: http.StatusBadRequest, wantError: "invalid_status"},
+		{name: "missing status", userID: "alice", body: `{}`, wantStatus: http.StatusBadRequest, wantError: "invalid_status"},
+		{name: "malformed body", userID: "alice", body: `{"verification_status":`, wantStatus: http.StatusBadRequest, wantError: "invalid_request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeEventRepo{events: map[string]*models.IngestionEvent{
+				"evt-1": {EventID: "evt-1", UserID: "alice", TenantID: "acme", VerificationStatus: models.VerificationStatusPending},
+			}}
+			h := NewIngestHandler(repo, &fakePublisher{}, nil, discardLogger())
+
+			tenantID := tt.tenantID
+			if tenantID == "" {
+				tenantID = "acme"
+			}
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Set(middleware.ContextKeyUserID, tt.userID)
+				c.Set(middleware.ContextKeyTenantID, tenantID)
+			})
+			r.PATCH("/events/:id", h.HandleUpdateVerification)
+			req := httptest.NewRequest(http.MethodPatch, "/events/evt-1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			stored := repo.events["evt-1"]
+			if tt.wantError != "" {
+				if !jsonHasError(w.Body.Bytes(), tt.wantError) {
+					t.Errorf("body = %s, want %s", w.Body, tt.wantError)
+				}
+				if stored.VerifiedAt != nil {
+					t.Error("rejected update was stored")
+				}
+				return
+			}
+			if stored.VerificationStatus != tt.wantStored || stored.VerifiedAt == nil {
+				t.Errorf("stored status = %q at %v, want %q", stored.VerificationStatus, stored.VerifiedAt, tt.wantStored)
+			}
+			var resp models.IngestionEvent
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.VerificationStatus != tt.wantStored {
+				t.Errorf("response = %s, want status %q", w.Body, tt.wantStored)
+			}
+		})
+	}
+}
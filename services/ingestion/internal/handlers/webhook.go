@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+	"github.com/uigs/ingestion/internal/webhook"
+)
+
+// WebhookHandler manages the current user's webhook endpoints.
+type WebhookHandler struct {
+	repo       repository.WebhookRepository
+	dispatcher *webhook.Dispatcher
+	logger     *slog.Logger
+}
+
+// NewWebhookHandler creates a webhook handler. Endpoints on internal
+// addresses are accepted only if the dispatcher allows them.
+func NewWebhookHandler(repo repository.WebhookRepository, dispatcher *webhook.Dispatcher, logger *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		repo:       repo,
+		dispatcher: dispatcher,
+		logger:     logger,
+	}
+}
+
+// webhookView is a webhook as listed to its owner, with the delivery state
+// of ordered webhooks.
+type webhookView struct {
+	models.UserWebhook
+	Delivery *webhook.SubscriberStats `json:"delivery,omitempty"`
+}
+
+// HandleListWebhooks lists the current user's webhooks without secrets.
+// GET /api/v1/webhooks
+func (h *WebhookHandler) HandleListWebhooks(c *gin.Context) {
+	userID := currentUserID(c)
+
+	hooks, err := h.repo.ListUserWebhooks(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to list webhooks", "error", err, "user_id", userID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to retrieve webhooks",
+		})
+		return
+	}
+	stats := h.dispatcher.SubscriberStats()
+	views := make([]webhookView, len(hooks))
+	for i, hook := range hooks {
+		hook.Secret = ""
+		views[i] = webhookView{UserWebhook: hook}
+		if s, ok := stats[hook.OrderKey()]; ok && hook.Ordered {
+			views[i].Delivery = &s
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": views,
+		"count":    len(views),
+	})
+}
+
+// HandleWebhookLag reports the ordered delivery state and lag of every
+// ordered webhook subscriber.
+// GET /api/v1/admin/webhooks/lag
+func (h *WebhookHandler) HandleWebhookLag(c *gin.Context) {
+	stats := h.dispatcher.SubscriberStats()
+	c.JSON(http.StatusOK, gin.H{
+		"subscribers": stats,
+		"count":       len(stats),
+	})
+}
+
+// HandlePutWebhook opts the current user into an event type, or changes the
+// endpoint. A new signing secret is generated and returned only here.
+// PUT /api/v1/webhooks/:event_type
+func (h *WebhookHandler) HandlePutWebhook(c *gin.Context) {
+	eventType := c.Param("event_type")
+	if !slices.Contains(models.WebhookEventTypes, eventType) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Unknown webhook event type",
+		})
+		return
+	}
+
+	var req models.UserWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "invalid_request",
+			"message": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	if err := webhook.ValidateURL(c.Request.Context(), req.URL, h.dispatcher.AllowPrivate()); err != nil {
+		code := "invalid_webhook_url"
+		if errors.Is(err, webhook.ErrForbiddenDestination) {
+			code = "forbidden_webhook_url"
+		}
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   code,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		h.logger.Error("Failed to generate webhook secret", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to register webhook",
+		})
+		return
+	}
+
+	hook := &models.UserWebhook{
+		UserID:    currentUserID(c),
+		EventType: eventType,
+		URL:       req.URL,
+		Secret:    secret,
+		Ordered:   req.Ordered,
+	}
+	if err := h.repo.UpsertUserWebhook(c.Request.Context(), hook); err != nil {
+		h.logger.Error("Failed to save webhook", "error", err, "user_id", hook.UserID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to register webhook",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, hook)
+}
+
+// HandleDeleteWebhook opts the current user out of an event type.
+// DELETE /api/v1/webhooks/:event_type
+func (h *WebhookHandler) HandleDeleteWebhook(c *gin.Context) {
+	userID := currentUserID(c)
+
+	found, err := h.repo.DeleteUserWebhook(c.Request.Context(), userID, c.Param("event_type"))
+	if err != nil {
+		h.logger.Error("Failed to delete webhook", "error", err, "user_id", userID)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "internal_error",
+			"message": "Failed to delete webhook",
+		})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error":   "not_found",
+			"message": "Webhook not found",
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
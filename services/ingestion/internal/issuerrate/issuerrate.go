@@ -0,0 +1,234 @@
+// Package issuerrate tracks ingestion rates per credential issuer and flags
+// issuers whose current rate spikes well above their own baseline, an early
+// sign of a compromised or misbehaving issuer.
+package issuerrate
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// AlertEvent is the webhook event type of an anomaly alert.
+const AlertEvent = "issuer.rate_anomaly"
+
+// Config controls rate tracking and anomaly detection.
+type Config struct {
+	// Window is the length of the sliding window the current rate is
+	// measured over.
+	Window time.Duration
+	// Baseline is how much history, in whole windows, the baseline rate is
+	// averaged over.
+	Baseline time.Duration
+	// SpikeFactor flags an issuer whose current rate exceeds this multiple
+	// of its baseline.
+	SpikeFactor float64
+	// MinCount is the smallest current count that can be flagged, so
+	// quiet issuers are not flagged for a handful of events.
+	MinCount int
+	// MaxIssuers bounds the number of issuers tracked.
+	MaxIssuers int
+	// OnAnomaly, if set, is called when an issuer becomes anomalous. It
+	// is called again only after the issuer has returned to normal.
+	OnAnomaly func(Anomaly)
+}
+
+// Anomaly describes an issuer whose rate spiked above its baseline.
+type Anomaly struct {
+	Issuer     string    `json:"issuer"`
+	Current    float64   `json:"current"`
+	Baseline   float64   `json:"baseline"`
+	Factor     float64   `json:"spike_factor"`
+	Window     string    `json:"window"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+type issuerState struct {
+	// buckets holds one count per window, indexed by window number
+	// modulo len(buckets)
+	buckets  []int
+	last     int64 // window number of the most recent event
+	flagged  bool
+	lastSeen time.Time
+}
+
+// Tracker counts events per issuer in fixed windows and estimates the
+// current rate with a sliding window over the last two.
+type Tracker struct {
+	cfg Config
+
+	mu        sync.Mutex
+	issuers   map[string]*issuerState
+	untracked int64
+}
+
+// NewTracker creates an issuer rate tracker.
+func NewTracker(cfg Config) *Tracker {
+	return &Tracker{
+		cfg:     cfg,
+		issuers: make(map[string]*issuerState),
+	}
+}
+
+// Record counts one ingestion from issuer and checks it for an anomaly.
+func (t *Tracker) Record(issuer string) {
+	if issuer == "" {
+		return
+	}
+	now := time.Now()
+	window := t.windowOf(now)
+
+	t.mu.Lock()
+	s, ok := t.issuers[issuer]
+	if !ok {
+		if len(t.issuers) >= t.cfg.MaxIssuers {
+			t.evictIdleLocked(now)
+		}
+		if len(t.issuers) >= t.cfg.MaxIssuers {
+			t.untracked++
+			t.mu.Unlock()
+			return
+		}
+		s = &issuerState{buckets: make([]int, t.bucketCount()), last: window}
+		t.issuers[issuer] = s
+	}
+	t.advanceLocked(s, window)
+	s.buckets[window%int64(len(s.buckets))]++
+	s.lastSeen = now
+
+	current, baseline := t.ratesLocked(s, now, window)
+	anomalous := t.anomalous(current, baseline)
+	fire := anomalous && !s.flagged
+	s.flagged = anomalous
+	t.mu.Unlock()
+
+	if fire && t.cfg.OnAnomaly != nil {
+		t.cfg.OnAnomaly(Anomaly{
+			Issuer:     issuer,
+			Current:    current,
+			Baseline:   baseline,
+			Factor:     t.cfg.SpikeFactor,
+			Window:     t.cfg.Window.String(),
+			DetectedAt: now.UTC(),
+		})
+	}
+}
+
+func (t *Tracker) anomalous(current, baseline float64) bool {
+	return current >= float64(t.cfg.MinCount) && current > baseline*t.cfg.SpikeFactor
+}
+
+func (t *Tracker) windowOf(at time.Time) int64 {
+	return at.UnixNano() / int64(t.cfg.Window)
+}
+
+// bucketCount is the baseline windows plus the current one.
+func (t *Tracker) bucketCount() int {
+	n := int(t.cfg.Baseline / t.cfg.Window)
+	if n < 1 {
+		n = 1
+	}
+	return n + 1
+}
+
+// advanceLocked clears the buckets of windows skipped since the issuer's
+// last event. Callers must hold t.mu.
+func (t *Tracker) advanceLocked(s *issuerState, window int64) {
+	n := int64(len(s.buckets))
+	for w := s.last + 1; w <= window && w <= s.last+n; w++ {
+		s.buckets[w%n] = 0
+	}
+	if window > s.last {
+		s.last = window
+	}
+}
+
+// ratesLocked returns the sliding-window count for the current window and
+// the mean count of the complete windows before it. The sliding count
+// weights the previous window by the part of it still inside the window.
+// Callers must hold t.mu.
+func (t *Tracker) ratesLocked(s *issuerState, now time.Time, window int64) (float64, float64) {
+	n := int64(len(s.buckets))
+	if window-s.last >= n {
+		return 0, 0
+	}
+	t.advanceLocked(s, window)
+
+	elapsed := float64(now.UnixNano()%int64(t.cfg.Window)) / float64(t.cfg.Window)
+	prev := s.buckets[(window-1+n)%n]
+	current := float64(s.buckets[window%n]) + float64(prev)*(1-elapsed)
+
+	total := 0
+	for i, count := range s.buckets {
+		if int64(i) != window%n {
+			total += count
+		}
+	}
+	baseline := float64(total) / float64(n-1)
+	return current, baseline
+}
+
+// evictIdleLocked drops issuers with no events in the baseline period.
+// Callers must hold t.mu.
+func (t *Tracker) evictIdleLocked(now time.Time) {
+	cutoff := now.Add(-t.cfg.Baseline - t.cfg.Window)
+	for issuer, s := range t.issuers {
+		if s.lastSeen.Before(cutoff) {
+			delete(t.issuers, issuer)
+		}
+	}
+}
+
+// IssuerStats reports the rates of one issuer.
+type IssuerStats struct {
+	Issuer   string  `json:"issuer"`
+	Current  float64 `json:"current"`
+	Baseline float64 `json:"baseline"`
+	Flagged  bool    `json:"flagged"`
+}
+
+// Stats is a point-in-time snapshot of the tracker.
+type Stats struct {
+	Window    string        `json:"window"`
+	Tracked   int           `json:"tracked"`
+	Untracked int64         `json:"untracked"`
+	Flagged   []IssuerStats `json:"flagged"`
+	Top       []IssuerStats `json:"top"`
+}
+
+// topIssuers is the number of busiest issuers reported by Stats.
+const topIssuers = 20
+
+// Stats returns the flagged issuers and the busiest issuers by current rate.
+func (t *Tracker) Stats() Stats {
+	now := time.Now()
+	window := t.windowOf(now)
+
+	t.mu.Lock()
+	all := make([]IssuerStats, 0, len(t.issuers))
+	for issuer, s := range t.issuers {
+		current, baseline := t.ratesLocked(s, now, window)
+		all = append(all, IssuerStats{
+			Issuer:   issuer,
+			Current:  current,
+			Baseline: baseline,
+			Flagged:  s.flagged && t.anomalous(current, baseline),
+		})
+	}
+	stats := Stats{
+		Window:    t.cfg.Window.String(),
+		Tracked:   len(t.issuers),
+		Untracked: t.untracked,
+		Flagged:   []IssuerStats{},
+	}
+	t.mu.Unlock()
+
+	sort.Slice(all, func(i, j int) bool { return all[i].Current > all[j].Current })
+	for _, s := range all {
+		if s.Flagged {
+			stats.Flagged = append(stats.Flagged, s)
+		}
+	}
+	stats.Top = all[:min(len(all), topIssuers)]
+	return stats
+}
@@ -0,0 +1,226 @@
+// Package keyring encrypts event payloads at rest with versioned AES-256-GCM
+// keys, so the current key can be rotated while older versions stay readable.
+// Each payload is sealed under its own data key, wrapped with a versioned key.
+package keyring
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeySize is the required key length in bytes (AES-256).
+const KeySize = 32
+
+// ErrUnknownVersion is returned when data was encrypted with a key version
+// that is not in the keyring.
+var ErrUnknownVersion = errors.New("unknown encryption key version")
+
+// Keyring holds every configured key version and encrypts with the current one.
+type Keyring struct {
+	aeads   map[int]cipher.AEAD
+	current int
+}
+
+// New creates a keyring from raw keys by version. current selects the key
+// used for new encryptions; zero selects the highest version.
+func New(keys map[int][]byte, current int) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no encryption keys configured")
+	}
+
+	k := &Keyring{aeads: make(map[int]cipher.AEAD, len(keys))}
+	for version, key := range keys {
+		if version <= 0 {
+			return nil, fmt.Errorf("key version %d must be positive", version)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("key version %d must be %d bytes, got %d", version, KeySize, len(key))
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, fmt.Errorf("key version %d: %w", version, err)
+		}
+		k.aeads[version] = aead
+		if version > k.current && current == 0 {
+			k.current = version
+		}
+	}
+
+	if current != 0 {
+		if _, ok := k.aeads[current]; !ok {
+			return nil, fmt.Errorf("%w: current version %d", ErrUnknownVersion, current)
+		}
+		k.current = current
+	}
+	return k, nil
+}
+
+// Parse creates a keyring from a comma-separated list of version:key pairs,
+// with each key base64 encoded, e.g. "1:<base64>,2:<base64>".
+func Parse(spec string, current int) (*Keyring, error) {
+	keys := make(map[int][]byte)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		v, encoded, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid key entry %q: expected version:key", pair)
+		}
+		version, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid key version %q", v)
+		}
+		if _, dup := keys[version]; dup {
+			return nil, fmt.Errorf("duplicate key version %d", version)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("key version %d is not valid base64: %w", version, err)
+		}
+		keys[version] = key
+	}
+	return New(keys, current)
+}
+
+// Current returns the version used for new encryptions.
+func (k *Keyring) Current() int {
+	return k.current
+}
+
+// Versions returns the configured key versions in ascending order.
+func (k *Keyring) Versions() []int {
+	versions := make([]int, 0, len(k.aeads))
+	for v := range k.aeads {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// Envelope prefixes mark ciphertext sealed under its own data key. Older
+// ciphertext, sealed directly with a key version, starts with its nonce.
+// Envelopes with boundPrefix are authenticated with associated data;
+// those with legacyPrefix, and direct ciphertext, are not.
+var (
+	boundPrefix  = []byte("env2")
+	legacyPrefix = []byte("env1")
+)
+
+// Encrypt seals plaintext under a fresh random data key and wraps the data
+// key with the current key, so no two payloads share a GCM key. Both are
+// authenticated with aad and the key version, so the ciphertext only opens
+// for the same aad, such as the ID of the row it is stored in, and the
+// version it is stored with. It returns the key version and the envelope:
+// the prefix, the wrapped data key and the sealed plaintext, each sealed
+// value prefixed with its nonce.
+func (k *Keyring) Encrypt(plaintext, aad []byte) (int, []byte, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return 0, nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	ad := associatedData(k.current, aad)
+	envelope := append([]byte(nil), boundPrefix...)
+	if envelope, err = seal(k.aeads[k.current], envelope, dataKey, ad); err != nil {
+		return 0, nil, err
+	}
+	if envelope, err = seal(data, envelope, plaintext, ad); err != nil {
+		return 0, nil, err
+	}
+	return k.current, envelope, nil
+}
+
+// Decrypt opens ciphertext produced by Encrypt with the given key version
+// and aad. Envelopes sealed before associated data was bound, and
+// ciphertext sealed directly with the key before data keys were used, are
+// still accepted whatever the aad; re-encrypting them binds them.
+func (k *Keyring) Decrypt(version int, ciphertext, aad []byte) ([]byte, error) {
+	master, ok := k.aeads[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+
+	if rest, ok := bytes.CutPrefix(ciphertext, boundPrefix); ok {
+		if plaintext, err := openEnvelope(master, rest, associatedData(version, aad)); err == nil {
+			return plaintext, nil
+		}
+		// A direct ciphertext whose nonce happens to start with the prefix
+	} else if rest, ok := bytes.CutPrefix(ciphertext, legacyPrefix); ok {
+		if plaintext, err := openEnvelope(master, rest, nil); err == nil {
+			return plaintext, nil
+		}
+	}
+	plaintext, err := open(master, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with key version %d: %w", version, err)
+	}
+	return plaintext, nil
+}
+
+// associatedData returns the data authenticated along with a payload: the
+// key version, big-endian, followed by the caller's aad.
+func associatedData(version int, aad []byte) []byte {
+	ad := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(aad)), uint32(version))
+	return append(ad, aad...)
+}
+
+// openEnvelope unwraps the data key of an envelope with master and opens
+// the sealed plaintext with it.
+func openEnvelope(master cipher.AEAD, envelope, ad []byte) ([]byte, error) {
+	wrappedSize := master.NonceSize() + KeySize + master.Overhead()
+	if len(envelope) < wrappedSize {
+		return nil, errors.New("envelope too short")
+	}
+	dataKey, err := open(master, envelope[:wrappedSize], ad)
+	if err != nil {
+		return nil, err
+	}
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return open(data, envelope[wrappedSize:], ad)
+}
+
+// newAEAD returns AES-GCM with key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal appends a random nonce and plaintext sealed under it with ad to dst.
+func seal(aead cipher.AEAD, dst, plaintext, ad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	dst = append(dst, nonce...)
+	return aead.Seal(dst, nonce, plaintext, ad), nil
+}
+
+// open opens a nonce-prefixed value produced by seal.
+func open(aead cipher.AEAD, ciphertext, ad []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, ad)
+}
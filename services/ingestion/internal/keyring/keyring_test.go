@@ -0,0 +1,124 @@
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func testKeyring(t *testing.T) *Keyring {
+	t.Helper()
+	k, err := New(map[int][]byte{
+		1: bytes.Repeat([]byte{1}, KeySize),
+		2: bytes.Repeat([]byte{2}, KeySize),
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	k := testKeyring(t)
+	plaintext := []byte(`{"raw":{"ssn":"078-05-1120"}}`)
+	aad := []byte("evt-1")
+
+	version, ciphertext, err := k.Encrypt(plaintext, aad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 2 {
+		t.Fatalf("Encrypt() version = %d, want 2", version)
+	}
+	if bytes.Contains(ciphertext, []byte("078-05-1120")) {
+		t.Fatal("ciphertext contains the plaintext")
+	}
+
+	flipped := func(i int) []byte {
+		c := bytes.Clone(ciphertext)
+		c[i] ^= 0x01
+		return c
+	}
+	tests := []struct {
+		name        string
+		version     int
+		ciphertext  []byte
+		aad         []byte
+		wantErr     bool
+		wantUnknown bool
+	}{
+		{name: "round trip", version: version, ciphertext: ciphertext, aad: aad},
+		{name: "flipped byte in the wrapped key", version: version, ciphertext: flipped(len(boundPrefix) + 20), aad: aad, wantErr: true},
+		{name: "flipped byte in the payload", version: version, ciphertext: flipped(len(ciphertext) - 20), aad: aad, wantErr: true},
+		{name: "flipped last byte", version: version, ciphertext: flipped(len(ciphertext) - 1), aad: aad, wantErr: true},
+		{name: "another event's ID", version: version, ciphertext: ciphertext, aad: []byte("evt-2"), wantErr: true},
+		{name: "no associated data", version: version, ciphertext: ciphertext, wantErr: true},
+		{name: "another key version", version: 1, ciphertext: ciphertext, aad: aad, wantErr: true},
+		{name: "unknown version", version: 3, ciphertext: ciphertext, aad: aad, wantErr: true, wantUnknown: true},
+		{name: "truncated", version: version, ciphertext: ciphertext[:len(boundPrefix)+8], aad: aad, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := k.Decrypt(tt.version, tt.ciphertext, tt.aad)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decrypt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrUnknownVersion) != tt.wantUnknown {
+				t.Errorf("Decrypt() error = %v, want ErrUnknownVersion %v", err, tt.wantUnknown)
+			}
+			if !tt.wantErr && !bytes.Equal(got, plaintext) {
+				t.Errorf("Decrypt() = %q, want %q", got, plaintext)
+			}
+		})
+	}
+}
+
+func TestDecryptLegacy(t *testing.T) {
+	k := testKeyring(t)
+	plaintext := []byte(`{"raw":{"n":1}}`)
+	master := k.aeads[1]
+
+	direct, err := seal(master, nil, plaintext, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataKey := bytes.Repeat([]byte{9}, KeySize)
+	data, err := newAEAD(dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope, err := seal(master, bytes.Clone(legacyPrefix), dataKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if envelope, err = seal(data, envelope, plaintext, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		ciphertext []byte
+	}{
+		{name: "sealed directly with the key", ciphertext: direct},
+		{name: "envelope without associated data", ciphertext: envelope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, aad := range [][]byte{nil, []byte("evt-1")} {
+				got, err := k.Decrypt(1, tt.ciphertext, aad)
+				if err != nil {
+					t.Fatalf("Decrypt(aad %q) error = %v", aad, err)
+				}
+				if !bytes.Equal(got, plaintext) {
+					t.Errorf("Decrypt(aad %q) = %q, want %q", aad, got, plaintext)
+				}
+			}
+
+			tampered := bytes.Clone(tt.ciphertext)
+			tampered[len(tampered)-1] ^= 0x01
+			if _, err := k.Decrypt(1, tampered, nil); err == nil {
+				t.Error("Decrypt() of a tampered legacy ciphertext succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKeyIsAdmin is the gin context key marking an admin-authenticated request.
+const ContextKeyIsAdmin = "is_admin"
+
+// AdminKeyHeader carries the static admin API key.
+const AdminKeyHeader = "X-Admin-Key"
+
+// AdminKey returns a middleware that marks the request as admin when it
+// presents the configured admin API key. An empty key disables the check,
+// so no request can become admin this way.
+func AdminKey(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		presented := c.GetHeader(AdminKeyHeader)
+		if key != "" && presented != "" &&
+			subtle.ConstantTimeCompare([]byte(presented), []byte(key)) == 1 {
+			c.Set(ContextKeyIsAdmin, true)
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin returns a middleware that rejects requests not marked as admin.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool(ContextKeyIsAdmin) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "forbidden",
+				"message": "Admin access required",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireAdminScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	token := func(scope string) string {
+		claims := map[string]any{"sub": testUserID, "exp": time.Now().Add(time.Hour).Unix()}
+		if scope != "" {
+			claims["scope"] = scope
+		}
+		return "Bearer " + signToken(t, "HS256", testSecret, claims)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		adminKey      string
+		wantStatus    int
+		wantError     string
+	}{
+		{name: "admin scope", authorization: token("admin"), wantStatus: http.StatusOK},
+		{name: "admin among other scopes", authorization: token("events:read admin events:write"), wantStatus: http.StatusOK},
+		{name: "admin key", adminKey: "key", wantStatus: http.StatusOK},
+		{name: "no scope", authorization: token(""), wantStatus: http.StatusForbidden, wantError: "forbidden"},
+		{name: "other scopes", authorization: token("events:read events:write"), wantStatus: http.StatusForbidden, wantError: "forbidden"},
+		{name: "scope prefix", authorization: token("administrator"), wantStatus: http.StatusForbidden, wantError: "forbidden"},
+		{name: "wrong admin key", adminKey: "other", authorization: token("read"), wantStatus: http.StatusForbidden, wantError: "forbidden"},
+		{name: "no token", wantStatus: http.StatusUnauthorized, wantError: "unauthorized"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(AdminKey("key"), AuthJWT(testSecret, testClock))
+			r.GET("/admin/events", RequireAdmin(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/events", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.adminKey != "" {
+				req.Header.Set(AdminKeyHeader, tt.adminKey)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantError != "" {
+				var resp struct {
+					Error string `json:"error"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &resp)
+				if resp.Error != tt.wantError {
+					t.Errorf("error = %q, want %q", resp.Error, tt.wantError)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminActingForUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userToken := "Bearer " + signToken(t, "HS256", testSecret, map[string]any{"sub": testUserID, "exp": time.Now().Add(time.Hour).Unix()})
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantUserID    string
+	}{
+		{name: "admin key alone", wantStatus: http.StatusOK},
+		{name: "admin key with a user token", authorization: userToken, wantStatus: http.StatusOK, wantUserID: testUserID},
+		{name: "admin key with an invalid token", authorization: "Bearer not-a-token", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID string
+			r := gin.New()
+			r.Use(AdminKey("key"), AuthJWT(testSecret, testClock))
+			r.POST("/ingest", func(c *gin.Context) {
+				userID = c.GetString(ContextKeyUserID)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/ingest", nil)
+			req.Header.Set(AdminKeyHeader, "key")
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if userID != tt.wantUserID {
+				t.Errorf("user ID = %q, want %q", userID, tt.wantUserID)
+			}
+		})
+	}
+}
+
+func TestRequireUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userToken := "Bearer " + signToken(t, "HS256", testSecret, map[string]any{"sub": testUserID, "exp": time.Now().Add(time.Hour).Unix()})
+
+	tests := []struct {
+		name          string
+		authorization string
+		adminKey      bool
+		wantStatus    int
+	}{
+		{name: "user token", authorization: userToken, wantStatus: http.StatusOK},
+		{name: "admin key with a user token", authorization: userToken, adminKey: true, wantStatus: http.StatusOK},
+		{name: "admin key alone", adminKey: true, wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			r := gin.New()
+			r.Use(AdminKey("key"), AuthJWT(testSecret, testClock))
+			r.POST("/ingest", RequireUser(), func(c *gin.Context) {
+				reached = true
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/ingest", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.adminKey {
+				req.Header.Set(AdminKeyHeader, "key")
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if reached != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("handler reached = %v, want %v", reached, tt.wantStatus == http.StatusOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/admission"
+)
+
+// FairAdmission returns a middleware that admits requests through the
+// scheduler, queueing them per tenant when the concurrency budget is spent.
+// Requests that cannot be admitted within timeout are rejected with 503.
+// Event streams are exempt, since they would hold a slot for their lifetime.
+func FairAdmission(s *admission.Scheduler, timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		release, err := s.Acquire(ctx, TenantID(c))
+		cancel()
+		if err != nil {
+			message := "Server is busy, retry later"
+			if errors.Is(err, admission.ErrQueueFull) {
+				message = "Too many requests waiting for this tenant, retry later"
+			}
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "overloaded",
+				"message": message,
+			})
+			return
+		}
+		defer release()
+
+		c.Next()
+	}
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/timecheck"
+)
+
+const (
+	testSecret = "test-secret"
+	testUserID = "6f1c8a52-3d4e-4b7a-9c1d-2e3f4a5b6c7d"
+)
+
+var testClock = timecheck.New(time.Minute)
+
+// signToken builds a compact JWS of claims with the given alg, signed with
+// secret using HS256.
+func signToken(t *testing.T, alg, secret string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthJWT(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Now()
+	valid := map[string]any{"sub": testUserID, "exp": now.Add(time.Hour).Unix()}
+	with := func(extra map[string]any) map[string]any {
+		claims := map[string]any{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		for k, v := range extra {
+			claims[k] = v
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		preAdmin      bool
+		wantStatus    int
+		wantError     string
+		wantTenant    string
+		wantAdmin     bool
+	}{
+		{name: "valid token", authorization: "Bearer " + signToken(t, "HS256", testSecret, valid), wantStatus: http.StatusOK},
+		{name: "tenant and admin scope", authorization: "Bearer " + signToken(t, "HS256", testSecret, with(map[string]any{"tenant_id": "acme", "scope": "read admin"})), wantStatus: http.StatusOK, wantTenant: "acme", wantAdmin: true},
+		{name: "missing header", wantStatus: http.StatusUnauthorized, wantError: "unauthorized"},
+		{name: "basic scheme", authorization: "Basic dXNlcjpwYXNz", wantStatus: http.StatusUnauthorized, wantError: "unauthorized"},
+		{name: "empty bearer", authorization: "Bearer ", wantStatus: http.StatusUnauthorized, wantError: "unauthorized"},
+		{name: "not a JWS", authorization: "Bearer abc.def", wantStatus: http.StatusUnauthorized, wantError: "invalid_token"},
+		{name: "garbage segments", authorization: "Bearer !!.??.**", wantStatus: http.StatusUnauthorized, wantError: "invalid_token"},
+		{name: "wrong signature", authorization: "Bearer " + signToken(t, "HS256", "other-secret", valid), wantStatus: http.StatusUnauthorized, wantError: "invalid_token"},
+		{name: "alg none", authorization: "Bearer " + signToken(t, "none", testSecret, valid), wantStatus: http.StatusUnauthorized, wantError: "invalid_token"},
+		{name: "expired", authorization: "Bearer " + signToken(t, "HS256", testSecret, with(map[string]any{"exp": now.Add(-time.Minute).Unix()})), wantStatus: http.StatusUnauthorized, wantError: "invalid_token"},
+		{name: "no exp", authorization: "Bearer " + signToken(t, "HS256", testSecret, map[string]any{"sub": testUserID}), wantStatus: http.StatusUnauthorized, wantError: "invalid_token"},
+		{name: "not yet valid", authorization: "Bearer " + signToken(t, "HS256", testSecret, with(map[string]any{"nbf": now.Add(time.Hour).Unix()})), wantStatus: http.StatusUnauthorized, wantError: "invalid_token"},
+		{name: "sub not a user ID", authorization: "Bearer " + signToken(t, "HS256", testSecret, with(map[string]any{"sub": "alice"})), wantStatus: http.StatusUnauthorized, wantError: "invalid_token"},
+		{name: "admin key without token", preAdmin: true, wantStatus: http.StatusOK, wantAdmin: true},
+		{name: "admin key with a bad token", preAdmin: true, authorization: "Bearer abc.def.ghi", wantStatus: http.StatusUnauthorized, wantError: "invalid_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var userID, tenant string
+			var admin bool
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.preAdmin {
+					c.Set(ContextKeyIsAdmin, true)
+				}
+			})
+			r.Use(AuthJWT(testSecret, testClock))
+			r.GET("/", func(c *gin.Context) {
+				userID = c.GetString(ContextKeyUserID)
+				tenant = c.GetString(ContextKeyTenantID)
+				admin = c.GetBool(ContextKeyIsAdmin)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantError != "" {
+				var resp struct {
+					Error string `json:"error"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &resp)
+				if resp.Error != tt.wantError {
+					t.Errorf("error = %q, want %q", resp.Error, tt.wantError)
+				}
+				if w.Header().Get("WWW-Authenticate") == "" {
+					t.Error("WWW-Authenticate is not set")
+				}
+				return
+			}
+			if !tt.preAdmin && userID != testUserID {
+				t.Errorf("user_id = %q, want %q", userID, testUserID)
+			}
+			if tenant != tt.wantTenant || admin != tt.wantAdmin {
+				t.Errorf("tenant = %q, admin = %v; want %q, %v", tenant, admin, tt.wantTenant, tt.wantAdmin)
+			}
+		})
+	}
+}
+
+func TestVerifyTokenTimeBoundaries(t *testing.T) {
+	const skew = time.Minute
+	exp := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	nbf := exp.Add(-time.Hour)
+	token := signToken(t, "HS256", testSecret, map[string]any{"sub": testUserID, "exp": exp.Unix(), "nbf": nbf.Unix()})
+
+	tests := []struct {
+		name    string
+		skew    time.Duration
+		now     time.Time
+		wantErr string
+	}{
+		{name: "a second before exp", skew: skew, now: exp.Add(-time.Second)},
+		{name: "at exp", skew: skew, now: exp},
+		{name: "within skew after exp", skew: skew, now: exp.Add(skew / 2)},
+		{name: "exactly skew after exp", skew: skew, now: exp.Add(skew)},
+		{name: "just past skew after exp", skew: skew, now: exp.Add(skew + time.Second), wantErr: "token has expired"},
+		{name: "at nbf", skew: skew, now: nbf},
+		{name: "exactly skew before nbf", skew: skew, now: nbf.Add(-skew)},
+		{name: "just past skew before nbf", skew: skew, now: nbf.Add(-skew - time.Second), wantErr: "token is not valid yet"},
+		{name: "after exp without skew", now: exp.Add(time.Second), wantErr: "token has expired"},
+		{name: "before nbf without skew", now: nbf.Add(-time.Second), wantErr: "token is not valid yet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := timecheck.New(tt.skew).WithNow(func() time.Time { return tt.now })
+			_, err := verifyToken(token, []byte(testSecret), clock)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("verifyToken() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("verifyToken() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxBodySize returns a middleware that limits request bodies to limit
+// bytes. A declared Content-Length over the limit is rejected with 413
+// before anything is read; an undeclared or understated one fails the read
+// once the limit is passed. A limit of 0 is unlimited.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limit <= 0 || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			abortBodyTooLarge(c, limit)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// bodyTooLarge reports whether reading the body failed because it passed
+// the MaxBodySize limit, and if so rejects the request.
+func bodyTooLarge(c *gin.Context, err error) bool {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	abortBodyTooLarge(c, tooLarge.Limit)
+	return true
+}
+
+func abortBodyTooLarge(c *gin.Context, limit int64) {
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+		"error":   "payload_too_large",
+		"message": fmt.Sprintf("Request body is over the %d byte limit", limit),
+	})
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const limit = 100
+
+	tests := []struct {
+		name       string
+		limit      int64
+		size       int
+		undeclared bool
+		wantStatus int
+	}{
+		{name: "just under the limit", limit: limit, size: limit - 1, wantStatus: http.StatusOK},
+		{name: "at the limit", limit: limit, size: limit, wantStatus: http.StatusOK},
+		{name: "just over the limit", limit: limit, size: limit + 1, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "undeclared length at the limit", limit: limit, size: limit, undeclared: true, wantStatus: http.StatusOK},
+		{name: "undeclared length just over the limit", limit: limit, size: limit + 1, undeclared: true, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "unlimited", size: 10 * limit, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(MaxBodySize(tt.limit), UTF8Body(InvalidUTF8Reject, discardLogger()))
+			r.POST("/", func(c *gin.Context) {
+				b, _ := io.ReadAll(c.Request.Body)
+				if len(b) != tt.size {
+					t.Errorf("handler read %d bytes, want %d", len(b), tt.size)
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("x", tt.size)))
+			if tt.undeclared {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code == http.StatusRequestEntityTooLarge && !strings.Contains(w.Body.String(), `"payload_too_large"`) {
+				t.Errorf("body = %s, want payload_too_large", w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/capture"
+	"github.com/uigs/ingestion/internal/redact"
+)
+
+// CaptureHeader lets a debug-flagged client opt into capture with a key
+// that an admin has armed.
+const CaptureHeader = "X-Debug-Capture"
+
+// captureWriter tees the response body into a buffer of up to limit
+// bytes. A longer body is dropped from the buffer, since it could not be
+// redacted whole.
+type captureWriter struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	limit    int64
+	overflow bool
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if !w.overflow {
+		if int64(w.buf.Len()+len(b)) > w.limit {
+			w.overflow = true
+			w.buf = bytes.Buffer{}
+		} else {
+			w.buf.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so
+// handlers can still clear write deadlines and flush through a capture.
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Capture returns a middleware that records the raw request and response of
+// requests whose user ID or capture header matches an armed key. Secrets in
+// headers and JSON bodies are redacted with redactor before the capture is
+// stored, and bodies are then cut to maxBodyBytes. Bodies longer than
+// maxReadBytes cannot be redacted whole and are left out of the capture;
+// the request body is still passed on to the handler in full.
+func Capture(store *capture.Store, maxBodyBytes int, maxReadBytes int64, redactor *redact.Redactor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := store.Claim(c.GetString(ContextKeyUserID), c.GetHeader(CaptureHeader))
+		if !ok {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		var reqBody []byte
+		reqOverflow := false
+		if c.Request.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxReadBytes+1))
+			if err != nil {
+				c.Error(err)
+			}
+			c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+			if int64(len(body)) > maxReadBytes {
+				reqOverflow = true
+			} else {
+				reqBody = body
+			}
+		}
+
+		writer := &captureWriter{ResponseWriter: c.Writer, limit: maxReadBytes}
+		c.Writer = writer
+
+		c.Next()
+
+		reqCaptured, reqTruncated := captureBody(redactor, reqBody, maxBodyBytes)
+		respCaptured, respTruncated := captureBody(redactor, writer.buf.Bytes(), maxBodyBytes)
+
+		store.Add(capture.Capture{
+			Key:            key,
+			Method:         c.Request.Method,
+			Path:           c.Request.URL.Path,
+			Query:          c.Request.URL.RawQuery,
+			RemoteAddress:  c.ClientIP(),
+			RequestHeader:  redact.Headers(c.Request.Header),
+			RequestBody:    reqCaptured,
+			Status:         writer.Status(),
+			ResponseHeader: redact.Headers(writer.Header()),
+			ResponseBody:   respCaptured,
+			Truncated:      reqOverflow || reqTruncated || writer.overflow || respTruncated,
+			LatencyMillis:  time.Since(start).Milliseconds(),
+			CapturedAt:     start.UTC(),
+		})
+	}
+}
+
+// captureBody redacts body and then cuts it to limit bytes, reporting
+// whether it was cut.
+func captureBody(redactor *redact.Redactor, body []byte, limit int) (string, bool) {
+	redacted := redactor.JSON(body)
+	if len(redacted) > limit {
+		return string(redacted[:limit]), true
+	}
+	return string(redacted), false
+}
+
+// readCloser reads from a reader and closes the original body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/capture"
+	"github.com/uigs/ingestion/internal/redact"
+)
+
+func TestCaptureRedactsBeforeTruncating(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	long := `{"password":"hunter2","proof":{"proofValue":"z58DAdFfa9"},"padding":"` + strings.Repeat("x", 200) + `"}`
+	tests := []struct {
+		name          string
+		body          string
+		maxBodyBytes  int
+		maxReadBytes  int64
+		wantBody      string
+		wantOmitted   bool
+		wantTruncated bool
+	}{
+		{
+			name:         "short body is redacted whole",
+			body:         `{"password":"hunter2"}`,
+			maxBodyBytes: 1024,
+			maxReadBytes: 1024,
+			wantBody:     `{"password":"[REDACTED]"}`,
+		},
+		{
+			name:          "body over the capture limit is redacted then cut",
+			body:          long,
+			maxBodyBytes:  64,
+			maxReadBytes:  1024,
+			wantTruncated: true,
+		},
+		{
+			name:          "body over the read limit is left out",
+			body:          long,
+			maxBodyBytes:  64,
+			maxReadBytes:  100,
+			wantOmitted:   true,
+			wantTruncated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := capture.NewStore(time.Minute, 10)
+			store.Arm("user-1", 1)
+
+			var handlerBody string
+			r := gin.New()
+			r.Use(func(c *gin.Context) { c.Set(ContextKeyUserID, "user-1") })
+			r.Use(Capture(store, tt.maxBodyBytes, tt.maxReadBytes, redact.New(nil)))
+			r.POST("/", func(c *gin.Context) {
+				b, _ := io.ReadAll(c.Request.Body)
+				handlerBody = string(b)
+				c.Data(http.StatusOK, "application/json", b)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+
+			if handlerBody != tt.body {
+				t.Fatalf("handler read %d bytes, want the full %d", len(handlerBody), len(tt.body))
+			}
+			captures, _ := store.List()
+			if len(captures) != 1 {
+				t.Fatalf("got %d captures, want 1", len(captures))
+			}
+			got := captures[0]
+			for _, body := range []string{got.RequestBody, got.ResponseBody} {
+				if strings.Contains(body, "hunter2") || strings.Contains(body, "z58DAdFfa9") {
+					t.Errorf("captured body leaks a secret: %s", body)
+				}
+				if len(body) > tt.maxBodyBytes {
+					t.Errorf("captured body is %d bytes, over the %d byte limit", len(body), tt.maxBodyBytes)
+				}
+				if tt.wantBody != "" && body != tt.wantBody {
+					t.Errorf("captured body = %s, want %s", body, tt.wantBody)
+				}
+			}
+			if tt.wantOmitted && (got.RequestBody != "" || got.ResponseBody != "") {
+				t.Errorf("bodies over the read limit were captured: %q, %q", got.RequestBody, got.ResponseBody)
+			}
+			if got.Truncated != tt.wantTruncated {
+				t.Errorf("Truncated = %v, want %v", got.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
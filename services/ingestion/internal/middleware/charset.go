@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handling of request bodies that are not valid UTF-8.
+const (
+	// InvalidUTF8Reject rejects the request with 422.
+	InvalidUTF8Reject = "reject"
+	// InvalidUTF8Sanitize replaces each invalid sequence with U+FFFD.
+	InvalidUTF8Sanitize = "sanitize"
+)
+
+// UTF8Body returns a middleware that makes sure a JSON request body is valid
+// UTF-8 before it is decoded. Bodies declared as ISO-8859-1 in the
+// Content-Type charset are transcoded to UTF-8; other non-UTF-8 charsets are
+// rejected with 415. Undeclared invalid byte sequences, typically Latin-1
+// text sent as UTF-8, are rejected with 422 naming the byte offset, or
+// replaced in sanitize mode.
+func UTF8Body(mode string, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		charset := "utf-8"
+		mediaType, params, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err == nil && params["charset"] != "" {
+			charset = strings.ToLower(params["charset"])
+		}
+		switch charset {
+		case "utf-8", "utf8", "us-ascii", "iso-8859-1", "latin1":
+		default:
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+				"error":   "unsupported_charset",
+				"message": "Request body charset must be UTF-8 or ISO-8859-1, got " + charset,
+			})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil && bodyTooLarge(c, err) {
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_request",
+				"message": "Failed to read request body",
+			})
+			return
+		}
+
+		if charset == "iso-8859-1" || charset == "latin1" {
+			body = latin1ToUTF8(body)
+			// The body is UTF-8 now, also for a replica forwarding it
+			params["charset"] = "utf-8"
+			c.Request.Header.Set("Content-Type", mime.FormatMediaType(mediaType, params))
+		} else if offset := invalidUTF8Offset(body); offset >= 0 {
+			if mode != InvalidUTF8Sanitize {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{
+					"error":   "invalid_encoding",
+					"message": "Request body is not valid UTF-8; declare charset=iso-8859-1 for Latin-1 payloads",
+					"offset":  offset,
+				})
+				return
+			}
+			logger.Warn("Replaced invalid UTF-8 in request body",
+				"path", c.FullPath(),
+				"offset", offset,
+			)
+			body = bytes.ToValidUTF8(body, []byte(string(utf8.RuneError)))
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Request.ContentLength = int64(len(body))
+		c.Next()
+	}
+}
+
+// invalidUTF8Offset returns the byte offset of the first invalid UTF-8
+// sequence in b, or -1 if b is valid.
+func invalidUTF8Offset(b []byte) int {
+	if utf8.Valid(b) {
+		return -1
+	}
+	for i := 0; i < len(b); {
+		r, size := utf8.DecodeRune(b[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
+// latin1ToUTF8 transcodes ISO-8859-1 text, where every byte is the code
+// point of the same value, to UTF-8.
+func latin1ToUTF8(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for _, c := range b {
+		out = utf8.AppendRune(out, rune(c))
+	}
+	return out
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUTF8Body(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		mode        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    string
+		wantOffset  int
+	}{
+		{name: "valid UTF-8", mode: InvalidUTF8Reject, contentType: "application/json", body: `{"name":"Zoë"}`, wantStatus: http.StatusOK, wantBody: `{"name":"Zoë"}`},
+		{name: "Latin-1 sent as UTF-8 is rejected", mode: InvalidUTF8Reject, contentType: "application/json", body: "{\"name\":\"Zo\xeb\"}", wantStatus: http.StatusUnprocessableEntity, wantOffset: 11},
+		{name: "truncated sequence is rejected", mode: InvalidUTF8Reject, contentType: "application/json", body: "{\"a\":\"\xe2\x82\"}", wantStatus: http.StatusUnprocessableEntity, wantOffset: 6},
+		{name: "sanitize replaces invalid bytes", mode: InvalidUTF8Sanitize, contentType: "application/json", body: "{\"name\":\"Zo\xeb\"}", wantStatus: http.StatusOK, wantBody: `{"name":"Zo` + "�" + `"}`},
+		{name: "declared Latin-1 is transcoded", mode: InvalidUTF8Reject, contentType: "application/json; charset=ISO-8859-1", body: "{\"name\":\"Zo\xeb\"}", wantStatus: http.StatusOK, wantBody: `{"name":"Zoë"}`},
+		{name: "other charsets are unsupported", mode: InvalidUTF8Reject, contentType: "application/json; charset=utf-16", body: `{}`, wantStatus: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got, gotContentType string
+			r := gin.New()
+			r.Use(UTF8Body(tt.mode, discardLogger()))
+			r.POST("/", func(c *gin.Context) {
+				b, _ := io.ReadAll(c.Request.Body)
+				got = string(b)
+				gotContentType = c.GetHeader("Content-Type")
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusUnprocessableEntity {
+				var resp struct {
+					Error  string `json:"error"`
+					Offset int    `json:"offset"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Error != "invalid_encoding" || resp.Offset != tt.wantOffset {
+					t.Errorf("body = %s, want invalid_encoding at offset %d", w.Body, tt.wantOffset)
+				}
+			}
+			if tt.wantBody != "" && got != tt.wantBody {
+				t.Errorf("handler read %q, want %q", got, tt.wantBody)
+			}
+			if strings.Contains(tt.contentType, "ISO-8859-1") && gotContentType != "application/json; charset=utf-8" {
+				t.Errorf("Content-Type = %q after transcoding", gotContentType)
+			}
+		})
+	}
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Decompress returns a middleware that inflates request bodies sent with
+// Content-Encoding: gzip, so handlers and later body limits see the
+// decompressed bytes. At most limit decompressed bytes are read, whatever
+// the route, so a small compressed body cannot expand without bound; 0 is
+// unlimited. Other encodings are rejected with 415.
+func Decompress(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+		switch {
+		case encoding == "" || encoding == "identity" || c.Request.Body == nil:
+			c.Next()
+			return
+		case encoding != "gzip" && encoding != "x-gzip":
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+				"error":   "unsupported_encoding",
+				"message": "Request bodies may only be gzip encoded",
+			})
+			return
+		}
+
+		zr, err := gzip.NewReader(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "invalid_encoding",
+				"message": "Request body is not valid gzip",
+			})
+			return
+		}
+		var body io.ReadCloser = zr
+		if limit > 0 {
+			body = http.MaxBytesReader(c.Writer, zr, limit)
+		}
+		c.Request.Body = body
+		// The declared length is the compressed one
+		c.Request.ContentLength = -1
+		c.Request.Header.Del("Content-Length")
+		c.Request.Header.Del("Content-Encoding")
+		c.Next()
+	}
+}
+
+// Compress returns a middleware that gzips responses of at least minSize
+// bytes for clients sending Accept-Encoding: gzip. Smaller responses,
+// event streams and responses already encoded are sent as they are.
+func Compress(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		w := &gzipWriter{ResponseWriter: c.Writer, minSize: minSize}
+		c.Writer = w
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		defer func() {
+			w.close()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		if _, q, ok := strings.Cut(params, "q="); ok {
+			if v, err := strconv.ParseFloat(strings.TrimSpace(q), 64); err == nil && v == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipWriter buffers the start of a response until it knows whether the
+// body reaches minSize, then either gzips it or passes it through.
+type gzipWriter struct {
+	gin.ResponseWriter
+	minSize int
+
+	buf     bytes.Buffer
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf.Write(data)
+		if w.buf.Len() < w.minSize {
+			return len(data), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.gz != nil {
+		return w.gz.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so
+// handlers can still clear write deadlines and flush through compression.
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush sends what has been written so far; a response flushed before it
+// reaches minSize is sent uncompressed.
+func (w *gzipWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(); err != nil {
+			return
+		}
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide chooses the encoding from the buffered body and writes it out.
+func (w *gzipWriter) decide() error {
+	w.decided = true
+	h := w.Header()
+	compress := w.buf.Len() >= w.minSize &&
+		h.Get("Content-Encoding") == "" &&
+		!strings.HasPrefix(h.Get("Content-Type"), "text/event-stream")
+	if compress {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+		_, err := w.gz.Write(w.buf.Bytes())
+		w.buf.Reset()
+		return err
+	}
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// close writes out a response that stayed under minSize and ends the gzip
+// stream of one that did not.
+func (w *gzipWriter) close() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
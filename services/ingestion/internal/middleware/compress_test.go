@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const limit = 64 << 10
+	ingestBody := []byte(`{"source_type":"vc","payload":{"id":"urn:uuid:1"}}`)
+
+	tests := []struct {
+		name       string
+		body       []byte
+		encoding   string
+		wantStatus int
+		wantError  string
+		wantBody   []byte
+	}{
+		{name: "gzipped ingest request", body: gzipBytes(t, ingestBody), encoding: "gzip", wantStatus: http.StatusOK, wantBody: ingestBody},
+		{name: "x-gzip", body: gzipBytes(t, ingestBody), encoding: "x-gzip", wantStatus: http.StatusOK, wantBody: ingestBody},
+		{name: "identity", body: ingestBody, encoding: "identity", wantStatus: http.StatusOK, wantBody: ingestBody},
+		{name: "unencoded", body: ingestBody, wantStatus: http.StatusOK, wantBody: ingestBody},
+		{name: "unsupported encoding", body: ingestBody, encoding: "br", wantStatus: http.StatusUnsupportedMediaType, wantError: "unsupported_encoding"},
+		{name: "not gzip", body: ingestBody, encoding: "gzip", wantStatus: http.StatusBadRequest, wantError: "invalid_encoding"},
+		{name: "decompression bomb", body: gzipBytes(t, make([]byte, 16*limit)), encoding: "gzip", wantStatus: http.StatusRequestEntityTooLarge, wantError: "payload_too_large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(Decompress(limit), UTF8Body(InvalidUTF8Reject, discardLogger()))
+			r.POST("/api/v1/ingest", func(c *gin.Context) {
+				b, err := io.ReadAll(c.Request.Body)
+				if err != nil {
+					t.Errorf("reading body: %v", err)
+				}
+				if !bytes.Equal(b, tt.wantBody) {
+					t.Errorf("handler read %q, want %q", b, tt.wantBody)
+				}
+				if enc := c.GetHeader("Content-Encoding"); strings.Contains(enc, "gzip") {
+					t.Errorf("Content-Encoding = %q after decompressing, want it removed", enc)
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/ingest", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantError != "" && !strings.Contains(w.Body.String(), `"`+tt.wantError+`"`) {
+				t.Errorf("body = %s, want %s", w.Body, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestCompress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const minSize = 256
+	events := `{"events":[` + strings.Repeat(`{"event_id":"evt","source_type":"vc"},`, 20) + `{}]}`
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		wantGzip       bool
+	}{
+		{name: "gzipped events response", acceptEncoding: "gzip, deflate", contentType: "application/json", body: events, wantGzip: true},
+		{name: "under the minimum size", acceptEncoding: "gzip", contentType: "application/json", body: `{"events":[]}`},
+		{name: "gzip not accepted", acceptEncoding: "deflate", contentType: "application/json", body: events},
+		{name: "gzip refused with q=0", acceptEncoding: "gzip;q=0", contentType: "application/json", body: events},
+		{name: "event stream", acceptEncoding: "gzip", contentType: "text/event-stream", body: events},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(Compress(minSize))
+			r.GET("/api/v1/events", func(c *gin.Context) {
+				c.Data(http.StatusOK, tt.contentType, []byte(tt.body))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/events", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			gotGzip := w.Header().Get("Content-Encoding") == "gzip"
+			if gotGzip != tt.wantGzip {
+				t.Fatalf("gzipped = %v, want %v", gotGzip, tt.wantGzip)
+			}
+			body := w.Body.Bytes()
+			if gotGzip {
+				zr, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatalf("response is not valid gzip: %v", err)
+				}
+				if body, err = io.ReadAll(zr); err != nil {
+					t.Fatalf("reading gzipped response: %v", err)
+				}
+			}
+			if string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireJSON returns a middleware that rejects requests whose Content-Type
+// is not application/json with 415, so form or text bodies are not
+// misread as JSON. Parameters such as charset are allowed.
+func RequireJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{
+				"error":   "unsupported_media_type",
+				"message": "Content-Type must be application/json",
+			})
+			return
+		}
+		c.Next()
+	}
+}
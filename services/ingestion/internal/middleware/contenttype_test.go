@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		contentType string
+		wantStatus  int
+	}{
+		{name: "application/json", contentType: "application/json", wantStatus: http.StatusOK},
+		{name: "with a charset", contentType: "application/json; charset=utf-8", wantStatus: http.StatusOK},
+		{name: "mixed case", contentType: "Application/JSON", wantStatus: http.StatusOK},
+		{name: "missing", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "form data", contentType: "application/x-www-form-urlencoded", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "multipart", contentType: "multipart/form-data; boundary=x", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "plain text", contentType: "text/plain", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "JSON suffix type", contentType: "application/ld+json", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "malformed", contentType: "application/json; charset", wantStatus: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/", RequireJSON(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantStatus == http.StatusUnsupportedMediaType {
+				var resp struct {
+					Error string `json:"error"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &resp)
+				if resp.Error != "unsupported_media_type" {
+					t.Errorf("error = %q, want unsupported_media_type", resp.Error)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		allowed         []string
+		origin          string
+		method          string
+		wantStatus      int
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{name: "allowed origin", allowed: []string{"https://app.example"}, origin: "https://app.example", method: http.MethodGet, wantStatus: http.StatusOK, wantOrigin: "https://app.example", wantCredentials: "true"},
+		{name: "allowed origin configured with a slash", allowed: []string{"https://app.example/"}, origin: "https://app.example", method: http.MethodGet, wantStatus: http.StatusOK, wantOrigin: "https://app.example", wantCredentials: "true"},
+		{name: "disallowed origin", allowed: []string{"https://app.example"}, origin: "https://evil.example", method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "no origins configured", origin: "https://app.example", method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "wildcard", allowed: []string{"*"}, origin: "https://any.example", method: http.MethodGet, wantStatus: http.StatusOK, wantOrigin: "*"},
+		{name: "listed origin beside the wildcard", allowed: []string{"*", "https://app.example"}, origin: "https://app.example", method: http.MethodGet, wantStatus: http.StatusOK, wantOrigin: "https://app.example", wantCredentials: "true"},
+		{name: "preflight from an allowed origin", allowed: []string{"https://app.example"}, origin: "https://app.example", method: http.MethodOptions, wantStatus: http.StatusNoContent, wantOrigin: "https://app.example", wantCredentials: "true"},
+		{name: "preflight from a disallowed origin", allowed: []string{"https://app.example"}, origin: "https://evil.example", method: http.MethodOptions, wantStatus: http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(CORS(tt.allowed))
+			r.Handle(tt.method, "/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Methods"); (got != "") != (tt.wantOrigin != "") {
+				t.Errorf("Access-Control-Allow-Methods = %q for allowed origin %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Vary"); got != "Origin" {
+				t.Errorf("Vary = %q, want Origin", got)
+			}
+		})
+	}
+}
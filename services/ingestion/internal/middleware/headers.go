@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxHeaders returns a middleware that rejects requests with more than
+// maxCount header fields, or whose header names and values add up to more
+// than maxBytes, with 431. A repeated header counts once per value. A limit
+// of 0 is unlimited.
+func MaxHeaders(maxCount, maxBytes int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		count, size := 0, 0
+		for name, values := range c.Request.Header {
+			count += len(values)
+			for _, v := range values {
+				size += len(name) + len(v)
+			}
+		}
+
+		var message string
+		switch {
+		case maxCount > 0 && count > maxCount:
+			message = fmt.Sprintf("Request has %d header fields, over the limit of %d", count, maxCount)
+		case maxBytes > 0 && size > maxBytes:
+			message = fmt.Sprintf("Request headers are over the %d byte limit", maxBytes)
+		default:
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusRequestHeaderFieldsTooLarge, gin.H{
+			"error":   "headers_too_large",
+			"message": message,
+		})
+	}
+}
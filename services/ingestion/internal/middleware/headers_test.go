@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMaxHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const maxCount, maxBytes = 10, 100
+
+	// fields returns n distinct header fields of 5 bytes each
+	fields := func(n int) http.Header {
+		h := http.Header{}
+		for i := 0; i < n; i++ {
+			h.Set(fmt.Sprintf("X-%02d", i), "v")
+		}
+		return h
+	}
+	// padded returns one header field of size bytes
+	padded := func(size int) http.Header {
+		return http.Header{"X-Pad": {strings.Repeat("x", size-len("X-Pad"))}}
+	}
+
+	tests := []struct {
+		name       string
+		maxCount   int
+		maxBytes   int
+		header     http.Header
+		wantStatus int
+	}{
+		{name: "count at the limit", maxCount: maxCount, header: fields(maxCount), wantStatus: http.StatusOK},
+		{name: "count just over the limit", maxCount: maxCount, header: fields(maxCount + 1), wantStatus: http.StatusRequestHeaderFieldsTooLarge},
+		{name: "repeated header counts each value", maxCount: 2, header: http.Header{"Accept": {"a", "b", "c"}}, wantStatus: http.StatusRequestHeaderFieldsTooLarge},
+		{name: "size at the limit", maxBytes: maxBytes, header: padded(maxBytes), wantStatus: http.StatusOK},
+		{name: "size just over the limit", maxBytes: maxBytes, header: padded(maxBytes + 1), wantStatus: http.StatusRequestHeaderFieldsTooLarge},
+		{name: "size summed over fields", maxBytes: maxBytes, header: fields(maxBytes/5 + 1), wantStatus: http.StatusRequestHeaderFieldsTooLarge},
+		{name: "both limits", maxCount: maxCount, maxBytes: maxBytes, header: fields(maxCount), wantStatus: http.StatusOK},
+		{name: "unlimited", header: fields(10 * maxCount), wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			r := gin.New()
+			r.Use(MaxHeaders(tt.maxCount, tt.maxBytes))
+			r.GET("/", func(c *gin.Context) {
+				reached = true
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header = tt.header
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if reached != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("handler reached = %v, want %v", reached, tt.wantStatus == http.StatusOK)
+			}
+			if w.Code == http.StatusRequestHeaderFieldsTooLarge && !strings.Contains(w.Body.String(), `"headers_too_large"`) {
+				t.Errorf("body = %s, want headers_too_large", w.Body)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package middleware
 
 import (
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -38,24 +39,52 @@ func Logger(logger *slog.Logger) gin.HandlerFunc {
 			attrs = append(attrs, "errors", c.Errors.String())
 		}
 
+		ctx := c.Request.Context()
 		switch {
 		case status >= 500:
-			logger.Error("Server error", attrs...)
+			logger.ErrorContext(ctx, "Server error", attrs...)
 		case status >= 400:
-			logger.Warn("Client error", attrs...)
+			logger.WarnContext(ctx, "Client error", attrs...)
 		default:
-			logger.Info("Request completed", attrs...)
+			logger.InfoContext(ctx, "Request completed", attrs...)
 		}
 	}
 }
 
-// CORS returns a middleware that adds CORS headers.
-func CORS() gin.HandlerFunc {
+// CORS returns a middleware that adds CORS headers for the allowed origins.
+// A request's Origin is echoed back, with credentials allowed, only when it
+// is in the list. An "*" entry allows any origin without credentials.
+// Requests from other origins get no CORS headers, so browsers block them.
+func CORS(allowedOrigins []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(allowedOrigins))
+	wildcard := false
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			wildcard = true
+			continue
+		}
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
-		c.Header("Access-Control-Max-Age", "86400")
+		origin := c.GetHeader("Origin")
+		c.Header("Vary", "Origin")
+		corsAllowed := true
+		switch {
+		case origin != "" && allowed[origin]:
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Allow-Credentials", "true")
+		case wildcard:
+			c.Header("Access-Control-Allow-Origin", "*")
+		default:
+			corsAllowed = false
+		}
+		if corsAllowed {
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Encoding, Authorization, X-Request-ID")
+			c.Header("Access-Control-Expose-Headers", "X-Request-ID")
+			c.Header("Access-Control-Max-Age", "86400")
+		}
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
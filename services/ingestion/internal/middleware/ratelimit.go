@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit returns a middleware that allows each client IP at most limit
+// requests per window. Further requests are rejected with 429 until the
+// window rolls over.
+func RateLimit(limit int, window time.Duration) gin.HandlerFunc {
+	type bucket struct {
+		start time.Time
+		count int
+	}
+	var (
+		mu      sync.Mutex
+		buckets = make(map[string]*bucket)
+	)
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		key := c.ClientIP()
+
+		mu.Lock()
+		for k, b := range buckets {
+			if now.Sub(b.start) >= window {
+				delete(buckets, k)
+			}
+		}
+		b, ok := buckets[key]
+		if !ok {
+			b = &bucket{start: now}
+			buckets[key] = b
+		}
+		b.count++
+		allowed := b.count <= limit
+		retryAfter := window - now.Sub(b.start)
+		mu.Unlock()
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":   "rate_limited",
+				"message": "Too many requests, retry later",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request's correlation ID.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds a client-supplied request ID.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestID returns a middleware that gives each request a correlation ID:
+// the client's X-Request-ID when it is a short printable string, else a new
+// UUID. The ID is echoed in the response header and carried in the request
+// context, where RequestIDFromContext and ContextLogHandler find it.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+			// Requests forwarded to another region keep the same ID
+			c.Request.Header.Set(RequestIDHeader, id)
+		}
+
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDFromContext returns the request ID carried by ctx, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextLogHandler adds the request ID of the context to each record.
+type contextLogHandler struct {
+	slog.Handler
+}
+
+// ContextLogHandler wraps next so records logged with a request context,
+// such as by Logger.InfoContext, carry its request_id.
+func ContextLogHandler(next slog.Handler) slog.Handler {
+	return contextLogHandler{Handler: next}
+}
+
+func (h contextLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextLogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextLogHandler) WithGroup(name string) slog.Handler {
+	return contextLogHandler{Handler: h.Handler.WithGroup(name)}
+}
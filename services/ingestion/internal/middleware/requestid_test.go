@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		header    string
+		wantID    string
+		generated bool
+	}{
+		{name: "no header", generated: true},
+		{name: "client ID", header: "req-abc-123", wantID: "req-abc-123"},
+		{name: "ID with spaces", header: "not valid", generated: true},
+		{name: "ID over the length limit", header: strings.Repeat("a", maxRequestIDLength+1), generated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := slog.New(ContextLogHandler(slog.NewJSONHandler(&logs, nil)))
+
+			var handlerID string
+			r := gin.New()
+			r.Use(RequestID(), Logger(logger))
+			r.GET("/", func(c *gin.Context) {
+				handlerID = RequestIDFromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			if tt.generated {
+				if _, err := uuid.Parse(id); err != nil {
+					t.Fatalf("%s = %q, want a generated UUID", RequestIDHeader, id)
+				}
+			} else if id != tt.wantID {
+				t.Fatalf("%s = %q, want %q", RequestIDHeader, id, tt.wantID)
+			}
+			if handlerID != id {
+				t.Errorf("RequestIDFromContext() = %q, want %q", handlerID, id)
+			}
+
+			var record struct {
+				Msg       string `json:"msg"`
+				RequestID string `json:"request_id"`
+			}
+			if err := json.Unmarshal(logs.Bytes(), &record); err != nil {
+				t.Fatalf("log record %q: %v", logs.String(), err)
+			}
+			if record.RequestID != id {
+				t.Errorf("logged request_id = %q, want %q", record.RequestID, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// ContextKeyTenantID is the gin context key holding the caller's tenant.
+const ContextKeyTenantID = "tenant_id"
+
+// DefaultTenant is the tenant of requests that carry no tenant_id.
+const DefaultTenant = "default"
+
+// TenantID returns the caller's tenant, or DefaultTenant when none was set.
+func TenantID(c *gin.Context) string {
+	if tenant := c.GetString(ContextKeyTenantID); tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestTimeout returns a middleware that gives each request a context
+// deadline of timeout, which database queries and publishes made for it
+// observe. When the deadline passes before the handler produced a
+// successful response, that response is discarded and the request gets 503
+// with a timeout error instead; work that completed is still reported. Event
+// streams and the routes in exempt, such as long exports, run without a
+// deadline.
+func RequestTimeout(timeout time.Duration, exempt ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.Contains(c.GetHeader("Accept"), "text/event-stream") || slices.Contains(exempt, c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		writer := &heldWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		failed := writer.buf.Len() == 0 || c.Writer.Status() >= http.StatusInternalServerError
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && failed {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{
+				"error":   "timeout",
+				"message": "Request did not complete within " + timeout.String(),
+			})
+			return
+		}
+		if writer.buf.Len() > 0 {
+			writer.ResponseWriter.Write(writer.buf.Bytes())
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const timeout = 20 * time.Millisecond
+
+	// slow waits out the deadline as a blocked query would, then reports
+	// the failure the way a handler does
+	slow := func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "storage_error"})
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		accept       string
+		handler      gin.HandlerFunc
+		wantStatus   int
+		wantError    string
+		wantDeadline bool
+	}{
+		{
+			name:         "fast handler",
+			path:         "/events",
+			handler:      func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) },
+			wantStatus:   http.StatusOK,
+			wantDeadline: true,
+		},
+		{
+			name:         "slow handler whose query is canceled",
+			path:         "/events",
+			handler:      slow,
+			wantStatus:   http.StatusServiceUnavailable,
+			wantError:    "timeout",
+			wantDeadline: true,
+		},
+		{
+			name: "slow handler that writes nothing",
+			path: "/events",
+			handler: func(c *gin.Context) {
+				<-c.Request.Context().Done()
+			},
+			wantStatus:   http.StatusServiceUnavailable,
+			wantError:    "timeout",
+			wantDeadline: true,
+		},
+		{
+			name: "work completed after the deadline",
+			path: "/events",
+			handler: func(c *gin.Context) {
+				<-c.Request.Context().Done()
+				c.JSON(http.StatusCreated, gin.H{"event_id": "evt-1"})
+			},
+			wantStatus:   http.StatusCreated,
+			wantDeadline: true,
+		},
+		{
+			name:       "event stream",
+			path:       "/events",
+			accept:     "text/event-stream",
+			handler:    func(c *gin.Context) { c.Status(http.StatusOK) },
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "exempt route",
+			path:       "/export",
+			handler:    func(c *gin.Context) { c.Status(http.StatusOK) },
+			wantStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hasDeadline bool
+			var ctxErr error
+			r := gin.New()
+			r.Use(RequestTimeout(timeout, "/export"))
+			r.GET(tt.path, func(c *gin.Context) {
+				_, hasDeadline = c.Request.Context().Deadline()
+				tt.handler(c)
+				ctxErr = c.Request.Context().Err()
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if hasDeadline != tt.wantDeadline {
+				t.Errorf("handler context has deadline = %v, want %v", hasDeadline, tt.wantDeadline)
+			}
+			if tt.wantError == "" {
+				return
+			}
+			if !errors.Is(ctxErr, context.DeadlineExceeded) {
+				t.Errorf("handler context error = %v, want %v", ctxErr, context.DeadlineExceeded)
+			}
+			var resp struct {
+				Error string `json:"error"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if resp.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", resp.Error, tt.wantError)
+			}
+		})
+	}
+}
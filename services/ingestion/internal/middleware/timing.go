@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"bytes"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// heldWriter buffers the response body so it can be released later.
+type heldWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *heldWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+func (w *heldWriter) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so
+// handlers can still clear write deadlines and flush through a held response.
+func (w *heldWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// ContextKeyPadResponse is the gin context key marking a request whose
+// response MinResponseTime holds.
+const ContextKeyPadResponse = "pad_response"
+
+// PadResponse marks the request as having reached a path whose latency
+// could reveal its outcome, such as an idempotency lookup that may find the
+// key already used, so MinResponseTime holds its response.
+func PadResponse(c *gin.Context) {
+	c.Set(ContextKeyPadResponse, true)
+}
+
+// MinResponseTime returns a middleware that holds the response of each
+// request marked with PadResponse until at least floor plus a random share
+// of jitter has passed since the request arrived. Padding the dedup paths
+// this way keeps their latency from revealing whether a submission was
+// stored or recognized as already known. Unmarked responses, and those
+// slower than the floor, are released unchanged.
+func MinResponseTime(floor, jitter time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		writer := &heldWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		target := floor
+		if jitter > 0 {
+			target += time.Duration(rand.Int63n(int64(jitter)))
+		}
+		if wait := target - time.Since(start); wait > 0 && c.GetBool(ContextKeyPadResponse) {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-c.Request.Context().Done():
+				timer.Stop()
+			}
+		}
+
+		c.Writer = writer.ResponseWriter
+		if writer.buf.Len() > 0 {
+			writer.ResponseWriter.Write(writer.buf.Bytes())
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMinResponseTime(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const floor = 300 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		pad      bool
+		wantHeld bool
+	}{
+		{name: "marked response is held", pad: true, wantHeld: true},
+		{name: "unmarked response is released", pad: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(MinResponseTime(floor, 0))
+			r.POST("/api/v1/ingest", func(c *gin.Context) {
+				if tt.pad {
+					PadResponse(c)
+				}
+				c.JSON(http.StatusOK, gin.H{"ok": true})
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/ingest", nil)
+			w := httptest.NewRecorder()
+			start := time.Now()
+			r.ServeHTTP(w, req)
+			elapsed := time.Since(start)
+
+			if w.Code != http.StatusOK || w.Body.String() != `{"ok":true}` {
+				t.Fatalf("response = %d %s, want 200 {\"ok\":true}", w.Code, w.Body)
+			}
+			if held := elapsed >= floor; held != tt.wantHeld {
+				t.Errorf("elapsed = %v with floor %v, want held %v", elapsed, floor, tt.wantHeld)
+			}
+		})
+	}
+}
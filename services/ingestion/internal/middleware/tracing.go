@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing returns a middleware that records a server span for each request.
+// A valid traceparent header continues the caller's trace; it is passed on
+// to published messages even while tracing is disabled.
+func Tracing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := tracing.Propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		ctx, span := tracing.Start(ctx, c.Request.Method+" "+route, trace.SpanKindServer)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(
+			attribute.String("http.method", c.Request.Method),
+			attribute.String("http.route", route),
+			attribute.Int("http.status_code", status),
+		)
+		if status >= http.StatusInternalServerError {
+			tracing.RecordError(span, fmt.Errorf("%d %s", status, http.StatusText(status)))
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/uigs/ingestion/internal/capture"
+	"github.com/uigs/ingestion/internal/redact"
+)
+
+func TestWrappedWritersSupportWriteDeadlines(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := capture.NewStore(time.Minute, 10)
+	store.Arm("user-1", 1)
+	tests := []struct {
+		name       string
+		middleware gin.HandlerFunc
+	}{
+		{name: "compress", middleware: Compress(1)},
+		{name: "min response time", middleware: MinResponseTime(0, 0)},
+		{name: "capture", middleware: Capture(store, 1024, 1024, redact.New(nil))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deadlineErr error
+			r := gin.New()
+			r.Use(func(c *gin.Context) { c.Set(ContextKeyUserID, "user-1") })
+			r.Use(tt.middleware)
+			r.GET("/", func(c *gin.Context) {
+				deadlineErr = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+				c.String(http.StatusOK, "ok")
+			})
+			srv := httptest.NewServer(r)
+			defer srv.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if deadlineErr != nil {
+				t.Fatalf("SetWriteDeadline through %s: %v", tt.name, deadlineErr)
+			}
+		})
+	}
+}
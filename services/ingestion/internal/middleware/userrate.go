@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// userRateSweepInterval is how often limiters that have refilled are dropped.
+const userRateSweepInterval = time.Minute
+
+// UserRateLimit returns a middleware that limits each user to perSecond
+// requests per second with bursts of up to burst, using a token bucket per
+// user_id. Requests without a user, such as admin ones, are limited per
+// client IP. Rejected requests get 429 with Retry-After. It must run after
+// AuthJWT.
+func UserRateLimit(perSecond float64, burst int) gin.HandlerFunc {
+	var (
+		mu        sync.Mutex
+		limiters  = make(map[string]*rate.Limiter)
+		lastSweep = time.Now()
+	)
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		key := "user:" + c.GetString(ContextKeyUserID)
+		if key == "user:" {
+			key = "ip:" + c.ClientIP()
+		}
+
+		mu.Lock()
+		// A full bucket behaves like a new one, so it need not be kept
+		if now.Sub(lastSweep) >= userRateSweepInterval {
+			for k, l := range limiters {
+				if l.TokensAt(now) >= float64(burst) {
+					delete(limiters, k)
+				}
+			}
+			lastSweep = now
+		}
+		limiter, ok := limiters[key]
+		if !ok {
+			limiter = rate.NewLimiter(rate.Limit(perSecond), burst)
+			limiters[key] = limiter
+		}
+		mu.Unlock()
+
+		// A reservation that cannot be met, with a zero burst, waits forever;
+		// a single token never takes longer than one interval
+		reservation := limiter.ReserveN(now, 1)
+		if wait := reservation.DelayFrom(now); wait > 0 {
+			reservation.CancelAt(now)
+			wait = min(wait, time.Duration(float64(time.Second)/perSecond))
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":   "rate_limited",
+				"message": "Too many requests, retry later",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUserRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		perSecond  float64
+		burst      int
+		users      []string
+		wantStatus []int
+	}{
+		{
+			name:       "burst then rejected",
+			perSecond:  0.5,
+			burst:      2,
+			users:      []string{"alice", "alice", "alice"},
+			wantStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:       "users have separate buckets",
+			perSecond:  0.5,
+			burst:      1,
+			users:      []string{"alice", "bob", "alice"},
+			wantStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:       "anonymous callers are limited per IP",
+			perSecond:  0.5,
+			burst:      1,
+			users:      []string{"", ""},
+			wantStatus: []int{http.StatusOK, http.StatusTooManyRequests},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := UserRateLimit(tt.perSecond, tt.burst)
+			for i, user := range tt.users {
+				r := gin.New()
+				r.Use(func(c *gin.Context) {
+					if user != "" {
+						c.Set(ContextKeyUserID, user)
+					}
+				})
+				r.Use(limit)
+				r.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+				if w.Code != tt.wantStatus[i] {
+					t.Fatalf("request %d: status = %d, want %d", i, w.Code, tt.wantStatus[i])
+				}
+				if w.Code == http.StatusTooManyRequests && w.Header().Get("Retry-After") != "2" {
+					t.Errorf("request %d: Retry-After = %q, want 2", i, w.Header().Get("Retry-After"))
+				}
+			}
+		})
+	}
+}
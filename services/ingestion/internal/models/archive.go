@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ArchiveRecord indexes a batch of events exported to cold storage.
+type ArchiveRecord struct {
+	ArchiveID       string     `json:"archive_id" db:"archive_id"`
+	Location        string     `json:"location" db:"location"`
+	Checksum        string     `json:"checksum" db:"checksum"`
+	EventCount      int        `json:"event_count" db:"event_count"`
+	EventIDs        []string   `json:"event_ids" db:"event_ids"`
+	OldestCreatedAt time.Time  `json:"oldest_created_at" db:"oldest_created_at"`
+	NewestCreatedAt time.Time  `json:"newest_created_at" db:"newest_created_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	RestoredAt      *time.Time `json:"restored_at,omitempty" db:"restored_at"`
+}
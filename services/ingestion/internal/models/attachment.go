@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Attachment actions for infected content.
+const (
+	// AttachmentInfectedReject refuses the upload and discards its content.
+	AttachmentInfectedReject = "reject"
+	// AttachmentInfectedQuarantine keeps the content for review but never
+	// serves it.
+	AttachmentInfectedQuarantine = "quarantine"
+)
+
+// Attachment is a file uploaded alongside an event's credential, with the
+// result of scanning its content.
+type Attachment struct {
+	AttachmentID  string     `json:"attachment_id" db:"attachment_id"`
+	EventID       string     `json:"event_id" db:"event_id"`
+	UserID        string     `json:"user_id" db:"user_id"`
+	Filename      string     `json:"filename" db:"filename"`
+	ContentType   string     `json:"content_type" db:"content_type"`
+	SizeBytes     int64      `json:"size_bytes" db:"size_bytes"`
+	SHA256        string     `json:"sha256" db:"sha256"`
+	ScanStatus    string     `json:"scan_status" db:"scan_status"`
+	ScanSignature string     `json:"scan_signature,omitempty" db:"scan_signature"`
+	ScanError     string     `json:"scan_error,omitempty" db:"scan_error"`
+	ScannedAt     *time.Time `json:"scanned_at,omitempty" db:"scanned_at"`
+	// Stored is false when the content was discarded after the scan.
+	Stored    bool      `json:"stored" db:"stored"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
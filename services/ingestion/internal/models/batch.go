@@ -0,0 +1,42 @@
+package models
+
+// Batch item outcomes.
+const (
+	BatchItemCommitted   = "committed"
+	BatchItemRejected    = "rejected"    // failed validation or credential checks
+	BatchItemFailed      = "failed"      // failed to insert
+	BatchItemRolledBack  = "rolled_back" // valid, but discarded with its batch
+	BatchItemQuarantined = "quarantined" // held for review after extraction failed
+)
+
+// BatchIngestionRequest ingests up to 500 items in one transaction. By default
+// the batch is all-or-nothing; with Partial, valid items are committed and
+// only failed items are rolled back.
+type BatchIngestionRequest struct {
+	Items   []IngestionRequest `json:"items" binding:"required,min=1,max=500,dive"`
+	Partial bool               `json:"partial"`
+}
+
+// BatchItemResult reports the outcome of one batch item.
+type BatchItemResult struct {
+	Index       int    `json:"index"`
+	EventID     string `json:"event_id,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	Message     string `json:"message,omitempty"`
+	Field       string `json:"field,omitempty"`
+	Queued      bool   `json:"queued"`
+	QueueReason string `json:"queue_reason,omitempty"`
+
+	QuarantineID string `json:"quarantine_id,omitempty"`
+}
+
+// BatchIngestionResponse reports the outcome of a batch request.
+type BatchIngestionResponse struct {
+	Partial   bool `json:"partial"`
+	Committed int  `json:"committed"`
+	Failed    int  `json:"failed"`
+
+	Quarantined int               `json:"quarantined,omitempty"`
+	Items       []BatchItemResult `json:"items"`
+}
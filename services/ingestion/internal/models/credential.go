@@ -1,25 +1,135 @@
 // Package models defines data structures for the ingestion service.
 package models
 
+import (
+	"bytes"
+	"encoding/json"
+)
+
 // VerifiableCredential represents a W3C Verifiable Credential.
 type VerifiableCredential struct {
-	Context           []string               `json:"@context"`
-	Type              []string               `json:"type"`
+	Context           Contexts               `json:"@context"`
+	Type              Strings                `json:"type"`
 	ID                string                 `json:"id,omitempty"`
 	Issuer            interface{}            `json:"issuer"` // Can be string or object
 	IssuanceDate      string                 `json:"issuanceDate"`
 	ExpirationDate    string                 `json:"expirationDate,omitempty"`
+	ValidFrom         string                 `json:"validFrom,omitempty"`
+	ValidUntil        string                 `json:"validUntil,omitempty"`
 	CredentialSubject map[string]interface{} `json:"credentialSubject"`
-	Proof             *Proof                 `json:"proof,omitempty"`
+	CredentialStatus  *CredentialStatus      `json:"credentialStatus,omitempty"`
+	Proof             ProofSet               `json:"proof,omitempty"`
+}
+
+// CredentialStatus describes where the revocation or suspension status of a
+// credential can be looked up. Which fields are used depends on Type.
+type CredentialStatus struct {
+	ID                   string `json:"id"`
+	Type                 string `json:"type"`
+	StatusPurpose        string `json:"statusPurpose,omitempty"`
+	StatusListIndex      string `json:"statusListIndex,omitempty"`
+	StatusListCredential string `json:"statusListCredential,omitempty"`
 }
 
 // Proof represents the cryptographic proof of a Verifiable Credential.
 type Proof struct {
+	ID                 string `json:"id,omitempty"`
 	Type               string `json:"type"`
-	Created            string `json:"created"`
+	Cryptosuite        string `json:"cryptosuite,omitempty"`
+	Created            string `json:"created,omitempty"`
 	VerificationMethod string `json:"verificationMethod"`
 	ProofPurpose       string `json:"proofPurpose,omitempty"`
 	ProofValue         string `json:"proofValue"`
+	Challenge          string `json:"challenge,omitempty"`
+	Domain             string `json:"domain,omitempty"`
+	// PreviousProof is the ID, or array of IDs, of the proofs this one
+	// chains onto.
+	PreviousProof interface{} `json:"previousProof,omitempty"`
+}
+
+// ProofSet is a credential's proofs. The proof property may hold a single
+// proof object or an array of them (a proof set or chain).
+type ProofSet []Proof
+
+// UnmarshalJSON accepts a single proof object or an array of proofs.
+func (s *ProofSet) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if bytes.HasPrefix(data, []byte("[")) {
+		var proofs []Proof
+		if err := json.Unmarshal(data, &proofs); err != nil {
+			return err
+		}
+		*s = proofs
+		return nil
+	}
+
+	var proof Proof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		return err
+	}
+	*s = ProofSet{proof}
+	return nil
+}
+
+// MarshalJSON writes a single proof as an object and several as an array.
+func (s ProofSet) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 {
+		return json.Marshal(s[0])
+	}
+	return json.Marshal([]Proof(s))
+}
+
+// VerifiablePresentation represents a W3C Verifiable Presentation.
+type VerifiablePresentation struct {
+	Context              Contexts    `json:"@context"`
+	Type                 Strings     `json:"type"`
+	ID                   string      `json:"id,omitempty"`
+	Holder               string      `json:"holder,omitempty"`
+	VerifiableCredential interface{} `json:"verifiableCredential,omitempty"` // An object, a VC-JWT or an array of them
+	Proof                *Proof      `json:"proof,omitempty"`
+}
+
+// Strings is a property that may hold a single string or an array of
+// strings, such as type.
+type Strings []string
+
+// UnmarshalJSON accepts a single string or an array of strings.
+func (s *Strings) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*s = Strings{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*s = many
+	return nil
+}
+
+// Contexts is an @context property: a single context or an array of them,
+// each a URL or an embedded context object.
+type Contexts []interface{}
+
+// UnmarshalJSON accepts a single context or an array of contexts.
+func (c *Contexts) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case nil:
+		*c = nil
+	case []interface{}:
+		*c = v
+	default:
+		*c = Contexts{v}
+	}
+	return nil
 }
 
 // GetIssuerID extracts the issuer identifier from the issuer field.
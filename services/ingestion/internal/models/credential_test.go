@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestVerifiablePresentationDecoding(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantType    Strings
+		wantContext int
+	}{
+		{
+			name:        "arrays",
+			body:        `{"@context":["https://www.w3.org/2018/credentials/v1"],"type":["VerifiablePresentation"]}`,
+			wantType:    Strings{"VerifiablePresentation"},
+			wantContext: 1,
+		},
+		{
+			name:        "single strings",
+			body:        `{"@context":"https://www.w3.org/2018/credentials/v1","type":"VerifiablePresentation"}`,
+			wantType:    Strings{"VerifiablePresentation"},
+			wantContext: 1,
+		},
+		{
+			name:        "embedded context object",
+			body:        `{"@context":["https://www.w3.org/2018/credentials/v1",{"ex":"https://example.org/vocab#"}],"type":["VerifiablePresentation","ExamplePresentation"]}`,
+			wantType:    Strings{"VerifiablePresentation", "ExamplePresentation"},
+			wantContext: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var vp VerifiablePresentation
+			if err := json.Unmarshal([]byte(tt.body), &vp); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(vp.Type, tt.wantType) {
+				t.Errorf("Type = %v, want %v", vp.Type, tt.wantType)
+			}
+			if len(vp.Context) != tt.wantContext {
+				t.Errorf("got %d contexts, want %d", len(vp.Context), tt.wantContext)
+			}
+		})
+	}
+}
+
+func TestStringsRejectsNonStrings(t *testing.T) {
+	var s Strings
+	if err := json.Unmarshal([]byte(`[1,2]`), &s); err == nil {
+		t.Fatal("Unmarshal accepted an array of numbers")
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Timestamp is a payload date normalized to UTC. OriginalOffset keeps the
+// offset the producer wrote it in ("Z", "+05:30", ...).
+type Timestamp struct {
+	UTC            time.Time `json:"utc"`
+	OriginalOffset string    `json:"original_offset"`
+}
+
+// ExtractedDates are the validity dates extracted from an event's payload.
+type ExtractedDates struct {
+	IssuedAt  *Timestamp `json:"issued_at,omitempty"`
+	ExpiresAt *Timestamp `json:"expires_at,omitempty"`
+}
+
+// IsZero reports whether no dates were extracted.
+func (d *ExtractedDates) IsZero() bool {
+	return d == nil || (d.IssuedAt == nil && d.ExpiresAt == nil)
+}
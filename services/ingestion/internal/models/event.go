@@ -2,6 +2,10 @@
 package models
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"time"
 )
 
@@ -14,31 +18,225 @@ const (
 	SourceTypeManual SourceType = "MANUAL"
 )
 
+// Verification statuses of an event. VC events are verified once their
+// presentation and credential checks pass; other source types are not.
+// Credential checks may be deferred to the background when verification is
+// overloaded. Re-verifying a stored credential may move it to one of the
+// failure statuses. Downstream verifiers may also report an event verified,
+// failed or pending.
+const (
+	VerificationStatusVerified    = "verified"
+	VerificationStatusUnverified  = "unverified"
+	VerificationStatusDeferred    = "deferred"
+	VerificationStatusRevoked     = "revoked"
+	VerificationStatusSuspended   = "suspended"
+	VerificationStatusExpired     = "expired"
+	VerificationStatusNotYetValid = "not_yet_valid"
+	VerificationStatusInvalid     = "invalid"
+	VerificationStatusFailed      = "failed"
+	VerificationStatusPending     = "pending"
+)
+
+// Delivery statuses of an event's publication to the message queue.
+const (
+	DeliveryStatusPending = "pending"
+	DeliveryStatusQueued  = "queued"
+	DeliveryStatusFailed  = "failed"
+	DeliveryStatusSkipped = "skipped"
+	// DeliveryStatusDeadLettered marks an event whose message was routed to
+	// the dead-letter queue after repeatedly failing to publish.
+	DeliveryStatusDeadLettered = "dead_lettered"
+)
+
+// Durability levels a client can request for a single ingestion with the
+// Durability header: the point at which the response is returned.
+const (
+	// DurabilityStored returns once the event is committed to the database
+	// and publishes it in the background.
+	DurabilityStored = "stored"
+	// DurabilityQueued returns after the publish attempt.
+	DurabilityQueued = "queued"
+	// DurabilityConfirmed returns after the broker confirms the message.
+	DurabilityConfirmed = "confirmed"
+)
+
+// Idempotency key scopes: the namespace in which an Idempotency-Key must be
+// unique.
+const (
+	// IdempotencyScopeUser makes keys unique per user.
+	IdempotencyScopeUser = "user"
+	// IdempotencyScopeUserSourceType makes keys unique per user and source
+	// type, for clients that reuse keys across submission types.
+	IdempotencyScopeUserSourceType = "user_source_type"
+	// IdempotencyScopeGlobal makes keys unique across all users.
+	IdempotencyScopeGlobal = "global"
+)
+
+// ValidDurability reports whether level is a known durability level.
+func ValidDurability(level string) bool {
+	switch level {
+	case DurabilityStored, DurabilityQueued, DurabilityConfirmed:
+		return true
+	}
+	return false
+}
+
 // IngestionEvent represents an ingested identity signal.
 type IngestionEvent struct {
 	EventID    string     `json:"event_id" db:"event_id"`
 	UserID     string     `json:"user_id" db:"user_id"`
 	SourceType SourceType `json:"source_type" db:"source_type"`
-	RawPayload []byte     `json:"raw_payload" db:"raw_payload"`
+	RawPayload []byte     `json:"raw_payload,omitempty" db:"raw_payload"`
 	Checksum   string     `json:"checksum" db:"checksum"`
+	Enrichment []byte     `json:"enrichment,omitempty" db:"enrichment"`
 	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+
+	// TenantID is the organization the event belongs to, taken from the
+	// ingesting caller's token. Reads are scoped to it.
+	TenantID string `json:"tenant_id" db:"tenant_id"`
+
+	// OccurredAt is the event's logical time. It equals CreatedAt unless a
+	// trusted caller backfilled the event with its original time.
+	OccurredAt time.Time `json:"occurred_at" db:"occurred_at"`
+
+	VerificationStatus string     `json:"verification_status" db:"verification_status"`
+	VerifiedAt         *time.Time `json:"verified_at,omitempty" db:"verified_at"`
+	DeliveryStatus     string     `json:"delivery_status" db:"delivery_status"`
+
+	Dates *ExtractedDates `json:"dates,omitempty" db:"extracted_dates"`
+
+	Tags     []string               `json:"tags,omitempty" db:"tags"`
+	Metadata map[string]interface{} `json:"metadata,omitempty" db:"metadata"`
+
+	// DataModel is the VC Data Model version the issuer used. When it
+	// differs from the canonical version, NormalizedPayload holds the
+	// payload converted to the canonical version.
+	DataModel         string `json:"data_model,omitempty" db:"data_model"`
+	NormalizedPayload []byte `json:"normalized_payload,omitempty" db:"normalized_payload"`
+
+	// DeletedAt is set when the event is soft-deleted. Deleted events can be
+	// restored until the grace period ends, after which they are purged.
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+
+	// KeyVersion is the encryption key version the stored payloads are
+	// sealed with, or nil when they are stored in plaintext.
+	KeyVersion *int `json:"key_version,omitempty" db:"key_version"`
+
+	// EncryptedPayload holds the sealed payloads of an event exported to an
+	// archive, in place of RawPayload, NormalizedPayload and CredentialJWT.
+	// It is empty for events read for any other purpose.
+	EncryptedPayload []byte `json:"encrypted_payload,omitempty" db:"-"`
+
+	// ParentEventID is the event this one's credential was derived from.
+	ParentEventID *string `json:"parent_event_id,omitempty" db:"parent_event_id"`
+
+	// CredentialJWT is the VC-JWT the credential was submitted as. The
+	// decoded credential is held in RawPayload.
+	CredentialJWT string `json:"credential_jwt,omitempty" db:"credential_jwt"`
+
+	// IdempotencyKey is the client's Idempotency-Key, unique within
+	// IdempotencyScope.
+	IdempotencyKey   string `json:"idempotency_key,omitempty" db:"idempotency_key"`
+	IdempotencyScope string `json:"idempotency_scope,omitempty" db:"idempotency_scope"`
+
+	// OIDCClaims are the parsed claims of an OIDC event, stored alongside
+	// it in oidc_claims. Nil when the claims could not be parsed.
+	OIDCClaims *OIDCClaims `json:"-" db:"-"`
+}
+
+// VerifyIntegrity reports whether RawPayload still hashes to Checksum. The
+// payload is re-encoded as it was when the checksum was taken, since the
+// database returns JSON formatted its own way.
+func (e *IngestionEvent) VerifyIntegrity() bool {
+	var payload interface{}
+	if err := json.Unmarshal(e.RawPayload, &payload); err != nil {
+		return false
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return false
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]) == e.Checksum
+}
+
+// EventStatus is the compact status projection of an event.
+type EventStatus struct {
+	VerificationStatus string     `json:"verification_status"`
+	DeliveryStatus     string     `json:"delivery_status"`
+	VerifiedAt         *time.Time `json:"verified_at"`
+}
+
+// EventStatusRequest asks for the status of several events at once.
+type EventStatusRequest struct {
+	EventIDs []string `json:"event_ids" binding:"required,min=1,max=500"`
 }
 
 // IngestionRequest represents the incoming request for credential ingestion.
 type IngestionRequest struct {
-	// SourceType indicates the type of credential (VC, OIDC, MANUAL)
-	SourceType SourceType `json:"source_type" binding:"required,oneof=VC OIDC MANUAL"`
-	
+	// SourceType indicates the type of credential (VC, OIDC, MANUAL).
+	// It may be omitted when a preset supplies it.
+	SourceType SourceType `json:"source_type" binding:"omitempty,oneof=VC OIDC MANUAL"`
+
 	// Payload contains the credential data
-	Payload map[string]interface{} `json:"payload" binding:"required"`
+	Payload map[string]interface{} `json:"payload" binding:"required_without=CredentialJWT"`
+
+	// CredentialJWT is set instead of Payload when the payload is a JSON
+	// string, which holds a VC-JWT.
+	CredentialJWT string `json:"-"`
+
+	// Tags and Metadata are free-form labels stored with the event
+	Tags     []string               `json:"tags,omitempty" binding:"max=20,dive,min=1,max=64"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// ParentEventID links a derived credential to the event it was
+	// derived from
+	ParentEventID string `json:"parent_event_id,omitempty" binding:"omitempty,uuid"`
+
+	// OccurredAt backdates the event to when the credential was originally
+	// received, for admin backfills. Other callers may not set it.
+	OccurredAt *time.Time `json:"occurred_at,omitempty"`
+
+	// CallbackURL receives the IngestionResponse in a signed POST once the
+	// event is stored. It must be a public https URL.
+	CallbackURL string `json:"callback_url,omitempty" binding:"omitempty,max=2048"`
+}
+
+// UnmarshalJSON accepts the payload as a credential object or as a VC-JWT
+// string.
+func (r *IngestionRequest) UnmarshalJSON(data []byte) error {
+	type request IngestionRequest
+	aux := struct {
+		*request
+		Payload json.RawMessage `json:"payload"`
+	}{request: (*request)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	payload := bytes.TrimSpace(aux.Payload)
+	if len(payload) > 0 && payload[0] == '"' {
+		return json.Unmarshal(payload, &r.CredentialJWT)
+	}
+	if len(payload) > 0 {
+		return json.Unmarshal(payload, &r.Payload)
+	}
+	return nil
 }
 
 // IngestionResponse represents the response after successful ingestion.
 type IngestionResponse struct {
-	EventID   string    `json:"event_id"`
-	Status    string    `json:"status"`
-	Message   string    `json:"message,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	EventID     string    `json:"event_id"`
+	Status      string    `json:"status"`
+	Message     string    `json:"message,omitempty"`
+	Queued      bool      `json:"queued"`
+	QueueReason string    `json:"queue_reason,omitempty"`
+	Durability  string    `json:"durability,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	QuarantineID string `json:"quarantine_id,omitempty"`
+
+	Receipt *Receipt `json:"receipt,omitempty"`
 }
 
 // QueueMessage represents the message published to RabbitMQ.
@@ -47,5 +245,13 @@ type QueueMessage struct {
 	UserID     string                 `json:"user_id"`
 	SourceType SourceType             `json:"source_type"`
 	Payload    map[string]interface{} `json:"payload"`
+	Enrichment json.RawMessage        `json:"enrichment,omitempty"`
+	Tags       []string               `json:"tags,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	DataModel  string                 `json:"data_model,omitempty"`
 	Timestamp  time.Time              `json:"timestamp"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	// Replay marks a stored event published again by an admin, rather
+	// than a new one. The message also carries a replay=true header.
+	Replay bool `json:"replay,omitempty"`
 }
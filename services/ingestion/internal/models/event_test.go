@@ -0,0 +1,35 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestVerifyIntegrity(t *testing.T) {
+	stored := []byte(`{"name":"Alice","age":30}`)
+	sum := sha256.Sum256([]byte(`{"age":30,"name":"Alice"}`))
+	checksum := hex.EncodeToString(sum[:])
+
+	tests := []struct {
+		name     string
+		payload  []byte
+		checksum string
+		want     bool
+	}{
+		{name: "intact", payload: []byte(`{"age":30,"name":"Alice"}`), checksum: checksum, want: true},
+		{name: "reformatted by the database", payload: []byte(`{"name": "Alice", "age": 30}`), checksum: checksum, want: true},
+		{name: "tampered byte", payload: []byte(`{"name":"Alicf","age":30}`), checksum: checksum},
+		{name: "tampered checksum", payload: stored, checksum: checksum[:63] + "0"},
+		{name: "truncated payload", payload: stored[:10], checksum: checksum},
+		{name: "no checksum", payload: stored},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &IngestionEvent{RawPayload: tt.payload, Checksum: tt.checksum}
+			if got := e.VerifyIntegrity(); got != tt.want {
+				t.Errorf("VerifyIntegrity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
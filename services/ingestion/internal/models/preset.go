@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// IngestionPreset pre-fills the source type, tags and metadata of ingestion
+// requests that name it. Presets are stored per tenant.
+type IngestionPreset struct {
+	TenantID   string                 `json:"tenant_id" db:"tenant_id"`
+	Name       string                 `json:"name" db:"name"`
+	SourceType SourceType             `json:"source_type" db:"source_type"`
+	Tags       []string               `json:"tags" db:"tags"`
+	Metadata   map[string]interface{} `json:"metadata" db:"metadata"`
+	CreatedAt  time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// IngestionPresetRequest creates or replaces a preset.
+type IngestionPresetRequest struct {
+	SourceType SourceType             `json:"source_type" binding:"required,oneof=VC OIDC MANUAL"`
+	Tags       []string               `json:"tags" binding:"max=20,dive,min=1,max=64"`
+	Metadata   map[string]interface{} `json:"metadata"`
+}
+
+// Apply fills in the request from the preset. Values given explicitly in the
+// request win: the source type is kept, tags are merged, and metadata keys
+// override the preset's.
+func (p *IngestionPreset) Apply(req *IngestionRequest) {
+	if req.SourceType == "" {
+		req.SourceType = p.SourceType
+	}
+
+	seen := make(map[string]bool, len(p.Tags)+len(req.Tags))
+	tags := make([]string, 0, len(p.Tags)+len(req.Tags))
+	for _, tag := range append(append([]string{}, p.Tags...), req.Tags...) {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	req.Tags = tags
+
+	if len(p.Metadata) > 0 {
+		merged := make(map[string]interface{}, len(p.Metadata)+len(req.Metadata))
+		for k, v := range p.Metadata {
+			merged[k] = v
+		}
+		for k, v := range req.Metadata {
+			merged[k] = v
+		}
+		req.Metadata = merged
+	}
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ProvenanceLink is one ancestor in an event's derivation chain, with the
+// fields needed to judge whether it may still be derived from.
+type ProvenanceLink struct {
+	EventID            string     `json:"event_id" db:"event_id"`
+	UserID             string     `json:"user_id" db:"user_id"`
+	ParentEventID      *string    `json:"parent_event_id,omitempty" db:"parent_event_id"`
+	VerificationStatus string     `json:"verification_status" db:"verification_status"`
+	ExpiresAt          *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	DeletedAt          *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Extraction failure modes.
+const (
+	// ExtractionFailureIgnore stores the event with the fields that could
+	// be extracted.
+	ExtractionFailureIgnore = "ignore"
+	// ExtractionFailureQuarantine holds the event for manual review.
+	ExtractionFailureQuarantine = "quarantine"
+	// ExtractionFailureReject rejects the request with 422.
+	ExtractionFailureReject = "reject"
+)
+
+// QuarantinedEvent is a verified event held back because extracting its
+// normalized fields failed. Event is the prepared event as it would have
+// been stored; reprocessing extracts it again and stores it.
+type QuarantinedEvent struct {
+	QuarantineID  string          `json:"quarantine_id" db:"quarantine_id"`
+	EventID       string          `json:"event_id" db:"event_id"`
+	UserID        string          `json:"user_id" db:"user_id"`
+	SourceType    SourceType      `json:"source_type" db:"source_type"`
+	Event         json.RawMessage `json:"event" db:"event"`
+	Error         string          `json:"error" db:"error"`
+	Field         string          `json:"field,omitempty" db:"field"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	ReprocessedAt *time.Time      `json:"reprocessed_at,omitempty" db:"reprocessed_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// Receipt is the service's signature over an accepted event, proof that it
+// stored a payload with Checksum under EventID at the event's created_at.
+type Receipt struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	Checksum  string `json:"checksum"`
+	// Signature is the base64-encoded signature.
+	Signature string `json:"signature"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// ReplayFilter selects the stored events to republish.
+type ReplayFilter struct {
+	From       time.Time
+	To         time.Time
+	SourceType SourceType
+	UserID     string
+	// IncludeDeleted also selects soft-deleted events.
+	IncludeDeleted bool
+}
+
+// ReplayRequest asks to republish a range of stored events to the queue.
+type ReplayRequest struct {
+	From       time.Time  `json:"from" binding:"required"`
+	To         time.Time  `json:"to" binding:"required"`
+	SourceType SourceType `json:"source_type" binding:"omitempty,oneof=VC OIDC MANUAL"`
+	UserID     string     `json:"user_id" binding:"omitempty,uuid"`
+	// Mode overrides the configured ordering mode.
+	Mode string `json:"mode" binding:"omitempty,oneof=ordered keyed unordered"`
+	// After resumes a previous run from its next_cursor.
+	After string `json:"after"`
+	Limit int    `json:"limit" binding:"omitempty,min=1,max=10000"`
+}
+
+// ReplayFailure identifies an event that could not be republished.
+type ReplayFailure struct {
+	EventID string `json:"event_id"`
+	Error   string `json:"error"`
+}
+
+// ReplayResponse reports the outcome of a republish run.
+type ReplayResponse struct {
+	Mode      string          `json:"mode"`
+	Published int             `json:"published"`
+	Skipped   int             `json:"skipped"`
+	Failures  []ReplayFailure `json:"failures,omitempty"`
+	// NextCursor resumes the run; events from it on are republished again.
+	NextCursor string `json:"next_cursor,omitempty"`
+	Done       bool   `json:"done"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// EventStats summarizes a user's events.
+type EventStats struct {
+	Total        int64                `json:"total"`
+	BySourceType map[SourceType]int64 `json:"by_source_type"`
+	Earliest     *time.Time           `json:"earliest"`
+	Latest       *time.Time           `json:"latest"`
+}
+
+// UserActivity summarizes the events one user has ingested.
+type UserActivity struct {
+	UserID     string `json:"user_id"`
+	EventCount int64  `json:"event_count"`
+	// LastActivityAt is when the user's latest event was ingested.
+	LastActivityAt time.Time `json:"last_activity_at"`
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrMalformedVCJWT is returned for a token that is not a compact JWS
+// carrying a credential.
+var ErrMalformedVCJWT = errors.New("malformed VC-JWT")
+
+// DecodeVCJWT decodes a credential in the compact JWT form (vc+jwt) into a
+// VerifiableCredential. The signature is not verified.
+func DecodeVCJWT(token string) (*VerifiableCredential, error) {
+	claims, err := DecodeVCJWTClaims(token)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedVCJWT, err)
+	}
+	var vc VerifiableCredential
+	if err := json.Unmarshal(raw, &vc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedVCJWT, err)
+	}
+	return &vc, nil
+}
+
+// DecodeVCJWTClaims decodes a VC-JWT into the JSON form of its credential.
+// A token with a vc claim is a VC Data Model 1.1 JWT, whose registered
+// claims fill in the credential's missing properties: iss the issuer, jti
+// the id, sub the subject's id, nbf the issuance date and exp the
+// expiration date. A token without one is a Data Model 2.0 vc+jwt, whose
+// claims are the credential itself.
+func DecodeVCJWTClaims(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: token must have three segments", ErrMalformedVCJWT)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: claims are not base64url encoded", ErrMalformedVCJWT)
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(raw, &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims are not a JSON object", ErrMalformedVCJWT)
+	}
+
+	vc, ok := claims["vc"]
+	if !ok {
+		if _, ok := claims["@context"]; !ok {
+			return nil, fmt.Errorf("%w: token has no vc claim", ErrMalformedVCJWT)
+		}
+		return claims, nil
+	}
+	credential, ok := vc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%w: vc claim must be an object", ErrMalformedVCJWT)
+	}
+
+	if iss, ok := claims["iss"].(string); ok {
+		switch issuer := credential["issuer"].(type) {
+		case nil:
+			credential["issuer"] = iss
+		case map[string]interface{}:
+			if _, ok := issuer["id"]; !ok {
+				issuer["id"] = iss
+			}
+		}
+	}
+	if jti, ok := claims["jti"].(string); ok {
+		setMissing(credential, "id", jti)
+	}
+	if sub, ok := claims["sub"].(string); ok {
+		if subject, ok := credential["credentialSubject"].(map[string]interface{}); ok {
+			setMissing(subject, "id", sub)
+		}
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && credential["validFrom"] == nil {
+		setMissing(credential, "issuanceDate", numericDate(nbf))
+	}
+	if exp, ok := claims["exp"].(float64); ok && credential["validUntil"] == nil {
+		setMissing(credential, "expirationDate", numericDate(exp))
+	}
+	return credential, nil
+}
+
+// setMissing sets m[key] to value unless key is already present.
+func setMissing(m map[string]interface{}, key string, value interface{}) {
+	if _, ok := m[key]; !ok {
+		m[key] = value
+	}
+}
+
+// numericDate formats a JWT NumericDate as an XML Schema dateTime in UTC.
+func numericDate(seconds float64) string {
+	return time.Unix(int64(seconds), 0).UTC().Format(time.RFC3339)
+}
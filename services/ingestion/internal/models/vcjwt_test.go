@@ -0,0 +1,140 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// sampleVCJWT builds an ES256-headed compact JWS of claims with a dummy
+// signature, which DecodeVCJWT does not check.
+func sampleVCJWT(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"ES256","typ":"vc+jwt"}`))
+	return header + "." + base64.RawURLEncoding.EncodeToString(raw) + ".c2lnbmF0dXJl"
+}
+
+func TestDecodeVCJWT(t *testing.T) {
+	credential := func() map[string]interface{} {
+		return map[string]interface{}{
+			"@context":          []interface{}{"https://www.w3.org/2018/credentials/v1"},
+			"type":              []interface{}{"VerifiableCredential", "UniversityDegreeCredential"},
+			"credentialSubject": map[string]interface{}{"degree": "BSc"},
+		}
+	}
+	withIssuer := func(issuer interface{}) map[string]interface{} {
+		vc := credential()
+		vc["issuer"] = issuer
+		return vc
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		wantIssuer     string
+		wantID         string
+		wantSubject    string
+		wantIssuance   string
+		wantExpiration string
+		wantErr        bool
+	}{
+		{
+			name: "data model 1.1 with registered claims",
+			token: sampleVCJWT(t, map[string]interface{}{
+				"iss": "did:example:issuer", "jti": "urn:uuid:1", "sub": "did:example:alice",
+				"nbf": 1767225600, "exp": 1798761600, "vc": credential(),
+			}),
+			wantIssuer:     "did:example:issuer",
+			wantID:         "urn:uuid:1",
+			wantSubject:    "did:example:alice",
+			wantIssuance:   "2026-01-01T00:00:00Z",
+			wantExpiration: "2027-01-01T00:00:00Z",
+		},
+		{
+			name:       "issuer in the vc claim wins over iss",
+			token:      sampleVCJWT(t, map[string]interface{}{"iss": "did:example:other", "vc": withIssuer("did:example:issuer")}),
+			wantIssuer: "did:example:issuer",
+		},
+		{
+			name:       "object issuer without an id takes iss",
+			token:      sampleVCJWT(t, map[string]interface{}{"iss": "did:example:issuer", "vc": withIssuer(map[string]interface{}{"name": "Example University"})}),
+			wantIssuer: "did:example:issuer",
+		},
+		{
+			name:       "data model 2.0 claims are the credential",
+			token:      sampleVCJWT(t, withIssuer("did:example:issuer")),
+			wantIssuer: "did:example:issuer",
+		},
+		{name: "two segments", token: "eyJhbGciOiJFUzI1NiJ9.e30", wantErr: true},
+		{name: "four segments", token: "a.b.c.d", wantErr: true},
+		{name: "claims not base64url", token: "eyJhbGciOiJFUzI1NiJ9.!!!.c2ln", wantErr: true},
+		{name: "claims not an object", token: "eyJhbGciOiJFUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(`[1]`)) + ".c2ln", wantErr: true},
+		{name: "no vc claim", token: sampleVCJWT(t, map[string]interface{}{"iss": "did:example:issuer"}), wantErr: true},
+		{name: "vc claim not an object", token: sampleVCJWT(t, map[string]interface{}{"vc": "credential"}), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vc, err := DecodeVCJWT(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrMalformedVCJWT) {
+					t.Fatalf("DecodeVCJWT() error = %v, want %v", err, ErrMalformedVCJWT)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodeVCJWT() error = %v", err)
+			}
+			if got := vc.GetIssuerID(); got != tt.wantIssuer {
+				t.Errorf("issuer = %q, want %q", got, tt.wantIssuer)
+			}
+			if vc.ID != tt.wantID || vc.IssuanceDate != tt.wantIssuance || vc.ExpirationDate != tt.wantExpiration {
+				t.Errorf("id, issuanceDate, expirationDate = %q, %q, %q; want %q, %q, %q",
+					vc.ID, vc.IssuanceDate, vc.ExpirationDate, tt.wantID, tt.wantIssuance, tt.wantExpiration)
+			}
+			if got, _ := vc.CredentialSubject["id"].(string); got != tt.wantSubject {
+				t.Errorf("credentialSubject.id = %q, want %q", got, tt.wantSubject)
+			}
+			if vc.CredentialSubject["degree"] != "BSc" {
+				t.Errorf("credentialSubject = %v, want the degree kept", vc.CredentialSubject)
+			}
+		})
+	}
+}
+
+func TestIngestionRequestPayloadForms(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantJWT     string
+		wantPayload bool
+		wantErr     bool
+	}{
+		{name: "credential object", body: `{"source_type":"VC","payload":{"issuer":"did:example:issuer"}}`, wantPayload: true},
+		{name: "VC-JWT string", body: `{"payload":"eyJhbGciOiJFUzI1NiJ9.e30.c2ln"}`, wantJWT: "eyJhbGciOiJFUzI1NiJ9.e30.c2ln"},
+		{name: "no payload", body: `{"source_type":"VC"}`},
+		{name: "payload of another type", body: `{"payload":42}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req IngestionRequest
+			err := json.Unmarshal([]byte(tt.body), &req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if req.CredentialJWT != tt.wantJWT {
+				t.Errorf("CredentialJWT = %q, want %q", req.CredentialJWT, tt.wantJWT)
+			}
+			if got := req.Payload != nil; got != tt.wantPayload {
+				t.Errorf("has payload = %v, want %v", got, tt.wantPayload)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Webhook event types a user can subscribe to.
+const (
+	WebhookEventVerificationChanged = "verification.status_changed"
+)
+
+// CallbackEventIngested is the event of per-request ingestion callbacks.
+// Callbacks are not subscribed to, so it is not in WebhookEventTypes.
+const CallbackEventIngested = "ingestion.accepted"
+
+// WebhookEventTypes lists the supported webhook event types.
+var WebhookEventTypes = []string{WebhookEventVerificationChanged}
+
+// UserWebhook is a user's opt-in endpoint for one webhook event type.
+type UserWebhook struct {
+	UserID    string    `json:"user_id" db:"user_id"`
+	EventType string    `json:"event_type" db:"event_type"`
+	URL       string    `json:"url" db:"url"`
+	Secret    string    `json:"secret,omitempty" db:"secret"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	// Ordered deliveries are sent one at a time in event order, at the
+	// cost of throughput.
+	Ordered bool `json:"ordered" db:"ordered"`
+}
+
+// OrderKey identifies the webhook as an ordered delivery subscriber, or is
+// empty when the webhook is unordered.
+func (w *UserWebhook) OrderKey() string {
+	if !w.Ordered {
+		return ""
+	}
+	return w.UserID + "/" + w.EventType
+}
+
+// UserWebhookRequest registers or updates a webhook endpoint.
+type UserWebhookRequest struct {
+	URL     string `json:"url" binding:"required,url"`
+	Ordered bool   `json:"ordered"`
+}
+
+// VerificationChange is the payload of a verification.status_changed webhook.
+type VerificationChange struct {
+	EventID   string    `json:"event_id"`
+	UserID    string    `json:"user_id"`
+	Check     string    `json:"check"`
+	OldStatus string    `json:"old_status"`
+	NewStatus string    `json:"new_status"`
+	Reason    string    `json:"reason,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
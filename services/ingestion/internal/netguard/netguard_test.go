@@ -0,0 +1,71 @@
+package netguard
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{ip: "100.63.255.255", want: true},
+		{ip: "100.128.0.0", want: true},
+		{ip: "127.0.0.1"},
+		{ip: "::1"},
+		{ip: "10.0.0.5"},
+		{ip: "172.16.0.1"},
+		{ip: "192.168.1.20"},
+		{ip: "169.254.169.254"},
+		{ip: "fe80::1"},
+		{ip: "fd00::1"},
+		{ip: "100.64.0.1"},
+		{ip: "100.127.255.255"},
+		{ip: "0.0.0.0"},
+		{ip: "224.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := IsPublic(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("IsPublic(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name          string
+		allowPrivate  bool
+		wantForbidden bool
+	}{
+		{name: "internal address refused", wantForbidden: true},
+		{name: "internal address allowed for development", allowPrivate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := NewClient(time.Second, tt.allowPrivate).Get(srv.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if got := errors.Is(err, ErrForbiddenDestination); got != tt.wantForbidden {
+				t.Errorf("Get(%s) error = %v, want forbidden destination %v", srv.URL, err, tt.wantForbidden)
+			}
+			if !tt.wantForbidden && err != nil {
+				t.Errorf("Get(%s) error = %v", srv.URL, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDocumentBytes bounds the size of a fetched discovery document or JWKS.
+const maxDocumentBytes = 1 << 20
+
+// minRefreshInterval limits refetches of an issuer's keys for unknown key
+// IDs, so tokens with made-up kids cannot hammer the issuer.
+const minRefreshInterval = time.Minute
+
+// jwk is one JSON Web Key. Only RSA and P-256 EC signing keys are used.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type cachedKeys struct {
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// keySet fetches issuers' signing keys through OpenID Connect discovery and
+// caches them per issuer.
+type keySet struct {
+	client *http.Client
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedKeys
+}
+
+func newKeySet(timeout, ttl time.Duration) *keySet {
+	return &keySet{
+		client: &http.Client{Timeout: timeout},
+		ttl:    ttl,
+		cache:  make(map[string]cachedKeys),
+	}
+}
+
+// key returns the issuer's key with the given ID. Cached keys are refetched
+// when they are older than the TTL, or when the ID is unknown and the keys
+// were not fetched within minRefreshInterval, to pick up rotated keys.
+func (s *keySet) key(ctx context.Context, issuer, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	entry, ok := s.cache[issuer]
+	s.mu.Unlock()
+
+	age := time.Since(entry.fetchedAt)
+	if ok && age < s.ttl {
+		if key, found := entry.keys[kid]; found {
+			return key, nil
+		}
+		if age < minRefreshInterval {
+			return nil, fmt.Errorf("%w: unknown key ID %q", ErrInvalidToken, kid)
+		}
+	}
+
+	keys, err := s.fetch(ctx, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeysUnavailable, err)
+	}
+	s.mu.Lock()
+	s.cache[issuer] = cachedKeys{keys: keys, fetchedAt: time.Now()}
+	s.mu.Unlock()
+
+	key, found := keys[kid]
+	if !found {
+		return nil, fmt.Errorf("%w: unknown key ID %q", ErrInvalidToken, kid)
+	}
+	return key, nil
+}
+
+// fetch discovers the issuer's jwks_uri and reads its signing keys.
+func (s *keySet) fetch(ctx context.Context, issuer string) (map[string]crypto.PublicKey, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	if err := s.getJSON(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if discovery.Issuer != issuer {
+		return nil, fmt.Errorf("discovery document issuer %q does not match %q", discovery.Issuer, issuer)
+	}
+	if !strings.HasPrefix(discovery.JWKSURI, "https://") {
+		return nil, fmt.Errorf("jwks_uri %q is not an HTTPS URL", discovery.JWKSURI)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := s.getJSON(ctx, discovery.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (s *keySet) getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", url, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch of %s returned %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDocumentBytes)).Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON from %s: %w", url, err)
+	}
+	return nil
+}
+
+// publicKey decodes an RSA or P-256 EC JWK.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+		if !key.Curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("EC point is not on the curve")
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, fmt.Errorf("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,216 @@
+// Package oidc validates OpenID Connect ID tokens against the signing keys
+// their issuers publish, found through OpenID Connect discovery.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+var (
+	// ErrInvalidToken wraps the reason an ID token was rejected.
+	ErrInvalidToken = errors.New("invalid ID token")
+	// ErrKeysUnavailable is returned when the issuer's keys could not be
+	// fetched, so the token could not be checked.
+	ErrKeysUnavailable = errors.New("issuer keys unavailable")
+)
+
+// GitHubActionsIssuer issues tokens to GitHub Actions workflows. Any
+// workflow of any repository can get one, with an audience of its choice,
+// so tokens from it are only accepted under a Constraint.
+const GitHubActionsIssuer = "https://token.actions.githubusercontent.com"
+
+// openIssuers are the issuers that need a Constraint with both an audience
+// and repositories.
+var openIssuers = []string{GitHubActionsIssuer}
+
+// Constraint narrows the tokens accepted from one issuer.
+type Constraint struct {
+	// Audience, if set, is the only audience accepted from the issuer,
+	// instead of Config.Audiences.
+	Audience string
+	// Repositories, if set, are the only accepted values of the token's
+	// repository claim, as owner/name. The sub claim must name the same
+	// repository.
+	Repositories []string
+}
+
+// Config controls a validator.
+type Config struct {
+	// Issuers are the token issuers accepted, such as
+	// https://accounts.google.com. Keys are only fetched for these.
+	Issuers []string
+	// Audiences are the client IDs a token must be issued to.
+	Audiences []string
+	// Constraints narrow what is accepted from particular issuers, by
+	// issuer.
+	Constraints map[string]Constraint
+	// FetchTimeout bounds each discovery and JWKS request.
+	FetchTimeout time.Duration
+	// KeyCacheTTL is how long an issuer's keys are reused.
+	KeyCacheTTL time.Duration
+}
+
+// Validator checks the signature, issuer and audience of ID tokens. Time
+// claims are left to the caller's clock.
+type Validator struct {
+	issuers     []string
+	audiences   []string
+	constraints map[string]Constraint
+	keys        *keySet
+}
+
+// NewValidator creates a validator. At least one issuer is required, and
+// an audience for each issuer. Open issuers such as GitHubActionsIssuer
+// also need a Constraint naming both an audience and repositories.
+func NewValidator(cfg Config) (*Validator, error) {
+	if len(cfg.Issuers) == 0 {
+		return nil, errors.New("at least one OIDC issuer is required")
+	}
+	for _, issuer := range cfg.Issuers {
+		c := cfg.Constraints[issuer]
+		if slices.Contains(openIssuers, issuer) && (c.Audience == "" || len(c.Repositories) == 0) {
+			return nil, fmt.Errorf("OIDC issuer %s requires an explicit audience and the accepted repositories", issuer)
+		}
+		if c.Audience == "" && len(cfg.Audiences) == 0 {
+			return nil, fmt.Errorf("at least one OIDC client ID is required for issuer %s", issuer)
+		}
+	}
+	return &Validator{
+		issuers:     cfg.Issuers,
+		audiences:   cfg.Audiences,
+		constraints: cfg.Constraints,
+		keys:        newKeySet(cfg.FetchTimeout, cfg.KeyCacheTTL),
+	}, nil
+}
+
+// tokenClaims are the ID token claims as sent; aud may be a string or an
+// array.
+type tokenClaims struct {
+	models.OIDCClaims
+	Audience   json.RawMessage `json:"aud"`
+	Repository string          `json:"repository"`
+}
+
+// Validate verifies idToken and returns its claims, with Audience set to the
+// configured client ID it was issued to. Errors wrap ErrInvalidToken, or
+// ErrKeysUnavailable when the issuer's keys could not be fetched.
+func (v *Validator) Validate(ctx context.Context, idToken string) (*models.OIDCClaims, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: token must be a compact JWS", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	if header.Alg != "RS256" && header.Alg != "ES256" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Alg)
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	if !slices.Contains(v.issuers, claims.Issuer) {
+		return nil, fmt.Errorf("%w: issuer %q is not accepted", ErrInvalidToken, claims.Issuer)
+	}
+	constraint := v.constraints[claims.Issuer]
+	audiences := v.audiences
+	if constraint.Audience != "" {
+		audiences = []string{constraint.Audience}
+	}
+	audience, ok := matchAudience(claims.Audience, audiences)
+	if !ok {
+		return nil, fmt.Errorf("%w: audience does not match a configured client ID", ErrInvalidToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: token has no sub", ErrInvalidToken)
+	}
+	if len(constraint.Repositories) > 0 &&
+		(!slices.Contains(constraint.Repositories, claims.Repository) || !strings.HasPrefix(claims.Subject, "repo:"+claims.Repository+":")) {
+		return nil, fmt.Errorf("%w: repository %q is not accepted", ErrInvalidToken, claims.Repository)
+	}
+	if claims.Expiration == 0 {
+		return nil, fmt.Errorf("%w: token has no exp", ErrInvalidToken)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature is not base64url encoded", ErrInvalidToken)
+	}
+	key, err := v.keys.key(ctx, claims.Issuer, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if !verifySignature(header.Alg, key, digest[:], signature) {
+		return nil, fmt.Errorf("%w: signature does not match", ErrInvalidToken)
+	}
+
+	result := claims.OIDCClaims
+	result.Audience = audience
+	return &result, nil
+}
+
+// matchAudience returns the first of accepted in aud.
+func matchAudience(raw json.RawMessage, accepted []string) (string, bool) {
+	var audiences []string
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		audiences = []string{single}
+	} else if err := json.Unmarshal(raw, &audiences); err != nil {
+		return "", false
+	}
+	for _, aud := range audiences {
+		if slices.Contains(accepted, aud) {
+			return aud, true
+		}
+	}
+	return "", false
+}
+
+func verifySignature(alg string, key crypto.PublicKey, digest, signature []byte) bool {
+	switch alg {
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest, signature) == nil
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(pub, digest, r, s)
+	}
+	return false
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("not base64url encoded")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("not valid JSON")
+	}
+	return nil
+}
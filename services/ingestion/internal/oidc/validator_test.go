@@ -0,0 +1,263 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// mockIssuer serves a discovery document and JWKS for its keys.
+type mockIssuer struct {
+	*httptest.Server
+	rsaKey    *rsa.PrivateKey
+	ecKey     *ecdsa.PrivateKey
+	fetches   atomic.Int32
+	unhealthy atomic.Bool
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIssuer{rsaKey: rsaKey, ecKey: ecKey}
+
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		if m.unhealthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"issuer": m.URL, "jwks_uri": m.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.fetches.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		}})
+	})
+	m.Server = httptest.NewTLSServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// sign builds an ID token with claims, signed by key under kid.
+func sign(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestValidate(t *testing.T) {
+	issuer := newMockIssuer(t)
+	stranger, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := func(extra map[string]any) map[string]any {
+		c := map[string]any{"iss": issuer.URL, "sub": "user-1", "aud": "client-a", "exp": time.Now().Add(time.Hour).Unix()}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		wantAudience string
+		wantErr      error
+	}{
+		{name: "RS256", token: sign(t, "RS256", "rsa-1", issuer.rsaKey, claims(nil)), wantAudience: "client-a"},
+		{name: "ES256", token: sign(t, "ES256", "ec-1", issuer.ecKey, claims(nil)), wantAudience: "client-a"},
+		{name: "audience array", token: sign(t, "RS256", "rsa-1", issuer.rsaKey, claims(map[string]any{"aud": []string{"other", "client-b"}})), wantAudience: "client-b"},
+		{name: "bad signature", token: sign(t, "RS256", "rsa-1", stranger, claims(nil)), wantErr: ErrInvalidToken},
+		{name: "mismatched audience", token: sign(t, "RS256", "rsa-1", issuer.rsaKey, claims(map[string]any{"aud": "client-z"})), wantErr: ErrInvalidToken},
+		{name: "unaccepted issuer", token: sign(t, "RS256", "rsa-1", issuer.rsaKey, claims(map[string]any{"iss": "https://evil.example"})), wantErr: ErrInvalidToken},
+		{name: "unknown key ID", token: sign(t, "RS256", "rsa-9", issuer.rsaKey, claims(nil)), wantErr: ErrInvalidToken},
+		{name: "HMAC algorithm", token: sign(t, "HS256", "rsa-1", issuer.rsaKey, claims(nil)), wantErr: ErrInvalidToken},
+		{name: "no exp", token: sign(t, "RS256", "rsa-1", issuer.rsaKey, claims(map[string]any{"exp": nil})), wantErr: ErrInvalidToken},
+		{name: "no sub", token: sign(t, "RS256", "rsa-1", issuer.rsaKey, claims(map[string]any{"sub": ""})), wantErr: ErrInvalidToken},
+		{name: "not a JWS", token: "abc.def", wantErr: ErrInvalidToken},
+	}
+
+	v, err := NewValidator(Config{Issuers: []string{issuer.URL}, Audiences: []string{"client-a", "client-b"}, FetchTimeout: 5 * time.Second, KeyCacheTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.keys.client = issuer.Client()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Validate(context.Background(), tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Validate error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if got.Subject != "user-1" || got.Audience != tt.wantAudience {
+				t.Errorf("claims = %+v, want sub user-1 and aud %s", got, tt.wantAudience)
+			}
+		})
+	}
+	if n := issuer.fetches.Load(); n != 1 {
+		t.Errorf("fetched the JWKS %d times, want once while cached", n)
+	}
+}
+
+func TestValidateKeysUnavailable(t *testing.T) {
+	issuer := newMockIssuer(t)
+	issuer.unhealthy.Store(true)
+
+	v, err := NewValidator(Config{Issuers: []string{issuer.URL}, Audiences: []string{"client-a"}, FetchTimeout: 5 * time.Second, KeyCacheTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.keys.client = issuer.Client()
+
+	token := sign(t, "RS256", "rsa-1", issuer.rsaKey, map[string]any{"iss": issuer.URL, "sub": "user-1", "aud": "client-a", "exp": time.Now().Add(time.Hour).Unix()})
+	if _, err := v.Validate(context.Background(), token); !errors.Is(err, ErrKeysUnavailable) {
+		t.Fatalf("Validate error = %v, want ErrKeysUnavailable", err)
+	}
+}
+
+func TestNewValidatorRequiresIssuersAndAudiences(t *testing.T) {
+	github := func(c Constraint) map[string]Constraint { return map[string]Constraint{GitHubActionsIssuer: c} }
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{name: "no issuers", cfg: Config{Audiences: []string{"client-a"}}, wantErr: true},
+		{name: "no audiences", cfg: Config{Issuers: []string{"https://accounts.google.com"}}, wantErr: true},
+		{name: "issuer with client IDs", cfg: Config{Issuers: []string{"https://accounts.google.com"}, Audiences: []string{"client-a"}}},
+		{
+			name:    "GitHub Actions without a constraint",
+			cfg:     Config{Issuers: []string{GitHubActionsIssuer}, Audiences: []string{"client-a"}},
+			wantErr: true,
+		},
+		{
+			name:    "GitHub Actions without an audience",
+			cfg:     Config{Issuers: []string{GitHubActionsIssuer}, Audiences: []string{"client-a"}, Constraints: github(Constraint{Repositories: []string{"acme/app"}})},
+			wantErr: true,
+		},
+		{
+			name:    "GitHub Actions without repositories",
+			cfg:     Config{Issuers: []string{GitHubActionsIssuer}, Audiences: []string{"client-a"}, Constraints: github(Constraint{Audience: "uigs"})},
+			wantErr: true,
+		},
+		{
+			name: "GitHub Actions constrained",
+			cfg:  Config{Issuers: []string{GitHubActionsIssuer}, Constraints: github(Constraint{Audience: "uigs", Repositories: []string{"acme/app"}})},
+		},
+		{
+			name:    "constrained GitHub Actions with an unconstrained issuer and no client IDs",
+			cfg:     Config{Issuers: []string{GitHubActionsIssuer, "https://accounts.google.com"}, Constraints: github(Constraint{Audience: "uigs", Repositories: []string{"acme/app"}})},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewValidator(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewValidator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConstraint(t *testing.T) {
+	issuer := newMockIssuer(t)
+	claims := func(extra map[string]any) map[string]any {
+		c := map[string]any{
+			"iss": issuer.URL, "sub": "repo:acme/app:ref:refs/heads/main", "aud": "uigs",
+			"repository": "acme/app", "exp": time.Now().Add(time.Hour).Unix(),
+		}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		claims  map[string]any
+		wantErr bool
+	}{
+		{name: "accepted repository", claims: claims(nil)},
+		{name: "another repository", claims: claims(map[string]any{"repository": "evil/app", "sub": "repo:evil/app:ref:refs/heads/main"}), wantErr: true},
+		{name: "no repository claim", claims: claims(map[string]any{"repository": nil}), wantErr: true},
+		{name: "sub naming another repository", claims: claims(map[string]any{"sub": "repo:evil/app:ref:refs/heads/main"}), wantErr: true},
+		{name: "repository prefix in sub", claims: claims(map[string]any{"sub": "repo:acme/app-fork:ref:refs/heads/main"}), wantErr: true},
+		{name: "a global client ID", claims: claims(map[string]any{"aud": "client-a"}), wantErr: true},
+		{name: "the default GitHub audience", claims: claims(map[string]any{"aud": "https://github.com/acme"}), wantErr: true},
+	}
+
+	v, err := NewValidator(Config{
+		Issuers:     []string{issuer.URL},
+		Audiences:   []string{"client-a"},
+		Constraints: map[string]Constraint{issuer.URL: {Audience: "uigs", Repositories: []string{"acme/app"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.keys.client = issuer.Client()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := v.Validate(context.Background(), sign(t, "RS256", "rsa-1", issuer.rsaKey, tt.claims))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("Validate() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if got.Audience != "uigs" {
+				t.Errorf("Validate() audience = %q, want uigs", got.Audience)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package outbox
+
+import "time"
+
+// publishTimeout bounds a single confirmed publish, so a stalled broker
+// cannot hold a batch's row locks indefinitely.
+const publishTimeout = 10 * time.Second
+
+// nextBatchSize returns the batch size to use after a batch of size events
+// published with the given mean latency, leaving backlog events. Large
+// backlogs double the batch to drain faster; a backlog that fits in one
+// batch, or slow publishes, halve it so locks are held briefly. The result
+// stays within the configured bounds.
+func nextBatchSize(size, backlog int, latency time.Duration, cfg Config) int {
+	switch {
+	case cfg.LatencyTarget > 0 && latency > cfg.LatencyTarget:
+		size /= 2
+	case backlog < size:
+		size /= 2
+	case backlog > 2*size:
+		size *= 2
+	}
+	return min(max(size, cfg.MinBatchSize), cfg.MaxBatchSize)
+}
@@ -0,0 +1,235 @@
+// Package outbox relays events that were stored but never confirmed as
+// published, such as those accepted while the broker was unreachable, to
+// the queue.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/queue"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// Config controls the relay.
+type Config struct {
+	// Interval is the time between passes over the backlog.
+	Interval time.Duration
+	// After is how old a pending event must be before the relay takes it
+	// over from the publish that was started at ingestion.
+	After time.Duration
+	// MinBatchSize and MaxBatchSize bound the number of events locked and
+	// published per transaction.
+	MinBatchSize int
+	MaxBatchSize int
+	// LatencyTarget is the mean publish latency above which batches shrink.
+	LatencyTarget time.Duration
+}
+
+// Stats summarizes the relay's progress.
+type Stats struct {
+	BatchSize int   `json:"batch_size"`
+	Backlog   int   `json:"backlog"`
+	Published int64 `json:"published"`
+	Failed    int64 `json:"failed"`
+	// DeadLettered counts events routed to the dead-letter queue; they are
+	// included in Published.
+	DeadLettered int64      `json:"dead_lettered"`
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+}
+
+// Relay periodically publishes undelivered events, adapting its batch size
+// to the backlog and the broker's latency.
+type Relay struct {
+	repo      repository.OutboxRepository
+	publisher queue.ConfirmPublisher
+	cfg       Config
+	logger    *slog.Logger
+
+	mu    sync.Mutex
+	stats Stats
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewRelay creates an outbox relay starting at the minimum batch size.
+func NewRelay(repo repository.OutboxRepository, publisher queue.ConfirmPublisher, cfg Config, logger *slog.Logger) *Relay {
+	if cfg.MinBatchSize < 1 {
+		cfg.MinBatchSize = 1
+	}
+	if cfg.MaxBatchSize < cfg.MinBatchSize {
+		cfg.MaxBatchSize = cfg.MinBatchSize
+	}
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+		cfg:       cfg,
+		logger:    logger,
+		stats:     Stats{BatchSize: cfg.MinBatchSize},
+	}
+}
+
+// Start runs the relay in the background until ctx is cancelled or Stop is called.
+func (r *Relay) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(r.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			if n, err := r.RunOnce(ctx); err != nil {
+				r.logger.Error("Outbox relay run failed", "error", err, "published", n)
+			} else if n > 0 {
+				r.logger.Info("Outbox relay run completed", "published", n)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop halts the relay and waits for an in-progress run to finish.
+func (r *Relay) Stop() {
+	r.once.Do(func() {
+		if r.cancel != nil {
+			r.cancel()
+			<-r.done
+		}
+	})
+}
+
+// RunOnce publishes batches of undelivered events until the backlog is
+// drained or a publish fails, and returns the number published.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	published := 0
+
+	defer func() {
+		now := time.Now().UTC()
+		r.mu.Lock()
+		r.stats.LastRunAt = &now
+		r.mu.Unlock()
+	}()
+
+	for ctx.Err() == nil {
+		backlog, err := r.repo.CountUndelivered(ctx, time.Now().Add(-r.cfg.After))
+		if err != nil {
+			return published, err
+		}
+		r.setBacklog(backlog)
+		if backlog == 0 {
+			return published, nil
+		}
+
+		size := r.BatchSize()
+		start := time.Now()
+		n, err := r.repo.RelayUndelivered(ctx, time.Now().Add(-r.cfg.After), size, r.publish)
+		elapsed := time.Since(start)
+		published += n
+		r.record(n, err != nil)
+		if err != nil {
+			r.resize(r.cfg.MinBatchSize)
+			return published, err
+		}
+		if n > 0 {
+			r.resize(nextBatchSize(size, backlog-n, elapsed/time.Duration(n), r.cfg))
+		}
+		// A short batch means the rest is held by another relay
+		if n < size {
+			return published, nil
+		}
+	}
+
+	return published, ctx.Err()
+}
+
+// BatchSize returns the number of events the next batch will take.
+func (r *Relay) BatchSize() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats.BatchSize
+}
+
+// Stats returns the relay's counters and current batch size.
+func (r *Relay) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+// publish publishes one event and returns the delivery status to record.
+// A dead-lettered event is done with rather than failed, so it is not
+// relayed again.
+func (r *Relay) publish(ctx context.Context, event *models.IngestionEvent) (string, error) {
+	raw := event.RawPayload
+	if len(event.NormalizedPayload) > 0 {
+		raw = event.NormalizedPayload
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return "", fmt.Errorf("failed to decode stored payload of %s: %w", event.EventID, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, publishTimeout)
+	defer cancel()
+	err := r.publisher.PublishConfirmed(ctx, &models.QueueMessage{
+		EventID:    event.EventID,
+		UserID:     event.UserID,
+		SourceType: event.SourceType,
+		Payload:    payload,
+		Enrichment: event.Enrichment,
+		Tags:       event.Tags,
+		Metadata:   event.Metadata,
+		DataModel:  event.DataModel,
+		Timestamp:  event.CreatedAt,
+		OccurredAt: event.OccurredAt,
+	})
+	if errors.Is(err, queue.ErrDeadLettered) {
+		r.mu.Lock()
+		r.stats.DeadLettered++
+		r.mu.Unlock()
+		return models.DeliveryStatusDeadLettered, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return models.DeliveryStatusQueued, nil
+}
+
+func (r *Relay) setBacklog(backlog int) {
+	r.mu.Lock()
+	r.stats.Backlog = backlog
+	r.mu.Unlock()
+}
+
+func (r *Relay) resize(size int) {
+	r.mu.Lock()
+	r.stats.BatchSize = size
+	r.mu.Unlock()
+}
+
+func (r *Relay) record(published int, failed bool) {
+	r.mu.Lock()
+	r.stats.Published += int64(published)
+	r.stats.Backlog -= published
+	if failed {
+		r.stats.Failed++
+	}
+	r.mu.Unlock()
+}
@@ -0,0 +1,214 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/queue"
+)
+
+// fakeOutbox holds events in memory with their delivery status.
+type fakeOutbox struct {
+	mu     sync.Mutex
+	events []*models.IngestionEvent
+}
+
+func newFakeOutbox(n int) *fakeOutbox {
+	o := &fakeOutbox{}
+	for i := 0; i < n; i++ {
+		o.events = append(o.events, &models.IngestionEvent{
+			EventID:        fmt.Sprintf("evt-%d", i),
+			RawPayload:     []byte(`{"n":1}`),
+			DeliveryStatus: models.DeliveryStatusPending,
+			CreatedAt:      time.Now().Add(-time.Hour),
+		})
+	}
+	return o
+}
+
+func undelivered(e *models.IngestionEvent, before time.Time) bool {
+	return (e.DeliveryStatus == models.DeliveryStatusPending || e.DeliveryStatus == models.DeliveryStatusFailed) && e.CreatedAt.Before(before)
+}
+
+func (o *fakeOutbox) CountUndelivered(_ context.Context, before time.Time) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n := 0
+	for _, e := range o.events {
+		if undelivered(e, before) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (o *fakeOutbox) RelayUndelivered(ctx context.Context, before time.Time, limit int, publish func(context.Context, *models.IngestionEvent) (string, error)) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n := 0
+	for _, e := range o.events {
+		if n == limit {
+			break
+		}
+		if !undelivered(e, before) {
+			continue
+		}
+		status, err := publish(ctx, e)
+		if err != nil {
+			return n, err
+		}
+		e.DeliveryStatus = status
+		n++
+	}
+	return n, nil
+}
+
+func (o *fakeOutbox) statuses() map[string]int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	counts := make(map[string]int)
+	for _, e := range o.events {
+		counts[e.DeliveryStatus]++
+	}
+	return counts
+}
+
+// fakeBroker confirms publishes unless it is down; events in deadLetter are
+// dead-lettered.
+type fakeBroker struct {
+	mu         sync.Mutex
+	down       bool
+	deadLetter map[string]bool
+	confirmed  []string
+}
+
+func (b *fakeBroker) PublishConfirmed(_ context.Context, msg *models.QueueMessage) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.down {
+		return errors.New("connection refused")
+	}
+	if b.deadLetter[msg.EventID] {
+		return queue.ErrDeadLettered
+	}
+	b.confirmed = append(b.confirmed, msg.EventID)
+	return nil
+}
+
+func (b *fakeBroker) setDown(down bool) {
+	b.mu.Lock()
+	b.down = down
+	b.mu.Unlock()
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestRelayBrokerOutageAndRecovery(t *testing.T) {
+	repo := newFakeOutbox(5)
+	broker := &fakeBroker{down: true}
+	relay := NewRelay(repo, broker, Config{MinBatchSize: 2, MaxBatchSize: 8}, testLogger())
+
+	steps := []struct {
+		name          string
+		down          bool
+		wantPublished int
+		wantErr       bool
+		wantStatuses  map[string]int
+	}{
+		{name: "broker down", down: true, wantErr: true, wantStatuses: map[string]int{models.DeliveryStatusPending: 5}},
+		{name: "still down", down: true, wantErr: true, wantStatuses: map[string]int{models.DeliveryStatusPending: 5}},
+		{name: "broker recovered", down: false, wantPublished: 5, wantStatuses: map[string]int{models.DeliveryStatusQueued: 5}},
+		{name: "nothing left", down: false, wantStatuses: map[string]int{models.DeliveryStatusQueued: 5}},
+	}
+	for _, step := range steps {
+		broker.setDown(step.down)
+		n, err := relay.RunOnce(context.Background())
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: RunOnce error = %v, want error %v", step.name, err, step.wantErr)
+		}
+		if n != step.wantPublished {
+			t.Errorf("%s: published %d, want %d", step.name, n, step.wantPublished)
+		}
+		if got := repo.statuses(); fmt.Sprint(got) != fmt.Sprint(step.wantStatuses) {
+			t.Errorf("%s: statuses = %v, want %v", step.name, got, step.wantStatuses)
+		}
+	}
+
+	stats := relay.Stats()
+	if stats.Published != 5 || stats.Failed != 2 || stats.Backlog != 0 {
+		t.Errorf("stats = %+v, want 5 published, 2 failed runs and no backlog", stats)
+	}
+	if len(broker.confirmed) != 5 {
+		t.Errorf("broker confirmed %d messages, want each event once", len(broker.confirmed))
+	}
+}
+
+func TestRelayDeadLettersAreDone(t *testing.T) {
+	repo := newFakeOutbox(3)
+	broker := &fakeBroker{deadLetter: map[string]bool{"evt-1": true}}
+	relay := NewRelay(repo, broker, Config{MinBatchSize: 10, MaxBatchSize: 10}, testLogger())
+
+	if _, err := relay.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	want := map[string]int{models.DeliveryStatusQueued: 2, models.DeliveryStatusDeadLettered: 1}
+	if got := repo.statuses(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+	if relay.Stats().DeadLettered != 1 {
+		t.Errorf("DeadLettered = %d, want 1", relay.Stats().DeadLettered)
+	}
+}
+
+func TestRelayStartStop(t *testing.T) {
+	repo := newFakeOutbox(4)
+	broker := &fakeBroker{}
+	relay := NewRelay(repo, broker, Config{Interval: 10 * time.Millisecond, MinBatchSize: 1, MaxBatchSize: 4}, testLogger())
+
+	relay.Start(context.Background())
+	deadline := time.Now().Add(2 * time.Second)
+	for repo.statuses()[models.DeliveryStatusQueued] < 4 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	relay.Stop()
+	relay.Stop()
+
+	if got := repo.statuses()[models.DeliveryStatusQueued]; got != 4 {
+		t.Errorf("relayed %d events, want 4", got)
+	}
+}
+
+func TestNextBatchSize(t *testing.T) {
+	cfg := Config{MinBatchSize: 10, MaxBatchSize: 100, LatencyTarget: 50 * time.Millisecond}
+
+	tests := []struct {
+		name    string
+		size    int
+		backlog int
+		latency time.Duration
+		want    int
+	}{
+		{name: "large backlog doubles", size: 20, backlog: 1000, latency: time.Millisecond, want: 40},
+		{name: "doubling is capped", size: 80, backlog: 1000, latency: time.Millisecond, want: 100},
+		{name: "small backlog halves", size: 40, backlog: 10, latency: time.Millisecond, want: 20},
+		{name: "halving is floored", size: 10, backlog: 0, latency: time.Millisecond, want: 10},
+		{name: "slow publishes halve", size: 40, backlog: 1000, latency: time.Second, want: 20},
+		{name: "steady backlog keeps size", size: 40, backlog: 60, latency: time.Millisecond, want: 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextBatchSize(tt.size, tt.backlog, tt.latency, cfg); got != tt.want {
+				t.Errorf("nextBatchSize = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package proof
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Policy requires valid proofs from at least Threshold of the listed issuers.
+type Policy struct {
+	Threshold int      `json:"threshold"`
+	Issuers   []string `json:"issuers"`
+}
+
+// Policies maps a credential type to its signature policy.
+type Policies map[string]Policy
+
+// Outcome is the evaluation of one policy against a credential's proofs.
+type Outcome struct {
+	CredentialType string
+	Policy         Policy
+	// Signers are the distinct required issuers with a valid proof.
+	Signers []string
+}
+
+// Satisfied reports whether enough required issuers signed.
+func (o Outcome) Satisfied() bool {
+	return len(o.Signers) >= o.Policy.Threshold
+}
+
+// LoadPolicies reads policies from a JSON file of the form
+// {"CredentialType": {"threshold": 2, "issuers": ["did:web:a", ...]}}.
+func LoadPolicies(path string) (Policies, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature policies: %w", err)
+	}
+
+	var policies Policies
+	if err := json.Unmarshal(data, &policies); err != nil {
+		return nil, fmt.Errorf("failed to parse signature policies: %w", err)
+	}
+	for credentialType, p := range policies {
+		if p.Threshold < 1 || p.Threshold > len(p.Issuers) {
+			return nil, fmt.Errorf("policy for %s: threshold %d must be between 1 and the number of issuers (%d)", credentialType, p.Threshold, len(p.Issuers))
+		}
+	}
+	return policies, nil
+}
+
+// Evaluate counts the required issuers with at least one valid proof.
+func Evaluate(credentialType string, p Policy, results []Result) Outcome {
+	outcome := Outcome{CredentialType: credentialType, Policy: p}
+	required := make(map[string]bool, len(p.Issuers))
+	for _, issuer := range p.Issuers {
+		required[issuer] = true
+	}
+	for _, r := range results {
+		if !r.Valid || !required[r.Signer] {
+			continue
+		}
+		outcome.Signers = append(outcome.Signers, r.Signer)
+		delete(required, r.Signer)
+	}
+	return outcome
+}
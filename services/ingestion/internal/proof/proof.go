@@ -0,0 +1,252 @@
+// Package proof verifies the Data Integrity proofs attached to Verifiable
+// Credentials, including proof sets and proof chains, and evaluates
+// multi-issuer signature policies against them. Only the eddsa-jcs-2022
+// cryptosuite is supported.
+package proof
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/uigs/ingestion/internal/did"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+const (
+	proofType    = "DataIntegrityProof"
+	cryptosuite  = "eddsa-jcs-2022"
+	proofPurpose = "assertionMethod"
+)
+
+// ErrMissingPreviousProof is returned when a chained proof references a
+// proof that is not on the credential.
+var ErrMissingPreviousProof = errors.New("previous proof not found")
+
+// ErrNoProof is returned by VerifyCredential for a credential without proofs.
+var ErrNoProof = errors.New("credential has no proof")
+
+// Result is the outcome of verifying one proof.
+type Result struct {
+	ID                 string `json:"id,omitempty"`
+	VerificationMethod string `json:"verification_method"`
+	// Signer is the DID controlling the verification method.
+	Signer      string `json:"signer"`
+	Cryptosuite string `json:"cryptosuite"`
+	Valid       bool   `json:"valid"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Verifier checks proofs against keys from resolved DID documents.
+type Verifier struct {
+	resolver did.Resolver
+}
+
+// NewVerifier creates a verifier resolving signer keys with resolver.
+func NewVerifier(resolver did.Resolver) *Verifier {
+	return &Verifier{resolver: resolver}
+}
+
+// Verify checks every proof on credential and returns one result per proof,
+// in document order. A proof naming previousProof is verified over the
+// credential secured with the proofs it references.
+func (v *Verifier) Verify(ctx context.Context, credential map[string]any) []Result {
+	proofs := proofsIn(credential)
+	byID := make(map[string]map[string]any, len(proofs))
+	for _, p := range proofs {
+		if id, ok := p["id"].(string); ok && id != "" {
+			byID[id] = p
+		}
+	}
+
+	unsecured := make(map[string]any, len(credential))
+	for k, val := range credential {
+		if k != "proof" {
+			unsecured[k] = val
+		}
+	}
+
+	results := make([]Result, 0, len(proofs))
+	for _, p := range proofs {
+		result := Result{}
+		result.ID, _ = p["id"].(string)
+		result.VerificationMethod, _ = p["verificationMethod"].(string)
+		result.Cryptosuite, _ = p["cryptosuite"].(string)
+		result.Signer, _ = did.SplitKeyID(result.VerificationMethod)
+
+		if err := v.verifyOne(ctx, unsecured, p, byID); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Valid = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// VerifyCredential checks that credential carries at least one valid proof.
+// When none is valid it returns the error of the first.
+func (v *Verifier) VerifyCredential(ctx context.Context, credential map[string]any) error {
+	results := v.Verify(ctx, credential)
+	if len(results) == 0 {
+		return ErrNoProof
+	}
+	for _, r := range results {
+		if r.Valid {
+			return nil
+		}
+	}
+	return errors.New(results[0].Error)
+}
+
+// VerifyProof checks that vc carries at least one valid proof, like
+// VerifyCredential. The credential is verified as the model serializes it:
+// a proof that also signed properties the model does not hold fails, so
+// verify the raw JSON with VerifyCredential when a credential may carry
+// other properties.
+func (v *Verifier) VerifyProof(ctx context.Context, vc *models.VerifiableCredential) error {
+	data, err := json.Marshal(vc)
+	if err != nil {
+		return fmt.Errorf("failed to serialize credential: %w", err)
+	}
+	var credential map[string]any
+	if err := json.Unmarshal(data, &credential); err != nil {
+		return fmt.Errorf("failed to serialize credential: %w", err)
+	}
+	return v.VerifyCredential(ctx, credential)
+}
+
+func (v *Verifier) verifyOne(ctx context.Context, unsecured, p map[string]any, byID map[string]map[string]any) error {
+	if t, _ := p["type"].(string); t != proofType {
+		return fmt.Errorf("unsupported proof type %q", t)
+	}
+	if cs, _ := p["cryptosuite"].(string); cs != cryptosuite {
+		return fmt.Errorf("unsupported cryptosuite %q", cs)
+	}
+	if purpose, _ := p["proofPurpose"].(string); purpose != proofPurpose {
+		return fmt.Errorf("proof purpose must be %s", proofPurpose)
+	}
+	method, _ := p["verificationMethod"].(string)
+	if method == "" {
+		return errors.New("proof has no verificationMethod")
+	}
+	value, _ := p["proofValue"].(string)
+	signature, err := did.DecodeMultibase(value)
+	if err != nil {
+		return fmt.Errorf("invalid proofValue: %w", err)
+	}
+	if len(signature) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid signature length %d", len(signature))
+	}
+
+	document := unsecured
+	if previous := stringsIn(p["previousProof"]); len(previous) > 0 {
+		chained := make([]any, 0, len(previous))
+		for _, id := range previous {
+			prev, ok := byID[id]
+			if !ok {
+				return fmt.Errorf("%w: %s", ErrMissingPreviousProof, id)
+			}
+			chained = append(chained, prev)
+		}
+		document = make(map[string]any, len(unsecured)+1)
+		for k, val := range unsecured {
+			document[k] = val
+		}
+		document["proof"] = chained
+	}
+
+	config := make(map[string]any, len(p))
+	for k, val := range p {
+		if k != "proofValue" {
+			config[k] = val
+		}
+	}
+
+	data, err := hashData(config, document)
+	if err != nil {
+		return err
+	}
+
+	signer, _ := did.SplitKeyID(method)
+	doc, err := v.resolver.Resolve(ctx, signer)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", signer, err)
+	}
+	key, err := doc.PublicKey(method)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(key, data, signature) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// hashData is the eddsa-jcs-2022 signing input: the SHA-256 of the
+// canonical proof configuration followed by that of the canonical document.
+func hashData(config, document map[string]any) ([]byte, error) {
+	canonicalConfig, err := canonicalize(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize proof: %w", err)
+	}
+	canonicalDocument, err := canonicalize(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize credential: %w", err)
+	}
+	configHash := sha256.Sum256(canonicalConfig)
+	documentHash := sha256.Sum256(canonicalDocument)
+	return append(configHash[:], documentHash[:]...), nil
+}
+
+// canonicalize serializes v with sorted object keys and no HTML escaping,
+// which matches the JSON Canonicalization Scheme (RFC 8785) for the values
+// credentials carry.
+func canonicalize(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// proofsIn returns the proofs on a credential, whose proof property may be
+// a single object or an array.
+func proofsIn(credential map[string]any) []map[string]any {
+	switch p := credential["proof"].(type) {
+	case map[string]any:
+		return []map[string]any{p}
+	case []any:
+		proofs := make([]map[string]any, 0, len(p))
+		for _, item := range p {
+			if m, ok := item.(map[string]any); ok {
+				proofs = append(proofs, m)
+			}
+		}
+		return proofs
+	}
+	return nil
+}
+
+// stringsIn reads a property that may be a string or an array of strings.
+func stringsIn(v any) []string {
+	switch s := v.(type) {
+	case string:
+		return []string{s}
+	case []any:
+		out := make([]string, 0, len(s))
+		for _, item := range s {
+			if str, ok := item.(string); ok {
+				out = append(out, str)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package proof
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/uigs/ingestion/internal/did"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+const testIssuer = "did:web:issuer.example"
+
+// fakeResolver serves fixed DID documents.
+type fakeResolver map[string]*did.Document
+
+func (r fakeResolver) Resolve(_ context.Context, id string) (*did.Document, error) {
+	doc, ok := r[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown DID %s", id)
+	}
+	return doc, nil
+}
+
+func resolverFor(issuer string, key ed25519.PublicKey) fakeResolver {
+	return fakeResolver{issuer: {
+		ID: issuer,
+		VerificationMethod: []did.VerificationMethod{{
+			ID:           issuer + "#key-1",
+			Type:         "JsonWebKey2020",
+			Controller:   issuer,
+			PublicKeyJwk: &did.JWK{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(key)},
+		}},
+	}}
+}
+
+// encodeMultibase encodes b as base58btc multibase ("z...").
+func encodeMultibase(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return "z" + string(out)
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// toMap round-trips v through JSON.
+func toMap(t *testing.T, v any) map[string]any {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// sign returns an eddsa-jcs-2022 proof of credential, which must not carry
+// a proof yet.
+func sign(t *testing.T, priv ed25519.PrivateKey, credential map[string]any) models.Proof {
+	t.Helper()
+	p := models.Proof{
+		Type:               proofType,
+		Cryptosuite:        cryptosuite,
+		Created:            "2026-10-15T09:30:00Z",
+		VerificationMethod: testIssuer + "#key-1",
+		ProofPurpose:       proofPurpose,
+	}
+	config := toMap(t, p)
+	delete(config, "proofValue")
+	data, err := hashData(config, credential)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.ProofValue = encodeMultibase(ed25519.Sign(priv, data))
+	return p
+}
+
+func testCredential() models.VerifiableCredential {
+	return models.VerifiableCredential{
+		Context:           models.Contexts{"https://www.w3.org/2018/credentials/v1"},
+		Type:              models.Strings{"VerifiableCredential", "DegreeCredential"},
+		Issuer:            testIssuer,
+		IssuanceDate:      "2026-10-01T00:00:00Z",
+		CredentialSubject: map[string]interface{}{"id": "did:example:alice", "degree": "BSc"},
+	}
+}
+
+func TestVerifyProof(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherPriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned := testCredential()
+	valid := sign(t, priv, toMap(t, unsigned))
+
+	tests := []struct {
+		name    string
+		vc      func() models.VerifiableCredential
+		wantErr error
+		wantOK  bool
+	}{
+		{
+			name: "valid proof",
+			vc: func() models.VerifiableCredential {
+				vc := testCredential()
+				vc.Proof = models.ProofSet{valid}
+				return vc
+			},
+			wantOK: true,
+		},
+		{
+			name: "one valid proof in a set",
+			vc: func() models.VerifiableCredential {
+				vc := testCredential()
+				vc.Proof = models.ProofSet{sign(t, otherPriv, toMap(t, unsigned)), valid}
+				return vc
+			},
+			wantOK: true,
+		},
+		{
+			name: "tampered subject",
+			vc: func() models.VerifiableCredential {
+				vc := testCredential()
+				vc.CredentialSubject["degree"] = "PhD"
+				vc.Proof = models.ProofSet{valid}
+				return vc
+			},
+		},
+		{
+			name: "signed by another key",
+			vc: func() models.VerifiableCredential {
+				vc := testCredential()
+				vc.Proof = models.ProofSet{sign(t, otherPriv, toMap(t, unsigned))}
+				return vc
+			},
+		},
+		{
+			name: "unsupported cryptosuite",
+			vc: func() models.VerifiableCredential {
+				vc := testCredential()
+				p := valid
+				p.Cryptosuite = "ecdsa-rdfc-2019"
+				vc.Proof = models.ProofSet{p}
+				return vc
+			},
+		},
+		{
+			name: "unknown signer",
+			vc: func() models.VerifiableCredential {
+				vc := testCredential()
+				p := valid
+				p.VerificationMethod = "did:web:other.example#key-1"
+				vc.Proof = models.ProofSet{p}
+				return vc
+			},
+		},
+		{
+			name:    "no proof",
+			vc:      testCredential,
+			wantErr: ErrNoProof,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVerifier(resolverFor(testIssuer, pub))
+			vc := tt.vc()
+			err := v.VerifyProof(context.Background(), &vc)
+			if (err == nil) != tt.wantOK {
+				t.Fatalf("VerifyProof() error = %v, want ok %v", err, tt.wantOK)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyProof() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyProofMatchesVerifyCredential(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewVerifier(resolverFor(testIssuer, pub))
+
+	tests := []struct {
+		name           string
+		extra          map[string]any
+		wantVerifyJSON bool
+		wantVerifyVC   bool
+	}{
+		{name: "only properties the model holds", wantVerifyJSON: true, wantVerifyVC: true},
+		{name: "a property the model drops", extra: map[string]any{"name": "Bachelor of Science"}, wantVerifyJSON: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := toMap(t, testCredential())
+			for k, val := range tt.extra {
+				raw[k] = val
+			}
+			raw["proof"] = toMap(t, sign(t, priv, raw))
+
+			if err := v.VerifyCredential(context.Background(), raw); (err == nil) != tt.wantVerifyJSON {
+				t.Errorf("VerifyCredential() error = %v, want ok %v", err, tt.wantVerifyJSON)
+			}
+
+			data, err := json.Marshal(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var vc models.VerifiableCredential
+			if err := json.Unmarshal(data, &vc); err != nil {
+				t.Fatal(err)
+			}
+			if err := v.VerifyProof(context.Background(), &vc); (err == nil) != tt.wantVerifyVC {
+				t.Errorf("VerifyProof() error = %v, want ok %v", err, tt.wantVerifyVC)
+			}
+		})
+	}
+}
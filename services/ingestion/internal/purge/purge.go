@@ -0,0 +1,99 @@
+// Package purge hard-deletes soft-deleted events once their restore grace
+// period has passed.
+package purge
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// Config controls the purge worker.
+type Config struct {
+	// Grace is how long a deleted event can still be restored.
+	Grace time.Duration
+	// Interval is the time between purge runs.
+	Interval time.Duration
+	// BatchSize is the maximum number of events deleted per statement.
+	BatchSize int
+}
+
+// Worker periodically purges events deleted longer ago than the grace period.
+type Worker struct {
+	repo   repository.DeletionRepository
+	cfg    Config
+	logger *slog.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewWorker creates a purge worker.
+func NewWorker(repo repository.DeletionRepository, cfg Config, logger *slog.Logger) *Worker {
+	return &Worker{
+		repo:   repo,
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Start runs the worker in the background until ctx is cancelled or Stop is called.
+func (w *Worker) Start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(w.cfg.Interval)
+		defer ticker.Stop()
+
+		for {
+			if n, err := w.RunOnce(ctx); err != nil {
+				w.logger.Error("Purge run failed", "error", err, "purged", n)
+			} else if n > 0 {
+				w.logger.Info("Purge run completed", "purged", n)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop halts the worker and waits for an in-progress run to finish.
+func (w *Worker) Stop() {
+	w.once.Do(func() {
+		if w.cancel != nil {
+			w.cancel()
+			<-w.done
+		}
+	})
+}
+
+// RunOnce purges every event whose grace period has passed and returns the
+// number of events purged.
+func (w *Worker) RunOnce(ctx context.Context) (int64, error) {
+	cutoff := time.Now().UTC().Add(-w.cfg.Grace)
+	var total int64
+
+	for ctx.Err() == nil {
+		n, err := w.repo.PurgeDeletedEvents(ctx, cutoff, w.cfg.BatchSize)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < int64(w.cfg.BatchSize) {
+			return total, nil
+		}
+	}
+
+	return total, ctx.Err()
+}
@@ -0,0 +1,188 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// Circuit breaker states.
+const (
+	// BreakerClosed passes publishes through.
+	BreakerClosed = "closed"
+	// BreakerOpen fails publishes without trying the broker.
+	BreakerOpen = "open"
+	// BreakerHalfOpen lets one probe publish through to test the broker.
+	BreakerHalfOpen = "half_open"
+)
+
+// ErrCircuitOpen is returned while the breaker fails publishes fast.
+var ErrCircuitOpen = errors.New("publish circuit open")
+
+// BreakerConfig controls when a BreakerPublisher opens and for how long.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed publishes that
+	// opens the breaker.
+	FailureThreshold int
+	// Cooldown is how long the breaker stays open before a probe.
+	Cooldown time.Duration
+}
+
+// BreakerStats reports the state of a BreakerPublisher.
+type BreakerStats struct {
+	State               string `json:"state"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	// Opened counts how often consecutive failures opened the breaker;
+	// failed probes reopen it without being counted.
+	Opened   int64      `json:"opened"`
+	Rejected int64      `json:"rejected"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+// BreakerPublisher is a circuit breaker around a Publisher. After
+// FailureThreshold consecutive failures it opens and fails publishes with
+// ErrCircuitOpen, which stores their events as failed for the outbox relay
+// to publish later, instead of waiting on a degraded broker. After the
+// cooldown it is half-open: one publish probes the broker, and closes the
+// breaker if it succeeds or reopens it if it fails.
+//
+// Dead-lettered messages and publishes cancelled by their caller do not
+// count as failures, since they say nothing about the broker's health.
+type BreakerPublisher struct {
+	next   Publisher
+	cfg    BreakerConfig
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+	opened   int64
+	rejected int64
+}
+
+// NewBreakerPublisher wraps next in a circuit breaker.
+func NewBreakerPublisher(next Publisher, cfg BreakerConfig, logger *slog.Logger) *BreakerPublisher {
+	return &BreakerPublisher{
+		next:   next,
+		cfg:    cfg,
+		logger: logger,
+		now:    time.Now,
+		state:  BreakerClosed,
+	}
+}
+
+// Publish publishes msg unless the breaker is open.
+func (b *BreakerPublisher) Publish(ctx context.Context, msg *models.QueueMessage) error {
+	probe, err := b.allow()
+	if err != nil {
+		return err
+	}
+	err = b.next.Publish(ctx, msg)
+	b.record(probe, err)
+	return err
+}
+
+// allow reports ErrCircuitOpen unless a publish may go to the broker, and
+// whether it is the probe of a half-open breaker. An open breaker whose
+// cooldown has passed becomes half-open.
+func (b *BreakerPublisher) allow() (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cfg.Cooldown {
+			b.rejected++
+			return false, ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.logger.Info("Publish circuit half-open, probing broker")
+	case BreakerHalfOpen:
+		if b.probing {
+			b.rejected++
+			return false, ErrCircuitOpen
+		}
+	default:
+		return false, nil
+	}
+	b.probing = true
+	return true, nil
+}
+
+// record updates the breaker with the outcome of a publish.
+func (b *BreakerPublisher) record(probe bool, err error) {
+	if errors.Is(err, ErrDeadLettered) || errors.Is(err, context.Canceled) {
+		err = nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if probe {
+		b.probing = false
+	}
+	if err == nil {
+		if probe {
+			b.logger.Info("Publish circuit closed")
+		}
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if probe || b.failures >= b.cfg.FailureThreshold {
+		if !probe {
+			b.opened++
+		}
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+		b.logger.Warn("Publish circuit open",
+			"error", err,
+			"consecutive_failures", b.failures,
+			"cooldown", b.cfg.Cooldown.String(),
+		)
+	}
+}
+
+// Healthy reports ErrCircuitOpen while the breaker is open, or else the
+// wrapped publisher's health.
+func (b *BreakerPublisher) Healthy() error {
+	b.mu.Lock()
+	open := b.state == BreakerOpen
+	b.mu.Unlock()
+	if open {
+		return ErrCircuitOpen
+	}
+	return b.next.Healthy()
+}
+
+// Close closes the wrapped publisher.
+func (b *BreakerPublisher) Close() error {
+	return b.next.Close()
+}
+
+// Stats returns the breaker's state.
+func (b *BreakerPublisher) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := BreakerStats{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Opened:              b.opened,
+		Rejected:            b.rejected,
+	}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		s.OpenedAt = &openedAt
+	}
+	return s
+}
@@ -0,0 +1,176 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// scriptedPublisher fails its publishes with the errors in failures, in
+// turn, and counts the publishes that reached it.
+type scriptedPublisher struct {
+	Publisher
+	failures []error
+	calls    int
+}
+
+func (p *scriptedPublisher) Publish(context.Context, *models.QueueMessage) error {
+	p.calls++
+	if p.calls <= len(p.failures) {
+		return p.failures[p.calls-1]
+	}
+	return nil
+}
+
+func (p *scriptedPublisher) Healthy() error { return nil }
+
+func TestBreakerPublisherTransitions(t *testing.T) {
+	down := errors.New("connection refused")
+	const cooldown = 30 * time.Second
+
+	type step struct {
+		advance     time.Duration
+		wantErr     error
+		wantState   string
+		wantReached bool
+	}
+	tests := []struct {
+		name         string
+		failures     []error
+		steps        []step
+		wantOpened   int64
+		wantRejected int64
+	}{
+		{
+			name:     "closed, open, half-open, closed",
+			failures: []error{down, down, down},
+			steps: []step{
+				{wantErr: down, wantState: BreakerClosed, wantReached: true},
+				{wantErr: down, wantState: BreakerClosed, wantReached: true},
+				{wantErr: down, wantState: BreakerOpen, wantReached: true},
+				{wantErr: ErrCircuitOpen, wantState: BreakerOpen},
+				{advance: cooldown - time.Second, wantErr: ErrCircuitOpen, wantState: BreakerOpen},
+				{advance: time.Second, wantState: BreakerClosed, wantReached: true},
+				{wantState: BreakerClosed, wantReached: true},
+			},
+			wantOpened:   1,
+			wantRejected: 2,
+		},
+		{
+			name:     "failed probe reopens",
+			failures: []error{down, down, down, down},
+			steps: []step{
+				{wantErr: down, wantState: BreakerClosed, wantReached: true},
+				{wantErr: down, wantState: BreakerClosed, wantReached: true},
+				{wantErr: down, wantState: BreakerOpen, wantReached: true},
+				{advance: cooldown, wantErr: down, wantState: BreakerOpen, wantReached: true},
+				{wantErr: ErrCircuitOpen, wantState: BreakerOpen},
+				{advance: cooldown, wantState: BreakerClosed, wantReached: true},
+			},
+			wantOpened:   1,
+			wantRejected: 1,
+		},
+		{
+			name:     "success resets the failure count",
+			failures: []error{down, down, nil, down, down},
+			steps: []step{
+				{wantErr: down, wantState: BreakerClosed, wantReached: true},
+				{wantErr: down, wantState: BreakerClosed, wantReached: true},
+				{wantState: BreakerClosed, wantReached: true},
+				{wantErr: down, wantState: BreakerClosed, wantReached: true},
+				{wantErr: down, wantState: BreakerClosed, wantReached: true},
+			},
+		},
+		{
+			name:     "dead letters and cancellations are not failures",
+			failures: []error{down, ErrDeadLettered, context.Canceled, down},
+			steps: []step{
+				{wantErr: down, wantState: BreakerClosed, wantReached: true},
+				{wantErr: ErrDeadLettered, wantState: BreakerClosed, wantReached: true},
+				{wantErr: context.Canceled, wantState: BreakerClosed, wantReached: true},
+				{wantErr: down, wantState: BreakerClosed, wantReached: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &scriptedPublisher{failures: tt.failures}
+			b := NewBreakerPublisher(next, BreakerConfig{FailureThreshold: 3, Cooldown: cooldown}, testLogger())
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			b.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				now = now.Add(s.advance)
+				calls := next.calls
+				err := b.Publish(context.Background(), &models.QueueMessage{EventID: "evt-1"})
+				if !errors.Is(err, s.wantErr) || (s.wantErr == nil && err != nil) {
+					t.Fatalf("step %d: Publish() = %v, want %v", i, err, s.wantErr)
+				}
+				if reached := next.calls > calls; reached != s.wantReached {
+					t.Fatalf("step %d: reached the broker = %v, want %v", i, reached, s.wantReached)
+				}
+				if got := b.Stats().State; got != s.wantState {
+					t.Fatalf("step %d: state = %q, want %q", i, got, s.wantState)
+				}
+				if open := errors.Is(b.Healthy(), ErrCircuitOpen); open != (s.wantState == BreakerOpen) {
+					t.Errorf("step %d: Healthy() reports open %v in state %q", i, open, s.wantState)
+				}
+			}
+			stats := b.Stats()
+			if stats.Opened != tt.wantOpened || stats.Rejected != tt.wantRejected {
+				t.Errorf("opened = %d, rejected = %d; want %d, %d", stats.Opened, stats.Rejected, tt.wantOpened, tt.wantRejected)
+			}
+		})
+	}
+}
+
+func TestBreakerPublisherSingleProbe(t *testing.T) {
+	release := make(chan struct{})
+	next := &blockingPublisher{release: release, started: make(chan struct{})}
+	b := NewBreakerPublisher(next, BreakerConfig{FailureThreshold: 1, Cooldown: time.Minute}, testLogger())
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	next.err = errors.New("connection refused")
+	b.Publish(context.Background(), &models.QueueMessage{})
+	next.err = nil
+	now = now.Add(time.Minute)
+
+	done := make(chan error)
+	go func() { done <- b.Publish(context.Background(), &models.QueueMessage{}) }()
+	<-next.started
+	if got := b.Stats().State; got != BreakerHalfOpen {
+		t.Errorf("state during the probe = %q, want %q", got, BreakerHalfOpen)
+	}
+	if err := b.Publish(context.Background(), &models.QueueMessage{}); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Publish() during the probe = %v, want %v", err, ErrCircuitOpen)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("probe Publish() = %v, want nil", err)
+	}
+	if got := b.Stats().State; got != BreakerClosed {
+		t.Errorf("state after the probe = %q, want %q", got, BreakerClosed)
+	}
+}
+
+// blockingPublisher fails with err at once while it is set, and otherwise
+// signals started and waits for release.
+type blockingPublisher struct {
+	Publisher
+	err     error
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingPublisher) Publish(context.Context, *models.QueueMessage) error {
+	if p.err != nil {
+		return p.err
+	}
+	close(p.started)
+	<-p.release
+	return nil
+}
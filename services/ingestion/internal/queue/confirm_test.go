@@ -0,0 +1,244 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// fakeConfirm is a broker confirm arriving after delay.
+type fakeConfirm struct {
+	nack  bool
+	delay time.Duration
+}
+
+func (c fakeConfirm) WaitContext(ctx context.Context) (bool, error) {
+	select {
+	case <-time.After(c.delay):
+		return !c.nack, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// fakePublish is a message published on a fakeChannel.
+type fakePublish struct {
+	exchange string
+	msg      amqp.Publishing
+}
+
+// fakeChannel is a channel in confirm mode. Each publish is confirmed as
+// the next of confirms says, and acked at once when they run out; once
+// closed, publishes fail with amqp.ErrClosed.
+type fakeChannel struct {
+	amqpChannel
+
+	mu        sync.Mutex
+	confirms  []fakeConfirm
+	published []fakePublish
+	closed    bool
+	notify    []chan *amqp.Error
+}
+
+func (c *fakeChannel) publish(ctx context.Context, exchange, key string, msg amqp.Publishing) (confirmation, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil, amqp.ErrClosed
+	}
+	c.published = append(c.published, fakePublish{exchange: exchange, msg: msg})
+	confirm := fakeConfirm{}
+	if len(c.confirms) > 0 {
+		confirm, c.confirms = c.confirms[0], c.confirms[1:]
+	}
+	return confirm, nil
+}
+
+func (c *fakeChannel) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	_, err := c.publish(ctx, exchange, key, msg)
+	return err
+}
+
+func (c *fakeChannel) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		close(receiver)
+		return receiver
+	}
+	c.notify = append(c.notify, receiver)
+	return receiver
+}
+
+// close closes the channel and notifies its listeners with cause.
+func (c *fakeChannel) close(cause *amqp.Error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	for _, n := range c.notify {
+		n <- cause
+		close(n)
+	}
+}
+
+func (c *fakeChannel) Close() error {
+	c.close(nil)
+	return nil
+}
+
+func (c *fakeChannel) sent() []fakePublish {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]fakePublish(nil), c.published...)
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newTestPublisher returns a publisher connected to s, without watching it
+// for a lost connection.
+func newTestPublisher(s *session, confirmTimeout time.Duration, maxAttempts int) *RabbitMQPublisher {
+	p := &RabbitMQPublisher{
+		exchange: ExchangeName,
+		logger:   testLogger(),
+
+		confirmTimeout: confirmTimeout,
+		maxAttempts:    maxAttempts,
+
+		session: s,
+		ready:   make(chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	close(p.ready)
+	return p
+}
+
+func TestPublishConfirmed(t *testing.T) {
+	tests := []struct {
+		name           string
+		confirms       []fakeConfirm
+		confirmTimeout time.Duration
+		ctxTimeout     time.Duration
+		maxAttempts    int
+		wantErr        error
+		wantStatus     string
+		wantPublished  int
+		wantDead       bool
+	}{
+		{
+			name:           "acked",
+			confirmTimeout: time.Second,
+			wantStatus:     models.DeliveryStatusQueued,
+			wantPublished:  1,
+		},
+		{
+			name:           "ack within the confirm timeout",
+			confirms:       []fakeConfirm{{delay: 20 * time.Millisecond}},
+			confirmTimeout: time.Second,
+			wantStatus:     models.DeliveryStatusQueued,
+			wantPublished:  1,
+		},
+		{
+			name:           "nacked",
+			confirms:       []fakeConfirm{{nack: true}},
+			confirmTimeout: time.Second,
+			wantErr:        ErrNacked,
+			wantStatus:     models.DeliveryStatusFailed,
+			wantPublished:  1,
+		},
+		{
+			name:           "ack after the confirm timeout",
+			confirms:       []fakeConfirm{{delay: time.Second}},
+			confirmTimeout: 20 * time.Millisecond,
+			wantErr:        ErrConfirmTimeout,
+			wantStatus:     models.DeliveryStatusFailed,
+			wantPublished:  1,
+		},
+		{
+			name:           "ack after the context deadline",
+			confirms:       []fakeConfirm{{delay: time.Second}},
+			confirmTimeout: time.Minute,
+			ctxTimeout:     20 * time.Millisecond,
+			maxAttempts:    3,
+			wantErr:        ErrConfirmTimeout,
+			wantStatus:     models.DeliveryStatusFailed,
+			wantPublished:  1,
+		},
+		{
+			name:           "nack then ack is retried",
+			confirms:       []fakeConfirm{{nack: true}},
+			confirmTimeout: time.Second,
+			maxAttempts:    3,
+			wantStatus:     models.DeliveryStatusQueued,
+			wantPublished:  2,
+		},
+		{
+			name:           "late ack then ack is retried",
+			confirms:       []fakeConfirm{{delay: time.Second}},
+			confirmTimeout: 20 * time.Millisecond,
+			maxAttempts:    3,
+			wantStatus:     models.DeliveryStatusQueued,
+			wantPublished:  2,
+		},
+		{
+			name:           "nacked on every attempt is dead-lettered",
+			confirms:       []fakeConfirm{{nack: true}, {nack: true}},
+			confirmTimeout: time.Second,
+			maxAttempts:    2,
+			wantErr:        ErrDeadLettered,
+			wantStatus:     models.DeliveryStatusDeadLettered,
+			wantPublished:  2,
+			wantDead:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := &fakeChannel{confirms: tt.confirms}
+			p := newTestPublisher(&session{channel: ch, confirmChannel: ch}, tt.confirmTimeout, tt.maxAttempts)
+
+			ctx := context.Background()
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+			}
+			err := p.Publish(ctx, &models.QueueMessage{EventID: "evt-1"})
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("Publish() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := DeliveryStatus(err); got != tt.wantStatus {
+				t.Errorf("DeliveryStatus() = %s, want %s", got, tt.wantStatus)
+			}
+
+			var published, dead []fakePublish
+			for _, pub := range ch.sent() {
+				if pub.exchange == DeadLetterExchangeName {
+					dead = append(dead, pub)
+				} else {
+					published = append(published, pub)
+				}
+			}
+			if len(published) != tt.wantPublished {
+				t.Errorf("published %d times, want %d", len(published), tt.wantPublished)
+			}
+			if (len(dead) > 0) != tt.wantDead {
+				t.Fatalf("dead-lettered %d times, want dead-lettered %v", len(dead), tt.wantDead)
+			}
+			if tt.wantDead && dead[0].msg.Headers[HeaderFailureReason] == nil {
+				t.Errorf("dead-lettered message has no %s header", HeaderFailureReason)
+			}
+		})
+	}
+}
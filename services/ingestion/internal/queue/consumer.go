@@ -0,0 +1,199 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// HeaderDeliveryCount is set by quorum queues to the number of earlier
+// deliveries of a message.
+const HeaderDeliveryCount = "x-delivery-count"
+
+// Consumer receives the messages published for the graph engine.
+type Consumer interface {
+	// Consume calls handler for each message until ctx ends or the
+	// deliveries stop. A message is acknowledged when handler returns nil
+	// and delivered again otherwise.
+	Consume(ctx context.Context, handler func(*models.QueueMessage) error) error
+	Close() error
+}
+
+// RabbitMQConsumer implements Consumer on the graph engine queue. A message
+// whose handler fails is requeued until it has been delivered
+// maxDeliveries times, then routed to the dead-letter queue.
+type RabbitMQConsumer struct {
+	session       *session
+	maxDeliveries int
+	logger        *slog.Logger
+
+	// deadLetter routes a delivery to the dead-letter queue
+	deadLetter func(ctx context.Context, d amqp.Delivery, reason string) error
+	// failures counts failed deliveries by message, for queues that do not
+	// count them in HeaderDeliveryCount
+	failures map[string]int
+}
+
+// NewRabbitMQConsumer connects to RabbitMQ and declares the same exchange,
+// queue and dead-letter queue as the publisher. Up to prefetch messages are
+// delivered ahead of the handler; maxDeliveries of 0 requeues failed
+// messages forever.
+func NewRabbitMQConsumer(url string, prefetch, maxDeliveries int, logger *slog.Logger) (*RabbitMQConsumer, error) {
+	s, err := dial(url)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.channel.Qos(prefetch, 0, false); err != nil {
+		s.conn.Close()
+		return nil, fmt.Errorf("failed to set prefetch: %w", err)
+	}
+
+	c := &RabbitMQConsumer{
+		session:       s,
+		maxDeliveries: maxDeliveries,
+		logger:        logger,
+		failures:      make(map[string]int),
+	}
+	c.deadLetter = c.publishToDLQ
+	return c, nil
+}
+
+// Consume consumes the graph engine queue. It returns nil once ctx ends, and
+// an error if the deliveries stop first, for example because the connection
+// was lost.
+func (c *RabbitMQConsumer) Consume(ctx context.Context, handler func(*models.QueueMessage) error) error {
+	deliveries, err := c.session.channel.Consume(QueueName, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", QueueName, err)
+	}
+	return c.consume(ctx, deliveries, handler)
+}
+
+// consume handles deliveries one at a time.
+func (c *RabbitMQConsumer) consume(ctx context.Context, deliveries <-chan amqp.Delivery, handler func(*models.QueueMessage) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case d, ok := <-deliveries:
+			if !ok {
+				return errors.New("delivery channel closed")
+			}
+			c.handle(ctx, d, handler)
+		}
+	}
+}
+
+// handle runs handler on one delivery and settles it: an ack on success, a
+// requeue on failure, or the dead-letter queue once it has failed
+// maxDeliveries times or cannot be decoded.
+func (c *RabbitMQConsumer) handle(ctx context.Context, d amqp.Delivery, handler func(*models.QueueMessage) error) {
+	var msg models.QueueMessage
+	if err := json.Unmarshal(d.Body, &msg); err != nil {
+		c.logger.Warn("Dead-lettering malformed message", "error", err)
+		c.reject(ctx, d, "malformed message: "+err.Error())
+		return
+	}
+
+	err := handler(&msg)
+	if err == nil {
+		delete(c.failures, msg.EventID)
+		if err := d.Ack(false); err != nil {
+			c.logger.Error("Failed to ack message", "error", err, "event_id", msg.EventID)
+		}
+		return
+	}
+
+	deliveries := c.deliveries(d, msg.EventID)
+	if c.maxDeliveries > 0 && deliveries >= c.maxDeliveries {
+		c.logger.Warn("Message failed too often, dead-lettering",
+			"error", err,
+			"event_id", msg.EventID,
+			"deliveries", deliveries,
+		)
+		delete(c.failures, msg.EventID)
+		c.reject(ctx, d, fmt.Sprintf("handler failed %d times: %v", deliveries, err))
+		return
+	}
+
+	c.logger.Warn("Message handler failed, requeueing",
+		"error", err,
+		"event_id", msg.EventID,
+		"deliveries", deliveries,
+	)
+	if err := d.Nack(false, true); err != nil {
+		c.logger.Error("Failed to nack message", "error", err, "event_id", msg.EventID)
+	}
+}
+
+// deliveries returns how many times a message has now been delivered and
+// failed, from the queue's delivery count if it keeps one.
+func (c *RabbitMQConsumer) deliveries(d amqp.Delivery, eventID string) int {
+	switch n := d.Headers[HeaderDeliveryCount].(type) {
+	case int64:
+		return int(n) + 1
+	case int32:
+		return int(n) + 1
+	case int:
+		return n + 1
+	}
+	c.failures[eventID]++
+	return c.failures[eventID]
+}
+
+// reject routes a delivery to the dead-letter queue and acks it, or
+// requeues it if that fails so it is not lost.
+func (c *RabbitMQConsumer) reject(ctx context.Context, d amqp.Delivery, reason string) {
+	if err := c.deadLetter(ctx, d, reason); err != nil {
+		c.logger.Error("Failed to dead-letter message, requeueing", "error", err)
+		if err := d.Nack(false, true); err != nil {
+			c.logger.Error("Failed to nack message", "error", err)
+		}
+		return
+	}
+	if err := d.Ack(false); err != nil {
+		c.logger.Error("Failed to ack message", "error", err)
+	}
+}
+
+// publishToDLQ republishes a delivery to the dead-letter exchange with
+// reason in its x-failure-reason header, and waits for the broker's
+// confirm.
+func (c *RabbitMQConsumer) publishToDLQ(ctx context.Context, d amqp.Delivery, reason string) error {
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[HeaderFailureReason] = reason
+	headers[HeaderFailedAt] = time.Now().UTC().Format(time.RFC3339)
+
+	confirm, err := c.session.confirmChannel.publish(ctx, DeadLetterExchangeName, "", amqp.Publishing{
+		Headers:      headers,
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    d.Timestamp,
+		Body:         d.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish to dead-letter queue: %w", err)
+	}
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for publish confirm: %w", err)
+	}
+	if !acked {
+		return ErrNacked
+	}
+	return nil
+}
+
+// Close closes the RabbitMQ connection.
+func (c *RabbitMQConsumer) Close() error {
+	return c.session.conn.Close()
+}
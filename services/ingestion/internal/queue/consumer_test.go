@@ -0,0 +1,166 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// fakeAcknowledger records how each delivery was settled.
+type fakeAcknowledger struct {
+	mu      sync.Mutex
+	settled []string
+}
+
+func (a *fakeAcknowledger) settle(outcome string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.settled = append(a.settled, outcome)
+	return nil
+}
+
+func (a *fakeAcknowledger) Ack(uint64, bool) error { return a.settle("ack") }
+
+func (a *fakeAcknowledger) Nack(_ uint64, _ bool, requeue bool) error {
+	if requeue {
+		return a.settle("requeue")
+	}
+	return a.settle("nack")
+}
+
+func (a *fakeAcknowledger) Reject(_ uint64, requeue bool) error { return a.Nack(0, false, requeue) }
+
+func TestRabbitMQConsumerSettlesDeliveries(t *testing.T) {
+	const body = `{"event_id":"evt-1","user_id":"alice","source_type":"MANUAL"}`
+	handlerErr := errors.New("graph engine unavailable")
+
+	tests := []struct {
+		name          string
+		maxDeliveries int
+		bodies        []string
+		deliveryCount []interface{}
+		handlerErrs   []error
+		deadLetterErr error
+		wantSettled   []string
+		wantDead      int
+	}{
+		{
+			name:        "handled",
+			bodies:      []string{body},
+			handlerErrs: []error{nil},
+			wantSettled: []string{"ack"},
+		},
+		{
+			name:          "failure is requeued",
+			maxDeliveries: 3,
+			bodies:        []string{body},
+			handlerErrs:   []error{handlerErr},
+			wantSettled:   []string{"requeue"},
+		},
+		{
+			name:          "failure then success",
+			maxDeliveries: 3,
+			bodies:        []string{body, body},
+			handlerErrs:   []error{handlerErr, nil},
+			wantSettled:   []string{"requeue", "ack"},
+		},
+		{
+			name:          "dead-lettered after max deliveries",
+			maxDeliveries: 3,
+			bodies:        []string{body, body, body},
+			handlerErrs:   []error{handlerErr, handlerErr, handlerErr},
+			wantSettled:   []string{"requeue", "requeue", "ack"},
+			wantDead:      1,
+		},
+		{
+			name:          "queue delivery count is used",
+			maxDeliveries: 3,
+			bodies:        []string{body},
+			deliveryCount: []interface{}{int64(2)},
+			handlerErrs:   []error{handlerErr},
+			wantSettled:   []string{"ack"},
+			wantDead:      1,
+		},
+		{
+			name:        "requeued forever without a cutoff",
+			bodies:      []string{body, body, body, body},
+			handlerErrs: []error{handlerErr, handlerErr, handlerErr, handlerErr},
+			wantSettled: []string{"requeue", "requeue", "requeue", "requeue"},
+		},
+		{
+			name:        "malformed message is dead-lettered",
+			bodies:      []string{`{"event_id":`},
+			handlerErrs: []error{nil},
+			wantSettled: []string{"ack"},
+			wantDead:    1,
+		},
+		{
+			name:          "failed dead-letter is requeued",
+			maxDeliveries: 1,
+			bodies:        []string{body},
+			handlerErrs:   []error{handlerErr},
+			deadLetterErr: errors.New("channel closed"),
+			wantSettled:   []string{"requeue"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dead []string
+			c := &RabbitMQConsumer{
+				maxDeliveries: tt.maxDeliveries,
+				logger:        testLogger(),
+				failures:      make(map[string]int),
+				deadLetter: func(_ context.Context, d amqp.Delivery, reason string) error {
+					if tt.deadLetterErr != nil {
+						return tt.deadLetterErr
+					}
+					dead = append(dead, reason)
+					return nil
+				},
+			}
+
+			ack := &fakeAcknowledger{}
+			deliveries := make(chan amqp.Delivery, len(tt.bodies))
+			for i, b := range tt.bodies {
+				d := amqp.Delivery{Acknowledger: ack, DeliveryTag: uint64(i + 1), Body: []byte(b)}
+				if i < len(tt.deliveryCount) {
+					d.Headers = amqp.Table{HeaderDeliveryCount: tt.deliveryCount[i]}
+				}
+				deliveries <- d
+			}
+			close(deliveries)
+
+			calls := 0
+			err := c.consume(context.Background(), deliveries, func(msg *models.QueueMessage) error {
+				if msg.EventID != "evt-1" {
+					t.Errorf("handler got event %q", msg.EventID)
+				}
+				calls++
+				return tt.handlerErrs[calls-1]
+			})
+			if err == nil {
+				t.Error("consume() returned nil after the delivery channel closed")
+			}
+			if !reflect.DeepEqual(ack.settled, tt.wantSettled) {
+				t.Errorf("settled %v, want %v", ack.settled, tt.wantSettled)
+			}
+			if len(dead) != tt.wantDead {
+				t.Errorf("dead-lettered %d messages (%v), want %d", len(dead), dead, tt.wantDead)
+			}
+		})
+	}
+}
+
+func TestRabbitMQConsumerStopsWithContext(t *testing.T) {
+	c := &RabbitMQConsumer{logger: testLogger(), failures: make(map[string]int)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.consume(ctx, make(chan amqp.Delivery), func(*models.QueueMessage) error { return nil }); err != nil {
+		t.Errorf("consume() after cancel = %v, want nil", err)
+	}
+}
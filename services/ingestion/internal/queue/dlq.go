@@ -0,0 +1,207 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// HeaderRequeuedAt is set on a dead-lettered message moved back to the
+// graph engine queue.
+const HeaderRequeuedAt = "x-requeued-at"
+
+// DLQChannel is the part of an AMQP channel a DLQManager uses;
+// *amqp.Channel implements it.
+type DLQChannel interface {
+	Get(queue string, autoAck bool) (amqp.Delivery, bool, error)
+	Ack(tag uint64, multiple bool) error
+	Nack(tag uint64, multiple, requeue bool) error
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
+// DLQMessage describes a message in the dead-letter queue.
+type DLQMessage struct {
+	EventID  string `json:"event_id,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	FailedAt string `json:"failed_at,omitempty"`
+	// Message is the queue message, or Raw the body when it is not JSON.
+	Message json.RawMessage `json:"message,omitempty"`
+	Raw     string          `json:"raw,omitempty"`
+}
+
+// DLQManager inspects the dead-letter queue and moves its messages back to
+// the graph engine queue. Each call works on a channel of its own, so
+// messages it holds unacknowledged return to the queue when the channel
+// closes, even if the call fails halfway.
+type DLQManager struct {
+	open   func(ctx context.Context) (DLQChannel, error)
+	logger *slog.Logger
+}
+
+// NewDLQManager creates a manager opening its channels with open.
+func NewDLQManager(open func(ctx context.Context) (DLQChannel, error), logger *slog.Logger) *DLQManager {
+	return &DLQManager{open: open, logger: logger}
+}
+
+// Peek returns up to limit messages from the head of the dead-letter queue
+// and leaves them there.
+func (m *DLQManager) Peek(ctx context.Context, limit int) ([]DLQMessage, error) {
+	ch, err := m.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	messages := []DLQMessage{}
+	var last uint64
+	for len(messages) < limit && ctx.Err() == nil {
+		d, ok, err := ch.Get(DeadLetterQueueName, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dead-lettered message: %w", err)
+		}
+		if !ok {
+			break
+		}
+		last = d.DeliveryTag
+		messages = append(messages, dlqMessage(d))
+	}
+
+	// Messages stay unacknowledged until all are read, or the next get
+	// would return the one just put back
+	if last > 0 {
+		if err := ch.Nack(last, true, true); err != nil {
+			return nil, fmt.Errorf("failed to return dead-lettered messages: %w", err)
+		}
+	}
+	return messages, ctx.Err()
+}
+
+// Requeue moves up to limit messages from the dead-letter queue to the
+// graph engine queue and returns the number moved. Each is removed from the
+// dead-letter queue only once the broker has confirmed its republish; one
+// that is not confirmed stays, and stops the move.
+func (m *DLQManager) Requeue(ctx context.Context, limit int) (int, error) {
+	ch, err := m.open(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	if err := ch.Confirm(false); err != nil {
+		return 0, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+	confirms := ch.NotifyPublish(make(chan amqp.Confirmation, 1))
+
+	moved := 0
+	for moved < limit {
+		if err := ctx.Err(); err != nil {
+			return moved, err
+		}
+		d, ok, err := ch.Get(DeadLetterQueueName, false)
+		if err != nil {
+			return moved, fmt.Errorf("failed to get dead-lettered message: %w", err)
+		}
+		if !ok {
+			break
+		}
+		if err := m.republish(ctx, ch, confirms, d); err != nil {
+			if nackErr := ch.Nack(d.DeliveryTag, false, true); nackErr != nil {
+				m.logger.Error("Failed to return dead-lettered message", "error", nackErr)
+			}
+			return moved, err
+		}
+		if err := ch.Ack(d.DeliveryTag, false); err != nil {
+			return moved, fmt.Errorf("failed to remove requeued message from the dead-letter queue: %w", err)
+		}
+		moved++
+	}
+
+	if moved > 0 {
+		m.logger.Info("Dead-lettered messages requeued", "count", moved)
+	}
+	return moved, nil
+}
+
+// republish publishes a dead-lettered delivery to the graph engine
+// exchange without its failure headers, and waits for the broker's
+// confirm.
+func (m *DLQManager) republish(ctx context.Context, ch DLQChannel, confirms chan amqp.Confirmation, d amqp.Delivery) error {
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	delete(headers, HeaderFailureReason)
+	delete(headers, HeaderFailedAt)
+	delete(headers, HeaderDeliveryCount)
+	headers[HeaderRequeuedAt] = time.Now().UTC().Format(time.RFC3339)
+
+	err := ch.PublishWithContext(ctx,
+		ExchangeName, // exchange
+		RoutingKey,   // routing key
+		false,        // mandatory
+		false,        // immediate
+		amqp.Publishing{
+			Headers:      headers,
+			ContentType:  d.ContentType,
+			DeliveryMode: amqp.Persistent,
+			Timestamp:    d.Timestamp,
+			Body:         d.Body,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to requeue message: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("failed to wait for publish confirm: %w", ctx.Err())
+	case confirm, ok := <-confirms:
+		if !ok {
+			return fmt.Errorf("failed to wait for publish confirm: %w", amqp.ErrClosed)
+		}
+		if !confirm.Ack {
+			return ErrNacked
+		}
+	}
+	return nil
+}
+
+// dlqMessage describes a dead-lettered delivery.
+func dlqMessage(d amqp.Delivery) DLQMessage {
+	m := DLQMessage{}
+	m.Reason, _ = d.Headers[HeaderFailureReason].(string)
+	m.FailedAt, _ = d.Headers[HeaderFailedAt].(string)
+	if !json.Valid(d.Body) {
+		m.Raw = string(d.Body)
+		return m
+	}
+	m.Message = d.Body
+	var msg struct {
+		EventID string `json:"event_id"`
+	}
+	if json.Unmarshal(d.Body, &msg) == nil {
+		m.EventID = msg.EventID
+	}
+	return m
+}
+
+// OpenDLQChannel opens a channel on the current connection for a
+// DLQManager, waiting for a reconnect to finish until ctx ends.
+func (p *RabbitMQPublisher) OpenDLQChannel(ctx context.Context) (DLQChannel, error) {
+	s, err := p.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := s.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	return ch, nil
+}
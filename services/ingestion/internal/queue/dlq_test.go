@@ -0,0 +1,255 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// fakeDLQChannel simulates a dead-letter queue: gets hand out messages
+// from its head, nacks with requeue put them back in order, and closing
+// the channel returns every unacknowledged message, as the broker does.
+type fakeDLQChannel struct {
+	ready     []amqp.Delivery
+	unacked   []amqp.Delivery
+	nextTag   uint64
+	confirms  chan amqp.Confirmation
+	published []amqp.Publishing
+
+	getErr     error
+	confirmErr error
+	// publishErr and nackPublish fail the publish numbered failAt (from 1)
+	publishErr  error
+	nackPublish bool
+	failAt      int
+	closed      bool
+}
+
+func newFakeDLQChannel(bodies ...string) *fakeDLQChannel {
+	ch := &fakeDLQChannel{}
+	for i, body := range bodies {
+		ch.ready = append(ch.ready, amqp.Delivery{
+			Headers: amqp.Table{
+				HeaderFailureReason: "graph engine rejected the event",
+				HeaderFailedAt:      "2026-10-15T09:30:00Z",
+				HeaderDeliveryCount: int32(i + 3),
+			},
+			ContentType: "application/json",
+			Body:        []byte(body),
+		})
+	}
+	return ch
+}
+
+func (ch *fakeDLQChannel) Get(queue string, autoAck bool) (amqp.Delivery, bool, error) {
+	if queue != DeadLetterQueueName || autoAck {
+		return amqp.Delivery{}, false, errors.New("unexpected get")
+	}
+	if ch.getErr != nil {
+		return amqp.Delivery{}, false, ch.getErr
+	}
+	if len(ch.ready) == 0 {
+		return amqp.Delivery{}, false, nil
+	}
+	d := ch.ready[0]
+	ch.ready = ch.ready[1:]
+	ch.nextTag++
+	d.DeliveryTag = ch.nextTag
+	ch.unacked = append(ch.unacked, d)
+	return d, true, nil
+}
+
+// settle removes the unacknowledged messages up to tag, returning them to
+// the head of the queue if requeue is set.
+func (ch *fakeDLQChannel) settle(tag uint64, multiple, requeue bool) error {
+	var settled, kept []amqp.Delivery
+	for _, d := range ch.unacked {
+		if d.DeliveryTag == tag || (multiple && d.DeliveryTag < tag) {
+			settled = append(settled, d)
+		} else {
+			kept = append(kept, d)
+		}
+	}
+	if len(settled) == 0 {
+		return errors.New("unknown delivery tag")
+	}
+	ch.unacked = kept
+	if requeue {
+		ch.ready = append(settled, ch.ready...)
+	}
+	return nil
+}
+
+func (ch *fakeDLQChannel) Ack(tag uint64, multiple bool) error {
+	return ch.settle(tag, multiple, false)
+}
+
+func (ch *fakeDLQChannel) Nack(tag uint64, multiple, requeue bool) error {
+	return ch.settle(tag, multiple, requeue)
+}
+
+func (ch *fakeDLQChannel) Confirm(bool) error {
+	return ch.confirmErr
+}
+
+func (ch *fakeDLQChannel) NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation {
+	ch.confirms = confirm
+	return confirm
+}
+
+func (ch *fakeDLQChannel) PublishWithContext(_ context.Context, exchange, key string, _, _ bool, msg amqp.Publishing) error {
+	if exchange != ExchangeName || key != RoutingKey {
+		return errors.New("unexpected exchange or routing key")
+	}
+	n := len(ch.published) + 1
+	if n == ch.failAt && ch.publishErr != nil {
+		return ch.publishErr
+	}
+	ch.published = append(ch.published, msg)
+	ch.confirms <- amqp.Confirmation{DeliveryTag: uint64(n), Ack: !(n == ch.failAt && ch.nackPublish)}
+	return nil
+}
+
+func (ch *fakeDLQChannel) Close() error {
+	ch.closed = true
+	if len(ch.unacked) > 0 {
+		return ch.settle(ch.unacked[len(ch.unacked)-1].DeliveryTag, true, true)
+	}
+	return nil
+}
+
+// bodies returns the bodies of the messages left in the queue.
+func (ch *fakeDLQChannel) bodies() []string {
+	var out []string
+	for _, d := range ch.ready {
+		out = append(out, string(d.Body))
+	}
+	return out
+}
+
+func TestDLQManagerPeek(t *testing.T) {
+	bodies := []string{`{"event_id":"evt-1"}`, `{"event_id":"evt-2"}`, `not json`}
+
+	tests := []struct {
+		name     string
+		bodies   []string
+		limit    int
+		getErr   error
+		wantIDs  []string
+		wantRaw  []string
+		wantErr  bool
+		wantLeft []string
+	}{
+		{name: "fewer than the limit", bodies: bodies, limit: 10, wantIDs: []string{"evt-1", "evt-2", ""}, wantRaw: []string{"", "", "not json"}, wantLeft: bodies},
+		{name: "up to the limit", bodies: bodies, limit: 2, wantIDs: []string{"evt-1", "evt-2"}, wantRaw: []string{"", ""}, wantLeft: bodies},
+		{name: "empty queue", limit: 10, wantIDs: []string{}, wantRaw: []string{}},
+		{name: "get fails", bodies: bodies, limit: 10, getErr: errors.New("channel closed"), wantErr: true, wantLeft: bodies},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := newFakeDLQChannel(tt.bodies...)
+			ch.getErr = tt.getErr
+			m := NewDLQManager(func(context.Context) (DLQChannel, error) { return ch, nil }, testLogger())
+
+			messages, err := m.Peek(context.Background(), tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Peek() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !ch.closed {
+				t.Error("channel left open")
+			}
+			if !slices.Equal(ch.bodies(), tt.wantLeft) || len(ch.unacked) != 0 {
+				t.Errorf("queue = %v with %d unacknowledged, want %v", ch.bodies(), len(ch.unacked), tt.wantLeft)
+			}
+			if err != nil {
+				return
+			}
+			if messages == nil {
+				t.Fatal("Peek() = nil, want a list")
+			}
+			ids, raw := []string{}, []string{}
+			for _, msg := range messages {
+				ids = append(ids, msg.EventID)
+				raw = append(raw, msg.Raw)
+				if msg.Reason != "graph engine rejected the event" || msg.FailedAt != "2026-10-15T09:30:00Z" {
+					t.Errorf("message failure = %q at %q, want the dead-letter headers", msg.Reason, msg.FailedAt)
+				}
+			}
+			if !slices.Equal(ids, tt.wantIDs) || !slices.Equal(raw, tt.wantRaw) {
+				t.Errorf("Peek() event IDs = %v, raw = %q; want %v, %q", ids, raw, tt.wantIDs, tt.wantRaw)
+			}
+		})
+	}
+}
+
+func TestDLQManagerRequeue(t *testing.T) {
+	bodies := []string{`{"event_id":"evt-1"}`, `{"event_id":"evt-2"}`, `{"event_id":"evt-3"}`}
+	publishErr := errors.New("connection reset")
+
+	tests := []struct {
+		name        string
+		limit       int
+		confirmErr  error
+		publishErr  error
+		nackPublish bool
+		failAt      int
+		wantMoved   int
+		wantErr     error
+		wantLeft    []string
+	}{
+		{name: "every message", limit: 10, wantMoved: 3},
+		{name: "up to the limit", limit: 2, wantMoved: 2, wantLeft: bodies[2:]},
+		{name: "publish fails", limit: 10, publishErr: publishErr, failAt: 2, wantMoved: 1, wantErr: publishErr, wantLeft: bodies[1:]},
+		{name: "publish nacked", limit: 10, nackPublish: true, failAt: 1, wantErr: ErrNacked, wantLeft: bodies},
+		{name: "confirms unavailable", limit: 10, confirmErr: amqp.ErrClosed, wantErr: amqp.ErrClosed, wantLeft: bodies},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := newFakeDLQChannel(bodies...)
+			ch.confirmErr, ch.publishErr, ch.nackPublish, ch.failAt = tt.confirmErr, tt.publishErr, tt.nackPublish, tt.failAt
+			m := NewDLQManager(func(context.Context) (DLQChannel, error) { return ch, nil }, testLogger())
+
+			moved, err := m.Requeue(context.Background(), tt.limit)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("Requeue() error = %v, want %v", err, tt.wantErr)
+			}
+			if moved != tt.wantMoved {
+				t.Errorf("Requeue() = %d, want %d", moved, tt.wantMoved)
+			}
+			if !slices.Equal(ch.bodies(), tt.wantLeft) || len(ch.unacked) != 0 {
+				t.Errorf("queue = %v with %d unacknowledged, want %v", ch.bodies(), len(ch.unacked), tt.wantLeft)
+			}
+			for i, msg := range ch.published[:moved] {
+				if string(msg.Body) != bodies[i] {
+					t.Errorf("published body = %s, want %s", msg.Body, bodies[i])
+				}
+				var keys []string
+				for k := range msg.Headers {
+					keys = append(keys, k)
+				}
+				if !reflect.DeepEqual(keys, []string{HeaderRequeuedAt}) {
+					t.Errorf("published headers = %v, want only %s", msg.Headers, HeaderRequeuedAt)
+				}
+				if msg.DeliveryMode != amqp.Persistent {
+					t.Errorf("DeliveryMode = %d, want persistent", msg.DeliveryMode)
+				}
+			}
+		})
+	}
+}
+
+func TestDLQManagerOpenError(t *testing.T) {
+	openErr := errors.New("not connected")
+	m := NewDLQManager(func(context.Context) (DLQChannel, error) { return nil, openErr }, testLogger())
+
+	if _, err := m.Peek(context.Background(), 10); !errors.Is(err, openErr) {
+		t.Errorf("Peek() error = %v, want %v", err, openErr)
+	}
+	if _, err := m.Requeue(context.Background(), 10); !errors.Is(err, openErr) {
+		t.Errorf("Requeue() error = %v, want %v", err, openErr)
+	}
+}
@@ -0,0 +1,189 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultKafkaTopic is the topic identity events are published to when
+// none is configured.
+const DefaultKafkaTopic = "identity.events"
+
+// kafkaWriter is the part of *kafka.Writer the publisher uses.
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaPublisher implements Publisher using Kafka. Messages carry the same
+// JSON as on RabbitMQ and are keyed by user_id, so one user's events land
+// on one partition and are consumed in order.
+//
+// Each write waits until all in-sync replicas have acknowledged it, and a
+// failed write is retried up to maxAttempts times. A write that still fails
+// returns an error and the event's delivery status becomes failed, so the
+// outbox relay publishes it again. A retry whose first write did reach the
+// broker can be delivered twice: delivery is at-least-once, and consumers
+// must deduplicate by event_id.
+type KafkaPublisher struct {
+	writer kafkaWriter
+	topic  string
+	logger *slog.Logger
+
+	// inflight counts publishes in progress, for Drain
+	inflight sync.WaitGroup
+
+	mu sync.Mutex
+	// lastErr is the error of the latest write, reported by Healthy
+	lastErr error
+	closed  bool
+}
+
+// NewKafkaPublisher creates a publisher writing to topic on the given
+// brokers. Each write waits up to writeTimeout for the acknowledgement and
+// is made up to maxAttempts times; 0 uses the kafka-go defaults.
+func NewKafkaPublisher(brokers []string, topic string, writeTimeout time.Duration, maxAttempts int, logger *slog.Logger) (*KafkaPublisher, error) {
+	if len(brokers) == 0 {
+		return nil, errors.New("no Kafka brokers configured")
+	}
+	if topic == "" {
+		topic = DefaultKafkaTopic
+	}
+	w := &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		Topic:                  topic,
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireAll,
+		MaxAttempts:            maxAttempts,
+		WriteTimeout:           writeTimeout,
+		AllowAutoTopicCreation: true,
+	}
+	return newKafkaPublisher(w, topic, logger), nil
+}
+
+// newKafkaPublisher creates a publisher over w.
+func newKafkaPublisher(w kafkaWriter, topic string, logger *slog.Logger) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: w,
+		topic:  topic,
+		logger: logger,
+	}
+}
+
+// Publish writes a message to the topic and waits for the brokers'
+// acknowledgement.
+func (p *KafkaPublisher) Publish(ctx context.Context, msg *models.QueueMessage) (err error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrClosed
+	}
+	p.inflight.Add(1)
+	p.mu.Unlock()
+	defer p.inflight.Done()
+
+	ctx, span := tracing.Start(ctx, "publish "+p.topic, trace.SpanKindProducer)
+	span.SetAttributes(
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("messaging.destination.name", p.topic),
+		attribute.String("event_id", msg.EventID),
+	)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	record := kafka.Message{
+		Key:   []byte(msg.UserID),
+		Value: body,
+		Time:  time.Now(),
+		Headers: []kafka.Header{
+			{Key: "content-type", Value: []byte("application/json")},
+		},
+	}
+	tracing.Inject(ctx, func(key, value string) {
+		record.Headers = append(record.Headers, kafka.Header{Key: key, Value: []byte(value)})
+	})
+	if msg.Replay {
+		record.Headers = append(record.Headers, kafka.Header{Key: HeaderReplay, Value: []byte("true")})
+	}
+
+	err = p.writer.WriteMessages(ctx, record)
+	p.mu.Lock()
+	p.lastErr = err
+	p.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+
+	p.logger.Debug("Message published",
+		"event_id", msg.EventID,
+		"source_type", msg.SourceType,
+		"topic", p.topic,
+	)
+	return nil
+}
+
+// PublishConfirmed is Publish: every write already waits for the brokers'
+// acknowledgement.
+func (p *KafkaPublisher) PublishConfirmed(ctx context.Context, msg *models.QueueMessage) error {
+	return p.Publish(ctx, msg)
+}
+
+// Healthy reports the error of the latest write, if it failed. The writer
+// connects per write, so there is no connection to check between writes.
+func (p *KafkaPublisher) Healthy() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return ErrClosed
+	}
+	if p.lastErr != nil {
+		return fmt.Errorf("latest Kafka write failed: %w", p.lastErr)
+	}
+	return nil
+}
+
+// Drain waits until the publishes in progress have returned, or ctx ends.
+func (p *KafkaPublisher) Drain(ctx context.Context) error {
+	drained := make(chan struct{})
+	go func() {
+		p.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("publishes still in flight: %w", ctx.Err())
+	}
+}
+
+// Close rejects further publishes and closes the writer.
+func (p *KafkaPublisher) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+	return p.writer.Close()
+}
@@ -0,0 +1,143 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// fakeKafkaWriter records written messages, or fails each write with err.
+type fakeKafkaWriter struct {
+	mu       sync.Mutex
+	messages []kafka.Message
+	err      error
+	closed   bool
+}
+
+func (w *fakeKafkaWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeKafkaWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+func TestKafkaPublisher(t *testing.T) {
+	tests := []struct {
+		name        string
+		msg         *models.QueueMessage
+		writeErr    error
+		close       bool
+		wantErr     error
+		wantWritten bool
+		wantReplay  bool
+		wantHealthy bool
+	}{
+		{
+			name:        "keyed by user",
+			msg:         &models.QueueMessage{EventID: "evt-1", UserID: "alice", SourceType: models.SourceTypeVC},
+			wantWritten: true,
+			wantHealthy: true,
+		},
+		{
+			name:        "replay",
+			msg:         &models.QueueMessage{EventID: "evt-1", UserID: "alice", Replay: true},
+			wantWritten: true,
+			wantReplay:  true,
+			wantHealthy: true,
+		},
+		{
+			name:     "write fails",
+			msg:      &models.QueueMessage{EventID: "evt-1", UserID: "alice"},
+			writeErr: kafka.LeaderNotAvailable,
+			wantErr:  kafka.LeaderNotAvailable,
+		},
+		{
+			name:    "closed",
+			msg:     &models.QueueMessage{EventID: "evt-1", UserID: "alice"},
+			close:   true,
+			wantErr: ErrClosed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &fakeKafkaWriter{err: tt.writeErr}
+			p := newKafkaPublisher(w, DefaultKafkaTopic, testLogger())
+			if tt.close {
+				if err := p.Close(); err != nil || !w.closed {
+					t.Fatalf("Close() = %v, writer closed %v", err, w.closed)
+				}
+			}
+
+			err := p.PublishConfirmed(context.Background(), tt.msg)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("Publish() error = %v, want %v", err, tt.wantErr)
+			}
+			if healthy := p.Healthy() == nil; healthy != tt.wantHealthy {
+				t.Errorf("Healthy() = %v, want healthy %v", p.Healthy(), tt.wantHealthy)
+			}
+			if (len(w.messages) == 1) != tt.wantWritten {
+				t.Fatalf("wrote %d messages, want written %v", len(w.messages), tt.wantWritten)
+			}
+			if !tt.wantWritten {
+				return
+			}
+
+			record := w.messages[0]
+			if string(record.Key) != tt.msg.UserID {
+				t.Errorf("key = %q, want the user ID %q", record.Key, tt.msg.UserID)
+			}
+			var got models.QueueMessage
+			if err := json.Unmarshal(record.Value, &got); err != nil || got.EventID != tt.msg.EventID {
+				t.Errorf("value = %s, want the message JSON", record.Value)
+			}
+			headers := map[string]string{}
+			for _, h := range record.Headers {
+				headers[h.Key] = string(h.Value)
+			}
+			if headers["content-type"] != "application/json" {
+				t.Errorf("content-type header = %q", headers["content-type"])
+			}
+			if _, ok := headers[HeaderReplay]; ok != tt.wantReplay {
+				t.Errorf("replay header present = %v, want %v", ok, tt.wantReplay)
+			}
+		})
+	}
+}
+
+func TestKafkaPublisherRecovers(t *testing.T) {
+	w := &fakeKafkaWriter{err: kafka.NotEnoughReplicas}
+	p := newKafkaPublisher(w, DefaultKafkaTopic, testLogger())
+	msg := &models.QueueMessage{EventID: "evt-1", UserID: "alice"}
+
+	if err := p.Publish(context.Background(), msg); err == nil {
+		t.Fatal("Publish() succeeded while the brokers lack replicas")
+	}
+	if p.Healthy() == nil {
+		t.Fatal("Healthy() after a failed write = nil")
+	}
+
+	w.mu.Lock()
+	w.err = nil
+	w.mu.Unlock()
+	if err := p.Publish(context.Background(), msg); err != nil {
+		t.Fatalf("Publish() after recovery error = %v", err)
+	}
+	if err := p.Healthy(); err != nil {
+		t.Errorf("Healthy() after recovery = %v", err)
+	}
+}
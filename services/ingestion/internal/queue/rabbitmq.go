@@ -4,12 +4,17 @@ package queue
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sync"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -19,24 +24,210 @@ const (
 	QueueName = "graph.engine.queue"
 	// RoutingKey is the routing key for identity events.
 	RoutingKey = "identity.new"
+	// DeadLetterExchangeName is the exchange of messages that could not be
+	// published.
+	DeadLetterExchangeName = "identity.events.dlx"
+	// DeadLetterQueueName holds dead-lettered messages for inspection.
+	DeadLetterQueueName = "graph.engine.dlq"
 )
 
+// Headers added to a dead-lettered message.
+const (
+	HeaderFailureReason = "x-failure-reason"
+	HeaderFailedAt      = "x-failed-at"
+)
+
+// HeaderReplay is set to "true" on messages that replay a stored event.
+const HeaderReplay = "replay"
+
 // Publisher defines the interface for publishing messages.
 type Publisher interface {
 	Publish(ctx context.Context, msg *models.QueueMessage) error
+	// Healthy reports why messages cannot be published right now, if so.
+	Healthy() error
 	Close() error
 }
 
-// RabbitMQPublisher implements Publisher using RabbitMQ.
+// ConfirmPublisher publishes a message and waits until the broker has
+// confirmed it, so callers can order publishes one after another.
+type ConfirmPublisher interface {
+	PublishConfirmed(ctx context.Context, msg *models.QueueMessage) error
+}
+
+// Backend is a broker the service publishes events to: RabbitMQ or Kafka.
+type Backend interface {
+	Publisher
+	ConfirmPublisher
+	// Drain waits until the publishes in progress have returned, or ctx
+	// ends.
+	Drain(ctx context.Context) error
+}
+
+// ErrNacked is returned when the broker rejects a confirmed publish.
+var ErrNacked = errors.New("message not acknowledged by broker")
+
+// ErrConfirmTimeout is returned when the broker's confirm does not arrive
+// within the confirm timeout.
+var ErrConfirmTimeout = errors.New("timed out waiting for publish confirm")
+
+// ErrClosed is returned by publishes after Close.
+var ErrClosed = errors.New("publisher is closed")
+
+// ErrDeadLettered is returned when a message was routed to the dead-letter
+// queue after repeatedly failing to publish.
+var ErrDeadLettered = errors.New("message dead-lettered")
+
+// DeliveryStatus returns the delivery status to record for an event after
+// publishing it returned err.
+func DeliveryStatus(err error) string {
+	switch {
+	case err == nil:
+		return models.DeliveryStatusQueued
+	case errors.Is(err, ErrDeadLettered):
+		return models.DeliveryStatusDeadLettered
+	default:
+		return models.DeliveryStatusFailed
+	}
+}
+
+// Reconnect backoff after the broker connection is lost.
+const (
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = 30 * time.Second
+)
+
+// RabbitMQPublisher implements Publisher using RabbitMQ. When the connection
+// or a channel closes, it re-dials in the background with exponential
+// backoff; publishes meanwhile wait for the new connection until their
+// context ends.
 type RabbitMQPublisher struct {
-	conn     *amqp.Connection
-	channel  *amqp.Channel
+	url      string
 	exchange string
 	logger   *slog.Logger
+
+	// dial opens a new session on url
+	dial func(url string) (*session, error)
+
+	confirmTimeout time.Duration
+	maxAttempts    int
+
+	// inflight counts publishes in progress, for Drain
+	inflight sync.WaitGroup
+
+	mu sync.Mutex
+	// session is the live connection, or nil while reconnecting; ready is
+	// closed once session is set
+	session *session
+	ready   chan struct{}
+	closed  bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// session is one connection with its channels.
+type session struct {
+	conn    amqpConnection
+	channel amqpChannel
+
+	// confirmChannel is in confirm mode and is used by PublishConfirmed,
+	// and by Publish when confirmTimeout is set
+	confirmChannel confirmChannel
+}
+
+// amqpConnection is the part of an AMQP connection a session uses;
+// *amqp.Connection implements it.
+type amqpConnection interface {
+	Channel() (*amqp.Channel, error)
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	IsClosed() bool
+	Close() error
+}
+
+// amqpChannel is the part of an AMQP channel a session uses;
+// *amqp.Channel implements it.
+type amqpChannel interface {
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	Close() error
+}
+
+// confirmChannel is a channel in confirm mode; amqpConfirmChannel
+// implements it.
+type confirmChannel interface {
+	// publish sends msg and returns the broker's pending confirm of it
+	publish(ctx context.Context, exchange, key string, msg amqp.Publishing) (confirmation, error)
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	Close() error
+}
+
+// confirmation is the pending confirm of one publish;
+// *amqp.DeferredConfirmation implements it.
+type confirmation interface {
+	WaitContext(ctx context.Context) (bool, error)
+}
+
+// amqpConfirmChannel is an *amqp.Channel in confirm mode.
+type amqpConfirmChannel struct {
+	*amqp.Channel
+}
+
+func (c amqpConfirmChannel) publish(ctx context.Context, exchange, key string, msg amqp.Publishing) (confirmation, error) {
+	confirm, err := c.PublishWithDeferredConfirmWithContext(ctx,
+		exchange, // exchange
+		key,      // routing key
+		false,    // mandatory
+		false,    // immediate
+		msg,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return confirm, nil
 }
 
-// NewRabbitMQPublisher creates a new RabbitMQ publisher.
-func NewRabbitMQPublisher(url string, logger *slog.Logger) (*RabbitMQPublisher, error) {
+// NewRabbitMQPublisher creates a new RabbitMQ publisher. With a non-zero
+// confirmTimeout, Publish waits up to that long for the broker to confirm
+// each message; otherwise it returns once the channel accepted it. A
+// confirmed publish that is nacked or unconfirmed maxAttempts times is
+// routed to the dead-letter queue; 0 disables dead-lettering.
+func NewRabbitMQPublisher(url string, confirmTimeout time.Duration, maxAttempts int, logger *slog.Logger) (*RabbitMQPublisher, error) {
+	s, err := dial(url)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("RabbitMQ publisher initialized",
+		"exchange", ExchangeName,
+		"queue", QueueName,
+		"confirm_timeout", confirmTimeout.String(),
+		"max_attempts", maxAttempts,
+	)
+
+	p := &RabbitMQPublisher{
+		url:      url,
+		exchange: ExchangeName,
+		logger:   logger,
+
+		dial: dial,
+
+		confirmTimeout: confirmTimeout,
+		maxAttempts:    maxAttempts,
+
+		session: s,
+		ready:   make(chan struct{}),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	close(p.ready)
+	go p.watch(s)
+	return p, nil
+}
+
+// dial connects to RabbitMQ, declares the exchanges, queues and bindings,
+// and opens the publishing channels.
+func dial(url string) (*session, error) {
 	// Connect to RabbitMQ
 	conn, err := amqp.Dial(url)
 	if err != nil {
@@ -61,7 +252,6 @@ func NewRabbitMQPublisher(url string, logger *slog.Logger) (*RabbitMQPublisher,
 		nil,          // arguments
 	)
 	if err != nil {
-		channel.Close()
 		conn.Close()
 		return nil, fmt.Errorf("failed to declare exchange: %w", err)
 	}
@@ -76,7 +266,6 @@ func NewRabbitMQPublisher(url string, logger *slog.Logger) (*RabbitMQPublisher,
 		nil,       // arguments
 	)
 	if err != nil {
-		channel.Close()
 		conn.Close()
 		return nil, fmt.Errorf("failed to declare queue: %w", err)
 	}
@@ -90,45 +279,175 @@ func NewRabbitMQPublisher(url string, logger *slog.Logger) (*RabbitMQPublisher,
 		nil,          // arguments
 	)
 	if err != nil {
-		channel.Close()
 		conn.Close()
 		return nil, fmt.Errorf("failed to bind queue: %w", err)
 	}
 
-	logger.Info("RabbitMQ publisher initialized",
-		"exchange", ExchangeName,
-		"queue", QueueName,
-	)
+	// Dead-letter exchange and queue for messages that cannot be published
+	if err := channel.ExchangeDeclare(DeadLetterExchangeName, "fanout", true, false, false, false, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare dead-letter exchange: %w", err)
+	}
+	if _, err := channel.QueueDeclare(DeadLetterQueueName, true, false, false, false, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare dead-letter queue: %w", err)
+	}
+	if err := channel.QueueBind(DeadLetterQueueName, "", DeadLetterExchangeName, false, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to bind dead-letter queue: %w", err)
+	}
 
-	return &RabbitMQPublisher{
-		conn:     conn,
-		channel:  channel,
-		exchange: ExchangeName,
-		logger:   logger,
-	}, nil
+	// Confirmed publishes use their own channel so the main channel stays
+	// fire-and-forget
+	confirmChannel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open confirm channel: %w", err)
+	}
+	if err := confirmChannel.Confirm(false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+
+	return &session{conn: conn, channel: channel, confirmChannel: amqpConfirmChannel{confirmChannel}}, nil
+}
+
+// watch waits for the session's connection or one of its channels to close,
+// then reconnects until it succeeds or the publisher is closed.
+func (p *RabbitMQPublisher) watch(s *session) {
+	defer close(p.done)
+
+	for {
+		connClosed := s.conn.NotifyClose(make(chan *amqp.Error, 1))
+		channelClosed := s.channel.NotifyClose(make(chan *amqp.Error, 1))
+		confirmClosed := s.confirmChannel.NotifyClose(make(chan *amqp.Error, 1))
+
+		var cause *amqp.Error
+		select {
+		case <-p.stop:
+			return
+		case cause = <-connClosed:
+		case cause = <-channelClosed:
+		case cause = <-confirmClosed:
+		}
+
+		p.disconnected(s)
+		s.conn.Close()
+		p.logger.Warn("RabbitMQ connection lost, reconnecting", "error", cause)
+
+		if s = p.reconnect(); s == nil {
+			return
+		}
+	}
 }
 
-// Publish sends a message to the queue.
-func (p *RabbitMQPublisher) Publish(ctx context.Context, msg *models.QueueMessage) error {
+// reconnect dials with exponential backoff and installs the new session. It
+// returns nil if the publisher was closed first.
+func (p *RabbitMQPublisher) reconnect() *session {
+	delay := reconnectMinDelay
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-p.stop:
+			return nil
+		case <-time.After(delay):
+		}
+
+		s, err := p.dial(p.url)
+		if err != nil {
+			p.logger.Warn("RabbitMQ reconnect failed", "error", err, "attempt", attempt, "retry_in", delay.String())
+			delay = min(delay*2, reconnectMaxDelay)
+			continue
+		}
+
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			s.conn.Close()
+			return nil
+		}
+		p.session = s
+		close(p.ready)
+		p.mu.Unlock()
+		p.logger.Info("RabbitMQ connection re-established", "attempts", attempt)
+		return s
+	}
+}
+
+// disconnected marks s as gone, so publishes wait for the reconnect instead
+// of using it, unless it was already replaced.
+func (p *RabbitMQPublisher) disconnected(s *session) {
+	p.mu.Lock()
+	if p.session == s {
+		p.session = nil
+		p.ready = make(chan struct{})
+	}
+	p.mu.Unlock()
+}
+
+// current returns the live session, waiting for a reconnect to finish until
+// ctx ends.
+func (p *RabbitMQPublisher) current(ctx context.Context) (*session, error) {
+	for {
+		p.mu.Lock()
+		s, ready, closed := p.session, p.ready, p.closed
+		p.mu.Unlock()
+		if closed {
+			return nil, ErrClosed
+		}
+		if s != nil {
+			return s, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("RabbitMQ is reconnecting: %w", ctx.Err())
+		case <-ready:
+		}
+	}
+}
+
+// Publish sends a message to the queue, waiting for the broker's confirm
+// when a confirm timeout is configured.
+func (p *RabbitMQPublisher) Publish(ctx context.Context, msg *models.QueueMessage) (err error) {
+	if p.confirmTimeout > 0 {
+		return p.PublishConfirmed(ctx, msg)
+	}
+
+	p.inflight.Add(1)
+	defer p.inflight.Done()
+
+	ctx, span := startPublishSpan(ctx, msg)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	body, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	err = p.channel.PublishWithContext(ctx,
-		p.exchange, // exchange
-		RoutingKey, // routing key
-		false,      // mandatory
-		false,      // immediate
-		amqp.Publishing{
-			ContentType:  "application/json",
-			DeliveryMode: amqp.Persistent,
-			Timestamp:    time.Now(),
-			Body:         body,
-		},
-	)
-	if err != nil {
-		return fmt.Errorf("failed to publish message: %w", err)
+	// A channel closed under the publish is retried on the next connection
+	for {
+		s, err := p.current(ctx)
+		if err != nil {
+			return err
+		}
+		err = s.channel.PublishWithContext(ctx,
+			p.exchange, // exchange
+			RoutingKey, // routing key
+			false,      // mandatory
+			false,      // immediate
+			publishing(ctx, msg, body),
+		)
+		if errors.Is(err, amqp.ErrClosed) {
+			p.disconnected(s)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to publish message: %w", err)
+		}
+		break
 	}
 
 	p.logger.Debug("Message published",
@@ -139,12 +458,230 @@ func (p *RabbitMQPublisher) Publish(ctx context.Context, msg *models.QueueMessag
 	return nil
 }
 
-// Close closes the RabbitMQ connection.
+// PublishConfirmed sends a message and waits for the broker's confirm, up
+// to the confirm timeout or ctx's deadline, whichever comes first. A nack
+// returns ErrNacked and a missed deadline ErrConfirmTimeout. Waiting for a
+// reconnect is bounded by ctx alone. A message nacked or unconfirmed on
+// each of maxAttempts attempts is dead-lettered and ErrDeadLettered is
+// returned.
+func (p *RabbitMQPublisher) PublishConfirmed(ctx context.Context, msg *models.QueueMessage) (err error) {
+	p.inflight.Add(1)
+	defer p.inflight.Done()
+
+	ctx, span := startPublishSpan(ctx, msg)
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = p.publishConfirmed(ctx, msg, body)
+		if err == nil || p.maxAttempts == 0 || ctx.Err() != nil {
+			return err
+		}
+		if !errors.Is(err, ErrNacked) && !errors.Is(err, ErrConfirmTimeout) {
+			return err
+		}
+		if attempt >= p.maxAttempts {
+			break
+		}
+		p.logger.Warn("Publish not confirmed, retrying", "error", err, "event_id", msg.EventID, "attempt", attempt)
+	}
+
+	if dlqErr := p.PublishToDLQ(ctx, msg, err.Error()); dlqErr != nil {
+		return fmt.Errorf("%w; dead-lettering also failed: %v", err, dlqErr)
+	}
+	return fmt.Errorf("%w after %d attempts: %v", ErrDeadLettered, p.maxAttempts, err)
+}
+
+// publishConfirmed makes one confirmed publish attempt of msg, marshaled
+// as body.
+func (p *RabbitMQPublisher) publishConfirmed(ctx context.Context, msg *models.QueueMessage, body []byte) error {
+	var confirm confirmation
+	for {
+		s, err := p.current(ctx)
+		if err != nil {
+			return err
+		}
+		confirm, err = s.confirmChannel.publish(ctx, p.exchange, RoutingKey, publishing(ctx, msg, body))
+		if errors.Is(err, amqp.ErrClosed) {
+			p.disconnected(s)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to publish message: %w", err)
+		}
+		break
+	}
+	return p.waitConfirm(ctx, confirm, msg.EventID)
+}
+
+// waitConfirm waits for the broker's confirm of one publish.
+func (p *RabbitMQPublisher) waitConfirm(ctx context.Context, confirm confirmation, eventID string) error {
+	if p.confirmTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.confirmTimeout)
+		defer cancel()
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: event %s", ErrConfirmTimeout, eventID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to wait for publish confirm: %w", err)
+	}
+	if !acked {
+		return ErrNacked
+	}
+	return nil
+}
+
+// PublishToDLQ routes a message to the dead-letter queue with reason in its
+// x-failure-reason header, and waits for the broker's confirm.
+func (p *RabbitMQPublisher) PublishToDLQ(ctx context.Context, msg *models.QueueMessage, reason string) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	dead := publishing(ctx, msg, body)
+	if dead.Headers == nil {
+		dead.Headers = amqp.Table{}
+	}
+	dead.Headers[HeaderFailureReason] = reason
+	dead.Headers[HeaderFailedAt] = time.Now().UTC().Format(time.RFC3339)
+
+	var confirm confirmation
+	for {
+		s, err := p.current(ctx)
+		if err != nil {
+			return err
+		}
+		confirm, err = s.confirmChannel.publish(ctx, DeadLetterExchangeName, "", dead)
+		if errors.Is(err, amqp.ErrClosed) {
+			p.disconnected(s)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to publish to dead-letter queue: %w", err)
+		}
+		break
+	}
+	if err := p.waitConfirm(ctx, confirm, msg.EventID); err != nil {
+		return err
+	}
+
+	p.logger.Warn("Message dead-lettered",
+		"event_id", msg.EventID,
+		"reason", reason,
+	)
+	return nil
+}
+
+// startPublishSpan starts the producer span of a publish.
+func startPublishSpan(ctx context.Context, msg *models.QueueMessage) (context.Context, trace.Span) {
+	ctx, span := tracing.Start(ctx, "publish "+RoutingKey, trace.SpanKindProducer)
+	span.SetAttributes(
+		attribute.String("messaging.system", "rabbitmq"),
+		attribute.String("messaging.destination.name", ExchangeName),
+		attribute.String("event_id", msg.EventID),
+	)
+	return ctx, span
+}
+
+// publishing builds a persistent JSON message of m, marshaled as body,
+// carrying the traceparent of ctx so the consumer can continue the trace.
+func publishing(ctx context.Context, m *models.QueueMessage, body []byte) amqp.Publishing {
+	msg := amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    time.Now(),
+		Body:         body,
+	}
+	setHeader := func(key, value string) {
+		if msg.Headers == nil {
+			msg.Headers = amqp.Table{}
+		}
+		msg.Headers[key] = value
+	}
+	tracing.Inject(ctx, setHeader)
+	if m.Replay {
+		setHeader(HeaderReplay, "true")
+	}
+	return msg
+}
+
+// ErrReconnecting is reported by Healthy while the connection is being
+// re-established.
+var ErrReconnecting = errors.New("RabbitMQ is reconnecting")
+
+// Healthy reports whether the connection to RabbitMQ is open.
+func (p *RabbitMQPublisher) Healthy() error {
+	p.mu.Lock()
+	s, closed := p.session, p.closed
+	p.mu.Unlock()
+	switch {
+	case closed:
+		return ErrClosed
+	case s == nil || s.conn.IsClosed():
+		return ErrReconnecting
+	}
+	return nil
+}
+
+// Drain waits until the publishes in progress have returned, or ctx ends.
+// Call it once no new publishes are started, before Close, so that closing
+// the connection does not cut off a publish waiting for its confirm.
+func (p *RabbitMQPublisher) Drain(ctx context.Context) error {
+	drained := make(chan struct{})
+	go func() {
+		p.inflight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("publishes still in flight: %w", ctx.Err())
+	}
+}
+
+// Close stops reconnecting and closes the RabbitMQ connection.
 func (p *RabbitMQPublisher) Close() error {
-	if err := p.channel.Close(); err != nil {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	s := p.session
+	if s == nil {
+		// Wake publishes waiting for the reconnect
+		close(p.ready)
+	}
+	p.mu.Unlock()
+
+	close(p.stop)
+	<-p.done
+	if s == nil {
+		p.logger.Info("RabbitMQ publisher closed while reconnecting")
+		return nil
+	}
+
+	if err := s.confirmChannel.Close(); err != nil {
+		p.logger.Error("Failed to close confirm channel", "error", err)
+	}
+	if err := s.channel.Close(); err != nil {
 		p.logger.Error("Failed to close channel", "error", err)
 	}
-	if err := p.conn.Close(); err != nil {
+	if err := s.conn.Close(); err != nil {
 		return fmt.Errorf("failed to close connection: %w", err)
 	}
 	p.logger.Info("RabbitMQ connection closed")
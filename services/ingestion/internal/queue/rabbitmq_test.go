@@ -0,0 +1,189 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/tracing"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestPublishingHeaders(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	tests := []struct {
+		name            string
+		traceparent     string
+		replay          bool
+		wantTraceparent bool
+		wantReplay      bool
+	}{
+		{name: "traced message", traceparent: traceparent, wantTraceparent: true},
+		{name: "untraced message"},
+		{name: "traced replay", traceparent: traceparent, replay: true, wantTraceparent: true, wantReplay: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tracing.Propagator.Extract(context.Background(), propagation.MapCarrier{"traceparent": tt.traceparent})
+			msg := &models.QueueMessage{EventID: "evt-1", Replay: tt.replay}
+			ctx, span := startPublishSpan(ctx, msg)
+			defer span.End()
+
+			pub := publishing(ctx, msg, []byte(`{}`))
+			got, ok := pub.Headers["traceparent"].(string)
+			if ok != tt.wantTraceparent {
+				t.Fatalf("traceparent header = %q, present %v, want %v", got, ok, tt.wantTraceparent)
+			}
+			if ok && got[3:35] != traceparent[3:35] {
+				t.Errorf("traceparent = %q, want trace %s", got, traceparent[3:35])
+			}
+			if _, ok := pub.Headers[HeaderReplay]; ok != tt.wantReplay {
+				t.Errorf("replay header present = %v, want %v", ok, tt.wantReplay)
+			}
+		})
+	}
+}
+
+func TestRabbitMQPublisherHealthy(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(p *RabbitMQPublisher)
+		want    error
+	}{
+		{name: "connected", prepare: func(*RabbitMQPublisher) {}},
+		{name: "connection closed", prepare: func(p *RabbitMQPublisher) { p.session.conn.(*fakeConnection).close(nil) }, want: ErrReconnecting},
+		{name: "reconnecting", prepare: func(p *RabbitMQPublisher) { p.disconnected(p.session) }, want: ErrReconnecting},
+		{name: "closed", prepare: func(p *RabbitMQPublisher) { p.closed = true }, want: ErrClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := (&fakeBroker{}).dial("")
+			p := newTestPublisher(s, 0, 0)
+			tt.prepare(p)
+			if err := p.Healthy(); !errors.Is(err, tt.want) || (err == nil) != (tt.want == nil) {
+				t.Errorf("Healthy() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishRoutesToDLQ(t *testing.T) {
+	tests := []struct {
+		name        string
+		confirms    []fakeConfirm
+		maxAttempts int
+		wantErr     error
+		wantDead    bool
+		wantReason  string
+	}{
+		{
+			name:        "nacked until out of attempts",
+			confirms:    []fakeConfirm{{nack: true}, {nack: true}, {nack: true}},
+			maxAttempts: 3,
+			wantErr:     ErrDeadLettered,
+			wantDead:    true,
+			wantReason:  ErrNacked.Error(),
+		},
+		{
+			name:        "dead-lettering disabled",
+			confirms:    []fakeConfirm{{nack: true}},
+			maxAttempts: 0,
+			wantErr:     ErrNacked,
+		},
+		{
+			name:        "dead-letter publish nacked too",
+			confirms:    []fakeConfirm{{nack: true}, {nack: true}},
+			maxAttempts: 1,
+			wantErr:     ErrNacked,
+			wantDead:    true,
+			wantReason:  ErrNacked.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := &fakeChannel{confirms: tt.confirms}
+			p := newTestPublisher(&session{channel: ch, confirmChannel: ch}, time.Second, tt.maxAttempts)
+
+			err := p.PublishConfirmed(context.Background(), &models.QueueMessage{EventID: "evt-1"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PublishConfirmed() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != ErrDeadLettered && errors.Is(err, ErrDeadLettered) {
+				t.Errorf("PublishConfirmed() error = %v, reports a dead-letter that was not confirmed", err)
+			}
+
+			var dead []fakePublish
+			for _, pub := range ch.sent() {
+				if pub.exchange == DeadLetterExchangeName {
+					dead = append(dead, pub)
+				}
+			}
+			if (len(dead) == 1) != tt.wantDead {
+				t.Fatalf("dead-lettered %d times, want dead-lettered %v", len(dead), tt.wantDead)
+			}
+			if !tt.wantDead {
+				return
+			}
+			headers := dead[0].msg.Headers
+			if headers[HeaderFailureReason] != tt.wantReason {
+				t.Errorf("%s = %v, want %q", HeaderFailureReason, headers[HeaderFailureReason], tt.wantReason)
+			}
+			if _, err := time.Parse(time.RFC3339, fmt.Sprint(headers[HeaderFailedAt])); err != nil {
+				t.Errorf("%s = %v, want an RFC 3339 time", HeaderFailedAt, headers[HeaderFailedAt])
+			}
+		})
+	}
+}
+
+func TestDrainWaitsForSlowPublish(t *testing.T) {
+	const confirmDelay = 200 * time.Millisecond
+
+	tests := []struct {
+		name         string
+		drainTimeout time.Duration
+		wantErr      error
+	}{
+		{name: "publish confirmed within the timeout", drainTimeout: 5 * time.Second},
+		{name: "timeout before the confirm", drainTimeout: 20 * time.Millisecond, wantErr: context.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := &fakeChannel{confirms: []fakeConfirm{{delay: confirmDelay}}}
+			p := newTestPublisher(&session{channel: ch, confirmChannel: ch}, time.Second, 0)
+
+			published := make(chan error, 1)
+			go func() { published <- p.Publish(context.Background(), &models.QueueMessage{EventID: "evt-1"}) }()
+			for len(ch.sent()) == 0 {
+				time.Sleep(time.Millisecond)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.drainTimeout)
+			defer cancel()
+			err := p.Drain(ctx)
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("Drain() error = %v, want %v", err, tt.wantErr)
+			}
+			select {
+			case err := <-published:
+				if tt.wantErr != nil {
+					t.Fatal("publish finished before Drain timed out")
+				}
+				if err != nil {
+					t.Errorf("Publish() error = %v", err)
+				}
+			default:
+				if tt.wantErr == nil {
+					t.Fatal("Drain() returned before the publish finished")
+				}
+			}
+			// A timed out Drain leaves the publish running to completion
+			if err := p.Drain(context.Background()); err != nil {
+				t.Fatalf("second Drain() error = %v", err)
+			}
+		})
+	}
+}
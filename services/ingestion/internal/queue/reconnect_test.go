@@ -0,0 +1,180 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// fakeConnection is a connection whose channels close with it.
+type fakeConnection struct {
+	amqpConnection
+
+	mu       sync.Mutex
+	channels []*fakeChannel
+	closed   bool
+	notify   []chan *amqp.Error
+}
+
+func (c *fakeConnection) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		close(receiver)
+		return receiver
+	}
+	c.notify = append(c.notify, receiver)
+	return receiver
+}
+
+func (c *fakeConnection) IsClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// close closes the connection and its channels, as a broker restart does.
+func (c *fakeConnection) close(cause *amqp.Error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.closed = true
+	for _, n := range c.notify {
+		n <- cause
+		close(n)
+	}
+	c.mu.Unlock()
+	for _, ch := range c.channels {
+		ch.close(cause)
+	}
+}
+
+func (c *fakeConnection) Close() error {
+	c.close(nil)
+	return nil
+}
+
+// fakeBroker dials sessions of one fake channel each, or fails while down.
+type fakeBroker struct {
+	mu       sync.Mutex
+	down     bool
+	sessions []*fakeChannel
+}
+
+func (b *fakeBroker) dial(url string) (*session, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.down {
+		return nil, errors.New("connection refused")
+	}
+	ch := &fakeChannel{}
+	b.sessions = append(b.sessions, ch)
+	return &session{conn: &fakeConnection{channels: []*fakeChannel{ch}}, channel: ch, confirmChannel: ch}, nil
+}
+
+func (b *fakeBroker) channels() []*fakeChannel {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*fakeChannel(nil), b.sessions...)
+}
+
+func TestPublishResumesAfterReconnect(t *testing.T) {
+	lost := &amqp.Error{Code: amqp.ConnectionForced, Reason: "broker restarting"}
+
+	tests := []struct {
+		name           string
+		confirmTimeout time.Duration
+		lose           func(s *session)
+	}{
+		{
+			name: "connection closed",
+			lose: func(s *session) { s.conn.(*fakeConnection).close(lost) },
+		},
+		{
+			name:           "connection closed under a confirmed publish",
+			confirmTimeout: time.Second,
+			lose:           func(s *session) { s.conn.(*fakeConnection).close(lost) },
+		},
+		{
+			name: "channel closed",
+			lose: func(s *session) { s.channel.(*fakeChannel).close(lost) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := &fakeBroker{}
+			s, _ := broker.dial("")
+			p := newTestPublisher(s, tt.confirmTimeout, 0)
+			p.dial = broker.dial
+			go p.watch(s)
+			defer p.Close()
+
+			tt.lose(s)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := p.Publish(ctx, &models.QueueMessage{EventID: "evt-1"}); err != nil {
+				t.Fatalf("Publish() after reconnect error = %v", err)
+			}
+			if err := p.Healthy(); err != nil {
+				t.Errorf("Healthy() after reconnect = %v", err)
+			}
+
+			channels := broker.channels()
+			if len(channels) != 2 {
+				t.Fatalf("dialed %d sessions, want 2", len(channels))
+			}
+			if n := len(channels[0].sent()); n != 0 {
+				t.Errorf("published %d messages on the lost channel", n)
+			}
+			if n := len(channels[1].sent()); n != 1 {
+				t.Errorf("published %d messages on the new channel, want 1", n)
+			}
+		})
+	}
+}
+
+func TestPublishWaitsForReconnect(t *testing.T) {
+	broker := &fakeBroker{}
+	s, _ := broker.dial("")
+	p := newTestPublisher(s, 0, 0)
+	p.dial = broker.dial
+	go p.watch(s)
+
+	broker.mu.Lock()
+	broker.down = true
+	broker.mu.Unlock()
+	s.conn.(*fakeConnection).close(&amqp.Error{Code: amqp.ConnectionForced})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := p.Publish(ctx, &models.QueueMessage{EventID: "evt-1"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Publish() while reconnecting error = %v, want the context deadline", err)
+	}
+	if err := p.Healthy(); !errors.Is(err, ErrReconnecting) {
+		t.Errorf("Healthy() while reconnecting = %v, want %v", err, ErrReconnecting)
+	}
+
+	// Closing wakes publishes waiting for the reconnect
+	published := make(chan error, 1)
+	go func() { published <- p.Publish(context.Background(), &models.QueueMessage{EventID: "evt-2"}) }()
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	select {
+	case err := <-published:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("Publish() after Close error = %v, want %v", err, ErrClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Publish() still waiting after Close")
+	}
+}
@@ -0,0 +1,190 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// Backpressure modes applied when a source type's buffer is full.
+const (
+	// BackpressureBuffer waits up to the enqueue timeout for buffer space.
+	BackpressureBuffer = "buffer"
+	// BackpressureShed rejects the message immediately.
+	BackpressureShed = "shed"
+)
+
+// ErrBackpressure is returned when a source type's buffer is full.
+var ErrBackpressure = errors.New("publish buffer full")
+
+// publishTimeout bounds a single downstream publish.
+const publishTimeout = 5 * time.Second
+
+// TypedConfig controls per-source-type flow control.
+type TypedConfig struct {
+	BufferSize     int
+	Mode           string
+	EnqueueTimeout time.Duration
+	// OnResult, if set, is called after each buffered message is published
+	// or fails to publish, from the source type's worker goroutine.
+	OnResult func(msg *models.QueueMessage, err error)
+}
+
+// TypeStats reports the flow-control state of one source type.
+type TypeStats struct {
+	Depth         int     `json:"depth"`
+	Capacity      int     `json:"capacity"`
+	Published     int64   `json:"published"`
+	Failed        int64   `json:"failed"`
+	Shed          int64   `json:"shed"`
+	AvgLatencyMs  float64 `json:"avg_latency_ms"`
+	LastLatencyMs float64 `json:"last_latency_ms"`
+}
+
+type typeLane struct {
+	messages chan *models.QueueMessage
+
+	mu           sync.Mutex
+	published    int64
+	failed       int64
+	shed         int64
+	totalLatency time.Duration
+	lastLatency  time.Duration
+}
+
+// TypedPublisher decouples publishing per source type. Each type has its
+// own buffer and worker, so a slow consumer of one type applies
+// backpressure to that type only.
+type TypedPublisher struct {
+	next   Publisher
+	cfg    TypedConfig
+	logger *slog.Logger
+
+	lanes map[models.SourceType]*typeLane
+	wg    sync.WaitGroup
+}
+
+// NewTypedPublisher wraps next with one buffered lane per source type.
+func NewTypedPublisher(next Publisher, sourceTypes []models.SourceType, cfg TypedConfig, logger *slog.Logger) (*TypedPublisher, error) {
+	if cfg.Mode != BackpressureBuffer && cfg.Mode != BackpressureShed {
+		return nil, fmt.Errorf("unsupported backpressure mode %q", cfg.Mode)
+	}
+
+	p := &TypedPublisher{
+		next:   next,
+		cfg:    cfg,
+		logger: logger,
+		lanes:  make(map[models.SourceType]*typeLane, len(sourceTypes)),
+	}
+	for _, t := range sourceTypes {
+		lane := &typeLane{messages: make(chan *models.QueueMessage, cfg.BufferSize)}
+		p.lanes[t] = lane
+		p.wg.Add(1)
+		go p.run(lane)
+	}
+	return p, nil
+}
+
+// Publish buffers msg on its source type's lane. It returns ErrBackpressure
+// when the lane is full: at once in shed mode, or after the enqueue timeout
+// in buffer mode. Source types without a lane are published directly.
+func (p *TypedPublisher) Publish(ctx context.Context, msg *models.QueueMessage) error {
+	lane, ok := p.lanes[msg.SourceType]
+	if !ok {
+		return p.next.Publish(ctx, msg)
+	}
+
+	select {
+	case lane.messages <- msg:
+		return nil
+	default:
+	}
+
+	if p.cfg.Mode == BackpressureBuffer {
+		timer := time.NewTimer(p.cfg.EnqueueTimeout)
+		defer timer.Stop()
+		select {
+		case lane.messages <- msg:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	lane.mu.Lock()
+	lane.shed++
+	lane.mu.Unlock()
+	return fmt.Errorf("%w for source type %s", ErrBackpressure, msg.SourceType)
+}
+
+func (p *TypedPublisher) run(lane *typeLane) {
+	defer p.wg.Done()
+
+	for msg := range lane.messages {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		start := time.Now()
+		err := p.next.Publish(ctx, msg)
+		latency := time.Since(start)
+		cancel()
+
+		lane.mu.Lock()
+		lane.lastLatency = latency
+		lane.totalLatency += latency
+		if err != nil {
+			lane.failed++
+		} else {
+			lane.published++
+		}
+		lane.mu.Unlock()
+
+		if err != nil {
+			p.logger.Error("Failed to publish buffered event", "error", err, "event_id", msg.EventID, "source_type", msg.SourceType)
+		}
+		if p.cfg.OnResult != nil {
+			p.cfg.OnResult(msg, err)
+		}
+	}
+}
+
+// Stats returns the flow-control state of every source type.
+func (p *TypedPublisher) Stats() map[models.SourceType]TypeStats {
+	stats := make(map[models.SourceType]TypeStats, len(p.lanes))
+	for t, lane := range p.lanes {
+		lane.mu.Lock()
+		s := TypeStats{
+			Depth:         len(lane.messages),
+			Capacity:      cap(lane.messages),
+			Published:     lane.published,
+			Failed:        lane.failed,
+			Shed:          lane.shed,
+			LastLatencyMs: float64(lane.lastLatency) / float64(time.Millisecond),
+		}
+		if n := lane.published + lane.failed; n > 0 {
+			s.AvgLatencyMs = float64(lane.totalLatency) / float64(n) / float64(time.Millisecond)
+		}
+		lane.mu.Unlock()
+		stats[t] = s
+	}
+	return stats
+}
+
+// Healthy reports the health of the wrapped publisher.
+func (p *TypedPublisher) Healthy() error {
+	return p.next.Healthy()
+}
+
+// Close drains the buffered messages. The wrapped publisher is left open
+// for its owner to close. Publish must not be called after Close.
+func (p *TypedPublisher) Close() error {
+	for _, lane := range p.lanes {
+		close(lane.messages)
+	}
+	p.wg.Wait()
+	return nil
+}
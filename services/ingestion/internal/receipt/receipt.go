@@ -0,0 +1,110 @@
+// Package receipt signs receipts for ingested events with an Ed25519
+// service key, so producers can later prove what the service received and
+// when. A receipt signs the concatenation of the event ID, the payload
+// checksum and created_at, the latter in UTC to the microsecond as
+// PostgreSQL stores it, e.g. 2026-10-15T09:30:00.123456Z.
+package receipt
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// Algorithm names the signature algorithm of every receipt.
+const Algorithm = "Ed25519"
+
+// createdAtLayout formats created_at in signed messages.
+const createdAtLayout = "2006-01-02T15:04:05.000000Z"
+
+// ErrInvalidReceipt is returned by Verify for a receipt that does not
+// match the event or was not signed by the key.
+var ErrInvalidReceipt = errors.New("invalid receipt")
+
+// Signer signs receipts with one key.
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+// NewSigner creates a signer for key. An empty keyID is derived from the
+// public key.
+func NewSigner(key ed25519.PrivateKey, keyID string) *Signer {
+	if keyID == "" {
+		sum := sha256.Sum256(key.Public().(ed25519.PublicKey))
+		keyID = hex.EncodeToString(sum[:8])
+	}
+	return &Signer{key: key, keyID: keyID}
+}
+
+// Parse creates a signer from a base64-encoded Ed25519 seed or private key.
+func Parse(encoded, keyID string) (*Signer, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("receipt key is not valid base64: %w", err)
+	}
+	switch len(raw) {
+	case ed25519.SeedSize:
+		return NewSigner(ed25519.NewKeyFromSeed(raw), keyID), nil
+	case ed25519.PrivateKeySize:
+		return NewSigner(ed25519.PrivateKey(raw), keyID), nil
+	default:
+		return nil, fmt.Errorf("receipt key must be a %d-byte seed or %d-byte private key, got %d bytes",
+			ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+	}
+}
+
+// KeyID returns the ID receipts name their key by.
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// PublicKey returns the key receipts are verified with.
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// Sign returns a receipt for a stored event.
+func (s *Signer) Sign(event *models.IngestionEvent) (models.Receipt, error) {
+	if event.EventID == "" || event.Checksum == "" || event.CreatedAt.IsZero() {
+		return models.Receipt{}, errors.New("event needs an ID, checksum and creation time to be signed")
+	}
+	signature := ed25519.Sign(s.key, message(event))
+	return models.Receipt{
+		KeyID:     s.keyID,
+		Algorithm: Algorithm,
+		Checksum:  event.Checksum,
+		Signature: base64.StdEncoding.EncodeToString(signature),
+	}, nil
+}
+
+// Verify checks that r was signed with key for event.
+func Verify(key ed25519.PublicKey, event *models.IngestionEvent, r models.Receipt) error {
+	if r.Algorithm != Algorithm {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidReceipt, r.Algorithm)
+	}
+	if r.Checksum != event.Checksum {
+		return fmt.Errorf("%w: checksum does not match the event", ErrInvalidReceipt)
+	}
+	signature, err := base64.StdEncoding.DecodeString(r.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: signature is not valid base64", ErrInvalidReceipt)
+	}
+	if !ed25519.Verify(key, message(event), signature) {
+		return fmt.Errorf("%w: signature does not verify", ErrInvalidReceipt)
+	}
+	return nil
+}
+
+// message returns the bytes a receipt for event signs.
+func message(event *models.IngestionEvent) []byte {
+	createdAt := event.CreatedAt.UTC().Truncate(time.Microsecond).Format(createdAtLayout)
+	return []byte(event.EventID + event.Checksum + createdAt)
+}
@@ -0,0 +1,151 @@
+package receipt
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// testSigner returns a signer with a fixed key.
+func testSigner(seed byte) *Signer {
+	return NewSigner(ed25519.NewKeyFromSeed([]byte(strings.Repeat(string(rune(seed)), ed25519.SeedSize))), "")
+}
+
+func testEvent() *models.IngestionEvent {
+	return &models.IngestionEvent{
+		EventID:   "6f1c8a52-3d4e-4b7a-9c1d-2e3f4a5b6c7d",
+		Checksum:  strings.Repeat("ab", 32),
+		CreatedAt: time.Date(2026, 10, 15, 9, 30, 0, 123456789, time.UTC),
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	signer := testSigner('a')
+
+	tests := []struct {
+		name    string
+		key     ed25519.PublicKey
+		event   func(e *models.IngestionEvent)
+		receipt func(r *models.Receipt)
+		wantErr bool
+	}{
+		{name: "round trip"},
+		{
+			name:  "created_at read back at microsecond precision",
+			event: func(e *models.IngestionEvent) { e.CreatedAt = e.CreatedAt.Truncate(time.Microsecond) },
+		},
+		{
+			name:  "created_at in another zone",
+			event: func(e *models.IngestionEvent) { e.CreatedAt = e.CreatedAt.In(time.FixedZone("CEST", 2*60*60)) },
+		},
+		{name: "other event ID", event: func(e *models.IngestionEvent) { e.EventID = "6f1c8a52-3d4e-4b7a-9c1d-000000000000" }, wantErr: true},
+		{name: "other checksum", event: func(e *models.IngestionEvent) { e.Checksum = strings.Repeat("cd", 32) }, wantErr: true},
+		{name: "other created_at", event: func(e *models.IngestionEvent) { e.CreatedAt = e.CreatedAt.Add(time.Second) }, wantErr: true},
+		{name: "other key", key: testSigner('b').PublicKey(), wantErr: true},
+		{name: "receipt for another checksum", receipt: func(r *models.Receipt) { r.Checksum = strings.Repeat("cd", 32) }, wantErr: true},
+		{name: "unsupported algorithm", receipt: func(r *models.Receipt) { r.Algorithm = "ES256" }, wantErr: true},
+		{name: "signature not base64", receipt: func(r *models.Receipt) { r.Signature = "not base64!" }, wantErr: true},
+		{
+			name: "tampered signature",
+			receipt: func(r *models.Receipt) {
+				sig, _ := base64.StdEncoding.DecodeString(r.Signature)
+				sig[0] ^= 0xff
+				r.Signature = base64.StdEncoding.EncodeToString(sig)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := signer.Sign(testEvent())
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if r.KeyID != signer.KeyID() || r.Algorithm != Algorithm {
+				t.Errorf("receipt key = %s/%s, want %s/%s", r.KeyID, r.Algorithm, signer.KeyID(), Algorithm)
+			}
+
+			event := testEvent()
+			if tt.event != nil {
+				tt.event(event)
+			}
+			if tt.receipt != nil {
+				tt.receipt(&r)
+			}
+			key := signer.PublicKey()
+			if tt.key != nil {
+				key = tt.key
+			}
+
+			err = Verify(key, event, r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidReceipt) {
+				t.Errorf("Verify() error = %v, want %v", err, ErrInvalidReceipt)
+			}
+		})
+	}
+}
+
+func TestSignIncompleteEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event func(e *models.IngestionEvent)
+	}{
+		{name: "no event ID", event: func(e *models.IngestionEvent) { e.EventID = "" }},
+		{name: "no checksum", event: func(e *models.IngestionEvent) { e.Checksum = "" }},
+		{name: "no creation time", event: func(e *models.IngestionEvent) { e.CreatedAt = time.Time{} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := testEvent()
+			tt.event(event)
+			if _, err := testSigner('a').Sign(event); err == nil {
+				t.Error("Sign() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	seed := []byte(strings.Repeat("a", ed25519.SeedSize))
+	want := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+
+	tests := []struct {
+		name      string
+		encoded   string
+		keyID     string
+		wantKeyID string
+		wantErr   bool
+	}{
+		{name: "seed", encoded: base64.StdEncoding.EncodeToString(seed), wantKeyID: testSigner('a').KeyID()},
+		{name: "private key", encoded: base64.StdEncoding.EncodeToString(ed25519.NewKeyFromSeed(seed)), wantKeyID: testSigner('a').KeyID()},
+		{name: "surrounding whitespace", encoded: " " + base64.StdEncoding.EncodeToString(seed) + "\n", wantKeyID: testSigner('a').KeyID()},
+		{name: "configured key ID", encoded: base64.StdEncoding.EncodeToString(seed), keyID: "receipts-2026", wantKeyID: "receipts-2026"},
+		{name: "not base64", encoded: "not base64!", wantErr: true},
+		{name: "wrong length", encoded: base64.StdEncoding.EncodeToString([]byte("short")), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := Parse(tt.encoded, tt.keyID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !signer.PublicKey().Equal(want) {
+				t.Errorf("PublicKey() = %x, want %x", signer.PublicKey(), want)
+			}
+			if signer.KeyID() != tt.wantKeyID {
+				t.Errorf("KeyID() = %q, want %q", signer.KeyID(), tt.wantKeyID)
+			}
+		})
+	}
+}
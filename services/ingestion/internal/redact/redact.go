@@ -0,0 +1,129 @@
+// Package redact masks sensitive values before they leave the service in logs or debug output.
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Mask replaces the value of every sensitive field.
+const Mask = "[REDACTED]"
+
+// DefaultKeys are payload fields that commonly carry secrets or signatures.
+var DefaultKeys = []string{
+	"password",
+	"secret",
+	"client_secret",
+	"token",
+	"id_token",
+	"access_token",
+	"refresh_token",
+	"private_key",
+	"proofValue",
+	"jws",
+}
+
+// DefaultHeaders are request headers that carry credentials.
+var DefaultHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Admin-Key",
+}
+
+// Redactor masks the values of a set of sensitive keys, at any depth of
+// nested objects and arrays.
+type Redactor struct {
+	keys map[string]struct{}
+}
+
+// defaultRedactor masks DefaultKeys.
+var defaultRedactor = New(nil)
+
+// New returns a Redactor masking DefaultKeys and the extra keys.
+func New(extra []string) *Redactor {
+	return &Redactor{keys: keySet(append(slices.Clip(DefaultKeys), extra...))}
+}
+
+// Map returns a deep copy of m with the values of DefaultKeys masked.
+func Map(m map[string]interface{}) map[string]interface{} {
+	return defaultRedactor.Map(m)
+}
+
+// JSON redacts DefaultKeys in a JSON document.
+func JSON(data []byte) []byte {
+	return defaultRedactor.JSON(data)
+}
+
+// Map returns a deep copy of m with the values of sensitive keys masked.
+// Keys are matched case-insensitively. The input is never modified, so it
+// can still be stored as received.
+func (r *Redactor) Map(m map[string]interface{}) map[string]interface{} {
+	return redactMap(m, r.keys)
+}
+
+// JSON redacts a JSON document. Bodies that are not JSON objects or arrays
+// are returned unchanged so malformed input can still be inspected.
+func (r *Redactor) JSON(data []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return data
+	}
+
+	out, err := json.Marshal(redactValue(v, r.keys))
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// Headers returns a copy of h with credential-bearing headers masked.
+func Headers(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range DefaultHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, Mask)
+		}
+	}
+	return out
+}
+
+func keySet(keys []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[strings.ToLower(k)] = struct{}{}
+	}
+	return set
+}
+
+func redactMap(m map[string]interface{}, keys map[string]struct{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if _, sensitive := keys[strings.ToLower(k)]; sensitive {
+			out[k] = Mask
+			continue
+		}
+		out[k] = redactValue(v, keys)
+	}
+	return out
+}
+
+func redactValue(v interface{}, keys map[string]struct{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return redactMap(val, keys)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = redactValue(item, keys)
+		}
+		return out
+	default:
+		return v
+	}
+}
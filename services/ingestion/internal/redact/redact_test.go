@@ -0,0 +1,154 @@
+package redact
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRedactorMap(t *testing.T) {
+	tests := []struct {
+		name  string
+		extra []string
+		in    map[string]interface{}
+		want  map[string]interface{}
+	}{
+		{
+			name: "top-level key",
+			in:   map[string]interface{}{"password": "hunter2", "issuer": "did:example:1"},
+			want: map[string]interface{}{"password": Mask, "issuer": "did:example:1"},
+		},
+		{
+			name: "nested object",
+			in: map[string]interface{}{
+				"proof": map[string]interface{}{"type": "Ed25519Signature2020", "proofValue": "z58DAdFfa9"},
+			},
+			want: map[string]interface{}{
+				"proof": map[string]interface{}{"type": "Ed25519Signature2020", "proofValue": Mask},
+			},
+		},
+		{
+			name: "objects in arrays",
+			in: map[string]interface{}{
+				"proof": []interface{}{
+					map[string]interface{}{"jws": "eyJ..", "created": "2026-01-01"},
+					map[string]interface{}{"nested": []interface{}{map[string]interface{}{"client_secret": "s"}}},
+					"plain",
+				},
+			},
+			want: map[string]interface{}{
+				"proof": []interface{}{
+					map[string]interface{}{"jws": Mask, "created": "2026-01-01"},
+					map[string]interface{}{"nested": []interface{}{map[string]interface{}{"client_secret": Mask}}},
+					"plain",
+				},
+			},
+		},
+		{
+			name: "sensitive key holding an object",
+			in:   map[string]interface{}{"secret": map[string]interface{}{"kty": "oct", "k": "c2VjcmV0"}},
+			want: map[string]interface{}{"secret": Mask},
+		},
+		{
+			name: "keys match case-insensitively",
+			in:   map[string]interface{}{"Password": "a", "ID_TOKEN": "b", "ProofValue": "c"},
+			want: map[string]interface{}{"Password": Mask, "ID_TOKEN": Mask, "ProofValue": Mask},
+		},
+		{
+			name:  "extra keys",
+			extra: []string{"ssn", "credentialSubject"},
+			in: map[string]interface{}{
+				"credentialSubject": map[string]interface{}{"name": "Alice"},
+				"holder":            map[string]interface{}{"SSN": "078-05-1120", "token": "t"},
+			},
+			want: map[string]interface{}{
+				"credentialSubject": Mask,
+				"holder":            map[string]interface{}{"SSN": Mask, "token": Mask},
+			},
+		},
+		{
+			name: "nil map",
+			in:   nil,
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := deepCopy(tt.in)
+			got := New(tt.extra).Map(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Map() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.in, before) {
+				t.Errorf("Map() modified its input: %v, want %v", tt.in, before)
+			}
+		})
+	}
+}
+
+func TestRedactorJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "object", in: `{"password":"hunter2","n":1}`, want: `{"n":1,"password":"[REDACTED]"}`},
+		{name: "array of objects", in: `[{"token":"t"},{"a":[{"jws":"x"}]}]`, want: `[{"token":"[REDACTED]"},{"a":[{"jws":"[REDACTED]"}]}]`},
+		{name: "not JSON", in: `password=hunter2`, want: `password=hunter2`},
+		{name: "scalar", in: `"password"`, want: `"password"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(JSON([]byte(tt.in))); got != tt.want {
+				t.Errorf("JSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer abc")
+	h.Set("X-Admin-Key", "key")
+	h.Set("Content-Type", "application/json")
+
+	got := Headers(h)
+	for _, name := range []string{"Authorization", "X-Admin-Key"} {
+		if v := got.Get(name); v != Mask {
+			t.Errorf("%s = %q, want %q", name, v, Mask)
+		}
+	}
+	if v := got.Get("Content-Type"); v != "application/json" {
+		t.Errorf("Content-Type = %q, want it kept", v)
+	}
+	if h.Get("Authorization") != "Bearer abc" {
+		t.Error("Headers() modified its input")
+	}
+}
+
+// deepCopy copies the maps and slices of v so later changes to them show.
+func deepCopy(v map[string]interface{}) map[string]interface{} {
+	if v == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(v))
+	for k, val := range v {
+		out[k] = copyValue(val)
+	}
+	return out
+}
+
+func copyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return deepCopy(val)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = copyValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -0,0 +1,238 @@
+// Package replay republishes stored events to the queue, optionally
+// preserving their original (created_at, event_id) order.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uigs/ingestion/internal/cursor"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/queue"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// Ordering modes, from strictest to fastest.
+const (
+	// ModeOrdered publishes one event at a time in created_at order and
+	// waits for each confirm before the next.
+	ModeOrdered = "ordered"
+	// ModeKeyed preserves order per user while publishing different users'
+	// events concurrently.
+	ModeKeyed = "keyed"
+	// ModeUnordered publishes concurrently with no ordering guarantee.
+	ModeUnordered = "unordered"
+)
+
+// Config controls republishing.
+type Config struct {
+	// Mode is the default ordering mode.
+	Mode string
+	// Concurrency is the number of publishers in keyed and unordered modes.
+	Concurrency int
+	// BatchSize is the number of events read from storage at a time.
+	BatchSize int
+}
+
+// Replayer republishes ranges of stored events. Every publish waits for the
+// broker's confirm. Delivery is at least once: a resumed run may republish
+// events that were already confirmed.
+type Replayer struct {
+	repo      repository.ReplayRepository
+	publisher queue.ConfirmPublisher
+	cfg       Config
+	logger    *slog.Logger
+}
+
+// New creates a replayer.
+func New(repo repository.ReplayRepository, publisher queue.ConfirmPublisher, cfg Config, logger *slog.Logger) (*Replayer, error) {
+	if !ValidMode(cfg.Mode) {
+		return nil, fmt.Errorf("unsupported replay mode %q", cfg.Mode)
+	}
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	return &Replayer{
+		repo:      repo,
+		publisher: publisher,
+		cfg:       cfg,
+		logger:    logger,
+	}, nil
+}
+
+// ValidMode reports whether mode is a known ordering mode.
+func ValidMode(mode string) bool {
+	return mode == ModeOrdered || mode == ModeKeyed || mode == ModeUnordered
+}
+
+// Run republishes up to limit events matching filter after the given
+// cursor, or from the start of the range when after is empty. It stops at
+// the first batch with a failure; NextCursor then points before the first
+// event that may not have been published.
+func (r *Replayer) Run(ctx context.Context, filter models.ReplayFilter, after, mode string, limit int) (*models.ReplayResponse, error) {
+	if mode == "" {
+		mode = r.cfg.Mode
+	}
+	if !ValidMode(mode) {
+		return nil, fmt.Errorf("unsupported replay mode %q", mode)
+	}
+
+	pos := cursor.New(time.Time{}, uuid.Nil.String())
+	if after != "" {
+		parsed, err := cursor.Parse(after)
+		if err != nil {
+			return nil, err
+		}
+		pos = parsed
+	}
+
+	result := &models.ReplayResponse{Mode: mode}
+	for result.Published+result.Skipped < limit {
+		size := min(r.cfg.BatchSize, limit-result.Published-result.Skipped)
+		events, err := r.repo.ListEventsForReplay(ctx, filter, pos, size)
+		if err != nil {
+			return nil, err
+		}
+		if len(events) == 0 {
+			result.Done = true
+			break
+		}
+
+		var next cursor.Cursor
+		var ok bool
+		if mode == ModeOrdered {
+			next, ok = r.publishOrdered(ctx, pos, events, result)
+		} else {
+			next, ok = r.publishConcurrent(ctx, pos, events, mode == ModeKeyed, result)
+		}
+		pos = next
+		if !ok {
+			break
+		}
+		if len(events) < size {
+			result.Done = true
+			break
+		}
+	}
+
+	if !result.Done {
+		result.NextCursor = pos.String()
+	}
+	r.logger.Info("Event replay finished",
+		"mode", mode,
+		"published", result.Published,
+		"skipped", result.Skipped,
+		"failed", len(result.Failures),
+		"done", result.Done,
+	)
+	return result, nil
+}
+
+// publishOrdered publishes events one at a time and stops at the first
+// failure. It returns the cursor of the last confirmed event.
+func (r *Replayer) publishOrdered(ctx context.Context, pos cursor.Cursor, events []models.IngestionEvent, result *models.ReplayResponse) (cursor.Cursor, bool) {
+	for i := range events {
+		event := &events[i]
+		if err := r.publish(ctx, event); err != nil {
+			result.Failures = append(result.Failures, models.ReplayFailure{EventID: event.EventID, Error: err.Error()})
+			return pos, false
+		}
+		result.Published++
+		pos = cursor.New(event.CreatedAt, event.EventID)
+	}
+	return pos, true
+}
+
+// publishConcurrent publishes a batch with cfg.Concurrency workers. With
+// keyed, each user's events go to the same worker in order, and a user's
+// remaining events are skipped after one of them fails. On any failure the
+// returned cursor is the start of the batch so the whole batch is retried.
+func (r *Replayer) publishConcurrent(ctx context.Context, pos cursor.Cursor, events []models.IngestionEvent, keyed bool, result *models.ReplayResponse) (cursor.Cursor, bool) {
+	shards := make([][]*models.IngestionEvent, r.cfg.Concurrency)
+	for i := range events {
+		shard := i % len(shards)
+		if keyed {
+			h := fnv.New32a()
+			h.Write([]byte(events[i].UserID))
+			shard = int(h.Sum32() % uint32(len(shards)))
+		}
+		shards[shard] = append(shards[shard], &events[i])
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, shard := range shards {
+		if len(shard) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(shard []*models.IngestionEvent) {
+			defer wg.Done()
+			failedUsers := make(map[string]bool)
+			for _, event := range shard {
+				if keyed && failedUsers[event.UserID] {
+					mu.Lock()
+					result.Skipped++
+					mu.Unlock()
+					continue
+				}
+				err := r.publish(ctx, event)
+				mu.Lock()
+				if err != nil {
+					failedUsers[event.UserID] = true
+					result.Failures = append(result.Failures, models.ReplayFailure{EventID: event.EventID, Error: err.Error()})
+				} else {
+					result.Published++
+				}
+				mu.Unlock()
+			}
+		}(shard)
+	}
+	wg.Wait()
+
+	if len(result.Failures) > 0 {
+		return pos, false
+	}
+	last := events[len(events)-1]
+	return cursor.New(last.CreatedAt, last.EventID), true
+}
+
+// ReplayEvent republishes one stored event.
+func (r *Replayer) ReplayEvent(ctx context.Context, event *models.IngestionEvent) error {
+	return r.publish(ctx, event)
+}
+
+// publish republishes an event, marked as a replay.
+func (r *Replayer) publish(ctx context.Context, event *models.IngestionEvent) error {
+	raw := event.RawPayload
+	if len(event.NormalizedPayload) > 0 {
+		raw = event.NormalizedPayload
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return fmt.Errorf("failed to decode stored payload: %w", err)
+	}
+
+	return r.publisher.PublishConfirmed(ctx, &models.QueueMessage{
+		EventID:    event.EventID,
+		UserID:     event.UserID,
+		SourceType: event.SourceType,
+		Payload:    payload,
+		Enrichment: event.Enrichment,
+		Tags:       event.Tags,
+		Metadata:   event.Metadata,
+		DataModel:  event.DataModel,
+		Timestamp:  event.CreatedAt,
+		OccurredAt: event.OccurredAt,
+		Replay:     true,
+	})
+}
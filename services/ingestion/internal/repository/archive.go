@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// ArchiveRepository defines storage operations for cold-storage archival.
+type ArchiveRepository interface {
+	ListEventsBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.IngestionEvent, error)
+	ArchiveEvents(ctx context.Context, record *models.ArchiveRecord) error
+	GetArchive(ctx context.Context, archiveID string) (*models.ArchiveRecord, error)
+	RestoreEvents(ctx context.Context, archiveID string, events []models.IngestionEvent) error
+}
+
+// ListEventsBefore returns the oldest events created before cutoff, with
+// their payloads sealed when a keyring is configured.
+func (r *PostgresRepository) ListEventsBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.IngestionEvent, error) {
+	query := `
+		SELECT ` + eventColumns + `
+		FROM ingestion_events
+		WHERE created_at < $1 AND deleted_at IS NULL
+		ORDER BY created_at ASC, event_id ASC
+		LIMIT $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, cutoff, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aged events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.IngestionEvent
+	for rows.Next() {
+		var event models.IngestionEvent
+		if err := r.scanEvent(rows, &event); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		if err := r.sealForArchive(&event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// ArchiveEvents records the archive in the index and deletes the archived
+// events from the hot table in a single transaction.
+func (r *PostgresRepository) ArchiveEvents(ctx context.Context, record *models.ArchiveRecord) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO event_archives (archive_id, location, checksum, event_count, event_ids,
+				oldest_created_at, newest_created_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`,
+			record.ArchiveID,
+			record.Location,
+			record.Checksum,
+			record.EventCount,
+			record.EventIDs,
+			record.OldestCreatedAt,
+			record.NewestCreatedAt,
+			record.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert archive record: %w", err)
+		}
+
+		tag, err := tx.Exec(ctx, `DELETE FROM ingestion_events WHERE event_id = ANY($1)`, record.EventIDs)
+		if err != nil {
+			return fmt.Errorf("failed to delete archived events: %w", err)
+		}
+		if int(tag.RowsAffected()) != record.EventCount {
+			return fmt.Errorf("archived %d events but deleted %d", record.EventCount, tag.RowsAffected())
+		}
+		return nil
+	})
+}
+
+// GetArchive retrieves an archive index record by its ID, or nil if it
+// does not exist.
+func (r *PostgresRepository) GetArchive(ctx context.Context, archiveID string) (*models.ArchiveRecord, error) {
+	query := `
+		SELECT archive_id, location, checksum, event_count, event_ids,
+			oldest_created_at, newest_created_at, created_at, restored_at
+		FROM event_archives
+		WHERE archive_id = $1
+	`
+
+	var record models.ArchiveRecord
+	err := r.pool.QueryRow(ctx, query, archiveID).Scan(
+		&record.ArchiveID,
+		&record.Location,
+		&record.Checksum,
+		&record.EventCount,
+		&record.EventIDs,
+		&record.OldestCreatedAt,
+		&record.NewestCreatedAt,
+		&record.CreatedAt,
+		&record.RestoredAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archive: %w", err)
+	}
+
+	return &record, nil
+}
+
+// RestoreEvents re-inserts archived events and marks the archive restored.
+// Events already present in the hot table are left untouched.
+func (r *PostgresRepository) RestoreEvents(ctx context.Context, archiveID string, events []models.IngestionEvent) error {
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		for _, event := range events {
+			if err := r.openArchived(&event); err != nil {
+				return err
+			}
+			args, err := r.eventInsertArgs(&event)
+			if err != nil {
+				return fmt.Errorf("failed to restore event %s: %w", event.EventID, err)
+			}
+			if _, err := tx.Exec(ctx, insertEventSQL+` ON CONFLICT (event_id) DO NOTHING`, args...); err != nil {
+				return fmt.Errorf("failed to restore event %s: %w", event.EventID, err)
+			}
+		}
+
+		_, err := tx.Exec(ctx, `UPDATE event_archives SET restored_at = NOW() WHERE archive_id = $1`, archiveID)
+		if err != nil {
+			return fmt.Errorf("failed to mark archive restored: %w", err)
+		}
+		return nil
+	})
+}
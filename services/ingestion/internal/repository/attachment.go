@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// AttachmentRepository defines storage operations for event attachments.
+type AttachmentRepository interface {
+	CreateAttachment(ctx context.Context, attachment *models.Attachment, content []byte) error
+	ListAttachments(ctx context.Context, eventID string) ([]models.Attachment, error)
+	GetAttachment(ctx context.Context, eventID, attachmentID string) (*models.Attachment, []byte, error)
+}
+
+const attachmentColumns = `attachment_id, event_id, user_id, filename, content_type, size_bytes, sha256,
+	scan_status, scan_signature, scan_error, scanned_at, content IS NOT NULL, created_at`
+
+func scanAttachment(row pgx.Row, a *models.Attachment, extra ...any) error {
+	var signature, scanError *string
+	dest := append([]any{
+		&a.AttachmentID,
+		&a.EventID,
+		&a.UserID,
+		&a.Filename,
+		&a.ContentType,
+		&a.SizeBytes,
+		&a.SHA256,
+		&a.ScanStatus,
+		&signature,
+		&scanError,
+		&a.ScannedAt,
+		&a.Stored,
+		&a.CreatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	if signature != nil {
+		a.ScanSignature = *signature
+	}
+	if scanError != nil {
+		a.ScanError = *scanError
+	}
+	return nil
+}
+
+// CreateAttachment records an attachment and its scan result. A nil content
+// records the scan result only. Content is encrypted like event payloads
+// when a keyring is set, bound to the attachment ID.
+func (r *PostgresRepository) CreateAttachment(ctx context.Context, a *models.Attachment, content []byte) error {
+	var keyVersion *int
+	if content != nil && r.keys != nil {
+		version, ciphertext, err := r.keys.Encrypt(content, []byte(a.AttachmentID))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt attachment: %w", err)
+		}
+		content, keyVersion = ciphertext, &version
+	}
+
+	err := r.pool.QueryRow(ctx, `
+		INSERT INTO event_attachments (
+			attachment_id, event_id, user_id, filename, content_type, size_bytes, sha256,
+			content, key_version, scan_status, scan_signature, scan_error, scanned_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), NULLIF($12, ''), $13)
+		RETURNING created_at
+	`, a.AttachmentID, a.EventID, a.UserID, a.Filename, a.ContentType, a.SizeBytes, a.SHA256,
+		content, keyVersion, a.ScanStatus, a.ScanSignature, a.ScanError, a.ScannedAt,
+	).Scan(&a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert attachment: %w", err)
+	}
+	a.Stored = content != nil
+	return nil
+}
+
+// ListAttachments returns an event's attachments without their content,
+// oldest first.
+func (r *PostgresRepository) ListAttachments(ctx context.Context, eventID string) ([]models.Attachment, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+attachmentColumns+`
+		FROM event_attachments
+		WHERE event_id = $1
+		ORDER BY created_at
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []models.Attachment
+	for rows.Next() {
+		var a models.Attachment
+		if err := scanAttachment(rows, &a); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// GetAttachment returns an attachment of an event with its decrypted
+// content, or nil if it does not exist.
+func (r *PostgresRepository) GetAttachment(ctx context.Context, eventID, attachmentID string) (*models.Attachment, []byte, error) {
+	var a models.Attachment
+	var content []byte
+	var keyVersion *int
+	err := scanAttachment(r.pool.QueryRow(ctx, `
+		SELECT `+attachmentColumns+`, content, key_version
+		FROM event_attachments
+		WHERE event_id = $1 AND attachment_id = $2
+	`, eventID, attachmentID), &a, &content, &keyVersion)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	if keyVersion != nil {
+		if r.keys == nil {
+			return nil, nil, fmt.Errorf("attachment %s is encrypted but no keyring is configured", a.AttachmentID)
+		}
+		content, err = r.keys.Decrypt(*keyVersion, content, []byte(a.AttachmentID))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decrypt attachment %s: %w", a.AttachmentID, err)
+		}
+	}
+	return &a, content, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// ErrBatchRolledBack is returned by CreateEvents when an all-or-nothing
+// batch was rolled back because one of its events failed to insert.
+var ErrBatchRolledBack = errors.New("batch rolled back")
+
+// CreateEvents inserts a batch of events in one transaction and returns the
+// insert error of each event, nil for those stored.
+//
+// Without partial, the first failure rolls back the whole batch and
+// ErrBatchRolledBack is returned. With partial, each event is inserted under
+// its own savepoint so a failed event is rolled back alone and the rest are
+// committed.
+func (r *PostgresRepository) CreateEvents(ctx context.Context, events []*models.IngestionEvent, partial bool) ([]error, error) {
+	itemErrs := make([]error, len(events))
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin batch: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	for i, event := range events {
+		if !partial {
+			args, err := r.eventInsertArgs(event)
+			if err != nil {
+				itemErrs[i] = err
+				return itemErrs, ErrBatchRolledBack
+			}
+			if _, err := tx.Exec(ctx, insertEventSQL, args...); err != nil {
+				itemErrs[i] = fmt.Errorf("failed to insert event: %w", err)
+				return itemErrs, ErrBatchRolledBack
+			}
+			if err := r.CreateOIDCClaims(ctx, tx, event); err != nil {
+				itemErrs[i] = err
+				return itemErrs, ErrBatchRolledBack
+			}
+			continue
+		}
+
+		if err := r.insertWithSavepoint(ctx, tx, event); err != nil {
+			var itemErr *itemError
+			if !errors.As(err, &itemErr) {
+				return nil, err
+			}
+			itemErrs[i] = itemErr.err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit batch: %w", err)
+	}
+	return itemErrs, nil
+}
+
+// itemError marks a failure confined to one event's savepoint.
+type itemError struct {
+	err error
+}
+
+func (e *itemError) Error() string {
+	return e.err.Error()
+}
+
+// insertWithSavepoint inserts event inside a savepoint, rolling back to it
+// if the insert fails. Insert failures are returned as *itemError; any other
+// error means the enclosing transaction is unusable.
+func (r *PostgresRepository) insertWithSavepoint(ctx context.Context, tx pgx.Tx, event *models.IngestionEvent) error {
+	args, err := r.eventInsertArgs(event)
+	if err != nil {
+		return &itemError{err: err}
+	}
+
+	sp, err := tx.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	if _, err := sp.Exec(ctx, insertEventSQL, args...); err != nil {
+		if rbErr := sp.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("failed to roll back savepoint: %w", rbErr)
+		}
+		return &itemError{err: fmt.Errorf("failed to insert event: %w", err)}
+	}
+	if err := r.CreateOIDCClaims(ctx, sp, event); err != nil {
+		if rbErr := sp.Rollback(ctx); rbErr != nil {
+			return fmt.Errorf("failed to roll back savepoint: %w", rbErr)
+		}
+		return &itemError{err: err}
+	}
+
+	if err := sp.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil
+}
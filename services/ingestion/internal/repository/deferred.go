@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// DeferredRepository lists events whose credential checks were deferred.
+type DeferredRepository interface {
+	ListDeferredEvents(ctx context.Context, afterCreated time.Time, afterID string, limit int) ([]models.IngestionEvent, error)
+}
+
+// ListDeferredEvents returns up to limit live events with deferred
+// verification, ordered by creation time and ID, that come after the given
+// position. Pass the zero time to start from the oldest.
+func (r *PostgresRepository) ListDeferredEvents(ctx context.Context, afterCreated time.Time, afterID string, limit int) ([]models.IngestionEvent, error) {
+	query := `
+		SELECT ` + eventColumns + `
+		FROM ingestion_events
+		WHERE verification_status = $1 AND deleted_at IS NULL
+			AND (created_at, event_id) > ($2, $3::uuid)
+		ORDER BY created_at, event_id
+		LIMIT $4
+	`
+
+	if afterID == "" {
+		afterID = "00000000-0000-0000-0000-000000000000"
+	}
+	rows, err := r.pool.Query(ctx, query, models.VerificationStatusDeferred, afterCreated, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deferred events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.IngestionEvent
+	for rows.Next() {
+		var event models.IngestionEvent
+		if err := r.scanEvent(rows, &event); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// DeletionRepository defines storage operations for soft deletion.
+type DeletionRepository interface {
+	GetEventByID(ctx context.Context, tenantID, eventID string) (*models.IngestionEvent, error)
+	SoftDeleteEvent(ctx context.Context, eventID string) (bool, error)
+	DeleteEventsBefore(ctx context.Context, tenantID, userID string, cutoff time.Time, hard bool) (int64, error)
+	UndeleteEvent(ctx context.Context, eventID string, deletedAfter time.Time) (bool, error)
+	PurgeDeletedEvents(ctx context.Context, deletedBefore time.Time, limit int) (int64, error)
+}
+
+// SoftDeleteEvent marks an event deleted. It reports false if the event
+// does not exist or is already deleted.
+func (r *PostgresRepository) SoftDeleteEvent(ctx context.Context, eventID string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE ingestion_events SET deleted_at = NOW()
+		WHERE event_id = $1 AND deleted_at IS NULL
+	`, eventID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete event: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// DeleteEventsBefore deletes a user's events of tenantID created before
+// cutoff and returns the number deleted. Soft deletion skips events already
+// deleted; hard deletion removes those too. An empty tenantID matches every
+// tenant.
+func (r *PostgresRepository) DeleteEventsBefore(ctx context.Context, tenantID, userID string, cutoff time.Time, hard bool) (int64, error) {
+	query := `
+		UPDATE ingestion_events SET deleted_at = NOW()
+		WHERE user_id = $1 AND ($2 = '' OR tenant_id = $2) AND created_at < $3
+			AND deleted_at IS NULL
+	`
+	if hard {
+		query = `
+		DELETE FROM ingestion_events
+		WHERE user_id = $1 AND ($2 = '' OR tenant_id = $2) AND created_at < $3
+	`
+	}
+	tag, err := r.pool.Exec(ctx, query, userID, tenantID, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete events: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// UndeleteEvent clears deleted_at on an event deleted after deletedAfter.
+// It reports false if the event is not deleted or was deleted earlier.
+func (r *PostgresRepository) UndeleteEvent(ctx context.Context, eventID string, deletedAfter time.Time) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE ingestion_events SET deleted_at = NULL
+		WHERE event_id = $1 AND deleted_at > $2
+	`, eventID, deletedAfter)
+	if err != nil {
+		return false, fmt.Errorf("failed to restore event: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// PurgeDeletedEvents hard-deletes up to limit events soft-deleted before
+// deletedBefore and returns the number removed.
+func (r *PostgresRepository) PurgeDeletedEvents(ctx context.Context, deletedBefore time.Time, limit int) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `
+		DELETE FROM ingestion_events
+		WHERE event_id IN (
+			SELECT event_id FROM ingestion_events
+			WHERE deleted_at < $1
+			LIMIT $2
+		)
+	`, deletedBefore, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted events: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/uigs/ingestion/internal/keyring"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// EncryptionRepository defines storage operations for rotating the key
+// event payloads are encrypted with.
+type EncryptionRepository interface {
+	ReencryptEvents(ctx context.Context, limit int) (int64, error)
+	CountEventsByKeyVersion(ctx context.Context) (map[int]int64, error)
+}
+
+// sealedPayload is the plaintext of encrypted_payload.
+type sealedPayload struct {
+	Raw        json.RawMessage `json:"raw"`
+	Normalized json.RawMessage `json:"normalized,omitempty"`
+	JWT        string          `json:"jwt,omitempty"`
+}
+
+// storedPayload holds the payload column values for one event.
+type storedPayload struct {
+	raw        []byte
+	normalized []byte
+	jwt        string
+	encrypted  []byte
+	keyVersion *int
+}
+
+// SetKeyring enables at-rest encryption of event payloads. New and rewritten
+// events are sealed with the keyring's current key; events sealed with any
+// other version in the keyring remain readable. Call it before serving.
+func (r *PostgresRepository) SetKeyring(k *keyring.Keyring) {
+	r.keys = k
+}
+
+// seal returns the payload column values for event. Without a keyring the
+// payloads are stored in plaintext. The ciphertext is bound to the event ID,
+// so it cannot be opened as another event's payload.
+func (r *PostgresRepository) seal(event *models.IngestionEvent) (storedPayload, error) {
+	if r.keys == nil {
+		return storedPayload{raw: event.RawPayload, normalized: event.NormalizedPayload, jwt: event.CredentialJWT}, nil
+	}
+
+	plaintext, err := json.Marshal(sealedPayload{Raw: event.RawPayload, Normalized: event.NormalizedPayload, JWT: event.CredentialJWT})
+	if err != nil {
+		return storedPayload{}, fmt.Errorf("failed to marshal payload for encryption: %w", err)
+	}
+	version, ciphertext, err := r.keys.Encrypt(plaintext, []byte(event.EventID))
+	if err != nil {
+		return storedPayload{}, fmt.Errorf("failed to encrypt payload: %w", err)
+	}
+	return storedPayload{encrypted: ciphertext, keyVersion: &version}, nil
+}
+
+// open decrypts a sealed payload into event.RawPayload,
+// event.NormalizedPayload and event.CredentialJWT. Plaintext rows are left
+// as scanned.
+func (r *PostgresRepository) open(event *models.IngestionEvent, encrypted []byte) error {
+	if event.KeyVersion == nil {
+		return nil
+	}
+	if r.keys == nil {
+		return fmt.Errorf("event %s is encrypted but no keyring is configured", event.EventID)
+	}
+
+	plaintext, err := r.keys.Decrypt(*event.KeyVersion, encrypted, []byte(event.EventID))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt event %s: %w", event.EventID, err)
+	}
+	var p sealedPayload
+	if err := json.Unmarshal(plaintext, &p); err != nil {
+		return fmt.Errorf("failed to unmarshal decrypted payload of event %s: %w", event.EventID, err)
+	}
+	event.RawPayload = p.Raw
+	event.NormalizedPayload = p.Normalized
+	event.CredentialJWT = p.JWT
+	return nil
+}
+
+// sealForArchive replaces the payloads of event with their sealed form, so
+// that events exported from the database stay encrypted at rest. Without a
+// keyring the event is left in plaintext.
+func (r *PostgresRepository) sealForArchive(event *models.IngestionEvent) error {
+	if r.keys == nil {
+		return nil
+	}
+	p, err := r.seal(event)
+	if err != nil {
+		return fmt.Errorf("failed to seal event %s: %w", event.EventID, err)
+	}
+	event.RawPayload = nil
+	event.NormalizedPayload = nil
+	event.CredentialJWT = ""
+	event.EncryptedPayload = p.encrypted
+	event.KeyVersion = p.keyVersion
+	return nil
+}
+
+// openArchived opens the payloads of an event sealed by sealForArchive.
+// The key version it was sealed with must still be in the keyring.
+func (r *PostgresRepository) openArchived(event *models.IngestionEvent) error {
+	if err := r.open(event, event.EncryptedPayload); err != nil {
+		return err
+	}
+	event.EncryptedPayload = nil
+	return nil
+}
+
+// ReencryptEvents seals up to limit events whose payloads are stored in
+// plaintext, under a key version other than the current one, or without
+// being bound to their event ID with the current key. It returns the number of events rewritten. Rows locked by a
+// concurrent run are skipped.
+func (r *PostgresRepository) ReencryptEvents(ctx context.Context, limit int) (int64, error) {
+	if r.keys == nil {
+		return 0, fmt.Errorf("no keyring configured")
+	}
+
+	var rewritten int64
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			SELECT event_id, raw_payload, normalized_payload, COALESCE(credential_jwt, ''), encrypted_payload, key_version
+			FROM ingestion_events
+			WHERE key_version IS DISTINCT FROM $1 OR substring(encrypted_payload FROM 1 FOR 4) <> 'env2'::bytea
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		`, r.keys.Current(), limit)
+		if err != nil {
+			return fmt.Errorf("failed to select events to re-encrypt: %w", err)
+		}
+
+		var events []*models.IngestionEvent
+		for rows.Next() {
+			var event models.IngestionEvent
+			var encrypted []byte
+			if err := rows.Scan(&event.EventID, &event.RawPayload, &event.NormalizedPayload, &event.CredentialJWT, &encrypted, &event.KeyVersion); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan event: %w", err)
+			}
+			if err := r.open(&event, encrypted); err != nil {
+				rows.Close()
+				return err
+			}
+			events = append(events, &event)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to read events to re-encrypt: %w", err)
+		}
+
+		for _, event := range events {
+			p, err := r.seal(event)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, `
+				UPDATE ingestion_events
+				SET raw_payload = NULL, normalized_payload = NULL, credential_jwt = NULL, encrypted_payload = $2, key_version = $3
+				WHERE event_id = $1
+			`, event.EventID, p.encrypted, p.keyVersion)
+			if err != nil {
+				return fmt.Errorf("failed to re-encrypt event %s: %w", event.EventID, err)
+			}
+		}
+		rewritten = int64(len(events))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rewritten, nil
+}
+
+// CountEventsByKeyVersion returns the number of events sealed with each key
+// version. Plaintext events are counted under version 0.
+func (r *PostgresRepository) CountEventsByKeyVersion(ctx context.Context) (map[int]int64, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT COALESCE(key_version, 0), COUNT(*)
+		FROM ingestion_events
+		GROUP BY 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events by key version: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int64)
+	for rows.Next() {
+		var version int
+		var n int64
+		if err := rows.Scan(&version, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan key version count: %w", err)
+		}
+		counts[version] = n
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/uigs/ingestion/internal/keyring"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+func TestArchivedEventsStaySealed(t *testing.T) {
+	keys, err := keyring.New(map[int][]byte{1: bytes.Repeat([]byte{1}, keyring.KeySize)}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		keys       *keyring.Keyring
+		wantSealed bool
+	}{
+		{name: "with a keyring", keys: keys, wantSealed: true},
+		{name: "without a keyring", keys: nil, wantSealed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &PostgresRepository{keys: tt.keys}
+			event := models.IngestionEvent{
+				EventID:       "evt-1",
+				RawPayload:    []byte(`{"ssn":"078-05-1120"}`),
+				CredentialJWT: "eyJ.secret.sig",
+			}
+
+			if err := r.sealForArchive(&event); err != nil {
+				t.Fatalf("sealForArchive: %v", err)
+			}
+			archived, err := json.Marshal(&event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			leaked := bytes.Contains(archived, []byte("078-05-1120")) || bytes.Contains(archived, []byte("secret"))
+			if leaked == tt.wantSealed {
+				t.Fatalf("archived event leaked = %v, want %v: %s", leaked, !tt.wantSealed, archived)
+			}
+
+			var restored models.IngestionEvent
+			if err := json.Unmarshal(archived, &restored); err != nil {
+				t.Fatal(err)
+			}
+			if err := r.openArchived(&restored); err != nil {
+				t.Fatalf("openArchived: %v", err)
+			}
+			if string(restored.RawPayload) != `{"ssn":"078-05-1120"}` || restored.CredentialJWT != "eyJ.secret.sig" {
+				t.Errorf("restored payload = %s, %q", restored.RawPayload, restored.CredentialJWT)
+			}
+			if restored.EncryptedPayload != nil {
+				t.Error("restored event still carries its sealed payload")
+			}
+		})
+	}
+}
+
+func TestOpenEvent(t *testing.T) {
+	keys, err := keyring.New(map[int][]byte{1: bytes.Repeat([]byte{1}, keyring.KeySize)}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &PostgresRepository{keys: keys}
+	sealed, err := r.seal(&models.IngestionEvent{EventID: "evt-1", RawPayload: []byte(`{"n":1}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		keys      *keyring.Keyring
+		event     models.IngestionEvent
+		encrypted []byte
+		wantErr   bool
+		wantRaw   string
+	}{
+		{name: "sealed row", keys: keys, event: models.IngestionEvent{EventID: "evt-1", KeyVersion: sealed.keyVersion}, encrypted: sealed.encrypted, wantRaw: `{"n":1}`},
+		{name: "sealed row copied onto another event", keys: keys, event: models.IngestionEvent{EventID: "evt-2", KeyVersion: sealed.keyVersion}, encrypted: sealed.encrypted, wantErr: true},
+		{name: "legacy plaintext row", keys: keys, event: models.IngestionEvent{EventID: "evt-3", RawPayload: []byte(`{"n":3}`)}, wantRaw: `{"n":3}`},
+		{name: "legacy plaintext row without a keyring", event: models.IngestionEvent{EventID: "evt-3", RawPayload: []byte(`{"n":3}`)}, wantRaw: `{"n":3}`},
+		{name: "sealed row without a keyring", event: models.IngestionEvent{EventID: "evt-1", KeyVersion: sealed.keyVersion}, encrypted: sealed.encrypted, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &PostgresRepository{keys: tt.keys}
+			event := tt.event
+			err := r.open(&event, tt.encrypted)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("open() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(event.RawPayload) != tt.wantRaw {
+				t.Errorf("RawPayload = %s, want %s", event.RawPayload, tt.wantRaw)
+			}
+		})
+	}
+}
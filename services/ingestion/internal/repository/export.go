@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// ExportRepository defines storage operations for exporting a user's data.
+type ExportRepository interface {
+	StreamEventsByUser(ctx context.Context, userID string, fn func(models.IngestionEvent) error) error
+}
+
+// StreamEventsByUser calls fn for each of a user's events, soft-deleted ones
+// included, oldest first. Rows are read as fn consumes them, so the events
+// are never all held in memory. An error from fn stops the iteration and is
+// returned.
+func (r *PostgresRepository) StreamEventsByUser(ctx context.Context, userID string, fn func(models.IngestionEvent) error) error {
+	query := `
+		SELECT ` + eventColumns + `
+		FROM ingestion_events
+		WHERE user_id = $1
+		ORDER BY created_at ASC, event_id ASC
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event models.IngestionEvent
+		if err := r.scanEvent(rows, &event); err != nil {
+			return fmt.Errorf("failed to scan event: %w", err)
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read events: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// ErrDuplicateIdempotencyKey is returned by CreateEvent when another event
+// already holds the event's idempotency key within its scope.
+var ErrDuplicateIdempotencyKey = errors.New("duplicate idempotency key")
+
+// idempotencyIndex is the unique index enforcing idempotency keys.
+const idempotencyIndex = "idx_ingestion_events_idempotency"
+
+// IdempotencyRepository defines lookups of events by idempotency key.
+type IdempotencyRepository interface {
+	GetEventByIdempotencyKey(ctx context.Context, scope, key string) (*models.IngestionEvent, error)
+	ReleaseIdempotencyKey(ctx context.Context, scope, key string, before time.Time) error
+}
+
+// GetEventByIdempotencyKey returns the event holding key within scope, or
+// nil if there is none.
+func (r *PostgresRepository) GetEventByIdempotencyKey(ctx context.Context, scope, key string) (*models.IngestionEvent, error) {
+	query := `
+		SELECT ` + eventColumns + `
+		FROM ingestion_events
+		WHERE idempotency_scope = $1 AND idempotency_key = $2
+	`
+
+	var event models.IngestionEvent
+	err := r.scanEvent(r.pool.QueryRow(ctx, query, scope, key), &event)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event by idempotency key: %w", err)
+	}
+
+	return &event, nil
+}
+
+// ReleaseIdempotencyKey frees key within scope for reuse when the event
+// holding it was created before the given time. The event itself is kept.
+func (r *PostgresRepository) ReleaseIdempotencyKey(ctx context.Context, scope, key string, before time.Time) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE ingestion_events SET idempotency_key = NULL, idempotency_scope = NULL
+		WHERE idempotency_scope = $1 AND idempotency_key = $2 AND created_at < $3
+	`, scope, key, before)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// isIdempotencyConflict reports whether err is a unique violation of the
+// idempotency key index.
+func isIdempotencyConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return isUniqueViolation(err) && errors.As(err, &pgErr) && pgErr.ConstraintName == idempotencyIndex
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// OIDCClaimsRepository defines lookups of events by their OIDC claims.
+type OIDCClaimsRepository interface {
+	GetEventsByEmail(ctx context.Context, email string) ([]models.IngestionEvent, error)
+}
+
+// CreateOIDCClaims stores the parsed OIDC claims of event in oidc_claims,
+// within tx so they commit or roll back with the event itself. Events
+// without parsed claims are skipped.
+func (r *PostgresRepository) CreateOIDCClaims(ctx context.Context, tx pgx.Tx, event *models.IngestionEvent) error {
+	claims := event.OIDCClaims
+	if claims == nil {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO oidc_claims (event_id, issuer, subject, audience, email, email_verified, name,
+			given_name, family_name, picture, issued_at, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6, NULLIF($7, ''),
+			NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), $11, $12)
+	`, event.EventID, claims.Issuer, claims.Subject, claims.Audience, claims.Email, claims.EmailVerified, claims.Name,
+		claims.GivenName, claims.FamilyName, claims.Picture, unixTime(claims.IssuedAt), unixTime(claims.Expiration))
+	if err != nil {
+		return fmt.Errorf("failed to insert OIDC claims: %w", err)
+	}
+	return nil
+}
+
+// GetEventsByEmail retrieves the OIDC events whose claims carry email,
+// matched case-insensitively, most recently occurred first. Soft-deleted
+// events are excluded.
+func (r *PostgresRepository) GetEventsByEmail(ctx context.Context, email string) ([]models.IngestionEvent, error) {
+	rows, err := r.pool.Query(ctx, eventsByEmailSQL, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events by email: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.IngestionEvent
+	for rows.Next() {
+		var event models.IngestionEvent
+		if err := r.scanEvent(rows, &event); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// eventsByEmailSQL selects the live events whose OIDC claims carry the email
+// in $1.
+const eventsByEmailSQL = `
+	SELECT ` + eventColumns + `
+	FROM ingestion_events
+	WHERE event_id IN (SELECT event_id FROM oidc_claims WHERE lower(email) = lower($1))
+		AND deleted_at IS NULL
+	ORDER BY occurred_at DESC, event_id DESC
+`
+
+// unixTime converts a NumericDate claim, nil when it is unset.
+func unixTime(seconds int64) *time.Time {
+	if seconds <= 0 {
+		return nil
+	}
+	t := time.Unix(seconds, 0).UTC()
+	return &t
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// fakeTx records the statements run in it, failing each with err.
+type fakeTx struct {
+	pgx.Tx
+	sql  []string
+	args [][]any
+	err  error
+}
+
+func (tx *fakeTx) Exec(_ context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	tx.sql = append(tx.sql, sql)
+	tx.args = append(tx.args, args)
+	return pgconn.NewCommandTag("INSERT 0 1"), tx.err
+}
+
+func TestCreateOIDCClaims(t *testing.T) {
+	iat := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	exp := iat.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		claims   *models.OIDCClaims
+		err      error
+		wantArgs []any
+		wantErr  bool
+	}{
+		{
+			name: "claims could not be parsed",
+		},
+		{
+			name: "every claim",
+			claims: &models.OIDCClaims{
+				Issuer: "https://issuer.example", Subject: "alice", Audience: "uigs",
+				Email: "Alice@Example.com", EmailVerified: true, Name: "Alice Liddell",
+				GivenName: "Alice", FamilyName: "Liddell", Picture: "https://example.com/a.png",
+				IssuedAt: iat.Unix(), Expiration: exp.Unix(),
+			},
+			wantArgs: []any{"event-1", "https://issuer.example", "alice", "uigs", "Alice@Example.com", true, "Alice Liddell",
+				"Alice", "Liddell", "https://example.com/a.png", &iat, &exp},
+		},
+		{
+			name:     "only iss and sub",
+			claims:   &models.OIDCClaims{Issuer: "https://issuer.example", Subject: "alice"},
+			wantArgs: []any{"event-1", "https://issuer.example", "alice", "", "", false, "", "", "", "", (*time.Time)(nil), (*time.Time)(nil)},
+		},
+		{
+			name:    "insert fails",
+			claims:  &models.OIDCClaims{Issuer: "https://issuer.example", Subject: "alice"},
+			err:     errors.New("foreign key violation"),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeTx{err: tt.err}
+			event := &models.IngestionEvent{EventID: "event-1", SourceType: models.SourceTypeOIDC, OIDCClaims: tt.claims}
+
+			err := (&PostgresRepository{}).CreateOIDCClaims(context.Background(), tx, event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateOIDCClaims() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("CreateOIDCClaims() error = %v, want it to wrap %v", err, tt.err)
+				}
+				return
+			}
+			if tt.claims == nil {
+				if len(tx.sql) != 0 {
+					t.Fatalf("ran %d statements for an event without claims, want none", len(tx.sql))
+				}
+				return
+			}
+			if len(tx.sql) != 1 || !strings.Contains(tx.sql[0], "INSERT INTO oidc_claims") {
+				t.Fatalf("statements = %q, want one insert into oidc_claims", tx.sql)
+			}
+			if !reflect.DeepEqual(tx.args[0], tt.wantArgs) {
+				t.Errorf("args = %v, want %v", tx.args[0], tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestEventsByEmailSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "selects full events", want: "SELECT " + eventColumns + "\n\tFROM ingestion_events"},
+		{name: "joins on claims by email, ignoring case", want: "event_id IN (SELECT event_id FROM oidc_claims WHERE lower(email) = lower($1))"},
+		{name: "leaves out deleted events", want: "AND deleted_at IS NULL"},
+		{name: "newest first", want: "ORDER BY occurred_at DESC, event_id DESC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(eventsByEmailSQL, tt.want) {
+				t.Errorf("query = %s, want it to contain %q", eventsByEmailSQL, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// OutboxRepository defines storage operations for relaying events that were
+// stored but never confirmed as published.
+type OutboxRepository interface {
+	CountUndelivered(ctx context.Context, before time.Time) (int, error)
+	RelayUndelivered(ctx context.Context, before time.Time, limit int, publish func(context.Context, *models.IngestionEvent) (string, error)) (int, error)
+}
+
+// undeliveredFilter matches events still waiting to be published.
+const undeliveredFilter = `delivery_status IN ('pending', 'failed') AND created_at < $1 AND deleted_at IS NULL`
+
+// CountUndelivered returns the number of events created before the given
+// time that are pending or failed delivery.
+func (r *PostgresRepository) CountUndelivered(ctx context.Context, before time.Time) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM ingestion_events WHERE `+undeliveredFilter, before).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count undelivered events: %w", err)
+	}
+	return count, nil
+}
+
+// RelayUndelivered locks up to limit undelivered events created before the
+// given time, oldest first, skipping events another relay holds. It calls
+// publish for each until one fails and records the delivery status publish
+// returned for the others, all in one transaction, so the locks are held
+// for as long as the batch takes. It returns the number handled and the
+// publish error, if any.
+func (r *PostgresRepository) RelayUndelivered(ctx context.Context, before time.Time, limit int, publish func(context.Context, *models.IngestionEvent) (string, error)) (int, error) {
+	query := `
+		SELECT ` + eventColumns + `
+		FROM ingestion_events
+		WHERE ` + undeliveredFilter + `
+		ORDER BY created_at ASC, event_id ASC
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`
+
+	handled := make(map[string][]string)
+	var n int
+	var publishErr error
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, before, limit)
+		if err != nil {
+			return fmt.Errorf("failed to query undelivered events: %w", err)
+		}
+		var events []models.IngestionEvent
+		for rows.Next() {
+			var event models.IngestionEvent
+			if err := r.scanEvent(rows, &event); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan event: %w", err)
+			}
+			events = append(events, event)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to query undelivered events: %w", err)
+		}
+
+		for i := range events {
+			var status string
+			if status, publishErr = publish(ctx, &events[i]); publishErr != nil {
+				break
+			}
+			handled[status] = append(handled[status], events[i].EventID)
+			n++
+		}
+
+		for status, eventIDs := range handled {
+			_, err = tx.Exec(ctx, `UPDATE ingestion_events SET delivery_status = $2 WHERE event_id = ANY($1)`,
+				eventIDs, status)
+			if err != nil {
+				return fmt.Errorf("failed to update delivery status: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, publishErr
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Pool defaults, used for the zero value of the matching PoolOptions field.
+const (
+	defaultMaxConns        = 10
+	defaultMaxConnLifetime = time.Hour
+	defaultMaxConnIdleTime = 30 * time.Minute
+)
+
+// PoolOptions sizes the connection pool and tunes how it detects and
+// recycles bad connections, how long statements may run and how writes
+// recover from transient failures.
+type PoolOptions struct {
+	// MaxConns caps the pool's connections and MinConns is how many it
+	// keeps open while idle.
+	MaxConns int32
+	MinConns int32
+
+	// MaxConnLifetime is how long a connection is used before it is
+	// replaced, and MaxConnIdleTime how long an idle one is kept.
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+
+	// HealthCheckPeriod is how often the pool sweeps idle connections.
+	HealthCheckPeriod time.Duration
+
+	// IdleCheckThreshold is how long a connection may sit idle before it is
+	// pinged on acquire. Connections failing the ping are discarded so that a
+	// handler never receives a connection left stale by a failover.
+	// Zero disables the check.
+	IdleCheckThreshold time.Duration
+
+	// IdleCheckTimeout bounds the ping issued by the idle check.
+	IdleCheckTimeout time.Duration
+
+	// QueryTimeout bounds each statement; a statement running longer fails
+	// with ErrQueryTimeout. Zero leaves statements bounded only by their
+	// context.
+	QueryTimeout time.Duration
+
+	// RetryMaxAttempts is how many times a write failing with a transient
+	// error, such as a lost connection or a serialization failure, is
+	// tried. One or less disables retries.
+	RetryMaxAttempts int
+
+	// RetryBaseDelay is the backoff before the first retry. It doubles
+	// with each retry, up to RetryMaxDelay.
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+}
+
+// idleTracker remembers when each pooled connection was last released.
+type idleTracker struct {
+	mu       sync.Mutex
+	released map[*pgx.Conn]time.Time
+}
+
+func newIdleTracker() *idleTracker {
+	return &idleTracker{released: make(map[*pgx.Conn]time.Time)}
+}
+
+func (t *idleTracker) markReleased(conn *pgx.Conn) {
+	t.mu.Lock()
+	t.released[conn] = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *idleTracker) idleFor(conn *pgx.Conn) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	releasedAt, ok := t.released[conn]
+	if !ok {
+		return 0
+	}
+	return time.Since(releasedAt)
+}
+
+func (t *idleTracker) forget(conn *pgx.Conn) {
+	t.mu.Lock()
+	delete(t.released, conn)
+	t.mu.Unlock()
+}
+
+// applyPoolOptions sizes the pool, caches prepared statements and installs
+// the health check hooks on a pool config. It rejects negative settings
+// and a MinConns above MaxConns.
+func applyPoolOptions(config *pgxpool.Config, opts PoolOptions) error {
+	if opts.MaxConns < 0 || opts.MinConns < 0 || opts.MaxConnLifetime < 0 || opts.MaxConnIdleTime < 0 {
+		return fmt.Errorf("pool settings must not be negative")
+	}
+	config.MaxConns = defaultMaxConns
+	if opts.MaxConns > 0 {
+		config.MaxConns = opts.MaxConns
+	}
+	if opts.MinConns > config.MaxConns {
+		return fmt.Errorf("pool MinConns (%d) exceeds MaxConns (%d)", opts.MinConns, config.MaxConns)
+	}
+	config.MinConns = opts.MinConns
+	config.MaxConnLifetime = defaultMaxConnLifetime
+	if opts.MaxConnLifetime > 0 {
+		config.MaxConnLifetime = opts.MaxConnLifetime
+	}
+	config.MaxConnIdleTime = defaultMaxConnIdleTime
+	if opts.MaxConnIdleTime > 0 {
+		config.MaxConnIdleTime = opts.MaxConnIdleTime
+	}
+
+	// Each connection prepares a statement on first use and reuses it
+	config.ConnConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+
+	if opts.HealthCheckPeriod > 0 {
+		config.HealthCheckPeriod = opts.HealthCheckPeriod
+	}
+	if opts.IdleCheckThreshold <= 0 {
+		return nil
+	}
+
+	tracker := newIdleTracker()
+
+	config.AfterRelease = func(conn *pgx.Conn) bool {
+		tracker.markReleased(conn)
+		return true
+	}
+	config.BeforeClose = func(conn *pgx.Conn) {
+		tracker.forget(conn)
+	}
+	config.BeforeAcquire = func(ctx context.Context, conn *pgx.Conn) bool {
+		if tracker.idleFor(conn) < opts.IdleCheckThreshold {
+			return true
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, opts.IdleCheckTimeout)
+		defer cancel()
+
+		// Returning false destroys the connection and the pool tries another
+		return conn.Ping(pingCtx) == nil
+	}
+	return nil
+}
+
+// PoolConfig returns the effective settings of the repository's pool.
+func (r *PostgresRepository) PoolConfig() *pgxpool.Config {
+	return r.pool.Config()
+}
+
+// PoolStats is a point-in-time snapshot of a connection pool.
+type PoolStats struct {
+	MaxConns          int32   `json:"max_conns"`
+	TotalConns        int32   `json:"total_conns"`
+	AcquiredConns     int32   `json:"acquired_conns"`
+	IdleConns         int32   `json:"idle_conns"`
+	AcquireCount      int64   `json:"acquire_count"`
+	EmptyAcquireCount int64   `json:"empty_acquire_count"`
+	AvgAcquireMillis  float64 `json:"avg_acquire_ms"`
+}
+
+// PoolStats reports the repository's connection pool usage.
+func (r *PostgresRepository) PoolStats() PoolStats {
+	stat := r.pool.Stat()
+	avg := 0.0
+	if n := stat.AcquireCount(); n > 0 {
+		avg = float64(stat.AcquireDuration()) / float64(n) / float64(time.Millisecond)
+	}
+	return PoolStats{
+		MaxConns:          stat.MaxConns(),
+		TotalConns:        stat.TotalConns(),
+		AcquiredConns:     stat.AcquiredConns(),
+		IdleConns:         stat.IdleConns(),
+		AcquireCount:      stat.AcquireCount(),
+		EmptyAcquireCount: stat.EmptyAcquireCount(),
+		AvgAcquireMillis:  avg,
+	}
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func TestApplyPoolOptions(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            PoolOptions
+		wantErr         bool
+		wantMaxConns    int32
+		wantMinConns    int32
+		wantLifetime    time.Duration
+		wantIdleTime    time.Duration
+		wantIdleChecked bool
+	}{
+		{
+			name:         "defaults",
+			wantMaxConns: defaultMaxConns,
+			wantLifetime: defaultMaxConnLifetime,
+			wantIdleTime: defaultMaxConnIdleTime,
+		},
+		{
+			name:         "configured",
+			opts:         PoolOptions{MaxConns: 25, MinConns: 5, MaxConnLifetime: 15 * time.Minute, MaxConnIdleTime: 2 * time.Minute},
+			wantMaxConns: 25,
+			wantMinConns: 5,
+			wantLifetime: 15 * time.Minute,
+			wantIdleTime: 2 * time.Minute,
+		},
+		{
+			name:            "idle check",
+			opts:            PoolOptions{MinConns: 2, IdleCheckThreshold: time.Minute, IdleCheckTimeout: time.Second},
+			wantMaxConns:    defaultMaxConns,
+			wantMinConns:    2,
+			wantLifetime:    defaultMaxConnLifetime,
+			wantIdleTime:    defaultMaxConnIdleTime,
+			wantIdleChecked: true,
+		},
+		{name: "min above max", opts: PoolOptions{MaxConns: 4, MinConns: 5}, wantErr: true},
+		{name: "min above the default max", opts: PoolOptions{MinConns: defaultMaxConns + 1}, wantErr: true},
+		{name: "negative max", opts: PoolOptions{MaxConns: -1}, wantErr: true},
+		{name: "negative lifetime", opts: PoolOptions{MaxConnLifetime: -time.Second}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := pgxpool.ParseConfig("postgres://uigs@localhost:5432/uigs")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = applyPoolOptions(config, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyPoolOptions() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if config.MaxConns != tt.wantMaxConns || config.MinConns != tt.wantMinConns {
+				t.Errorf("conns = %d..%d, want %d..%d", config.MinConns, config.MaxConns, tt.wantMinConns, tt.wantMaxConns)
+			}
+			if config.MaxConnLifetime != tt.wantLifetime || config.MaxConnIdleTime != tt.wantIdleTime {
+				t.Errorf("lifetime = %v, idle time = %v; want %v, %v", config.MaxConnLifetime, config.MaxConnIdleTime, tt.wantLifetime, tt.wantIdleTime)
+			}
+			if mode := config.ConnConfig.DefaultQueryExecMode; mode != pgx.QueryExecModeCacheStatement {
+				t.Errorf("query exec mode = %v, want %v", mode, pgx.QueryExecModeCacheStatement)
+			}
+			if got := config.BeforeAcquire != nil; got != tt.wantIdleChecked {
+				t.Errorf("idle check installed = %v, want %v", got, tt.wantIdleChecked)
+			}
+		})
+	}
+}
@@ -3,38 +3,61 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/uigs/ingestion/internal/cursor"
+	"github.com/uigs/ingestion/internal/keyring"
 	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrIntegrity is returned when a stored payload no longer matches its
+// checksum.
+var ErrIntegrity = errors.New("payload does not match its checksum")
+
+// ErrDuplicateEvent is returned by CreateEvent when an event with the same
+// event ID is already stored.
+var ErrDuplicateEvent = errors.New("duplicate event ID")
+
 // EventRepository defines the interface for event storage operations.
 type EventRepository interface {
 	CreateEvent(ctx context.Context, event *models.IngestionEvent) error
-	GetEventByID(ctx context.Context, eventID string) (*models.IngestionEvent, error)
-	GetEventsByUser(ctx context.Context, userID string, limit int) ([]models.IngestionEvent, error)
+	CreateEvents(ctx context.Context, events []*models.IngestionEvent, partial bool) ([]error, error)
+	GetEventByID(ctx context.Context, tenantID, eventID string) (*models.IngestionEvent, error)
+	GetEventsFiltered(ctx context.Context, userID string, filter EventFilter) ([]models.IngestionEvent, error)
+	GetEventsByUserPaged(ctx context.Context, userID string, limit int, after string) ([]models.IngestionEvent, string, error)
+	GetEventStatuses(ctx context.Context, userID string, eventIDs []string) (map[string]models.EventStatus, error)
+	UpdateDeliveryStatus(ctx context.Context, eventID, status string) error
+	UpdateVerificationStatus(ctx context.Context, eventID, status string, verifiedAt time.Time) error
+	Healthy(ctx context.Context) error
+	ServerVersion(ctx context.Context) (string, error)
 	Close()
 }
 
 // PostgresRepository implements EventRepository using PostgreSQL.
 type PostgresRepository struct {
-	pool *pgxpool.Pool
+	pool  *timedPool
+	keys  *keyring.Keyring
+	retry retryPolicy
 }
 
 // NewPostgresRepository creates a new PostgreSQL repository.
-func NewPostgresRepository(ctx context.Context, connString string) (*PostgresRepository, error) {
+func NewPostgresRepository(ctx context.Context, connString string, opts PoolOptions) (*PostgresRepository, error) {
 	config, err := pgxpool.ParseConfig(connString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse connection string: %w", err)
 	}
 
-	// Configure connection pool
-	config.MaxConns = 10
-	config.MinConns = 2
-	config.MaxConnLifetime = time.Hour
-	config.MaxConnIdleTime = 30 * time.Minute
+	if err := applyPoolOptions(config, opts); err != nil {
+		return nil, err
+	}
 
 	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
@@ -46,66 +69,157 @@ func NewPostgresRepository(ctx context.Context, connString string) (*PostgresRep
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &PostgresRepository{pool: pool}, nil
+	return &PostgresRepository{
+		pool: &timedPool{Pool: pool, timeout: opts.QueryTimeout},
+		retry: retryPolicy{
+			maxAttempts: opts.RetryMaxAttempts,
+			baseDelay:   opts.RetryBaseDelay,
+			maxDelay:    opts.RetryMaxDelay,
+		},
+	}, nil
 }
 
-// CreateEvent inserts a new ingestion event into the database.
+// CreateEvent inserts a new ingestion event into the database, retrying
+// transient failures.
 func (r *PostgresRepository) CreateEvent(ctx context.Context, event *models.IngestionEvent) error {
-	query := `
-		INSERT INTO ingestion_events (event_id, user_id, source_type, raw_payload, checksum, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`
+	args, err := r.eventInsertArgs(event)
+	if err != nil {
+		return err
+	}
 
-	_, err := r.pool.Exec(ctx, query,
-		event.EventID,
-		event.UserID,
-		event.SourceType,
-		event.RawPayload,
-		event.Checksum,
-		event.CreatedAt,
+	ctx, span := tracing.Start(ctx, "INSERT ingestion_events", trace.SpanKindClient)
+	span.SetAttributes(
+		attribute.String("db.system", "postgresql"),
+		attribute.String("event_id", event.EventID),
 	)
+	defer span.End()
+
+	err = r.withRetry(ctx, func(attempt int) error {
+		err := r.insertEvent(ctx, event, args)
+		if attempt > 1 && isEventConflict(err) {
+			return nil
+		}
+		return err
+	})
 	if err != nil {
+		if isIdempotencyConflict(err) {
+			return ErrDuplicateIdempotencyKey
+		}
+		if isEventConflict(err) {
+			return fmt.Errorf("%w: %s", ErrDuplicateEvent, event.EventID)
+		}
+		tracing.RecordError(span, err)
 		return fmt.Errorf("failed to insert event: %w", err)
 	}
 
 	return nil
 }
 
-// GetEventByID retrieves an event by its ID.
-func (r *PostgresRepository) GetEventByID(ctx context.Context, eventID string) (*models.IngestionEvent, error) {
+// insertEvent inserts an event, together with its OIDC claims in one
+// transaction when it has them.
+func (r *PostgresRepository) insertEvent(ctx context.Context, event *models.IngestionEvent, args []any) error {
+	if event.OIDCClaims == nil {
+		_, err := r.pool.Exec(ctx, insertEventSQL, args...)
+		return err
+	}
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, insertEventSQL, args...); err != nil {
+			return err
+		}
+		return r.CreateOIDCClaims(ctx, tx, event)
+	})
+}
+
+// GetEventByID retrieves an event of tenantID by its ID, including a
+// soft-deleted one. An empty tenantID matches every tenant; another
+// tenant's event is reported as pgx.ErrNoRows, like a missing one. It
+// returns ErrIntegrity when the payload no longer matches its checksum.
+func (r *PostgresRepository) GetEventByID(ctx context.Context, tenantID, eventID string) (*models.IngestionEvent, error) {
 	query := `
-		SELECT event_id, user_id, source_type, raw_payload, checksum, created_at
+		SELECT ` + eventColumns + `
 		FROM ingestion_events
-		WHERE event_id = $1
+		WHERE event_id = $1 AND ($2 = '' OR tenant_id = $2)
 	`
 
 	var event models.IngestionEvent
-	err := r.pool.QueryRow(ctx, query, eventID).Scan(
-		&event.EventID,
-		&event.UserID,
-		&event.SourceType,
-		&event.RawPayload,
-		&event.Checksum,
-		&event.CreatedAt,
-	)
-	if err != nil {
+	if err := r.scanEvent(r.pool.QueryRow(ctx, query, eventID, tenantID), &event); err != nil {
 		return nil, fmt.Errorf("failed to get event: %w", err)
 	}
+	if !event.VerifyIntegrity() {
+		return nil, fmt.Errorf("%w: event %s", ErrIntegrity, eventID)
+	}
 
 	return &event, nil
 }
 
-// GetEventsByUser retrieves events for a specific user.
-func (r *PostgresRepository) GetEventsByUser(ctx context.Context, userID string, limit int) ([]models.IngestionEvent, error) {
-	query := `
-		SELECT event_id, user_id, source_type, raw_payload, checksum, created_at
-		FROM ingestion_events
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2
-	`
+// EventFilter selects a page of a user's events. Zero fields match every
+// event.
+type EventFilter struct {
+	// TenantID restricts the page to one tenant's events.
+	TenantID   string
+	SourceType models.SourceType
+	// From and To bound occurred_at; From is inclusive, To exclusive.
+	From *time.Time
+	To   *time.Time
+	// Limit is the page size and After the cursor the page starts after.
+	Limit int
+	After *cursor.Cursor
+	// IncludeDeleted also selects soft-deleted events.
+	IncludeDeleted bool
+	// OmitPayload leaves RawPayload and CredentialJWT empty without reading
+	// them from the table.
+	OmitPayload bool
+}
+
+// GetEventsFiltered retrieves a page of a user's events matching filter,
+// most recently stored first, in (created_at, event_id) descending order.
+// A page starts strictly after the cursor, or at the newest matching event
+// when After is nil. Keyset pagination keeps pages stable while events are
+// inserted; created_at is set by the database, so a backdated occurred_at
+// cannot place a new event behind a cursor already handed out.
+func (r *PostgresRepository) GetEventsFiltered(ctx context.Context, userID string, filter EventFilter) ([]models.IngestionEvent, error) {
+	return r.queryEvents(ctx, userID, filter)
+}
 
-	rows, err := r.pool.Query(ctx, query, userID, limit)
+// GetEventsByUserPaged retrieves up to limit of a user's events in the order
+// of GetEventsFiltered, starting after the cursor returned with the
+// previous page, or at the newest event when after is empty. It returns the
+// page and the cursor of the next one, which is empty on the last page. A
+// malformed cursor returns an error wrapping cursor.ErrInvalid.
+func (r *PostgresRepository) GetEventsByUserPaged(ctx context.Context, userID string, limit int, after string) ([]models.IngestionEvent, string, error) {
+	filter := EventFilter{Limit: limit + 1}
+	if after != "" {
+		pos, err := cursor.Parse(after)
+		if err != nil {
+			return nil, "", err
+		}
+		filter.After = &pos
+	}
+	events, err := r.queryEvents(ctx, userID, filter)
+	if err != nil {
+		return nil, "", err
+	}
+	events, next := TrimPage(events, limit)
+	return events, next, nil
+}
+
+// TrimPage cuts events, read with a limit one higher than limit, down to
+// limit and returns the cursor of the next page: the (created_at, event_id)
+// of the last event kept, or "" if the extra row was not there.
+func TrimPage(events []models.IngestionEvent, limit int) ([]models.IngestionEvent, string) {
+	if len(events) <= limit {
+		return events, ""
+	}
+	events = events[:limit]
+	last := events[limit-1]
+	return events, cursor.New(last.CreatedAt, last.EventID).String()
+}
+
+// queryEvents retrieves a page of events matching filter, in the order of
+// GetEventsFiltered. An empty userID matches every user.
+func (r *PostgresRepository) queryEvents(ctx context.Context, userID string, filter EventFilter) ([]models.IngestionEvent, error) {
+	query, args := eventsQuery(userID, filter)
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query events: %w", err)
 	}
@@ -114,20 +228,257 @@ func (r *PostgresRepository) GetEventsByUser(ctx context.Context, userID string,
 	var events []models.IngestionEvent
 	for rows.Next() {
 		var event models.IngestionEvent
-		if err := rows.Scan(
-			&event.EventID,
-			&event.UserID,
-			&event.SourceType,
-			&event.RawPayload,
-			&event.Checksum,
-			&event.CreatedAt,
-		); err != nil {
+		if err := r.scanEvent(rows, &event); err != nil {
 			return nil, fmt.Errorf("failed to scan event: %w", err)
 		}
+		if filter.OmitPayload {
+			event.RawPayload, event.CredentialJWT = nil, ""
+		}
 		events = append(events, event)
 	}
 
-	return events, nil
+	return events, rows.Err()
+}
+
+// eventsQuery builds the query of queryEvents and its arguments.
+func eventsQuery(userID string, filter EventFilter) (string, []any) {
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if userID != "" {
+		where = append(where, "user_id = "+arg(userID))
+	}
+	if filter.TenantID != "" {
+		where = append(where, "tenant_id = "+arg(filter.TenantID))
+	}
+	if !filter.IncludeDeleted {
+		where = append(where, "deleted_at IS NULL")
+	}
+	if filter.SourceType != "" {
+		where = append(where, "source_type = "+arg(string(filter.SourceType)))
+	}
+	if filter.From != nil {
+		where = append(where, "occurred_at >= "+arg(*filter.From))
+	}
+	if filter.To != nil {
+		where = append(where, "occurred_at < "+arg(*filter.To))
+	}
+	if filter.After != nil {
+		where = append(where, fmt.Sprintf("(created_at, event_id) < (%s, %s::uuid)", arg(filter.After.CreatedAt), arg(filter.After.EventID)))
+	}
+
+	columns := eventColumns
+	if filter.OmitPayload {
+		columns = eventColumnsWithoutPayload
+	}
+	query := `
+		SELECT ` + columns + `
+		FROM ingestion_events`
+	if len(where) > 0 {
+		query += `
+		WHERE ` + strings.Join(where, " AND ")
+	}
+	query += `
+		ORDER BY created_at DESC, event_id DESC
+		LIMIT ` + arg(filter.Limit)
+	return query, args
+}
+
+// GetEventStatuses returns the status columns of the given events owned by
+// userID, keyed by event ID. Unknown or foreign IDs are omitted.
+func (r *PostgresRepository) GetEventStatuses(ctx context.Context, userID string, eventIDs []string) (map[string]models.EventStatus, error) {
+	query := `
+		SELECT event_id, verification_status, delivery_status, verified_at
+		FROM ingestion_events
+		WHERE user_id = $1 AND event_id = ANY($2) AND deleted_at IS NULL
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID, eventIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event statuses: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := make(map[string]models.EventStatus, len(eventIDs))
+	for rows.Next() {
+		var id string
+		var status models.EventStatus
+		if err := rows.Scan(&id, &status.VerificationStatus, &status.DeliveryStatus, &status.VerifiedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan event status: %w", err)
+		}
+		statuses[id] = status
+	}
+
+	return statuses, rows.Err()
+}
+
+// UpdateDeliveryStatus records the outcome of publishing an event.
+func (r *PostgresRepository) UpdateDeliveryStatus(ctx context.Context, eventID, status string) error {
+	err := r.withRetry(ctx, func(int) error {
+		_, err := r.pool.Exec(ctx, `UPDATE ingestion_events SET delivery_status = $2 WHERE event_id = $1`, eventID, status)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update delivery status: %w", err)
+	}
+	return nil
+}
+
+// insertEventSQL inserts one event with the arguments from eventInsertArgs.
+// expires_at is denormalized from the extracted dates for expiry queries,
+// and an event without a tenant belongs to the default tenant.
+const insertEventSQL = `
+	INSERT INTO ingestion_events (event_id, user_id, source_type, raw_payload, checksum, enrichment, created_at,
+		verification_status, verified_at, delivery_status, extracted_dates, expires_at, tags, metadata,
+		data_model, normalized_payload, encrypted_payload, key_version, parent_event_id,
+		idempotency_key, idempotency_scope, occurred_at, tenant_id, credential_jwt)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NULLIF($15, ''), $16, $17, $18, $19,
+		NULLIF($20, ''), NULLIF($21, ''), $22, COALESCE(NULLIF($23, ''), 'default'),
+		NULLIF($24, ''))
+`
+
+// eventInsertArgs returns the insertEventSQL arguments for event, sealing
+// its payloads when a keyring is configured.
+func (r *PostgresRepository) eventInsertArgs(event *models.IngestionEvent) ([]any, error) {
+	var expiresAt *time.Time
+	if event.Dates != nil && event.Dates.ExpiresAt != nil {
+		expiresAt = &event.Dates.ExpiresAt.UTC
+	}
+	p, err := r.seal(event)
+	if err != nil {
+		return nil, err
+	}
+	return []any{
+		event.EventID,
+		event.UserID,
+		event.SourceType,
+		p.raw,
+		event.Checksum,
+		event.Enrichment,
+		event.CreatedAt,
+		event.VerificationStatus,
+		event.VerifiedAt,
+		event.DeliveryStatus,
+		event.Dates,
+		expiresAt,
+		event.Tags,
+		event.Metadata,
+		event.DataModel,
+		p.normalized,
+		p.encrypted,
+		p.keyVersion,
+		event.ParentEventID,
+		event.IdempotencyKey,
+		event.IdempotencyScope,
+		occurredAt(event),
+		event.TenantID,
+		p.jwt,
+	}, nil
+}
+
+// occurredAt returns the event's logical time, which defaults to its
+// creation time.
+func occurredAt(event *models.IngestionEvent) time.Time {
+	if event.OccurredAt.IsZero() {
+		return event.CreatedAt
+	}
+	return event.OccurredAt
+}
+
+// UpdateVerificationStatus records the outcome of re-verifying an event.
+func (r *PostgresRepository) UpdateVerificationStatus(ctx context.Context, eventID, status string, verifiedAt time.Time) error {
+	err := r.withRetry(ctx, func(int) error {
+		_, err := r.pool.Exec(ctx, `
+			UPDATE ingestion_events SET verification_status = $2, verified_at = $3 WHERE event_id = $1
+		`, eventID, status, verifiedAt)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update verification status: %w", err)
+	}
+	return nil
+}
+
+// eventColumns lists the ingestion_events columns read by scanEvent.
+const eventColumns = `event_id, user_id, source_type, raw_payload, checksum, enrichment, created_at,
+	verification_status, verified_at, delivery_status, extracted_dates, tags, metadata,
+	data_model, normalized_payload, deleted_at, encrypted_payload, key_version, parent_event_id,
+	idempotency_key, idempotency_scope, COALESCE(occurred_at, created_at), tenant_id,
+	COALESCE(credential_jwt, '')`
+
+// eventColumnsWithoutPayload is eventColumns with the plaintext payload
+// columns selected as empty. Sealed payloads are still read, since the
+// normalized payload is sealed together with the raw one.
+var eventColumnsWithoutPayload = strings.NewReplacer(
+	"raw_payload", "NULL::bytea",
+	"COALESCE(credential_jwt, '')", "''",
+).Replace(eventColumns)
+
+// scanEvent scans a row selected with eventColumns into event, opening
+// sealed payloads.
+func (r *PostgresRepository) scanEvent(row pgx.Row, event *models.IngestionEvent) error {
+	var dataModel, idempotencyKey, idempotencyScope *string
+	var encrypted []byte
+	err := row.Scan(
+		&event.EventID,
+		&event.UserID,
+		&event.SourceType,
+		&event.RawPayload,
+		&event.Checksum,
+		&event.Enrichment,
+		&event.CreatedAt,
+		&event.VerificationStatus,
+		&event.VerifiedAt,
+		&event.DeliveryStatus,
+		&event.Dates,
+		&event.Tags,
+		&event.Metadata,
+		&dataModel,
+		&event.NormalizedPayload,
+		&event.DeletedAt,
+		&encrypted,
+		&event.KeyVersion,
+		&event.ParentEventID,
+		&idempotencyKey,
+		&idempotencyScope,
+		&event.OccurredAt,
+		&event.TenantID,
+		&event.CredentialJWT,
+	)
+	if err != nil {
+		return err
+	}
+	if dataModel != nil {
+		event.DataModel = *dataModel
+	}
+	if idempotencyKey != nil {
+		event.IdempotencyKey = *idempotencyKey
+	}
+	if idempotencyScope != nil {
+		event.IdempotencyScope = *idempotencyScope
+	}
+	return r.open(event, encrypted)
+}
+
+// Healthy pings the database.
+func (r *PostgresRepository) Healthy(ctx context.Context) error {
+	if err := r.pool.Ping(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+	return nil
+}
+
+// ServerVersion returns the version of the PostgreSQL server.
+func (r *PostgresRepository) ServerVersion(ctx context.Context) (string, error) {
+	var version string
+	if err := r.pool.QueryRow(ctx, `SHOW server_version`).Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to get server version: %w", err)
+	}
+	return version, nil
 }
 
 // Close closes the database connection pool.
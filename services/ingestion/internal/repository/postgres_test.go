@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uigs/ingestion/internal/cursor"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+func TestEventsQuery(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	after := cursor.New(from, "5f0c7c2e-7a4f-4d38-9a57-1b1f3c0e2b11")
+
+	tests := []struct {
+		name      string
+		filter    EventFilter
+		wantWhere string
+		wantArgs  []any
+	}{
+		{
+			name:      "no filter",
+			filter:    EventFilter{Limit: 10},
+			wantWhere: "WHERE user_id = $1 AND deleted_at IS NULL\n",
+			wantArgs:  []any{"alice", 10},
+		},
+		{
+			name:      "source type",
+			filter:    EventFilter{SourceType: models.SourceTypeVC, Limit: 10},
+			wantWhere: "WHERE user_id = $1 AND deleted_at IS NULL AND source_type = $2\n",
+			wantArgs:  []any{"alice", "VC", 10},
+		},
+		{
+			name:      "from",
+			filter:    EventFilter{From: &from, Limit: 10},
+			wantWhere: "WHERE user_id = $1 AND deleted_at IS NULL AND occurred_at >= $2\n",
+			wantArgs:  []any{"alice", from, 10},
+		},
+		{
+			name:      "to",
+			filter:    EventFilter{To: &to, Limit: 10},
+			wantWhere: "WHERE user_id = $1 AND deleted_at IS NULL AND occurred_at < $2\n",
+			wantArgs:  []any{"alice", to, 10},
+		},
+		{
+			name:      "every filter",
+			filter:    EventFilter{TenantID: "acme", SourceType: models.SourceTypeOIDC, From: &from, To: &to, Limit: 10},
+			wantWhere: "WHERE user_id = $1 AND tenant_id = $2 AND deleted_at IS NULL AND source_type = $3 AND occurred_at >= $4 AND occurred_at < $5\n",
+			wantArgs:  []any{"alice", "acme", "OIDC", from, to, 10},
+		},
+		{
+			name:      "after a cursor",
+			filter:    EventFilter{After: &after, Limit: 10},
+			wantWhere: "WHERE user_id = $1 AND deleted_at IS NULL AND (created_at, event_id) < ($2, $3::uuid)\n",
+			wantArgs:  []any{"alice", after.CreatedAt, after.EventID, 10},
+		},
+		{
+			name:      "including deleted",
+			filter:    EventFilter{IncludeDeleted: true, Limit: 10},
+			wantWhere: "WHERE user_id = $1\n",
+			wantArgs:  []any{"alice", 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := eventsQuery("alice", tt.filter)
+			if !strings.Contains(query, tt.wantWhere) {
+				t.Errorf("query = %s, want %q", query, tt.wantWhere)
+			}
+			if !strings.Contains(query, "ORDER BY created_at DESC, event_id DESC") {
+				t.Errorf("query = %s, want it ordered by (created_at, event_id) descending", query)
+			}
+			if want := fmt.Sprintf("LIMIT $%d", len(tt.wantArgs)); !strings.HasSuffix(query, want) {
+				t.Errorf("query = %s, want it to end in %s", query, want)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestEventInsertArgsTenant(t *testing.T) {
+	tests := []struct {
+		name     string
+		tenantID string
+	}{
+		{name: "tenant", tenantID: "acme"},
+		{name: "no tenant falls back in SQL", tenantID: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := &models.IngestionEvent{EventID: "evt-1", UserID: "alice", TenantID: tt.tenantID, RawPayload: []byte(`{}`)}
+			args, err := (&PostgresRepository{}).eventInsertArgs(event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			const tenantArg = 23
+			if !strings.Contains(insertEventSQL, fmt.Sprintf("COALESCE(NULLIF($%d, ''), 'default')", tenantArg)) {
+				t.Errorf("insertEventSQL does not default the tenant in $%d: %s", tenantArg, insertEventSQL)
+			}
+			if got := args[tenantArg-1]; got != tt.tenantID {
+				t.Errorf("tenant arg = %v, want %q", got, tt.tenantID)
+			}
+		})
+	}
+}
+
+func TestEventsQueryOmitPayload(t *testing.T) {
+	tests := []struct {
+		name        string
+		omitPayload bool
+		wantColumn  string
+		wantSkipped []string
+	}{
+		{name: "payload requested", wantColumn: "raw_payload, checksum", wantSkipped: []string{"NULL::bytea"}},
+		{name: "payload omitted", omitPayload: true, wantColumn: "NULL::bytea, checksum", wantSkipped: []string{"raw_payload", "credential_jwt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := eventsQuery("alice", EventFilter{OmitPayload: tt.omitPayload, Limit: 10})
+			if !strings.Contains(query, tt.wantColumn) {
+				t.Errorf("query = %s, want it to select %s", query, tt.wantColumn)
+			}
+			for _, column := range tt.wantSkipped {
+				if strings.Contains(query, column) {
+					t.Errorf("query = %s, want it not to select %s", query, column)
+				}
+			}
+		})
+	}
+}
+
+func TestTrimPage(t *testing.T) {
+	created := time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC)
+	events := make([]models.IngestionEvent, 3)
+	for i := range events {
+		events[i] = models.IngestionEvent{
+			EventID:    fmt.Sprintf("00000000-0000-0000-0000-00000000000%d", i),
+			CreatedAt:  created.Add(-time.Duration(i) * time.Minute),
+			OccurredAt: created.Add(-time.Duration(i) * 24 * time.Hour),
+		}
+	}
+
+	tests := []struct {
+		name      string
+		events    []models.IngestionEvent
+		limit     int
+		wantLen   int
+		wantAfter *models.IngestionEvent
+	}{
+		{name: "extra row", events: events, limit: 2, wantLen: 2, wantAfter: &events[1]},
+		{name: "exactly limit rows", events: events[:2], limit: 2, wantLen: 2},
+		{name: "fewer rows", events: events[:1], limit: 2, wantLen: 1},
+		{name: "no rows", limit: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, next := TrimPage(tt.events, tt.limit)
+			if len(page) != tt.wantLen {
+				t.Errorf("TrimPage() returned %d events, want %d", len(page), tt.wantLen)
+			}
+			if tt.wantAfter == nil {
+				if next != "" {
+					t.Errorf("TrimPage() cursor = %q, want none", next)
+				}
+				return
+			}
+			pos, err := cursor.Parse(next)
+			if err != nil {
+				t.Fatalf("TrimPage() cursor %q does not parse: %v", next, err)
+			}
+			if !pos.CreatedAt.Equal(tt.wantAfter.CreatedAt) || pos.EventID != tt.wantAfter.EventID {
+				t.Errorf("TrimPage() cursor = %v, want the created_at and ID of %s", pos, tt.wantAfter.EventID)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// PresetRepository defines storage operations for ingestion presets.
+type PresetRepository interface {
+	ListPresets(ctx context.Context, tenantID string) ([]models.IngestionPreset, error)
+	GetPreset(ctx context.Context, tenantID, name string) (*models.IngestionPreset, error)
+	UpsertPreset(ctx context.Context, preset *models.IngestionPreset) error
+	DeletePreset(ctx context.Context, tenantID, name string) (bool, error)
+}
+
+const presetColumns = `tenant_id, name, source_type, tags, metadata, created_at, updated_at`
+
+func scanPreset(row pgx.Row, preset *models.IngestionPreset) error {
+	return row.Scan(
+		&preset.TenantID,
+		&preset.Name,
+		&preset.SourceType,
+		&preset.Tags,
+		&preset.Metadata,
+		&preset.CreatedAt,
+		&preset.UpdatedAt,
+	)
+}
+
+// ListPresets returns a tenant's presets ordered by name.
+func (r *PostgresRepository) ListPresets(ctx context.Context, tenantID string) ([]models.IngestionPreset, error) {
+	query := `
+		SELECT ` + presetColumns + `
+		FROM ingestion_presets
+		WHERE tenant_id = $1
+		ORDER BY name
+	`
+
+	rows, err := r.pool.Query(ctx, query, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query presets: %w", err)
+	}
+	defer rows.Close()
+
+	var presets []models.IngestionPreset
+	for rows.Next() {
+		var preset models.IngestionPreset
+		if err := scanPreset(rows, &preset); err != nil {
+			return nil, fmt.Errorf("failed to scan preset: %w", err)
+		}
+		presets = append(presets, preset)
+	}
+
+	return presets, rows.Err()
+}
+
+// GetPreset returns a tenant's preset by name, or nil if it does not exist.
+func (r *PostgresRepository) GetPreset(ctx context.Context, tenantID, name string) (*models.IngestionPreset, error) {
+	query := `
+		SELECT ` + presetColumns + `
+		FROM ingestion_presets
+		WHERE tenant_id = $1 AND name = $2
+	`
+
+	var preset models.IngestionPreset
+	err := scanPreset(r.pool.QueryRow(ctx, query, tenantID, name), &preset)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preset: %w", err)
+	}
+
+	return &preset, nil
+}
+
+// UpsertPreset creates a preset or replaces an existing one.
+func (r *PostgresRepository) UpsertPreset(ctx context.Context, preset *models.IngestionPreset) error {
+	query := `
+		INSERT INTO ingestion_presets (tenant_id, name, source_type, tags, metadata, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT (tenant_id, name) DO UPDATE
+		SET source_type = EXCLUDED.source_type,
+			tags = EXCLUDED.tags,
+			metadata = EXCLUDED.metadata,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+
+	err := r.pool.QueryRow(ctx, query,
+		preset.TenantID,
+		preset.Name,
+		preset.SourceType,
+		preset.Tags,
+		preset.Metadata,
+	).Scan(&preset.CreatedAt, &preset.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert preset: %w", err)
+	}
+	return nil
+}
+
+// DeletePreset removes a preset and reports whether it existed.
+func (r *PostgresRepository) DeletePreset(ctx context.Context, tenantID, name string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM ingestion_presets WHERE tenant_id = $1 AND name = $2`, tenantID, name)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete preset: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
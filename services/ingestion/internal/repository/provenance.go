@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// ProvenanceRepository defines lookups along event derivation chains.
+type ProvenanceRepository interface {
+	GetProvenanceChain(ctx context.Context, eventID string, maxDepth int) ([]models.ProvenanceLink, error)
+}
+
+// GetProvenanceChain returns eventID and up to maxDepth-1 of its ancestors,
+// nearest first, following parent_event_id. The chain stops early at an
+// event with no parent or at an ancestor that no longer exists; in the
+// latter case the last link's ParentEventID names the missing event. An
+// unknown eventID yields an empty chain.
+func (r *PostgresRepository) GetProvenanceChain(ctx context.Context, eventID string, maxDepth int) ([]models.ProvenanceLink, error) {
+	rows, err := r.pool.Query(ctx, `
+		WITH RECURSIVE chain AS (
+			SELECT event_id, user_id, parent_event_id, verification_status, expires_at, deleted_at, 1 AS depth
+			FROM ingestion_events
+			WHERE event_id = $1
+			UNION ALL
+			SELECT e.event_id, e.user_id, e.parent_event_id, e.verification_status, e.expires_at, e.deleted_at, c.depth + 1
+			FROM ingestion_events e
+			JOIN chain c ON e.event_id = c.parent_event_id
+			WHERE c.depth < $2
+		)
+		SELECT event_id, user_id, parent_event_id, verification_status, expires_at, deleted_at
+		FROM chain
+		ORDER BY depth
+	`, eventID, maxDepth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query provenance chain: %w", err)
+	}
+	defer rows.Close()
+
+	var chain []models.ProvenanceLink
+	for rows.Next() {
+		var link models.ProvenanceLink
+		if err := rows.Scan(&link.EventID, &link.UserID, &link.ParentEventID, &link.VerificationStatus, &link.ExpiresAt, &link.DeletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan provenance link: %w", err)
+		}
+		chain = append(chain, link)
+	}
+
+	return chain, rows.Err()
+}
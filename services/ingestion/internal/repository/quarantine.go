@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// QuarantineRepository defines storage operations for quarantined events.
+type QuarantineRepository interface {
+	QuarantineEvent(ctx context.Context, q *models.QuarantinedEvent) error
+	ListQuarantined(ctx context.Context, reprocessed bool, limit int) ([]models.QuarantinedEvent, error)
+	GetQuarantined(ctx context.Context, quarantineID string) (*models.QuarantinedEvent, error)
+	RecordQuarantineAttempt(ctx context.Context, quarantineID, errMsg, field string) error
+	ReleaseQuarantined(ctx context.Context, quarantineID string, event *models.IngestionEvent) error
+}
+
+const quarantineColumns = `quarantine_id, event_id, user_id, source_type, event, error, field, attempts, created_at, reprocessed_at`
+
+func scanQuarantined(row pgx.Row, q *models.QuarantinedEvent) error {
+	var field *string
+	err := row.Scan(
+		&q.QuarantineID,
+		&q.EventID,
+		&q.UserID,
+		&q.SourceType,
+		&q.Event,
+		&q.Error,
+		&field,
+		&q.Attempts,
+		&q.CreatedAt,
+		&q.ReprocessedAt,
+	)
+	if field != nil {
+		q.Field = *field
+	}
+	return err
+}
+
+// QuarantineEvent stores an event held for review.
+func (r *PostgresRepository) QuarantineEvent(ctx context.Context, q *models.QuarantinedEvent) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO quarantined_events (quarantine_id, event_id, user_id, source_type, event, error, field, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+	`, q.QuarantineID, q.EventID, q.UserID, q.SourceType, q.Event, q.Error, q.Field, q.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to quarantine event: %w", err)
+	}
+	return nil
+}
+
+// ListQuarantined returns quarantined events, oldest first, that are
+// pending review or, with reprocessed, that have been released.
+func (r *PostgresRepository) ListQuarantined(ctx context.Context, reprocessed bool, limit int) ([]models.QuarantinedEvent, error) {
+	query := `
+		SELECT ` + quarantineColumns + `
+		FROM quarantined_events
+		WHERE (reprocessed_at IS NOT NULL) = $1
+		ORDER BY created_at ASC
+		LIMIT $2
+	`
+
+	rows, err := r.pool.Query(ctx, query, reprocessed, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quarantined events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.QuarantinedEvent
+	for rows.Next() {
+		var q models.QuarantinedEvent
+		if err := scanQuarantined(rows, &q); err != nil {
+			return nil, fmt.Errorf("failed to scan quarantined event: %w", err)
+		}
+		events = append(events, q)
+	}
+
+	return events, rows.Err()
+}
+
+// GetQuarantined returns a quarantined event, or nil if it does not exist.
+func (r *PostgresRepository) GetQuarantined(ctx context.Context, quarantineID string) (*models.QuarantinedEvent, error) {
+	query := `
+		SELECT ` + quarantineColumns + `
+		FROM quarantined_events
+		WHERE quarantine_id = $1
+	`
+
+	var q models.QuarantinedEvent
+	err := scanQuarantined(r.pool.QueryRow(ctx, query, quarantineID), &q)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quarantined event: %w", err)
+	}
+	return &q, nil
+}
+
+// RecordQuarantineAttempt records a failed reprocessing attempt.
+func (r *PostgresRepository) RecordQuarantineAttempt(ctx context.Context, quarantineID, errMsg, field string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE quarantined_events
+		SET attempts = attempts + 1, error = $2, field = NULLIF($3, '')
+		WHERE quarantine_id = $1
+	`, quarantineID, errMsg, field)
+	if err != nil {
+		return fmt.Errorf("failed to record quarantine attempt: %w", err)
+	}
+	return nil
+}
+
+// ReleaseQuarantined stores the reprocessed event and marks the quarantine
+// entry reprocessed in one transaction.
+func (r *PostgresRepository) ReleaseQuarantined(ctx context.Context, quarantineID string, event *models.IngestionEvent) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE quarantined_events
+		SET attempts = attempts + 1, reprocessed_at = $2
+		WHERE quarantine_id = $1 AND reprocessed_at IS NULL
+	`, quarantineID, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to release quarantined event: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("quarantined event %s already reprocessed", quarantineID)
+	}
+
+	args, err := r.eventInsertArgs(event)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, insertEventSQL, args...); err != nil {
+		return fmt.Errorf("failed to insert event: %w", err)
+	}
+	if err := r.CreateOIDCClaims(ctx, tx, event); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
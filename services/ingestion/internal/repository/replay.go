@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uigs/ingestion/internal/cursor"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// ReplayRepository defines storage operations for republishing events.
+type ReplayRepository interface {
+	ListEventsForReplay(ctx context.Context, filter models.ReplayFilter, after cursor.Cursor, limit int) ([]models.IngestionEvent, error)
+}
+
+// ListEventsForReplay returns events matching the filter strictly after the
+// cursor, in (created_at, event_id) order.
+func (r *PostgresRepository) ListEventsForReplay(ctx context.Context, filter models.ReplayFilter, after cursor.Cursor, limit int) ([]models.IngestionEvent, error) {
+	query := `
+		SELECT ` + eventColumns + `
+		FROM ingestion_events
+		WHERE created_at >= $1 AND created_at < $2
+			AND (created_at, event_id) > ($3, $4)
+			AND ($5 = '' OR source_type = $5)
+			AND ($6 = '' OR user_id::text = $6)
+			AND ($7 OR deleted_at IS NULL)
+		ORDER BY created_at ASC, event_id ASC
+		LIMIT $8
+	`
+
+	rows, err := r.pool.Query(ctx, query,
+		filter.From,
+		filter.To,
+		after.CreatedAt,
+		after.EventID,
+		string(filter.SourceType),
+		filter.UserID,
+		filter.IncludeDeleted,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events for replay: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.IngestionEvent
+	for rows.Next() {
+		var event models.IngestionEvent
+		if err := r.scanEvent(rows, &event); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// eventsPrimaryKey is the primary key constraint of ingestion_events.
+const eventsPrimaryKey = "ingestion_events_pkey"
+
+// retryPolicy bounds how often and how fast transient write failures are
+// retried.
+type retryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// isRetryable reports whether a failed statement may succeed if run again:
+// serialization failures, deadlocks, lost or refused connections and server
+// shutdowns. Constraint violations and other errors are permanent, and a
+// canceled or expired context is never retried.
+func isRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "40001", // serialization_failure
+			"40P01", // deadlock_detected
+			"57P01", // admin_shutdown
+			"57P03": // cannot_connect_now
+			return true
+		}
+		// Class 08 is connection exceptions
+		return strings.HasPrefix(pgErr.Code, "08")
+	}
+
+	return pgconn.SafeToRetry(err) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// withRetry runs fn until it succeeds, fails with an error isRetryable
+// rejects, or has been tried maxAttempts times. Attempts are spaced by
+// exponential backoff from baseDelay up to maxDelay, with the
+// upper half of each delay randomized so that callers failing together do
+// not retry together. Waiting stops when ctx ends.
+func (r *PostgresRepository) withRetry(ctx context.Context, fn func(attempt int) error) error {
+	delay := r.retry.baseDelay
+	for attempt := 1; ; attempt++ {
+		err := fn(attempt)
+		if err == nil || attempt >= r.retry.maxAttempts || !isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		wait := delay / 2
+		if half := int64(delay - wait); half > 0 {
+			wait += time.Duration(rand.Int63n(half + 1))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		if delay *= 2; r.retry.maxDelay > 0 && delay > r.retry.maxDelay {
+			delay = r.retry.maxDelay
+		}
+	}
+}
+
+// isEventConflict reports whether err is a unique violation of the event ID.
+// On a retried insert it means an earlier attempt was committed before its
+// connection was lost.
+func isEventConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return isUniqueViolation(err) && errors.As(err, &pgErr) && pgErr.ConstraintName == eventsPrimaryKey
+}
+
+// isUniqueViolation reports whether err is a unique violation (SQLSTATE
+// 23505) of any constraint.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
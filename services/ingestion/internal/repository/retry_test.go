@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil},
+		{name: "serialization failure", err: &pgconn.PgError{Code: "40001"}, want: true},
+		{name: "deadlock", err: &pgconn.PgError{Code: "40P01"}, want: true},
+		{name: "admin shutdown", err: &pgconn.PgError{Code: "57P01"}, want: true},
+		{name: "connection failure", err: &pgconn.PgError{Code: "08006"}, want: true},
+		{name: "unique violation", err: &pgconn.PgError{Code: "23505"}},
+		{name: "syntax error", err: &pgconn.PgError{Code: "42601"}},
+		{name: "connection reset", err: fmt.Errorf("write: %w", syscall.ECONNRESET), want: true},
+		{name: "connection refused", err: syscall.ECONNREFUSED, want: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "canceled", err: context.Canceled},
+		{name: "deadline exceeded", err: fmt.Errorf("query: %w", context.DeadlineExceeded)},
+		{name: "other error", err: errors.New("boom")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueViolations(t *testing.T) {
+	eventConflict := &pgconn.PgError{Code: "23505", ConstraintName: eventsPrimaryKey}
+	keyConflict := &pgconn.PgError{Code: "23505", ConstraintName: idempotencyIndex}
+
+	tests := []struct {
+		name            string
+		err             error
+		wantUnique      bool
+		wantEvent       bool
+		wantIdempotency bool
+	}{
+		{name: "nil"},
+		{name: "duplicate event ID", err: eventConflict, wantUnique: true, wantEvent: true},
+		{name: "wrapped duplicate event ID", err: fmt.Errorf("insert: %w", eventConflict), wantUnique: true, wantEvent: true},
+		{name: "duplicate idempotency key", err: keyConflict, wantUnique: true, wantIdempotency: true},
+		{name: "other unique constraint", err: &pgconn.PgError{Code: "23505", ConstraintName: "other_key"}, wantUnique: true},
+		{name: "other SQLSTATE on the primary key", err: &pgconn.PgError{Code: "23503", ConstraintName: eventsPrimaryKey}},
+		{name: "not a Postgres error", err: errors.New("duplicate key")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUniqueViolation(tt.err); got != tt.wantUnique {
+				t.Errorf("isUniqueViolation(%v) = %v, want %v", tt.err, got, tt.wantUnique)
+			}
+			if got := isEventConflict(tt.err); got != tt.wantEvent {
+				t.Errorf("isEventConflict(%v) = %v, want %v", tt.err, got, tt.wantEvent)
+			}
+			if got := isIdempotencyConflict(tt.err); got != tt.wantIdempotency {
+				t.Errorf("isIdempotencyConflict(%v) = %v, want %v", tt.err, got, tt.wantIdempotency)
+			}
+		})
+	}
+}
+
+// fakePool fails its statements with the errors in failures, in turn, and
+// then succeeds.
+type fakePool struct {
+	failures []error
+	calls    int
+}
+
+func (p *fakePool) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	p.calls++
+	if p.calls <= len(p.failures) {
+		return pgconn.CommandTag{}, p.failures[p.calls-1]
+	}
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func TestWithRetry(t *testing.T) {
+	serialization := &pgconn.PgError{Code: "40001"}
+	reset := fmt.Errorf("read: %w", syscall.ECONNRESET)
+	unique := &pgconn.PgError{Code: "23505"}
+
+	tests := []struct {
+		name        string
+		failures    []error
+		maxAttempts int
+		cancel      bool
+		wantErr     error
+		wantCalls   int
+	}{
+		{name: "fails twice then succeeds", failures: []error{serialization, reset}, maxAttempts: 3, wantCalls: 3},
+		{name: "succeeds at once", maxAttempts: 3, wantCalls: 1},
+		{name: "out of attempts", failures: []error{reset, reset, reset}, maxAttempts: 3, wantErr: reset, wantCalls: 3},
+		{name: "permanent error", failures: []error{unique}, maxAttempts: 3, wantErr: unique, wantCalls: 1},
+		{name: "retries disabled", failures: []error{reset}, maxAttempts: 1, wantErr: reset, wantCalls: 1},
+		{name: "canceled context", failures: []error{reset, reset}, maxAttempts: 3, cancel: true, wantErr: reset, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &fakePool{failures: tt.failures}
+			r := &PostgresRepository{retry: retryPolicy{maxAttempts: tt.maxAttempts, baseDelay: time.Millisecond, maxDelay: 4 * time.Millisecond}}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+			err := r.withRetry(ctx, func(int) error {
+				_, err := pool.Exec(ctx, "INSERT INTO ingestion_events DEFAULT VALUES")
+				return err
+			})
+			if !errors.Is(err, tt.wantErr) || (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("withRetry() error = %v, want %v", err, tt.wantErr)
+			}
+			if pool.calls != tt.wantCalls {
+				t.Errorf("ran the statement %d times, want %d", pool.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// S3ObjectRepository tracks bucket objects imported by the S3 ingestion
+// worker, so a repeated notification does not import an object twice.
+type S3ObjectRepository interface {
+	ClaimS3Object(ctx context.Context, bucket, key, etag string) (bool, error)
+	ReleaseS3Object(ctx context.Context, bucket, key, etag string) error
+	CompleteS3Object(ctx context.Context, bucket, key, etag string, ingested, rejected int, errMsg string) error
+}
+
+// ClaimS3Object marks an object version as being processed. It reports
+// false when the version was already claimed.
+func (r *PostgresRepository) ClaimS3Object(ctx context.Context, bucket, key, etag string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `
+		INSERT INTO s3_ingested_objects (bucket, object_key, etag, status)
+		VALUES ($1, $2, $3, 'processing')
+		ON CONFLICT (bucket, object_key, etag) DO NOTHING
+	`, bucket, key, etag)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim S3 object: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// ReleaseS3Object drops the claim on an object version that could not be
+// processed, so a redelivered notification retries it.
+func (r *PostgresRepository) ReleaseS3Object(ctx context.Context, bucket, key, etag string) error {
+	_, err := r.pool.Exec(ctx, `
+		DELETE FROM s3_ingested_objects
+		WHERE bucket = $1 AND object_key = $2 AND etag = $3 AND status = 'processing'
+	`, bucket, key, etag)
+	if err != nil {
+		return fmt.Errorf("failed to release S3 object: %w", err)
+	}
+	return nil
+}
+
+// CompleteS3Object records the outcome of importing an object version.
+// errMsg describes the first problem; an object from which nothing could be
+// ingested is marked failed.
+func (r *PostgresRepository) CompleteS3Object(ctx context.Context, bucket, key, etag string, ingested, rejected int, errMsg string) error {
+	status := "processed"
+	if errMsg != "" && ingested == 0 {
+		status = "failed"
+	}
+	_, err := r.pool.Exec(ctx, `
+		UPDATE s3_ingested_objects
+		SET status = $4, ingested = $5, rejected = $6, error = NULLIF($7, ''), completed_at = NOW()
+		WHERE bucket = $1 AND object_key = $2 AND etag = $3
+	`, bucket, key, etag, status, ingested, rejected, errMsg)
+	if err != nil {
+		return fmt.Errorf("failed to complete S3 object: %w", err)
+	}
+	return nil
+}
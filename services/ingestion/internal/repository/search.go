@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// SearchRepository defines storage operations for searching events across
+// users.
+type SearchRepository interface {
+	SearchEvents(ctx context.Context, filter AdminEventFilter) ([]models.IngestionEvent, error)
+	GetEventsByChecksumGlobal(ctx context.Context, checksum string) ([]models.IngestionEvent, error)
+}
+
+// AdminEventFilter selects a page of events of any user. An empty UserID
+// matches every user.
+type AdminEventFilter struct {
+	UserID string
+	EventFilter
+}
+
+// SearchEvents retrieves a page of events matching filter, in the order and
+// with the keyset pagination of GetEventsFiltered. The page is always
+// bounded by filter.Limit, so a search without a user reads no more than
+// one page from the created_at index.
+func (r *PostgresRepository) SearchEvents(ctx context.Context, filter AdminEventFilter) ([]models.IngestionEvent, error) {
+	return r.queryEvents(ctx, filter.UserID, filter.EventFilter)
+}
+
+// GetEventsByChecksumGlobal retrieves every event of any user whose payload
+// has the given SHA-256 checksum, soft-deleted ones included, oldest first.
+func (r *PostgresRepository) GetEventsByChecksumGlobal(ctx context.Context, checksum string) ([]models.IngestionEvent, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT `+eventColumns+`
+		FROM ingestion_events
+		WHERE checksum = $1
+		ORDER BY created_at, event_id
+	`, checksum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events by checksum: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.IngestionEvent
+	for rows.Next() {
+		var event models.IngestionEvent
+		if err := r.scanEvent(rows, &event); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+func TestSearchEventsQuery(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		filter    AdminEventFilter
+		wantWhere string
+		wantArgs  []any
+	}{
+		{
+			name:      "every user",
+			filter:    AdminEventFilter{EventFilter: EventFilter{Limit: 10}},
+			wantWhere: "WHERE deleted_at IS NULL\n",
+			wantArgs:  []any{10},
+		},
+		{
+			name:      "every user with filters",
+			filter:    AdminEventFilter{EventFilter: EventFilter{SourceType: models.SourceTypeVC, From: &from, Limit: 10}},
+			wantWhere: "WHERE deleted_at IS NULL AND source_type = $1 AND occurred_at >= $2\n",
+			wantArgs:  []any{"VC", from, 10},
+		},
+		{
+			name:      "one user",
+			filter:    AdminEventFilter{UserID: "alice", EventFilter: EventFilter{SourceType: models.SourceTypeOIDC, Limit: 10}},
+			wantWhere: "WHERE user_id = $1 AND deleted_at IS NULL AND source_type = $2\n",
+			wantArgs:  []any{"alice", "OIDC", 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := eventsQuery(tt.filter.UserID, tt.filter.EventFilter)
+			if !strings.Contains(query, tt.wantWhere) {
+				t.Errorf("query = %s, want %q", query, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
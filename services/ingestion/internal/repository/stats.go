@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// StatsRepository defines storage operations for summarizing a user's events.
+type StatsRepository interface {
+	GetEventStats(ctx context.Context, userID string) (*models.EventStats, error)
+}
+
+// GetEventStats counts a user's events by source type and finds the
+// earliest and latest occurred_at, excluding soft-deleted events. Every
+// source type is reported, with zero when the user has none; a user without
+// events gets zero counts and no timestamps.
+func (r *PostgresRepository) GetEventStats(ctx context.Context, userID string) (*models.EventStats, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT source_type, COUNT(*), MIN(COALESCE(occurred_at, created_at)), MAX(COALESCE(occurred_at, created_at))
+		FROM ingestion_events
+		WHERE user_id = $1 AND deleted_at IS NULL
+		GROUP BY source_type
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query event stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := newEventStats()
+	for rows.Next() {
+		var sourceType models.SourceType
+		var count int64
+		var earliest, latest time.Time
+		if err := rows.Scan(&sourceType, &count, &earliest, &latest); err != nil {
+			return nil, fmt.Errorf("failed to scan event stats: %w", err)
+		}
+		addSourceTypeStats(stats, sourceType, count, earliest, latest)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event stats: %w", err)
+	}
+	return stats, nil
+}
+
+// newEventStats returns the stats of a user without events: a zero count
+// for every source type.
+func newEventStats() *models.EventStats {
+	return &models.EventStats{
+		BySourceType: map[models.SourceType]int64{
+			models.SourceTypeVC:     0,
+			models.SourceTypeOIDC:   0,
+			models.SourceTypeManual: 0,
+		},
+	}
+}
+
+// addSourceTypeStats adds the count and time range of one source type's
+// events to stats.
+func addSourceTypeStats(stats *models.EventStats, sourceType models.SourceType, count int64, earliest, latest time.Time) {
+	stats.BySourceType[sourceType] = count
+	stats.Total += count
+	if stats.Earliest == nil || earliest.Before(*stats.Earliest) {
+		stats.Earliest = &earliest
+	}
+	if stats.Latest == nil || latest.After(*stats.Latest) {
+		stats.Latest = &latest
+	}
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+func TestEventStats(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+	type group struct {
+		sourceType       models.SourceType
+		count            int64
+		earliest, latest time.Time
+	}
+
+	tests := []struct {
+		name         string
+		groups       []group
+		wantTotal    int64
+		wantBySource map[models.SourceType]int64
+		wantEarliest time.Time
+		wantLatest   time.Time
+	}{
+		{
+			name:         "no events",
+			wantBySource: map[models.SourceType]int64{models.SourceTypeVC: 0, models.SourceTypeOIDC: 0, models.SourceTypeManual: 0},
+		},
+		{
+			name:         "one source type",
+			groups:       []group{{models.SourceTypeOIDC, 2, day(3), day(5)}},
+			wantTotal:    2,
+			wantBySource: map[models.SourceType]int64{models.SourceTypeVC: 0, models.SourceTypeOIDC: 2, models.SourceTypeManual: 0},
+			wantEarliest: day(3),
+			wantLatest:   day(5),
+		},
+		{
+			name: "mixed source types",
+			groups: []group{
+				{models.SourceTypeVC, 3, day(2), day(9)},
+				{models.SourceTypeManual, 1, day(4), day(4)},
+				{models.SourceTypeOIDC, 5, day(1), day(6)},
+			},
+			wantTotal:    9,
+			wantBySource: map[models.SourceType]int64{models.SourceTypeVC: 3, models.SourceTypeOIDC: 5, models.SourceTypeManual: 1},
+			wantEarliest: day(1),
+			wantLatest:   day(9),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := newEventStats()
+			for _, g := range tt.groups {
+				addSourceTypeStats(stats, g.sourceType, g.count, g.earliest, g.latest)
+			}
+
+			if stats.Total != tt.wantTotal {
+				t.Errorf("Total = %d, want %d", stats.Total, tt.wantTotal)
+			}
+			for sourceType, want := range tt.wantBySource {
+				if got, ok := stats.BySourceType[sourceType]; !ok || got != want {
+					t.Errorf("BySourceType[%s] = %d (present %v), want %d", sourceType, got, ok, want)
+				}
+			}
+			if tt.wantEarliest.IsZero() {
+				if stats.Earliest != nil || stats.Latest != nil {
+					t.Errorf("Earliest, Latest = %v, %v; want none", stats.Earliest, stats.Latest)
+				}
+				return
+			}
+			if stats.Earliest == nil || !stats.Earliest.Equal(tt.wantEarliest) {
+				t.Errorf("Earliest = %v, want %v", stats.Earliest, tt.wantEarliest)
+			}
+			if stats.Latest == nil || !stats.Latest.Equal(tt.wantLatest) {
+				t.Errorf("Latest = %v, want %v", stats.Latest, tt.wantLatest)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/uigs/ingestion/internal/cursor"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// StreamRepository defines storage operations for real-time event delivery.
+type StreamRepository interface {
+	ListEventsAfter(ctx context.Context, userID string, after cursor.Cursor, before time.Time, limit int) ([]models.IngestionEvent, error)
+	GetWatermark(ctx context.Context, userID, subscriberID string) (*cursor.Cursor, error)
+	SaveWatermark(ctx context.Context, userID, subscriberID string, c cursor.Cursor) error
+}
+
+// ListEventsAfter returns the user's events strictly after the cursor and
+// created before the given time, in (created_at, event_id) order.
+func (r *PostgresRepository) ListEventsAfter(ctx context.Context, userID string, after cursor.Cursor, before time.Time, limit int) ([]models.IngestionEvent, error) {
+	query := `
+		SELECT ` + eventColumns + `
+		FROM ingestion_events
+		WHERE user_id = $1
+			AND deleted_at IS NULL
+			AND (created_at, event_id) > ($2, $3)
+			AND created_at < $4
+		ORDER BY created_at ASC, event_id ASC
+		LIMIT $5
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID, after.CreatedAt, after.EventID, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events after cursor: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.IngestionEvent
+	for rows.Next() {
+		var event models.IngestionEvent
+		if err := r.scanEvent(rows, &event); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// GetWatermark returns the last event delivered to a subscriber, or nil if
+// the subscriber has none.
+func (r *PostgresRepository) GetWatermark(ctx context.Context, userID, subscriberID string) (*cursor.Cursor, error) {
+	query := `
+		SELECT last_created_at, last_event_id
+		FROM stream_watermarks
+		WHERE user_id = $1 AND subscriber_id = $2
+	`
+
+	var c cursor.Cursor
+	err := r.pool.QueryRow(ctx, query, userID, subscriberID).Scan(&c.CreatedAt, &c.EventID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watermark: %w", err)
+	}
+
+	c.CreatedAt = c.CreatedAt.UTC()
+	return &c, nil
+}
+
+// SaveWatermark records the last event delivered to a subscriber. The
+// watermark only moves forward.
+func (r *PostgresRepository) SaveWatermark(ctx context.Context, userID, subscriberID string, c cursor.Cursor) error {
+	query := `
+		INSERT INTO stream_watermarks (user_id, subscriber_id, last_created_at, last_event_id, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (user_id, subscriber_id) DO UPDATE
+		SET last_created_at = EXCLUDED.last_created_at,
+			last_event_id = EXCLUDED.last_event_id,
+			updated_at = NOW()
+		WHERE (stream_watermarks.last_created_at, stream_watermarks.last_event_id)
+			< (EXCLUDED.last_created_at, EXCLUDED.last_event_id)
+	`
+
+	if _, err := r.pool.Exec(ctx, query, userID, subscriberID, c.CreatedAt, c.EventID); err != nil {
+		return fmt.Errorf("failed to save watermark: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// SubjectRepository defines storage for the credential subject identifiers
+// (DIDs or other URIs) each user may ingest credentials about.
+type SubjectRepository interface {
+	ListSubjectIDs(ctx context.Context, userID string) ([]string, error)
+	ReplaceSubjectIDs(ctx context.Context, userID string, subjectIDs []string) error
+}
+
+// ListSubjectIDs returns the subject identifiers registered for a user.
+func (r *PostgresRepository) ListSubjectIDs(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT subject_id FROM user_subject_ids
+		WHERE user_id = $1
+		ORDER BY subject_id
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subject IDs: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan subject ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// ReplaceSubjectIDs sets the subject identifiers registered for a user.
+func (r *PostgresRepository) ReplaceSubjectIDs(ctx context.Context, userID string, subjectIDs []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM user_subject_ids WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear subject IDs: %w", err)
+	}
+	if len(subjectIDs) > 0 {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO user_subject_ids (user_id, subject_id)
+			SELECT $1, unnest($2::text[])
+			ON CONFLICT DO NOTHING
+		`, userID, subjectIDs)
+		if err != nil {
+			return fmt.Errorf("failed to insert subject IDs: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrQueryTimeout is returned when a statement does not finish within the
+// query timeout, or the caller's deadline if that comes first.
+var ErrQueryTimeout = errors.New("query timed out")
+
+// queryTimeoutKey is the context key of WithQueryTimeout.
+type queryTimeoutKey struct{}
+
+// WithQueryTimeout returns a context whose statements may run for timeout
+// instead of the repository's query timeout, e.g. for a long export. Zero
+// disables the timeout; the context's own deadline still applies.
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, queryTimeoutKey{}, timeout)
+}
+
+// timedPool runs each statement under the query timeout, so a stuck query
+// cannot hold a connection for the whole request. A query's rows count
+// against its timeout until they are read or closed.
+type timedPool struct {
+	*pgxpool.Pool
+	timeout time.Duration
+}
+
+// withTimeout bounds ctx by the query timeout, or the one set with
+// WithQueryTimeout.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if d, ok := ctx.Value(queryTimeoutKey{}).(time.Duration); ok {
+		timeout = d
+	}
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// timeoutError marks err as ErrQueryTimeout when ctx's deadline passed.
+func timeoutError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) && !errors.Is(err, ErrQueryTimeout) {
+		return fmt.Errorf("%w: %w", ErrQueryTimeout, err)
+	}
+	return err
+}
+
+func (p *timedPool) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return timedExec(ctx, p.timeout, p.Pool.Exec, sql, args...)
+}
+
+func (p *timedPool) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return timedQuery(ctx, p.timeout, p.Pool.Query, sql, args...)
+}
+
+func (p *timedPool) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	ctx, cancel := withTimeout(ctx, p.timeout)
+	return &timedRow{row: p.Pool.QueryRow(ctx, sql, args...), ctx: ctx, cancel: cancel}
+}
+
+// Begin starts a transaction whose statements each run under the query
+// timeout.
+func (p *timedPool) Begin(ctx context.Context) (pgx.Tx, error) {
+	tctx, cancel := withTimeout(ctx, p.timeout)
+	defer cancel()
+	tx, err := p.Pool.Begin(tctx)
+	if err != nil {
+		return nil, timeoutError(tctx, err)
+	}
+	return &timedTx{Tx: tx, timeout: p.timeout}, nil
+}
+
+// timedTx runs each statement of a transaction under the query timeout.
+type timedTx struct {
+	pgx.Tx
+	timeout time.Duration
+}
+
+func (t *timedTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	return timedExec(ctx, t.timeout, t.Tx.Exec, sql, args...)
+}
+
+func (t *timedTx) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return timedQuery(ctx, t.timeout, t.Tx.Query, sql, args...)
+}
+
+func (t *timedTx) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	return &timedRow{row: t.Tx.QueryRow(ctx, sql, args...), ctx: ctx, cancel: cancel}
+}
+
+// Begin starts a savepoint whose statements also run under the timeout.
+func (t *timedTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	tctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	tx, err := t.Tx.Begin(tctx)
+	if err != nil {
+		return nil, timeoutError(tctx, err)
+	}
+	return &timedTx{Tx: tx, timeout: t.timeout}, nil
+}
+
+func timedExec(ctx context.Context, timeout time.Duration, exec func(context.Context, string, ...any) (pgconn.CommandTag, error), sql string, args ...any) (pgconn.CommandTag, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	defer cancel()
+	tag, err := exec(ctx, sql, args...)
+	return tag, timeoutError(ctx, err)
+}
+
+func timedQuery(ctx context.Context, timeout time.Duration, query func(context.Context, string, ...any) (pgx.Rows, error), sql string, args ...any) (pgx.Rows, error) {
+	ctx, cancel := withTimeout(ctx, timeout)
+	rows, err := query(ctx, sql, args...)
+	if err != nil {
+		cancel()
+		return nil, timeoutError(ctx, err)
+	}
+	return &timedRows{Rows: rows, ctx: ctx, cancel: cancel}, nil
+}
+
+// timedRows releases the query's timeout once its rows are read or closed.
+type timedRows struct {
+	pgx.Rows
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (r *timedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	// The rows are closed once Next reports the end
+	r.cancel()
+	return false
+}
+
+func (r *timedRows) Close() {
+	r.Rows.Close()
+	r.cancel()
+}
+
+func (r *timedRows) Err() error {
+	return timeoutError(r.ctx, r.Rows.Err())
+}
+
+// timedRow releases the query's timeout once it is scanned.
+type timedRow struct {
+	row    pgx.Row
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (r *timedRow) Scan(dest ...any) error {
+	defer r.cancel()
+	return timeoutError(r.ctx, r.row.Scan(dest...))
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// slowPool answers each statement after delay, unless its context ends
+// first.
+type slowPool struct {
+	delay time.Duration
+}
+
+func (p *slowPool) wait(ctx context.Context) error {
+	select {
+	case <-time.After(p.delay):
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timeout: context already done: %w", ctx.Err())
+	}
+}
+
+func (p *slowPool) Exec(ctx context.Context, _ string, _ ...any) (pgconn.CommandTag, error) {
+	if err := p.wait(ctx); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (p *slowPool) Query(ctx context.Context, _ string, _ ...any) (pgx.Rows, error) {
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("slowPool has no rows")
+}
+
+func TestQueryTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		delay       time.Duration
+		timeout     time.Duration
+		override    *time.Duration
+		cancel      bool
+		wantErr     error
+		wantTimeout bool
+	}{
+		{name: "fast statement", delay: 0, timeout: time.Second},
+		{name: "slow statement trips the timeout", delay: time.Second, timeout: 20 * time.Millisecond, wantTimeout: true},
+		{name: "no timeout", delay: 20 * time.Millisecond},
+		{name: "longer timeout for the context", delay: 50 * time.Millisecond, timeout: 10 * time.Millisecond, override: ptr(time.Second)},
+		{name: "timeout disabled for the context", delay: 50 * time.Millisecond, timeout: 10 * time.Millisecond, override: ptr(time.Duration(0))},
+		{name: "shorter timeout for the context", delay: time.Second, timeout: time.Minute, override: ptr(20 * time.Millisecond), wantTimeout: true},
+		{name: "canceled caller is not a timeout", delay: time.Second, timeout: time.Minute, cancel: true, wantErr: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := &slowPool{delay: tt.delay}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.override != nil {
+				ctx = WithQueryTimeout(ctx, *tt.override)
+			}
+			if tt.cancel {
+				cancel()
+			}
+
+			_, err := timedExec(ctx, tt.timeout, pool.Exec, "UPDATE ingestion_events SET delivery_status = $1", "queued")
+			if got := errors.Is(err, ErrQueryTimeout); got != tt.wantTimeout {
+				t.Fatalf("timedExec() error = %v, want timeout %v", err, tt.wantTimeout)
+			}
+			if !tt.wantTimeout && !errors.Is(err, tt.wantErr) {
+				t.Errorf("timedExec() error = %v, want %v", err, tt.wantErr)
+			}
+
+			_, err = timedQuery(ctx, tt.timeout, pool.Query, "SELECT 1")
+			if got := errors.Is(err, ErrQueryTimeout); got != tt.wantTimeout {
+				t.Errorf("timedQuery() error = %v, want timeout %v", err, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
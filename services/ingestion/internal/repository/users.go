@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// UserRepository defines storage operations for enumerating the users who
+// have ingested events.
+type UserRepository interface {
+	ListUsers(ctx context.Context, limit int, cursor string) ([]models.UserActivity, error)
+}
+
+// ListUsers returns up to limit users with events, in user_id order, with
+// their event counts and latest ingestion time. Soft-deleted events are not
+// counted. A page starts strictly after the user_id cursor, or at the first
+// user when cursor is empty.
+func (r *PostgresRepository) ListUsers(ctx context.Context, limit int, cursor string) ([]models.UserActivity, error) {
+	query, args := usersQuery(limit, cursor)
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.UserActivity
+	for rows.Next() {
+		var u models.UserActivity
+		if err := rows.Scan(&u.UserID, &u.EventCount, &u.LastActivityAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// usersQuery builds the statement and arguments of ListUsers.
+func usersQuery(limit int, cursor string) (string, []any) {
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	query := `
+		SELECT user_id, COUNT(*), MAX(created_at)
+		FROM ingestion_events
+		WHERE deleted_at IS NULL`
+	if cursor != "" {
+		query += ` AND user_id > ` + arg(cursor) + `::uuid`
+	}
+	query += `
+		GROUP BY user_id
+		ORDER BY user_id
+		LIMIT ` + arg(limit)
+	return query, args
+}
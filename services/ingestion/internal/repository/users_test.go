@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUsersQuery(t *testing.T) {
+	const cursor = "6f1c8a52-3d4e-4b7a-9c1d-2e3f4a5b6c7d"
+
+	tests := []struct {
+		name      string
+		limit     int
+		cursor    string
+		wantWhere string
+		wantLimit string
+		wantArgs  []any
+	}{
+		{name: "first page", limit: 101, wantWhere: "WHERE deleted_at IS NULL\n", wantLimit: "LIMIT $1", wantArgs: []any{101}},
+		{name: "after a cursor", limit: 11, cursor: cursor, wantWhere: "WHERE deleted_at IS NULL AND user_id > $1::uuid\n", wantLimit: "LIMIT $2", wantArgs: []any{cursor, 11}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := usersQuery(tt.limit, tt.cursor)
+			if !strings.Contains(query, tt.wantWhere) {
+				t.Errorf("query = %s, want %q", query, tt.wantWhere)
+			}
+			if !strings.Contains(query, "GROUP BY user_id\n\t\tORDER BY user_id") || !strings.HasSuffix(query, tt.wantLimit) {
+				t.Errorf("query = %s, want users grouped and ordered by user_id, ending in %s", query, tt.wantLimit)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// WebhookRepository defines storage operations for user webhooks.
+type WebhookRepository interface {
+	ListUserWebhooks(ctx context.Context, userID string) ([]models.UserWebhook, error)
+	GetUserWebhook(ctx context.Context, userID, eventType string) (*models.UserWebhook, error)
+	UpsertUserWebhook(ctx context.Context, hook *models.UserWebhook) error
+	DeleteUserWebhook(ctx context.Context, userID, eventType string) (bool, error)
+}
+
+const webhookColumns = `user_id, event_type, url, secret, created_at, updated_at, ordered`
+
+func scanWebhook(row pgx.Row, hook *models.UserWebhook) error {
+	return row.Scan(
+		&hook.UserID,
+		&hook.EventType,
+		&hook.URL,
+		&hook.Secret,
+		&hook.CreatedAt,
+		&hook.UpdatedAt,
+		&hook.Ordered,
+	)
+}
+
+// ListUserWebhooks returns all webhooks registered by a user.
+func (r *PostgresRepository) ListUserWebhooks(ctx context.Context, userID string) ([]models.UserWebhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM user_webhooks
+		WHERE user_id = $1
+		ORDER BY event_type
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var hooks []models.UserWebhook
+	for rows.Next() {
+		var hook models.UserWebhook
+		if err := scanWebhook(rows, &hook); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, rows.Err()
+}
+
+// GetUserWebhook returns the user's webhook for an event type, or nil if the
+// user has not opted in.
+func (r *PostgresRepository) GetUserWebhook(ctx context.Context, userID, eventType string) (*models.UserWebhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM user_webhooks
+		WHERE user_id = $1 AND event_type = $2
+	`
+
+	var hook models.UserWebhook
+	err := scanWebhook(r.pool.QueryRow(ctx, query, userID, eventType), &hook)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return &hook, nil
+}
+
+// UpsertUserWebhook registers a webhook or updates its URL, secret and
+// ordering.
+func (r *PostgresRepository) UpsertUserWebhook(ctx context.Context, hook *models.UserWebhook) error {
+	query := `
+		INSERT INTO user_webhooks (user_id, event_type, url, secret, ordered, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW())
+		ON CONFLICT (user_id, event_type) DO UPDATE
+		SET url = EXCLUDED.url, secret = EXCLUDED.secret, ordered = EXCLUDED.ordered, updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+
+	err := r.pool.QueryRow(ctx, query, hook.UserID, hook.EventType, hook.URL, hook.Secret, hook.Ordered).
+		Scan(&hook.CreatedAt, &hook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert webhook: %w", err)
+	}
+	return nil
+}
+
+// DeleteUserWebhook removes a webhook and reports whether it existed.
+func (r *PostgresRepository) DeleteUserWebhook(ctx context.Context, userID, eventType string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM user_webhooks WHERE user_id = $1 AND event_type = $2`, userID, eventType)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
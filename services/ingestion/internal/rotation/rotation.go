@@ -0,0 +1,164 @@
+// Package rotation re-encrypts stored event payloads with the current
+// encryption key after a key rotation, in the background and in batches so
+// the service stays available while it runs.
+package rotation
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// Rotation states.
+const (
+	StateIdle      = "idle"
+	StateRunning   = "running"
+	StateCompleted = "completed"
+	StateFailed    = "failed"
+	StateCancelled = "cancelled"
+)
+
+// ErrRunning is returned when a rotation is triggered while one is running.
+var ErrRunning = errors.New("key rotation already running")
+
+// Config controls the rotator.
+type Config struct {
+	// TargetVersion is the current key version events are re-encrypted with.
+	TargetVersion int
+	// BatchSize is the maximum number of events re-encrypted per transaction.
+	BatchSize int
+	// Pause is the delay between batches, to limit load on the database.
+	Pause time.Duration
+}
+
+// Status describes the most recent rotation run.
+type Status struct {
+	State         string     `json:"state"`
+	TargetVersion int        `json:"target_version"`
+	Reencrypted   int64      `json:"reencrypted"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// Rotator runs one key rotation at a time.
+type Rotator struct {
+	repo   repository.EncryptionRepository
+	cfg    Config
+	logger *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	status Status
+}
+
+// New creates a rotator.
+func New(repo repository.EncryptionRepository, cfg Config, logger *slog.Logger) *Rotator {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Rotator{
+		repo:   repo,
+		cfg:    cfg,
+		logger: logger,
+		ctx:    ctx,
+		cancel: cancel,
+		status: Status{State: StateIdle, TargetVersion: cfg.TargetVersion},
+	}
+}
+
+// Trigger starts a rotation in the background and returns its initial status.
+func (r *Rotator) Trigger() (Status, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.status.State == StateRunning {
+		return r.status, ErrRunning
+	}
+	if r.ctx.Err() != nil {
+		return r.status, r.ctx.Err()
+	}
+
+	now := time.Now().UTC()
+	r.status = Status{
+		State:         StateRunning,
+		TargetVersion: r.cfg.TargetVersion,
+		StartedAt:     &now,
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run()
+	}()
+
+	return r.status, nil
+}
+
+// Status returns the state of the current or most recent rotation.
+func (r *Rotator) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Stop cancels a running rotation and waits for its current batch to finish.
+// Events already re-encrypted keep their new key version; triggering again
+// resumes where the rotation stopped.
+func (r *Rotator) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *Rotator) run() {
+	r.logger.Info("Key rotation started", "target_version", r.cfg.TargetVersion)
+
+	for {
+		n, err := r.repo.ReencryptEvents(r.ctx, r.cfg.BatchSize)
+
+		r.mu.Lock()
+		r.status.Reencrypted += n
+		r.mu.Unlock()
+
+		switch {
+		case r.ctx.Err() != nil:
+			r.finish(StateCancelled, nil)
+			return
+		case err != nil:
+			r.finish(StateFailed, err)
+			return
+		case n < int64(r.cfg.BatchSize):
+			r.finish(StateCompleted, nil)
+			return
+		}
+
+		if r.cfg.Pause > 0 {
+			select {
+			case <-r.ctx.Done():
+				r.finish(StateCancelled, nil)
+				return
+			case <-time.After(r.cfg.Pause):
+			}
+		}
+	}
+}
+
+func (r *Rotator) finish(state string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().UTC()
+	r.status.State = state
+	r.status.FinishedAt = &now
+	if err != nil {
+		r.status.Error = err.Error()
+		r.logger.Error("Key rotation failed", "error", err, "reencrypted", r.status.Reencrypted)
+		return
+	}
+	r.logger.Info("Key rotation finished", "state", state, "reencrypted", r.status.Reencrypted)
+}
@@ -0,0 +1,85 @@
+package s3ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// Notification is an S3 event notification, as published by S3 and
+// compatible stores such as MinIO.
+type Notification struct {
+	Records []Record `json:"Records"`
+}
+
+// Record is one event in a notification.
+type Record struct {
+	EventName string `json:"eventName"`
+	S3        struct {
+		Bucket struct {
+			Name string `json:"name"`
+		} `json:"bucket"`
+		Object struct {
+			Key  string `json:"key"`
+			Size int64  `json:"size"`
+			ETag string `json:"eTag"`
+		} `json:"object"`
+	} `json:"s3"`
+}
+
+// ObjectCreated reports whether the record announces a new object.
+func (r Record) ObjectCreated() bool {
+	name := strings.TrimPrefix(r.EventName, "s3:")
+	return strings.HasPrefix(name, "ObjectCreated:")
+}
+
+// ObjectKey returns the object key, which notifications URL-encode.
+func (r Record) ObjectKey() (string, error) {
+	return url.QueryUnescape(r.S3.Object.Key)
+}
+
+// parseFile reads the ingestion requests in a dropped file: a single
+// request object, a JSON array of requests, or one request per line.
+func parseFile(data []byte) ([]models.IngestionRequest, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+
+	if trimmed[0] == '[' {
+		var reqs []models.IngestionRequest
+		if err := json.Unmarshal(trimmed, &reqs); err != nil {
+			return nil, fmt.Errorf("invalid JSON array: %w", err)
+		}
+		return reqs, nil
+	}
+
+	var single models.IngestionRequest
+	if err := json.Unmarshal(trimmed, &single); err == nil {
+		return []models.IngestionRequest{single}, nil
+	}
+
+	var reqs []models.IngestionRequest
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(trimmed))
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var req models.IngestionRequest
+		if err := json.Unmarshal(text, &req); err != nil {
+			return nil, fmt.Errorf("invalid JSON on line %d: %w", line, err)
+		}
+		reqs = append(reqs, req)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return reqs, nil
+}
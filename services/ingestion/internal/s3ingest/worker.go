@@ -0,0 +1,236 @@
+// Package s3ingest imports credential files dropped into an S3-compatible
+// bucket. A worker consumes the bucket's object-created notifications from
+// RabbitMQ, downloads each new object and ingests the requests it contains.
+package s3ingest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/uigs/ingestion/internal/handlers"
+	"github.com/uigs/ingestion/internal/models"
+	"github.com/uigs/ingestion/internal/repository"
+)
+
+// retryDelay spaces out redeliveries of notifications that failed to process.
+const retryDelay = 5 * time.Second
+
+// Ingester runs one request through the ingestion service layer.
+// Rejections wrap handlers.ErrRejected.
+type Ingester interface {
+	Ingest(ctx context.Context, userID, tenantID, idempotencyKey string, req *models.IngestionRequest) (*models.IngestionEvent, error)
+}
+
+// Config controls the worker.
+type Config struct {
+	// Queue receives the bucket's event notifications.
+	Queue string
+	// UserID and TenantID are recorded as the owner of imported events.
+	UserID   string
+	TenantID string
+	// MaxObjectBytes bounds the size of a downloaded object.
+	MaxObjectBytes int64
+}
+
+// Worker consumes object-created notifications and ingests the objects.
+type Worker struct {
+	conn     *amqp.Connection
+	channel  *amqp.Channel
+	client   *Client
+	repo     repository.S3ObjectRepository
+	ingester Ingester
+	cfg      Config
+	logger   *slog.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewWorker connects to RabbitMQ and declares the notification queue.
+func NewWorker(amqpURL string, client *Client, repo repository.S3ObjectRepository, ingester Ingester, cfg Config, logger *slog.Logger) (*Worker, error) {
+	conn, err := amqp.Dial(amqpURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	if _, err := channel.QueueDeclare(cfg.Queue, true, false, false, false, nil); err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare queue: %w", err)
+	}
+	// One notification at a time keeps downloads and ingestion bounded
+	if err := channel.Qos(1, 0, false); err != nil {
+		channel.Close()
+		conn.Close()
+		return nil, fmt.Errorf("failed to set prefetch: %w", err)
+	}
+
+	return &Worker{
+		conn:     conn,
+		channel:  channel,
+		client:   client,
+		repo:     repo,
+		ingester: ingester,
+		cfg:      cfg,
+		logger:   logger,
+	}, nil
+}
+
+// Start consumes notifications in the background until ctx is cancelled or
+// Stop is called.
+func (w *Worker) Start(ctx context.Context) error {
+	deliveries, err := w.channel.Consume(w.cfg.Queue, "", false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", w.cfg.Queue, err)
+	}
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case d, ok := <-deliveries:
+				if !ok {
+					w.logger.Error("S3 notification consumer closed", "queue", w.cfg.Queue)
+					return
+				}
+				w.handle(ctx, d)
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop halts the worker, waits for the notification in progress and closes
+// the connection.
+func (w *Worker) Stop() {
+	w.once.Do(func() {
+		if w.cancel != nil {
+			w.cancel()
+			<-w.done
+		}
+		w.channel.Close()
+		w.conn.Close()
+	})
+}
+
+// handle processes one notification message. It is requeued when an object
+// could not be fetched or stored; objects already handled are skipped on
+// redelivery.
+func (w *Worker) handle(ctx context.Context, d amqp.Delivery) {
+	var n Notification
+	if err := json.Unmarshal(d.Body, &n); err != nil {
+		w.logger.Warn("Discarding malformed S3 notification", "error", err)
+		d.Nack(false, false)
+		return
+	}
+
+	for _, record := range n.Records {
+		if !record.ObjectCreated() {
+			continue
+		}
+		if err := w.processObject(ctx, record); err != nil {
+			w.logger.Error("Failed to import S3 object, will retry", "error", err,
+				"bucket", record.S3.Bucket.Name, "key", record.S3.Object.Key)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+			d.Nack(false, true)
+			return
+		}
+	}
+	d.Ack(false)
+}
+
+// processObject imports one object version unless it was already claimed.
+// It returns an error only for failures worth retrying.
+func (w *Worker) processObject(ctx context.Context, record Record) error {
+	bucket := record.S3.Bucket.Name
+	etag := record.S3.Object.ETag
+	key, err := record.ObjectKey()
+	if err != nil {
+		w.logger.Warn("Skipping S3 object with malformed key", "error", err, "bucket", bucket)
+		return nil
+	}
+
+	claimed, err := w.repo.ClaimS3Object(ctx, bucket, key, etag)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		w.logger.Debug("S3 object already imported", "bucket", bucket, "key", key, "etag", etag)
+		return nil
+	}
+
+	data, err := w.client.GetObject(ctx, bucket, key, w.cfg.MaxObjectBytes)
+	if errors.Is(err, ErrObjectNotFound) || errors.Is(err, ErrObjectTooLarge) {
+		return w.complete(ctx, bucket, key, etag, 0, 0, err.Error())
+	}
+	if err != nil {
+		return w.release(ctx, bucket, key, etag, err)
+	}
+
+	reqs, err := parseFile(data)
+	if err != nil {
+		return w.complete(ctx, bucket, key, etag, 0, 0, err.Error())
+	}
+
+	// Idempotency keys derived from the object version make a retried
+	// import skip the requests it already stored
+	version := sha256.Sum256([]byte(bucket + "/" + key + "#" + etag))
+	prefix := "s3:" + hex.EncodeToString(version[:]) + ":"
+
+	ingested, rejected := 0, 0
+	var firstRejection string
+	for i := range reqs {
+		_, err := w.ingester.Ingest(ctx, w.cfg.UserID, w.cfg.TenantID, fmt.Sprintf("%s%d", prefix, i), &reqs[i])
+		switch {
+		case errors.Is(err, handlers.ErrRejected):
+			rejected++
+			if firstRejection == "" {
+				firstRejection = fmt.Sprintf("item %d: %v", i, err)
+			}
+		case err != nil:
+			return w.release(ctx, bucket, key, etag, err)
+		default:
+			ingested++
+		}
+	}
+
+	w.logger.Info("S3 object imported", "bucket", bucket, "key", key, "ingested", ingested, "rejected", rejected)
+	return w.complete(ctx, bucket, key, etag, ingested, rejected, firstRejection)
+}
+
+func (w *Worker) complete(ctx context.Context, bucket, key, etag string, ingested, rejected int, errMsg string) error {
+	if errMsg != "" && ingested == 0 && rejected == 0 {
+		w.logger.Warn("S3 object could not be imported", "bucket", bucket, "key", key, "error", errMsg)
+	}
+	return w.repo.CompleteS3Object(ctx, bucket, key, etag, ingested, rejected, errMsg)
+}
+
+// release drops the claim so a redelivery retries the object, and returns
+// cause.
+func (w *Worker) release(ctx context.Context, bucket, key, etag string, cause error) error {
+	if err := w.repo.ReleaseS3Object(ctx, bucket, key, etag); err != nil {
+		w.logger.Error("Failed to release S3 object claim", "error", err, "bucket", bucket, "key", key)
+	}
+	return cause
+}
@@ -0,0 +1,86 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// clamChunkSize is the size of the chunks content is streamed to clamd in.
+const clamChunkSize = 64 << 10
+
+// ClamAV scans content with a clamd daemon using the INSTREAM command.
+type ClamAV struct {
+	network string
+	address string
+}
+
+// NewClamAV creates a scanner for the clamd at addr: host:port for TCP, or
+// an absolute path for a Unix socket.
+func NewClamAV(addr string) *ClamAV {
+	network := "tcp"
+	if strings.HasPrefix(addr, "/") {
+		network = "unix"
+	}
+	return &ClamAV{network: network, address: addr}
+}
+
+// Scan streams data to clamd and parses its reply. Content larger than
+// clamd's StreamMaxLength is reported as an error, not as clean.
+func (s *ClamAV) Scan(ctx context.Context, data []byte) (Verdict, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Verdict{}, fmt.Errorf("failed to send clamd command: %w", err)
+	}
+	var size [4]byte
+	for len(data) > 0 {
+		chunk := data[:min(len(data), clamChunkSize)]
+		data = data[len(chunk):]
+		binary.BigEndian.PutUint32(size[:], uint32(len(chunk)))
+		if _, err := conn.Write(size[:]); err != nil {
+			return Verdict{}, fmt.Errorf("failed to stream to clamd: %w", err)
+		}
+		if _, err := conn.Write(chunk); err != nil {
+			return Verdict{}, fmt.Errorf("failed to stream to clamd: %w", err)
+		}
+	}
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := conn.Write(size[:]); err != nil {
+		return Verdict{}, fmt.Errorf("failed to stream to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil {
+		return Verdict{}, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamReply(strings.TrimSuffix(reply, "\x00"))
+}
+
+// parseClamReply interprets a reply such as "stream: OK" or
+// "stream: Eicar-Test-Signature FOUND".
+func parseClamReply(reply string) (Verdict, error) {
+	result := strings.TrimSpace(reply)
+	if i := strings.Index(result, ": "); i >= 0 {
+		result = result[i+2:]
+	}
+	switch {
+	case result == "OK":
+		return Verdict{}, nil
+	case strings.HasSuffix(result, " FOUND"):
+		return Verdict{Infected: true, Signature: strings.TrimSuffix(result, " FOUND")}, nil
+	default:
+		return Verdict{}, fmt.Errorf("clamd: %s", reply)
+	}
+}
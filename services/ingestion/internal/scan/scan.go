@@ -0,0 +1,92 @@
+// Package scan checks uploaded attachment content for malware before it is
+// stored, through a pluggable Scanner such as a ClamAV daemon.
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Scan statuses recorded for each attachment.
+const (
+	StatusClean    = "clean"
+	StatusInfected = "infected"
+	// StatusUnscanned marks content stored without a verdict, because no
+	// scanner is configured or it failed under a fail-open policy.
+	StatusUnscanned = "unscanned"
+)
+
+// ErrUnavailable is returned when the scanner could not give a verdict.
+var ErrUnavailable = errors.New("content scanner unavailable")
+
+// Verdict is a scanner's finding for one piece of content.
+type Verdict struct {
+	Infected bool
+	// Signature names the detected threat.
+	Signature string
+}
+
+// Scanner inspects content for malware.
+type Scanner interface {
+	Scan(ctx context.Context, data []byte) (Verdict, error)
+}
+
+// Result is the recorded outcome of scanning an attachment.
+type Result struct {
+	Status    string    `json:"status"`
+	Signature string    `json:"signature,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+// Guard applies a timeout and a failure policy to a Scanner.
+type Guard struct {
+	scanner  Scanner
+	timeout  time.Duration
+	failOpen bool
+}
+
+// NewGuard creates a guard. A nil scanner marks all content unscanned. With
+// failOpen, content the scanner could not check is accepted as unscanned
+// instead of being refused.
+func NewGuard(scanner Scanner, timeout time.Duration, failOpen bool) *Guard {
+	return &Guard{
+		scanner:  scanner,
+		timeout:  timeout,
+		failOpen: failOpen,
+	}
+}
+
+// Check scans data. It returns an error wrapping ErrUnavailable only when
+// the scanner failed and the guard fails closed.
+func (g *Guard) Check(ctx context.Context, data []byte) (Result, error) {
+	result := Result{Status: StatusUnscanned, ScannedAt: time.Now().UTC()}
+	if g.scanner == nil {
+		return result, nil
+	}
+
+	if g.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.timeout)
+		defer cancel()
+	}
+
+	verdict, err := g.scanner.Scan(ctx, data)
+	result.ScannedAt = time.Now().UTC()
+	if err != nil {
+		if g.failOpen {
+			result.Error = err.Error()
+			return result, nil
+		}
+		return Result{}, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	result.Status = StatusClean
+	if verdict.Infected {
+		result.Status = StatusInfected
+		result.Signature = verdict.Signature
+	}
+	return result, nil
+}
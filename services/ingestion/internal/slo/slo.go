@@ -0,0 +1,150 @@
+// Package slo tracks ingestion latency against a service level objective.
+package slo
+
+import (
+	"sync"
+	"time"
+)
+
+// bucketCount is the number of slots the sliding window is divided into.
+const bucketCount = 60
+
+// Stage names used to attribute an SLO breach.
+const (
+	StageDBWrite = "db_write"
+	StagePublish = "publish"
+)
+
+type bucket struct {
+	start           int64 // bucket start, in units of bucket width since the epoch
+	total           int64
+	met             int64
+	dbBreaches      int64
+	publishBreaches int64
+	dbNanos         int64
+	publishNanos    int64
+}
+
+// Tracker records whether each ingestion met the latency target and keeps
+// the results for a sliding window.
+type Tracker struct {
+	mu      sync.Mutex
+	target  time.Duration
+	window  time.Duration
+	width   time.Duration
+	buckets [bucketCount]bucket
+	now     func() time.Time
+}
+
+// NewTracker creates a tracker for the given target latency and window.
+func NewTracker(target, window time.Duration) *Tracker {
+	width := window / bucketCount
+	if width <= 0 {
+		width = time.Second
+	}
+	return &Tracker{
+		target: target,
+		window: window,
+		width:  width,
+		now:    time.Now,
+	}
+}
+
+// Record adds one completed ingestion. total is the end-to-end handler
+// latency; db and publish are the time spent in each stage. When the total
+// misses the target, the breach is attributed to the slower stage.
+func (t *Tracker) Record(total, db, publish time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.bucketLocked(t.now())
+	b.total++
+	b.dbNanos += int64(db)
+	b.publishNanos += int64(publish)
+
+	if total <= t.target {
+		b.met++
+		return
+	}
+	if db >= publish {
+		b.dbBreaches++
+	} else {
+		b.publishBreaches++
+	}
+}
+
+// StageBreakdown summarizes one stage over the window.
+type StageBreakdown struct {
+	Breaches         int64   `json:"breaches"`
+	AvgLatencyMillis float64 `json:"avg_latency_ms"`
+}
+
+// Snapshot is the SLO compliance over the current window.
+type Snapshot struct {
+	Target       string                    `json:"target"`
+	Window       string                    `json:"window"`
+	Total        int64                     `json:"total"`
+	Met          int64                     `json:"met"`
+	SuccessRatio float64                   `json:"success_ratio"`
+	Stages       map[string]StageBreakdown `json:"stages"`
+}
+
+// Snapshot returns the compliance over the sliding window. An empty window
+// reports a success ratio of 1.
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var sum bucket
+	oldest := t.now().UnixNano()/int64(t.width) - bucketCount + 1
+	for _, b := range t.buckets {
+		if b.start < oldest {
+			continue
+		}
+		sum.total += b.total
+		sum.met += b.met
+		sum.dbBreaches += b.dbBreaches
+		sum.publishBreaches += b.publishBreaches
+		sum.dbNanos += b.dbNanos
+		sum.publishNanos += b.publishNanos
+	}
+
+	snap := Snapshot{
+		Target:       t.target.String(),
+		Window:       t.window.String(),
+		Total:        sum.total,
+		Met:          sum.met,
+		SuccessRatio: 1,
+		Stages: map[string]StageBreakdown{
+			StageDBWrite: {Breaches: sum.dbBreaches},
+			StagePublish: {Breaches: sum.publishBreaches},
+		},
+	}
+	if sum.total > 0 {
+		snap.SuccessRatio = float64(sum.met) / float64(sum.total)
+		snap.Stages[StageDBWrite] = StageBreakdown{
+			Breaches:         sum.dbBreaches,
+			AvgLatencyMillis: nanosToMillis(sum.dbNanos / sum.total),
+		}
+		snap.Stages[StagePublish] = StageBreakdown{
+			Breaches:         sum.publishBreaches,
+			AvgLatencyMillis: nanosToMillis(sum.publishNanos / sum.total),
+		}
+	}
+	return snap
+}
+
+// bucketLocked returns the bucket for now, resetting it if it holds data
+// from an earlier lap of the ring. Callers must hold t.mu.
+func (t *Tracker) bucketLocked(now time.Time) *bucket {
+	slot := now.UnixNano() / int64(t.width)
+	b := &t.buckets[slot%bucketCount]
+	if b.start != slot {
+		*b = bucket{start: slot}
+	}
+	return b
+}
+
+func nanosToMillis(n int64) float64 {
+	return float64(n) / float64(time.Millisecond)
+}
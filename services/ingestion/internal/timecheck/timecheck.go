@@ -0,0 +1,61 @@
+// Package timecheck applies a single clock-skew tolerance to every
+// time-based validation, so a slightly fast or slow issuer clock does not
+// cause spurious rejections.
+package timecheck
+
+import "time"
+
+// Clock evaluates timestamps against the current time with a fixed skew
+// tolerance. A timestamp exactly at the skew boundary is accepted.
+type Clock struct {
+	skew time.Duration
+	now  func() time.Time
+}
+
+// New creates a clock tolerating the given skew in either direction.
+func New(skew time.Duration) Clock {
+	return Clock{skew: skew, now: time.Now}
+}
+
+// WithNow returns a copy of the clock that reads the current time from now,
+// such as a fixed time in tests.
+func (c Clock) WithNow(now func() time.Time) Clock {
+	c.now = now
+	return c
+}
+
+// Skew returns the configured tolerance.
+func (c Clock) Skew() time.Duration {
+	return c.skew
+}
+
+// Now returns the current time.
+func (c Clock) Now() time.Time {
+	if c.now == nil {
+		return time.Now()
+	}
+	return c.now()
+}
+
+// Expired reports whether an expiry time (exp, expirationDate, validUntil)
+// has passed by more than the skew.
+func (c Clock) Expired(expiresAt time.Time) bool {
+	return c.Now().After(expiresAt.Add(c.skew))
+}
+
+// NotYetValid reports whether a start time (nbf, validFrom) is still more
+// than the skew in the future.
+func (c Clock) NotYetValid(validFrom time.Time) bool {
+	return c.Now().Add(c.skew).Before(validFrom)
+}
+
+// InFuture reports whether a creation time (iat, issuanceDate, proof
+// created) lies more than the skew in the future.
+func (c Clock) InFuture(t time.Time) bool {
+	return c.NotYetValid(t)
+}
+
+// OlderThan reports whether t lies more than maxAge plus the skew in the past.
+func (c Clock) OlderThan(t time.Time, maxAge time.Duration) bool {
+	return c.Now().Sub(t) > maxAge+c.skew
+}
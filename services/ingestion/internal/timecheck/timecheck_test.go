@@ -0,0 +1,54 @@
+package timecheck
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockSkewBoundaries(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	const skew = 5 * time.Minute
+	clock := Clock{skew: skew, now: func() time.Time { return now }}
+
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{name: "expired well within skew", got: clock.Expired(now.Add(-time.Minute)), want: false},
+		{name: "expired exactly at skew", got: clock.Expired(now.Add(-skew)), want: false},
+		{name: "expired just past skew", got: clock.Expired(now.Add(-skew - time.Second)), want: true},
+		{name: "not yet valid exactly at skew", got: clock.NotYetValid(now.Add(skew)), want: false},
+		{name: "not yet valid just past skew", got: clock.NotYetValid(now.Add(skew + time.Second)), want: true},
+		{name: "in future exactly at skew", got: clock.InFuture(now.Add(skew)), want: false},
+		{name: "in future just past skew", got: clock.InFuture(now.Add(skew + time.Second)), want: true},
+		{name: "in past is not in future", got: clock.InFuture(now.Add(-time.Hour)), want: false},
+		{name: "older than exactly max age plus skew", got: clock.OlderThan(now.Add(-time.Hour-skew), time.Hour), want: false},
+		{name: "older than just past max age plus skew", got: clock.OlderThan(now.Add(-time.Hour-skew-time.Second), time.Hour), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClockWithoutSkew(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := Clock{now: func() time.Time { return now }}
+
+	if clock.Expired(now) {
+		t.Error("a credential expiring now is expired")
+	}
+	if !clock.Expired(now.Add(-time.Nanosecond)) {
+		t.Error("a credential that just expired is not expired")
+	}
+	if !clock.NotYetValid(now.Add(time.Nanosecond)) {
+		t.Error("a credential valid from the next instant is valid")
+	}
+	if New(time.Minute).Skew() != time.Minute {
+		t.Error("Skew does not return the configured tolerance")
+	}
+}
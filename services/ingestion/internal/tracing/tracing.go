@@ -0,0 +1,103 @@
+// Package tracing records distributed traces of ingestions with
+// OpenTelemetry and exports them to a collector over OTLP/HTTP. Trace
+// context is propagated in the W3C Trace Context format, so traces continue
+// across the traceparent header of incoming requests and of published queue
+// messages.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the instrumentation scope of the service's spans.
+const instrumentationName = "github.com/uigs/ingestion"
+
+// Propagator carries trace context in W3C traceparent headers. It is used
+// whether or not tracing is enabled, so an incoming trace is passed on to
+// published messages either way.
+var Propagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// Config controls tracing.
+type Config struct {
+	// Endpoint is the collector's OTLP/HTTP base URL, such as
+	// http://otel-collector:4318. Spans are posted to Endpoint/v1/traces.
+	Endpoint string
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+	// ExportInterval is the longest time a finished span waits for export.
+	ExportInterval time.Duration
+	// ExportTimeout bounds each export request.
+	ExportTimeout time.Duration
+}
+
+// Init installs the process-wide tracer provider exporting to cfg.Endpoint
+// and returns a function that flushes pending spans and shuts it down.
+func Init(cfg Config, logger *slog.Logger) (func(context.Context) error, error) {
+	if cfg.Endpoint == "" {
+		return nil, errors.New("an OTLP endpoint is required for tracing")
+	}
+	if cfg.ExportInterval <= 0 {
+		cfg.ExportInterval = 5 * time.Second
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(strings.TrimSuffix(cfg.Endpoint, "/") + "/v1/traces")}
+	if cfg.ExportTimeout > 0 {
+		opts = append(opts, otlptracehttp.WithTimeout(cfg.ExportTimeout))
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(cfg.ExportInterval)),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(Propagator)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("Failed to export spans", "error", err)
+	}))
+
+	return provider.Shutdown, nil
+}
+
+// Start begins a span named name as a child of ctx's current span, or as the
+// root of a new trace, and returns ctx carrying it. While tracing is
+// disabled the span records nothing but still carries the incoming trace.
+func Start(ctx context.Context, name string, kind trace.SpanKind) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithSpanKind(kind))
+}
+
+// RecordError marks span as failed with err. A nil err is ignored.
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Inject sets the trace context of ctx with set, such as a message header
+// setter. Nothing is set when ctx carries no span.
+func Inject(ctx context.Context, set func(key, value string)) {
+	carrier := propagation.MapCarrier{}
+	Propagator.Inject(ctx, carrier)
+	for key, value := range carrier {
+		set(key, value)
+	}
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const incoming = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestInject(t *testing.T) {
+	tests := []struct {
+		name        string
+		traceparent string
+		wantTrace   string
+	}{
+		{name: "continues the incoming trace", traceparent: incoming, wantTrace: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{name: "malformed header starts none", traceparent: "00-zz-00f067aa0ba902b7-01"},
+		{name: "no header starts none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := Propagator.Extract(context.Background(), propagation.MapCarrier{"traceparent": tt.traceparent})
+			ctx, span := Start(ctx, "publish", trace.SpanKindProducer)
+			defer span.End()
+
+			headers := map[string]string{}
+			Inject(ctx, func(key, value string) { headers[key] = value })
+			got := headers["traceparent"]
+			if tt.wantTrace == "" {
+				if got != "" {
+					t.Fatalf("traceparent = %q, want none", got)
+				}
+				return
+			}
+			if len(got) != len(incoming) || got[3:35] != tt.wantTrace {
+				t.Fatalf("traceparent = %q, want trace %s", got, tt.wantTrace)
+			}
+		})
+	}
+}
+
+func TestStartRecordsChildSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	ctx := Propagator.Extract(context.Background(), propagation.MapCarrier{"traceparent": incoming})
+	ctx, parent := Start(ctx, "POST /api/v1/ingest", trace.SpanKindServer)
+	_, child := Start(ctx, "INSERT ingestion_events", trace.SpanKindClient)
+	RecordError(child, errors.New("connection refused"))
+	RecordError(child, nil)
+	child.End()
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	insert, server := spans[0], spans[1]
+	if server.Parent().SpanID().String() != "00f067aa0ba902b7" || !server.Parent().IsRemote() {
+		t.Errorf("server span parent = %v, want the incoming span", server.Parent())
+	}
+	if insert.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("insert span parent = %v, want the server span", insert.Parent().SpanID())
+	}
+	if insert.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("insert span trace = %v", insert.SpanContext().TraceID())
+	}
+	if insert.Status().Code != codes.Error || len(insert.Events()) != 1 {
+		t.Errorf("insert span status = %v with %d events, want one recorded error", insert.Status(), len(insert.Events()))
+	}
+	if server.Status().Code == codes.Error {
+		t.Error("server span was marked failed")
+	}
+}
@@ -0,0 +1,85 @@
+// Package ttlcache provides a size-capped cache whose entries expire, for
+// documents fetched from URLs that callers choose.
+package ttlcache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// Cache holds up to a fixed number of entries, evicting the least recently
+// used one to make room. It is safe for concurrent use.
+type Cache[V any] struct {
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// New creates a cache of at most maxEntries entries.
+func New[V any](maxEntries int) *Cache[V] {
+	return &Cache[V]{
+		maxEntries: maxEntries,
+		now:        time.Now,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the unexpired value stored under key.
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	e := elem.Value.(*entry[V])
+	if !c.now().Before(e.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return zero, false
+	}
+	c.order.MoveToFront(elem)
+	return e.value, true
+}
+
+// Set stores value under key for ttl. A ttl of zero or less stores nothing.
+func (c *Cache[V]) Set(key string, value V, ttl time.Duration) {
+	if ttl <= 0 || c.maxEntries <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e := &entry[V]{key: key, value: value, expiresAt: c.now().Add(ttl)}
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = e
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(e)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry[V]).key)
+	}
+}
+
+// Len returns the number of entries held, expired ones included.
+func (c *Cache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
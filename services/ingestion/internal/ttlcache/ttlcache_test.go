@@ -0,0 +1,80 @@
+package ttlcache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		run  func(c *Cache[int])
+		want map[string]int
+	}{
+		{
+			name: "get before expiry",
+			run:  func(c *Cache[int]) { c.Set("a", 1, time.Minute) },
+			want: map[string]int{"a": 1},
+		},
+		{
+			name: "expired",
+			run: func(c *Cache[int]) {
+				c.Set("a", 1, time.Minute)
+				now = now.Add(time.Minute)
+			},
+			want: map[string]int{},
+		},
+		{
+			name: "zero TTL stores nothing",
+			run:  func(c *Cache[int]) { c.Set("a", 1, 0) },
+			want: map[string]int{},
+		},
+		{
+			name: "overwrite",
+			run: func(c *Cache[int]) {
+				c.Set("a", 1, time.Minute)
+				c.Set("a", 2, time.Minute)
+			},
+			want: map[string]int{"a": 2},
+		},
+		{
+			name: "evicts the least recently used",
+			run: func(c *Cache[int]) {
+				c.Set("a", 1, time.Minute)
+				c.Set("b", 2, time.Minute)
+				c.Set("c", 3, time.Minute)
+				c.Get("a")
+				c.Set("d", 4, time.Minute)
+			},
+			want: map[string]int{"a": 1, "c": 3, "d": 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New[int](3)
+			c.now = func() time.Time { return now }
+			tt.run(c)
+
+			for _, key := range []string{"a", "b", "c", "d"} {
+				got, ok := c.Get(key)
+				want, wantOK := tt.want[key]
+				if ok != wantOK || got != want {
+					t.Errorf("Get(%q) = %d, %v; want %d, %v", key, got, ok, want, wantOK)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheBounded(t *testing.T) {
+	c := New[int](100)
+	for i := 0; i < 10000; i++ {
+		c.Set(fmt.Sprintf("https://attacker.example/%d", i), i, time.Hour)
+	}
+	if c.Len() != 100 {
+		t.Errorf("Len() = %d, want 100", c.Len())
+	}
+}
@@ -0,0 +1,251 @@
+package validation
+
+import (
+	"log/slog"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// maxSchemaDepth bounds how deep schemas are followed, which also stops
+// recursive $refs.
+const maxSchemaDepth = 16
+
+// DriftConfig controls schema drift detection.
+type DriftConfig struct {
+	// SampleRate is the fraction of valid payloads inspected, from 0 to 1.
+	SampleRate float64
+	// MaxFields bounds the number of distinct undeclared fields recorded per
+	// source type.
+	MaxFields int
+}
+
+// DriftField is a field path seen in payloads but not declared in the
+// source type's schema. Array elements are written as "[]".
+type DriftField struct {
+	Path      string    `json:"path"`
+	Count     int64     `json:"count"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// DriftReport summarizes drift for one source type.
+type DriftReport struct {
+	SourceType models.SourceType `json:"source_type"`
+	Sampled    int64             `json:"sampled"`
+	Drifted    int64             `json:"drifted"`
+	Fields     []DriftField      `json:"fields"`
+	// Truncated is set once MaxFields was reached and new fields are no
+	// longer recorded.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// fieldTree is the set of field paths a schema declares. A node without
+// declared properties, or whose properties are open-ended, is not checked.
+type fieldTree struct {
+	props map[string]*fieldTree
+	items *fieldTree
+	open  bool
+}
+
+type driftState struct {
+	sampled   int64
+	drifted   int64
+	fields    map[string]*DriftField
+	truncated bool
+}
+
+// DriftDetector samples payloads that passed validation and records field
+// paths their schema does not declare, so operators learn about new producer
+// fields before deciding whether to add them to the schema.
+type DriftDetector struct {
+	cfg    DriftConfig
+	logger *slog.Logger
+	trees  map[models.SourceType]*fieldTree
+
+	mu     sync.Mutex
+	states map[models.SourceType]*driftState
+}
+
+// NewDriftDetector creates a detector for the loaded schemas.
+func NewDriftDetector(schemas *Schemas, cfg DriftConfig, logger *slog.Logger) *DriftDetector {
+	d := &DriftDetector{
+		cfg:    cfg,
+		logger: logger,
+		trees:  make(map[models.SourceType]*fieldTree, len(schemas.bySource)),
+		states: make(map[models.SourceType]*driftState, len(schemas.bySource)),
+	}
+	for sourceType, schema := range schemas.bySource {
+		tree := &fieldTree{}
+		tree.add(schema, 0)
+		d.trees[sourceType] = tree
+		d.states[sourceType] = &driftState{fields: make(map[string]*DriftField)}
+	}
+	return d
+}
+
+// Observe inspects a sample of payloads for undeclared fields. Each field is
+// logged the first time it is seen.
+func (d *DriftDetector) Observe(sourceType models.SourceType, payload map[string]interface{}) {
+	tree, ok := d.trees[sourceType]
+	if !ok || rand.Float64() >= d.cfg.SampleRate {
+		return
+	}
+
+	var unknown []string
+	tree.unknown("", payload, &unknown)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state := d.states[sourceType]
+	state.sampled++
+	if len(unknown) == 0 {
+		return
+	}
+	state.drifted++
+
+	now := time.Now().UTC()
+	counted := make(map[string]bool, len(unknown))
+	for _, path := range unknown {
+		// Elements of one array report the same path; count it once
+		if counted[path] {
+			continue
+		}
+		counted[path] = true
+
+		if field, ok := state.fields[path]; ok {
+			field.Count++
+			field.LastSeen = now
+			continue
+		}
+		if len(state.fields) >= d.cfg.MaxFields {
+			state.truncated = true
+			continue
+		}
+		state.fields[path] = &DriftField{Path: path, Count: 1, FirstSeen: now, LastSeen: now}
+		d.logger.Warn("Schema drift: payload field not in schema",
+			"source_type", sourceType,
+			"field", path,
+		)
+	}
+}
+
+// Report returns the drift recorded for each source type with a schema,
+// fields sorted by path.
+func (d *DriftDetector) Report() []DriftReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	reports := make([]DriftReport, 0, len(d.states))
+	for sourceType, state := range d.states {
+		report := DriftReport{
+			SourceType: sourceType,
+			Sampled:    state.sampled,
+			Drifted:    state.drifted,
+			Fields:     make([]DriftField, 0, len(state.fields)),
+			Truncated:  state.truncated,
+		}
+		for _, field := range state.fields {
+			report.Fields = append(report.Fields, *field)
+		}
+		sort.Slice(report.Fields, func(i, j int) bool { return report.Fields[i].Path < report.Fields[j].Path })
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].SourceType < reports[j].SourceType })
+	return reports
+}
+
+// add merges the fields declared by s, including through references and
+// composition keywords, into t.
+func (t *fieldTree) add(s *jsonschema.Schema, depth int) {
+	if s == nil || depth > maxSchemaDepth {
+		t.open = true
+		return
+	}
+
+	for name, sub := range s.Properties {
+		if t.props == nil {
+			t.props = make(map[string]*fieldTree)
+		}
+		child, ok := t.props[name]
+		if !ok {
+			child = &fieldTree{}
+			t.props[name] = child
+		}
+		child.add(sub, depth+1)
+	}
+	if len(s.PatternProperties) > 0 {
+		t.open = true
+	}
+	if _, ok := s.AdditionalProperties.(*jsonschema.Schema); ok {
+		t.open = true
+	}
+
+	switch items := s.Items.(type) {
+	case *jsonschema.Schema:
+		t.addItems(items, depth)
+	case []*jsonschema.Schema:
+		for _, item := range items {
+			t.addItems(item, depth)
+		}
+	}
+	for _, item := range s.PrefixItems {
+		t.addItems(item, depth)
+	}
+	if s.Items2020 != nil {
+		t.addItems(s.Items2020, depth)
+	}
+
+	for _, ref := range []*jsonschema.Schema{s.Ref, s.RecursiveRef, s.DynamicRef, s.Then, s.Else} {
+		if ref != nil {
+			t.add(ref, depth+1)
+		}
+	}
+	for _, group := range [][]*jsonschema.Schema{s.AllOf, s.AnyOf, s.OneOf} {
+		for _, sub := range group {
+			t.add(sub, depth+1)
+		}
+	}
+}
+
+func (t *fieldTree) addItems(s *jsonschema.Schema, depth int) {
+	if t.items == nil {
+		t.items = &fieldTree{}
+	}
+	t.items.add(s, depth+1)
+}
+
+// unknown appends the paths under value that t does not declare. It does
+// not descend below an undeclared field.
+func (t *fieldTree) unknown(path string, value interface{}, out *[]string) {
+	if t == nil {
+		return
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if t.open || len(t.props) == 0 {
+			return
+		}
+		for name, child := range v {
+			childPath := name
+			if path != "" {
+				childPath = path + "." + name
+			}
+			sub, ok := t.props[name]
+			if !ok {
+				*out = append(*out, childPath)
+				continue
+			}
+			sub.unknown(childPath, child, out)
+		}
+	case []interface{}:
+		for _, item := range v {
+			t.items.unknown(path+"[]", item, out)
+		}
+	}
+}
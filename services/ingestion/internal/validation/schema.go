@@ -0,0 +1,172 @@
+// Package validation provides JSON Schema validation of ingestion payloads.
+package validation
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/uigs/ingestion/internal/models"
+)
+
+// Schema load modes decide what happens when schemas fail to load at startup.
+const (
+	// LoadModeStrict refuses to start the service.
+	LoadModeStrict = "strict"
+	// LoadModeLenient starts the service with validation disabled.
+	LoadModeLenient = "lenient"
+)
+
+// ValidationError describes why a payload failed schema validation.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// builtin holds the schemas used when no schema directory is configured.
+//
+//go:embed schemas/*.json
+var builtin embed.FS
+
+// sourceTypes are the source types that can have a schema.
+var sourceTypes = []models.SourceType{models.SourceTypeVC, models.SourceTypeOIDC, models.SourceTypeManual}
+
+// Schemas holds the compiled payload schema for each source type.
+type Schemas struct {
+	bySource map[models.SourceType]*jsonschema.Schema
+}
+
+// LoadDir compiles the schemas found in dir. Each source type is described by
+// a file named after it in lower case, e.g. vc.json, oidc.json, manual.json.
+// Source types without a schema file are not validated.
+func LoadDir(dir string) (*Schemas, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("schema path %q is not a directory", dir)
+	}
+
+	schemas, err := compile(os.DirFS(dir), filepath.ToSlash(dir))
+	if err != nil {
+		return nil, err
+	}
+	if len(schemas.bySource) == 0 {
+		return nil, fmt.Errorf("no schemas found in %q", dir)
+	}
+	return schemas, nil
+}
+
+// Builtin compiles the schemas embedded in the binary: a VC needs
+// @context, type and a credentialSubject (or, for a presentation, its
+// verifiableCredential); an OIDC payload an id_token or the iss and sub
+// claims; a MANUAL payload a non-empty attributes object.
+func Builtin() (*Schemas, error) {
+	sub, err := fs.Sub(builtin, "schemas")
+	if err != nil {
+		return nil, err
+	}
+	return compile(sub, "builtin")
+}
+
+// compile compiles the schema file of each source type found in fsys.
+// Resources are named under base, so errors point at the right file.
+func compile(fsys fs.FS, base string) (*Schemas, error) {
+	compiler := jsonschema.NewCompiler()
+	schemas := &Schemas{bySource: make(map[models.SourceType]*jsonschema.Schema)}
+
+	for _, sourceType := range sourceTypes {
+		name := strings.ToLower(string(sourceType)) + ".json"
+		f, err := fsys.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema for %s: %w", sourceType, err)
+		}
+		url := base + "/" + name
+		err = compiler.AddResource(url, f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema for %s: %w", sourceType, err)
+		}
+
+		schema, err := compiler.Compile(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile schema for %s: %w", sourceType, err)
+		}
+		schemas.bySource[sourceType] = schema
+	}
+	return schemas, nil
+}
+
+// builtinSchemas compiles the embedded schemas once, on first use.
+var builtinSchemas = sync.OnceValues(Builtin)
+
+// ValidatePayload checks the payload against the built-in schema for
+// sourceType. It returns a *ValidationError identifying the offending field
+// on failure.
+func ValidatePayload(sourceType models.SourceType, payload map[string]interface{}) error {
+	schemas, err := builtinSchemas()
+	if err != nil {
+		return fmt.Errorf("failed to load built-in schemas: %w", err)
+	}
+	return schemas.Validate(sourceType, payload)
+}
+
+// Validate checks the payload against the schema registered for sourceType.
+// It returns a *ValidationError identifying the offending field on failure.
+func (s *Schemas) Validate(sourceType models.SourceType, payload map[string]interface{}) error {
+	schema, ok := s.bySource[sourceType]
+	if !ok {
+		return nil
+	}
+
+	err := schema.Validate(payload)
+	if err == nil {
+		return nil
+	}
+
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return fmt.Errorf("failed to validate payload: %w", err)
+	}
+
+	leaf := verr
+	for len(leaf.Causes) > 0 {
+		leaf = leaf.Causes[0]
+	}
+	field := leaf.InstanceLocation
+	// A missing property is reported on its parent; point at the property
+	if strings.HasSuffix(leaf.KeywordLocation, "/required") {
+		if _, missing, ok := strings.Cut(leaf.Message, "'"); ok {
+			if name, _, ok := strings.Cut(missing, "'"); ok {
+				field += "/" + name
+			}
+		}
+	}
+	if field == "" {
+		field = "/"
+	}
+	return &ValidationError{Field: field, Message: leaf.Message}
+}
+
+// LoadStatus records the outcome of loading schemas at startup.
+type LoadStatus struct {
+	Enabled bool   `json:"enabled"`
+	Loaded  bool   `json:"loaded"`
+	Mode    string `json:"mode"`
+	Dir     string `json:"dir,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
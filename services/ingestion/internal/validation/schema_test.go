@@ -0,0 +1,163 @@
+package validation
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uigs/ingestion/internal/models"
+)
+
+func TestValidatePayload(t *testing.T) {
+	tests := []struct {
+		name       string
+		sourceType models.SourceType
+		payload    map[string]interface{}
+		wantField  string
+	}{
+		{
+			name:       "VC",
+			sourceType: models.SourceTypeVC,
+			payload: map[string]interface{}{
+				"@context":          []interface{}{"https://www.w3.org/2018/credentials/v1"},
+				"type":              []interface{}{"VerifiableCredential"},
+				"credentialSubject": map[string]interface{}{"id": "did:example:alice"},
+			},
+		},
+		{
+			name:       "VP",
+			sourceType: models.SourceTypeVC,
+			payload: map[string]interface{}{
+				"@context":             "https://www.w3.org/2018/credentials/v1",
+				"type":                 "VerifiablePresentation",
+				"verifiableCredential": []interface{}{map[string]interface{}{}},
+			},
+		},
+		{
+			name:       "VC without @context",
+			sourceType: models.SourceTypeVC,
+			payload: map[string]interface{}{
+				"type":              "VerifiableCredential",
+				"credentialSubject": map[string]interface{}{},
+			},
+			wantField: "/@context",
+		},
+		{
+			name:       "VC without a subject",
+			sourceType: models.SourceTypeVC,
+			payload: map[string]interface{}{
+				"@context": "https://www.w3.org/2018/credentials/v1",
+				"type":     "VerifiableCredential",
+			},
+			wantField: "/credentialSubject",
+		},
+		{
+			name:       "VC type of the wrong kind",
+			sourceType: models.SourceTypeVC,
+			payload: map[string]interface{}{
+				"@context":          "https://www.w3.org/2018/credentials/v1",
+				"type":              42,
+				"credentialSubject": map[string]interface{}{},
+			},
+			wantField: "/type",
+		},
+		{
+			name:       "OIDC id_token",
+			sourceType: models.SourceTypeOIDC,
+			payload:    map[string]interface{}{"id_token": "eyJhbGciOiJSUzI1NiJ9.e30.sig"},
+		},
+		{
+			name:       "OIDC claims",
+			sourceType: models.SourceTypeOIDC,
+			payload:    map[string]interface{}{"iss": "https://issuer.example", "sub": "alice"},
+		},
+		{
+			name:       "OIDC claims without sub",
+			sourceType: models.SourceTypeOIDC,
+			payload:    map[string]interface{}{"iss": "https://issuer.example"},
+			wantField:  "/id_token",
+		},
+		{
+			name:       "OIDC empty id_token",
+			sourceType: models.SourceTypeOIDC,
+			payload:    map[string]interface{}{"id_token": ""},
+			wantField:  "/id_token",
+		},
+		{
+			name:       "MANUAL",
+			sourceType: models.SourceTypeManual,
+			payload:    map[string]interface{}{"attributes": map[string]interface{}{"name": "Alice"}},
+		},
+		{
+			name:       "MANUAL without attributes",
+			sourceType: models.SourceTypeManual,
+			payload:    map[string]interface{}{"name": "Alice"},
+			wantField:  "/attributes",
+		},
+		{
+			name:       "MANUAL with empty attributes",
+			sourceType: models.SourceTypeManual,
+			payload:    map[string]interface{}{"attributes": map[string]interface{}{}},
+			wantField:  "/attributes",
+		},
+		{
+			name:       "unknown source type is not validated",
+			sourceType: models.SourceType("SAML"),
+			payload:    map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePayload(tt.sourceType, tt.payload)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("ValidatePayload() error = %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("ValidatePayload() error = %v, want a *ValidationError", err)
+			}
+			if verr.Field != tt.wantField {
+				t.Errorf("field = %q, want %q (%s)", verr.Field, tt.wantField, verr.Message)
+			}
+		})
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr bool
+	}{
+		{name: "one schema", files: map[string]string{"manual.json": `{"type":"object","required":["name"]}`}},
+		{name: "no schemas", files: map[string]string{"other.json": `{}`}, wantErr: true},
+		{name: "invalid schema", files: map[string]string{"vc.json": `{"type":`}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			schemas, err := LoadDir(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadDir() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if err := schemas.Validate(models.SourceTypeManual, map[string]interface{}{}); err == nil {
+				t.Error("payload without name passed the loaded schema")
+			}
+			if err := schemas.Validate(models.SourceTypeVC, map[string]interface{}{}); err != nil {
+				t.Errorf("VC without a schema file failed validation: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Package vcmodel detects the W3C VC Data Model version of a credential or
+// presentation and converts it between versions, so consumers see a single
+// canonical shape whichever version the issuer used.
+package vcmodel
+
+import "fmt"
+
+// Data model versions.
+const (
+	V1 = "1.1"
+	V2 = "2.0"
+)
+
+// Base contexts that identify each version. They must be the first entry
+// of @context.
+const (
+	ContextV1 = "https://www.w3.org/2018/credentials/v1"
+	ContextV2 = "https://www.w3.org/ns/credentials/v2"
+)
+
+// renamed lists properties that differ between versions, as v1 name to v2 name.
+var renamed = [][2]string{
+	{"issuanceDate", "validFrom"},
+	{"expirationDate", "validUntil"},
+}
+
+// Detect returns the data model version declared by the payload's
+// @context, or "" if it declares neither.
+func Detect(payload map[string]interface{}) string {
+	switch baseContext(payload) {
+	case ContextV1:
+		return V1
+	case ContextV2:
+		return V2
+	}
+	return ""
+}
+
+// Convert returns a copy of payload in the target version, converting any
+// embedded credentials of a presentation as well. Payloads of an unknown
+// version, or already in the target version, are returned unchanged with
+// converted false. The input is never modified.
+func Convert(payload map[string]interface{}, target string) (out map[string]interface{}, converted bool, err error) {
+	if target != V1 && target != V2 {
+		return nil, false, fmt.Errorf("unsupported data model version %q", target)
+	}
+	source := Detect(payload)
+	if source == "" || source == target {
+		return payload, false, nil
+	}
+	return convert(payload, target), true, nil
+}
+
+func convert(payload map[string]interface{}, target string) map[string]interface{} {
+	out := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		out[k] = v
+	}
+
+	out["@context"] = withBaseContext(payload["@context"], target)
+	for _, names := range renamed {
+		from, to := names[0], names[1]
+		if target == V1 {
+			from, to = to, from
+		}
+		if v, ok := out[from]; ok {
+			delete(out, from)
+			if _, exists := out[to]; !exists {
+				out[to] = v
+			}
+		}
+	}
+
+	switch vc := payload["verifiableCredential"].(type) {
+	case map[string]interface{}:
+		out["verifiableCredential"] = convertEmbedded(vc, target)
+	case []interface{}:
+		items := make([]interface{}, len(vc))
+		for i, item := range vc {
+			if m, ok := item.(map[string]interface{}); ok {
+				items[i] = convertEmbedded(m, target)
+			} else {
+				items[i] = item
+			}
+		}
+		out["verifiableCredential"] = items
+	}
+	return out
+}
+
+// convertEmbedded converts an embedded credential only if it declares a
+// different known version.
+func convertEmbedded(vc map[string]interface{}, target string) map[string]interface{} {
+	if source := Detect(vc); source == "" || source == target {
+		return vc
+	}
+	return convert(vc, target)
+}
+
+func baseContext(payload map[string]interface{}) string {
+	switch ctx := payload["@context"].(type) {
+	case string:
+		return ctx
+	case []interface{}:
+		if len(ctx) > 0 {
+			first, _ := ctx[0].(string)
+			return first
+		}
+	}
+	return ""
+}
+
+// withBaseContext replaces the base context with the target version's,
+// keeping any additional contexts.
+func withBaseContext(ctx interface{}, target string) interface{} {
+	base := ContextV2
+	if target == V1 {
+		base = ContextV1
+	}
+	switch c := ctx.(type) {
+	case string:
+		return base
+	case []interface{}:
+		out := make([]interface{}, len(c))
+		copy(out, c)
+		if len(out) > 0 {
+			out[0] = base
+		}
+		return out
+	}
+	return ctx
+}
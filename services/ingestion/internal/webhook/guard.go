@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/uigs/ingestion/internal/netguard"
+)
+
+// ErrForbiddenDestination is returned for a webhook URL that points at a
+// private, loopback or otherwise internal address.
+var ErrForbiddenDestination = netguard.ErrForbiddenDestination
+
+// ValidateURL checks that raw is an absolute http(s) URL whose host
+// resolves only to public addresses. With allowPrivate, internal addresses
+// are accepted, which is meant for local development only.
+func ValidateURL(ctx context.Context, raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("unsupported webhook URL scheme %q", u.Scheme)
+	}
+	if u.Hostname() == "" || u.User != nil {
+		return errors.New("webhook URL must have a host and no credentials")
+	}
+	if allowPrivate {
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to resolve webhook host: %w", err)
+	}
+	for _, addr := range addrs {
+		if !netguard.IsPublic(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenDestination, u.Hostname(), addr.IP)
+		}
+	}
+	return nil
+}
+
+// ValidateCallbackURL checks a per-request callback URL like ValidateURL,
+// and also requires https, since the callback carries the ingestion result.
+func ValidateCallbackURL(ctx context.Context, raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid callback URL: %w", err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("callback URL must use https, not %q", u.Scheme)
+	}
+	return ValidateURL(ctx, raw, allowPrivate)
+}
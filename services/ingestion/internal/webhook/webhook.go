@@ -0,0 +1,320 @@
+// Package webhook delivers signed event notifications to user-configured
+// HTTP endpoints, with retries and protection against requests to internal
+// addresses.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uigs/ingestion/internal/netguard"
+)
+
+// Headers set on every delivery.
+const (
+	EventHeader     = "X-UIGS-Event"
+	DeliveryHeader  = "X-UIGS-Delivery"
+	SignatureHeader = "X-UIGS-Signature"
+)
+
+// ErrQueueFull is returned when the dispatcher cannot accept more deliveries.
+var ErrQueueFull = errors.New("webhook queue full")
+
+// Config controls webhook delivery.
+type Config struct {
+	Timeout      time.Duration
+	MaxAttempts  int
+	QueueSize    int
+	Workers      int
+	AllowPrivate bool
+}
+
+// Delivery is a single notification to send.
+type Delivery struct {
+	URL     string
+	Secret  string
+	Event   string
+	Payload any
+
+	// OrderKey, if set, identifies a subscriber that receives deliveries
+	// in order: each is sent only after every earlier delivery with the
+	// same key has been delivered or abandoned.
+	OrderKey string
+}
+
+// SubscriberStats reports the ordered delivery state of one subscriber.
+type SubscriberStats struct {
+	Pending   int   `json:"pending"`
+	Delivered int64 `json:"delivered"`
+	Abandoned int64 `json:"abandoned"`
+	// LagMillis is how long the oldest pending delivery has been waiting.
+	LagMillis float64 `json:"lag_ms"`
+	// LastLagMillis is the time from enqueue to completion of the most
+	// recently finished delivery.
+	LastLagMillis float64 `json:"last_lag_ms"`
+}
+
+type pendingDelivery struct {
+	Delivery
+	enqueuedAt time.Time
+}
+
+// orderedQueue holds one subscriber's ordered deliveries. A runner
+// goroutine drains it while running is true.
+type orderedQueue struct {
+	pending   []pendingDelivery
+	running   bool
+	delivered int64
+	abandoned int64
+	lastLag   time.Duration
+}
+
+// Dispatcher sends deliveries from a bounded queue on a pool of workers,
+// retrying failures with exponential backoff. Ordered deliveries bypass the
+// pool and are sent one at a time per subscriber.
+type Dispatcher struct {
+	cfg    Config
+	client *http.Client
+	logger *slog.Logger
+
+	queue  chan Delivery
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+
+	mu      sync.Mutex
+	ordered map[string]*orderedQueue
+}
+
+// NewDispatcher creates a dispatcher. Call Start before enqueueing.
+func NewDispatcher(cfg Config, logger *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		cfg:    cfg,
+		client: netguard.NewClient(cfg.Timeout, cfg.AllowPrivate),
+		logger: logger,
+		queue:  make(chan Delivery, cfg.QueueSize),
+
+		ordered: make(map[string]*orderedQueue),
+	}
+}
+
+// AllowPrivate reports whether internal destinations are permitted.
+func (d *Dispatcher) AllowPrivate() bool {
+	return d.cfg.AllowPrivate
+}
+
+// Start runs the delivery workers until ctx is cancelled or Stop is called.
+func (d *Dispatcher) Start(ctx context.Context) {
+	d.mu.Lock()
+	ctx, d.cancel = context.WithCancel(ctx)
+	d.ctx = ctx
+	d.mu.Unlock()
+
+	for i := 0; i < d.cfg.Workers; i++ {
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case delivery := <-d.queue:
+					d.deliver(ctx, delivery)
+				}
+			}
+		}()
+	}
+}
+
+// Stop halts the workers and waits for in-flight deliveries to finish.
+// Queued deliveries that have not started are dropped.
+func (d *Dispatcher) Stop() {
+	d.once.Do(func() {
+		d.mu.Lock()
+		cancel := d.cancel
+		d.mu.Unlock()
+		if cancel != nil {
+			cancel()
+			d.wg.Wait()
+		}
+	})
+}
+
+// Enqueue schedules a delivery without blocking. Ordered deliveries are
+// limited to QueueSize pending per subscriber.
+func (d *Dispatcher) Enqueue(delivery Delivery) error {
+	if delivery.OrderKey != "" {
+		return d.enqueueOrdered(delivery)
+	}
+
+	select {
+	case d.queue <- delivery:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (d *Dispatcher) enqueueOrdered(delivery Delivery) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	q, ok := d.ordered[delivery.OrderKey]
+	if !ok {
+		q = &orderedQueue{}
+		d.ordered[delivery.OrderKey] = q
+	}
+	if len(q.pending) >= d.cfg.QueueSize {
+		return ErrQueueFull
+	}
+	q.pending = append(q.pending, pendingDelivery{Delivery: delivery, enqueuedAt: time.Now()})
+
+	// Before Start or after Stop nothing runs, as for the shared queue
+	if !q.running && d.ctx != nil && d.ctx.Err() == nil {
+		q.running = true
+		d.wg.Add(1)
+		go d.runOrdered(d.ctx, q)
+	}
+	return nil
+}
+
+// runOrdered sends a subscriber's deliveries one at a time until its queue
+// is empty or the dispatcher stops.
+func (d *Dispatcher) runOrdered(ctx context.Context, q *orderedQueue) {
+	defer d.wg.Done()
+
+	for {
+		d.mu.Lock()
+		if len(q.pending) == 0 || ctx.Err() != nil {
+			q.running = false
+			d.mu.Unlock()
+			return
+		}
+		next := q.pending[0]
+		d.mu.Unlock()
+
+		delivered := d.deliver(ctx, next.Delivery)
+
+		d.mu.Lock()
+		q.pending = q.pending[1:]
+		q.lastLag = time.Since(next.enqueuedAt)
+		if delivered {
+			q.delivered++
+		} else {
+			q.abandoned++
+		}
+		d.mu.Unlock()
+	}
+}
+
+// SubscriberStats returns the ordered delivery state of every subscriber
+// that has received ordered deliveries, keyed by OrderKey.
+func (d *Dispatcher) SubscriberStats() map[string]SubscriberStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	stats := make(map[string]SubscriberStats, len(d.ordered))
+	for key, q := range d.ordered {
+		s := SubscriberStats{
+			Pending:       len(q.pending),
+			Delivered:     q.delivered,
+			Abandoned:     q.abandoned,
+			LastLagMillis: float64(q.lastLag) / float64(time.Millisecond),
+		}
+		if len(q.pending) > 0 {
+			s.LagMillis = float64(now.Sub(q.pending[0].enqueuedAt)) / float64(time.Millisecond)
+		}
+		stats[key] = s
+	}
+	return stats
+}
+
+// deliver sends a delivery with retries and reports whether it succeeded.
+func (d *Dispatcher) deliver(ctx context.Context, delivery Delivery) bool {
+	body, err := json.Marshal(delivery.Payload)
+	if err != nil {
+		d.logger.Error("Failed to encode webhook payload", "error", err, "event", delivery.Event)
+		return false
+	}
+	id := uuid.New().String()
+
+	backoff := time.Second
+	for attempt := 1; attempt <= d.cfg.MaxAttempts; attempt++ {
+		err := d.send(ctx, id, delivery, body)
+		if err == nil {
+			d.logger.Info("Webhook delivered", "delivery_id", id, "event", delivery.Event, "attempt", attempt)
+			return true
+		}
+		d.logger.Warn("Webhook delivery failed", "error", err, "delivery_id", id, "event", delivery.Event, "attempt", attempt)
+
+		if attempt == d.cfg.MaxAttempts || errors.Is(err, ErrForbiddenDestination) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	d.logger.Error("Webhook delivery abandoned", "delivery_id", id, "event", delivery.Event)
+	return false
+}
+
+func (d *Dispatcher) send(ctx context.Context, id string, delivery Delivery, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, id)
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, time.Now(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("endpoint returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the signature header value "t=<unix>,v1=<hex>", where v1 is
+// the HMAC-SHA256 of "<unix>.<body>" keyed with secret. Receivers should
+// recompute it and reject stale timestamps.
+func Sign(secret string, at time.Time, body []byte) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret generates a random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
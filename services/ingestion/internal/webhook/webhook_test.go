@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// received is one request seen by a test endpoint.
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+func TestDispatcherDelivers(t *testing.T) {
+	const secret = "whsec_test"
+
+	tests := []struct {
+		name         string
+		statuses     []int
+		maxAttempts  int
+		allowPrivate bool
+		wantRequests int
+		wantOK       bool
+	}{
+		{name: "delivered at once", statuses: []int{http.StatusNoContent}, maxAttempts: 3, allowPrivate: true, wantRequests: 1, wantOK: true},
+		{name: "retried after a 5xx", statuses: []int{http.StatusBadGateway, http.StatusOK}, maxAttempts: 3, allowPrivate: true, wantRequests: 2, wantOK: true},
+		{name: "abandoned after the last attempt", statuses: []int{http.StatusInternalServerError}, maxAttempts: 1, allowPrivate: true, wantRequests: 1},
+		{name: "internal address refused at dial time", statuses: []int{http.StatusOK}, maxAttempts: 3, wantRequests: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			requests := make(chan received, len(tt.statuses)+1)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(calls.Add(1))
+				body, _ := io.ReadAll(r.Body)
+				requests <- received{header: r.Header.Clone(), body: body}
+				w.WriteHeader(tt.statuses[min(n, len(tt.statuses))-1])
+			}))
+			defer srv.Close()
+
+			d := NewDispatcher(Config{Timeout: time.Second, MaxAttempts: tt.maxAttempts, AllowPrivate: tt.allowPrivate}, discardLogger())
+			ok := d.deliver(context.Background(), Delivery{
+				URL:     srv.URL + "/callback",
+				Secret:  secret,
+				Event:   "ingestion.accepted",
+				Payload: map[string]string{"event_id": "evt-1", "status": "accepted"},
+			})
+
+			if ok != tt.wantOK {
+				t.Errorf("deliver() = %v, want %v", ok, tt.wantOK)
+			}
+			if got := int(calls.Load()); got != tt.wantRequests {
+				t.Fatalf("endpoint got %d requests, want %d", got, tt.wantRequests)
+			}
+			if tt.wantRequests == 0 {
+				return
+			}
+			first := <-requests
+			if got := first.header.Get(EventHeader); got != "ingestion.accepted" {
+				t.Errorf("%s = %q, want ingestion.accepted", EventHeader, got)
+			}
+			if first.header.Get(DeliveryHeader) == "" {
+				t.Errorf("%s is not set", DeliveryHeader)
+			}
+			var payload map[string]string
+			if err := json.Unmarshal(first.body, &payload); err != nil || payload["event_id"] != "evt-1" {
+				t.Errorf("body = %s, want the payload", first.body)
+			}
+			signature := first.header.Get(SignatureHeader)
+			ts, _, _ := strings.Cut(strings.TrimPrefix(signature, "t="), ",")
+			unix, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil || signature != Sign(secret, time.Unix(unix, 0), first.body) {
+				t.Errorf("%s = %q does not sign the body", SignatureHeader, signature)
+			}
+		})
+	}
+}
+
+func TestDispatcherQueue(t *testing.T) {
+	delivered := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- struct{}{}
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher(Config{Timeout: time.Second, MaxAttempts: 1, QueueSize: 1, Workers: 1, AllowPrivate: true}, discardLogger())
+	delivery := Delivery{URL: srv.URL, Event: "ingestion.accepted", Payload: map[string]string{}}
+	if err := d.Enqueue(delivery); err != nil {
+		t.Fatalf("Enqueue() = %v, want nil", err)
+	}
+	if err := d.Enqueue(delivery); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Enqueue() on a full queue = %v, want %v", err, ErrQueueFull)
+	}
+
+	d.Start(context.Background())
+	defer d.Stop()
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued delivery was not sent")
+	}
+}